@@ -0,0 +1,146 @@
+// Package admin provides an http.Handler exposing a cache's state and
+// maintenance actions for operational debugging, in the spirit of
+// net/http/pprof:
+//
+//	http.Handle("/debug/lfuda/", admin.Handler(c))
+//
+// Endpoints are matched on the last element of the path:
+//
+//	GET  stats          counters, size and age
+//	GET  hot?n=10       the n most frequently used entries
+//	GET  candidates?n=10 the next n entries to be evicted
+//	GET  dump           a text table of every entry, see Cache.Dump
+//	POST purge?key=k    removes a string key, or every entry without key
+//	POST resize?size=b  changes the cache size in bytes
+//
+// Responses other than dump are JSON.  The handler changes the cache, so it should not be
+// exposed to untrusted clients.
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+	"strconv"
+
+	lfuda "github.com/bparli/lfuda-go"
+)
+
+// DefaultN is the number of entries listed when n is not given.
+const DefaultN = 10
+
+// Stats is the response of the stats endpoint.
+type Stats struct {
+	lfuda.Stats
+	Len       int                 `json:"len"`
+	Size      float64             `json:"size"`
+	Capacity  float64             `json:"capacity"`
+	Age       float64             `json:"age"`
+	HitRatio  float64             `json:"hit_ratio"`
+	Breakdown lfuda.SizeBreakdown `json:"breakdown"`
+}
+
+// Handler returns a handler serving the admin endpoints for c.
+func Handler(c *lfuda.Cache) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch path.Base(r.URL.Path) {
+		case "stats":
+			if !method(w, r, http.MethodGet) {
+				return
+			}
+			st := c.Stats()
+			writeJSON(w, Stats{
+				Stats:     st,
+				Len:       c.Len(),
+				Size:      c.Size(),
+				Capacity:  c.Capacity(),
+				Age:       c.Age(),
+				HitRatio:  st.HitRatio(),
+				Breakdown: c.SizeBreakdown(),
+			})
+		case "hot":
+			n, ok := count(w, r)
+			if !ok || !method(w, r, http.MethodGet) {
+				return
+			}
+			hot := make([]lfuda.EntryInfo, 0, n)
+			if n > 0 {
+				c.Range(func(info lfuda.EntryInfo) bool {
+					hot = append(hot, info)
+					return len(hot) < n
+				})
+			}
+			writeJSON(w, hot)
+		case "candidates":
+			n, ok := count(w, r)
+			if !ok || !method(w, r, http.MethodGet) {
+				return
+			}
+			candidates := c.PeekEvictionCandidates(n)
+			infos := make([]lfuda.EntryInfo, len(candidates))
+			for i, candidate := range candidates {
+				infos[i] = candidate.EntryInfo
+			}
+			writeJSON(w, infos)
+		case "dump":
+			if !method(w, r, http.MethodGet) {
+				return
+			}
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			c.Dump(w)
+		case "purge":
+			if !method(w, r, http.MethodPost) {
+				return
+			}
+			if key := r.URL.Query().Get("key"); key != "" {
+				writeJSON(w, map[string]bool{"removed": c.Remove(key)})
+				return
+			}
+			n := c.Len()
+			c.Purge()
+			writeJSON(w, map[string]int{"removed": n})
+		case "resize":
+			if !method(w, r, http.MethodPost) {
+				return
+			}
+			size, err := strconv.ParseFloat(r.URL.Query().Get("size"), 64)
+			if err != nil || size < 0 {
+				http.Error(w, "bad size", http.StatusBadRequest)
+				return
+			}
+			writeJSON(w, map[string]int{"evicted": c.Resize(size)})
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+func method(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method != method {
+		w.Header().Set("Allow", method)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+	return true
+}
+
+// count parses the n query parameter.
+func count(w http.ResponseWriter, r *http.Request) (int, bool) {
+	v := r.URL.Query().Get("n")
+	if v == "" {
+		return DefaultN, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		http.Error(w, "bad n", http.StatusBadRequest)
+		return 0, false
+	}
+	return n, true
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
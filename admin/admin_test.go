@@ -0,0 +1,84 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	lfuda "github.com/bparli/lfuda-go"
+)
+
+func do(t *testing.T, h http.Handler, method, url string, v interface{}) int {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, url, nil))
+	if v != nil && w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+			t.Fatalf("%s %s: %v", method, url, err)
+		}
+	}
+	return w.Code
+}
+
+func TestHandler(t *testing.T) {
+	c := lfuda.New(100)
+	for _, key := range []string{"a", "b", "c"} {
+		c.Set(key, 1)
+	}
+	c.Get("a")
+	c.Get("a")
+	c.Get("b")
+	h := Handler(c)
+
+	var st Stats
+	if code := do(t, h, http.MethodGet, "/debug/lfuda/stats", &st); code != http.StatusOK {
+		t.Fatalf("stats: %d", code)
+	}
+	if st.Len != 3 || st.Hits != 3 || st.Capacity != 100 || st.Breakdown.LiveLen != 3 {
+		t.Errorf("bad stats: %+v", st)
+	}
+
+	var hot []lfuda.EntryInfo
+	do(t, h, http.MethodGet, "/debug/lfuda/hot?n=2", &hot)
+	if len(hot) != 2 || hot[0].Key != "a" || hot[1].Key != "b" {
+		t.Errorf("bad hot entries: %+v", hot)
+	}
+	var candidates []lfuda.EntryInfo
+	do(t, h, http.MethodGet, "/debug/lfuda/candidates?n=1", &candidates)
+	if len(candidates) != 1 || candidates[0].Key != "c" {
+		t.Errorf("bad candidates: %+v", candidates)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/lfuda/dump", nil))
+	if body := w.Body.String(); !strings.Contains(body, "3 entries") || strings.Index(body, "\nc ") > strings.Index(body, "\na ") {
+		t.Errorf("bad dump:\n%s", body)
+	}
+
+	if code := do(t, h, http.MethodGet, "/debug/lfuda/purge?key=a", nil); code != http.StatusMethodNotAllowed {
+		t.Errorf("actions should require POST: %d", code)
+	}
+	var removed map[string]bool
+	do(t, h, http.MethodPost, "/debug/lfuda/purge?key=a", &removed)
+	if !removed["removed"] || c.Contains("a") {
+		t.Errorf("key should be purged")
+	}
+	var evicted map[string]int
+	do(t, h, http.MethodPost, "/debug/lfuda/resize?size=1", &evicted)
+	if evicted["evicted"] != 1 || c.Capacity() != 1 {
+		t.Errorf("cache should be resized: %v", evicted)
+	}
+	do(t, h, http.MethodPost, "/debug/lfuda/purge", nil)
+	if c.Len() != 0 {
+		t.Errorf("cache should be purged")
+	}
+
+	if code := do(t, h, http.MethodGet, "/debug/lfuda/hot?n=x", nil); code != http.StatusBadRequest {
+		t.Errorf("bad n should be rejected: %d", code)
+	}
+	if code := do(t, h, http.MethodGet, "/debug/lfuda/nothing", nil); code != http.StatusNotFound {
+		t.Errorf("unknown endpoints should not be found: %d", code)
+	}
+}
@@ -0,0 +1,275 @@
+package lfuda
+
+import "sync"
+
+// QueueFull is what SetAsync does when its queue is full.
+type QueueFull int
+
+const (
+	// DropWhenFull drops the set, counted by Stats.DroppedSets.
+	DropWhenFull QueueFull = iota
+	// BlockWhenFull waits for room in the queue.
+	BlockWhenFull
+	// SetWhenFull sets the value synchronously, as Set does.
+	SetWhenFull
+)
+
+const (
+	// defaultSetQueue is the length of the SetAsync queue without
+	// WithSetQueue.
+	defaultSetQueue = 1024
+	// setBatch is the number of queued sets applied per lock hold.
+	setBatch = 64
+)
+
+// WithSetQueue sets the number of values SetAsync queues, 1024 by default,
+// and what it does when they are that many.
+func WithSetQueue(size int, full QueueFull) Option {
+	return func(o *options) {
+		o.setQueue = size
+		o.setQueueFull = full
+	}
+}
+
+// asyncSet is a set queued by SetAsync, or a marker closing done once the
+// sets queued before it are applied.
+type asyncSet struct {
+	key, value interface{}
+	// generation of the key when the set was queued
+	gen  uint64
+	done chan struct{}
+}
+
+// setQueue applies the sets queued by SetAsync in batches.
+type setQueue struct {
+	queue chan asyncSet
+	wg    sync.WaitGroup
+
+	// held by senders while queuing, so that Close drains the queue only
+	// once no more sets can be queued
+	closeLock sync.RWMutex
+	closed    bool
+	// closed along with closed being set
+	stop chan struct{}
+
+	// keys with queued sets, whose generation is bumped by synchronous
+	// writes so the older queued sets are skipped
+	lock sync.Mutex
+	keys map[interface{}]*queuedKey
+}
+
+type queuedKey struct {
+	queued int
+	gen    uint64
+}
+
+// track records a set of key about to be queued and returns the key's
+// generation.
+func (q *setQueue) track(key interface{}) uint64 {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	k := q.keys[key]
+	if k == nil {
+		k = &queuedKey{}
+		q.keys[key] = k
+	}
+	k.queued++
+	return k.gen
+}
+
+// untrack records that a tracked set of key was applied, skipped or not
+// queued, and reports whether it is still the latest write of the key.
+func (q *setQueue) untrack(key interface{}, gen uint64) bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	k := q.keys[key]
+	if k.queued--; k.queued == 0 {
+		delete(q.keys, key)
+	}
+	return k.gen == gen
+}
+
+// close stops queuing sets, after waiting for those being queued, and
+// makes the applier drain the queue.
+func (q *setQueue) close() {
+	q.closeLock.Lock()
+	q.closed = true
+	close(q.stop)
+	q.closeLock.Unlock()
+}
+
+// supersede makes the queued sets of key stale, if any.
+func (q *setQueue) supersede(key interface{}) {
+	q.lock.Lock()
+	if k := q.keys[key]; k != nil {
+		k.gen++
+	}
+	q.lock.Unlock()
+}
+
+// supersedeAll makes every queued set stale.
+func (q *setQueue) supersedeAll() {
+	q.lock.Lock()
+	for _, k := range q.keys {
+		k.gen++
+	}
+	q.lock.Unlock()
+}
+
+// written records a synchronous write of key, with the write lock held, so
+// the values SetAsync queued for it before don't overwrite it.
+func (c *Cache) written(key interface{}) {
+	if q := c.sets.Load(); q != nil && !c.opPromote && !c.opAsync {
+		q.supersede(key)
+	}
+}
+
+// SetAsync queues a value to be added to the cache by a background
+// goroutine, which applies queued values in batches under one lock hold, so
+// hot request paths can populate the cache without waiting for the lock.  A
+// Get right after may miss.  The value is not added if the key is set or
+// removed synchronously in the meantime.  Returns false if the value was
+// dropped because the queue is full, see WithSetQueue, or the cache is
+// closed.  Close applies the queued values.
+func (c *Cache) SetAsync(key, value interface{}) bool {
+	if c.badKey(key) {
+		return false
+	}
+	q := c.startSetQueue()
+	if q == nil {
+		return false
+	}
+	q.closeLock.RLock()
+	defer q.closeLock.RUnlock()
+	if q.closed {
+		return false
+	}
+	s := asyncSet{key: key, value: value, gen: q.track(key)}
+	select {
+	case q.queue <- s:
+		return true
+	default:
+	}
+	switch c.opts.setQueueFull {
+	case BlockWhenFull:
+		select {
+		case q.queue <- s:
+			return true
+		case <-c.done:
+			q.untrack(key, s.gen)
+			return false
+		}
+	case SetWhenFull:
+		// supersedes the older queued sets of the key
+		q.untrack(key, s.gen)
+		c.Set(key, value)
+		return true
+	}
+	q.untrack(key, s.gen)
+	c.stats.droppedSets.Add(1)
+	return false
+}
+
+// WaitAsync waits for the values queued by SetAsync before it to be added,
+// or for the queue to be applied by Close.
+func (c *Cache) WaitAsync() {
+	q := c.startSetQueue()
+	if q == nil {
+		return
+	}
+	done := make(chan struct{})
+	q.closeLock.RLock()
+	queued := false
+	if !q.closed {
+		select {
+		case q.queue <- asyncSet{done: done}:
+			queued = true
+		case <-c.done:
+		}
+	}
+	q.closeLock.RUnlock()
+	if !queued {
+		q.wg.Wait()
+		return
+	}
+	select {
+	case <-done:
+	case <-c.done:
+		// the queue is applied until it's empty, not to the marker
+		q.wg.Wait()
+	}
+}
+
+// startSetQueue returns the SetAsync queue, starting it if needed, or nil
+// if the cache was closed before it was started.
+func (c *Cache) startSetQueue() *setQueue {
+	c.setsOnce.Do(func() {
+		size := c.opts.setQueue
+		if size <= 0 {
+			size = defaultSetQueue
+		}
+		q := &setQueue{
+			queue: make(chan asyncSet, size),
+			keys:  make(map[interface{}]*queuedKey),
+			stop:  make(chan struct{}),
+		}
+		q.wg.Add(1)
+		c.sets.Store(q)
+		go c.applySets(q)
+	})
+	return c.sets.Load()
+}
+
+// applySets applies the queued sets until the queue is closed, then the
+// ones left.
+func (c *Cache) applySets(q *setQueue) {
+	defer q.wg.Done()
+	batch := make([]asyncSet, 0, setBatch)
+	for {
+		select {
+		case s := <-q.queue:
+			batch = append(batch[:0], s)
+		case <-q.stop:
+			for {
+				batch = q.receive(batch[:0])
+				if len(batch) == 0 {
+					return
+				}
+				c.applySetBatch(q, batch)
+			}
+		}
+		c.applySetBatch(q, q.receive(batch))
+	}
+}
+
+// receive appends the queued sets to batch, up to setBatch, without
+// waiting.
+func (q *setQueue) receive(batch []asyncSet) []asyncSet {
+	for len(batch) < setBatch {
+		select {
+		case s := <-q.queue:
+			batch = append(batch, s)
+		default:
+			return batch
+		}
+	}
+	return batch
+}
+
+func (c *Cache) applySetBatch(q *setQueue, batch []asyncSet) {
+	c.lockOp()
+	c.opAsync = true
+	for _, s := range batch {
+		if s.done == nil && q.untrack(s.key, s.gen) && !c.dropSet() {
+			c.set(s.key, s.value)
+		}
+	}
+	c.opAsync = false
+	c.unlockOp()
+	for i := range batch {
+		if batch[i].done != nil {
+			close(batch[i].done)
+		}
+		batch[i] = asyncSet{}
+	}
+}
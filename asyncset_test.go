@@ -0,0 +1,160 @@
+package lfuda
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSetAsync(t *testing.T) {
+	l := New(1000)
+	for i := 0; i < 100; i++ {
+		if !l.SetAsync(i, i) {
+			t.Fatalf("set %d dropped", i)
+		}
+	}
+	l.WaitAsync()
+	if l.Len() != 100 {
+		t.Errorf("got %d entries, want 100", l.Len())
+	}
+	if v, ok := l.Get(42); !ok || v != 42 {
+		t.Errorf("bad value %v", v)
+	}
+
+	// queued values are added by Close
+	l.SetAsync("last", 1)
+	l.Close()
+	if !l.Contains("last") {
+		t.Errorf("Close should apply the queued values")
+	}
+	if l.SetAsync("closed", 1) {
+		t.Errorf("values set after Close should be dropped")
+	}
+}
+
+func TestSetAsyncClose(t *testing.T) {
+	for round := 0; round < 20; round++ {
+		l := New(100000)
+		var wg sync.WaitGroup
+		accepted := make([][]int, 4)
+		for g := range accepted {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				for i := 0; i < 1000; i++ {
+					if l.SetAsync(g*1000+i, i) {
+						accepted[g] = append(accepted[g], g*1000+i)
+					}
+				}
+			}(g)
+		}
+		time.Sleep(time.Duration(round) * 10 * time.Microsecond)
+		l.Close()
+		wg.Wait()
+		for _, keys := range accepted {
+			for _, k := range keys {
+				if !l.Contains(k) {
+					t.Fatalf("accepted value %d lost by Close", k)
+				}
+			}
+		}
+	}
+}
+
+func TestSetAsyncFull(t *testing.T) {
+	l := NewWithOptions(1000, WithSetQueue(1, DropWhenFull))
+	defer l.Close()
+	// hold the lock so the queue can't drain
+	l.lock.Lock()
+	dropped := 0
+	for i := 0; i < 10; i++ {
+		if !l.SetAsync(i, i) {
+			dropped++
+		}
+	}
+	l.lock.Unlock()
+	l.WaitAsync()
+	if dropped == 0 || l.Stats().DroppedSets != uint64(dropped) || l.Len() != 10-dropped {
+		t.Errorf("%d dropped, stats %+v, %d entries", dropped, l.Stats(), l.Len())
+	}
+
+	s := NewWithOptions(1000, WithSetQueue(1, SetWhenFull))
+	defer s.Close()
+	s.lock.Lock()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 3; i++ {
+			s.SetAsync(i, i)
+		}
+	}()
+	time.Sleep(10 * time.Millisecond)
+	s.lock.Unlock()
+	<-done
+	s.WaitAsync()
+	if s.Len() != 3 || s.Stats().DroppedSets != 0 {
+		t.Errorf("values should be set: %d entries", s.Len())
+	}
+}
+
+func TestSetAsyncOrder(t *testing.T) {
+	l := New(1000)
+	defer l.Close()
+	// hold the lock so the queue can't drain before the synchronous writes
+	l.lock.Lock()
+	l.SetAsync("a", "old")
+	l.SetAsync("b", "old")
+	l.SetAsync("c", "old")
+	l.SetAsync("d", "old")
+	l.lock.Unlock()
+	l.Set("a", "new")
+	l.Remove("b")
+	l.Purge()
+	l.SetAsync("d", "new")
+	l.WaitAsync()
+	if v, _ := l.Get("a"); v != nil {
+		t.Errorf("Purge should drop the queued values: %v", v)
+	}
+	if l.Contains("b") || l.Contains("c") {
+		t.Errorf("removed keys should stay absent")
+	}
+	if v, _ := l.Get("d"); v != "new" {
+		t.Errorf("values queued after Purge should be added: %v", v)
+	}
+
+	l.lock.Lock()
+	l.SetAsync("a", "old")
+	l.lock.Unlock()
+	l.Set("a", "new")
+	l.WaitAsync()
+	if v, _ := l.Get("a"); v != "new" {
+		t.Errorf("queued values should not overwrite newer ones: %v", v)
+	}
+	l.lock.Lock()
+	l.SetAsync("b", "old")
+	l.lock.Unlock()
+	l.Remove("b")
+	l.WaitAsync()
+	if l.Contains("b") {
+		t.Errorf("queued values should not undo a Remove")
+	}
+
+	// sets made synchronously by a full queue don't jump ahead of older
+	// queued ones
+	s := NewWithOptions(1000, WithSetQueue(1, SetWhenFull))
+	defer s.Close()
+	s.lock.Lock()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.SetAsync("k", 1)
+		s.SetAsync("k", 2)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	s.lock.Unlock()
+	<-done
+	s.WaitAsync()
+	if v, _ := s.Get("k"); v != 2 {
+		t.Errorf("got %v, want the latest value", v)
+	}
+}
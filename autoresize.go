@@ -0,0 +1,103 @@
+package lfuda
+
+import (
+	"math"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// AutoResizeOptions configures AutoResize.
+type AutoResizeOptions struct {
+	// Min and Max bound the cache capacity.  If Max is not positive, the
+	// capacity never grows past what it was when AutoResize was called.
+	Min, Max float64
+	// HeapTarget is the heap size in bytes the cache shrinks to stay under.
+	// If 0, the limit set with debug.SetMemoryLimit is used.
+	HeapTarget uint64
+	// Pressure, if set, replaces the heap statistics: it returns the memory
+	// in use relative to what is available, e.g. from a cgroup, where values
+	// above 1 mean the process uses too much.
+	Pressure func() float64
+	// Interval between checks, 1s if 0.
+	Interval time.Duration
+	// Step is the fraction of the capacity added or removed per check, 0.1
+	// if 0.
+	Step float64
+}
+
+// AutoResize starts a controller that checks the memory pressure every
+// interval, shrinking the cache by a step, and evicting by policy, while it
+// is above 1 and growing it back once it falls below 1 - Step, within the
+// configured bounds.  It runs until the returned function is called or the
+// cache is closed.
+func (c *Cache) AutoResize(opts AutoResizeOptions) (stop func()) {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Second
+	}
+	if opts.Step <= 0 {
+		opts.Step = 0.1
+	}
+	if opts.Max <= 0 {
+		opts.Max = c.Capacity()
+	}
+	if opts.Pressure == nil {
+		target := opts.HeapTarget
+		if target == 0 {
+			if limit := debug.SetMemoryLimit(-1); limit != math.MaxInt64 {
+				target = uint64(limit)
+			}
+		}
+		opts.Pressure = heapPressure(target)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(opts.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				c.resizeFor(opts.Pressure(), opts)
+			case <-done:
+				return
+			case <-c.done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// resizeFor resizes the cache by a step for the given pressure, never
+// growing it under pressure nor shrinking it without.
+func (c *Cache) resizeFor(pressure float64, opts AutoResizeOptions) {
+	capacity := c.Capacity()
+	size := capacity
+	switch {
+	case pressure > 1:
+		size = math.Min(math.Max(capacity*(1-opts.Step), opts.Min), capacity)
+	case pressure < 1-opts.Step:
+		size = math.Max(math.Min(capacity*(1+opts.Step), opts.Max), capacity)
+	}
+	if size != capacity {
+		c.Resize(size)
+	}
+}
+
+// heapPressure returns the heap in use relative to target, 0 if target is 0.
+func heapPressure(target uint64) func() float64 {
+	return func() float64 {
+		if target == 0 {
+			return 0
+		}
+		var ms runtime.MemStats
+		runtime.ReadMemStats(&ms)
+		return float64(ms.HeapAlloc) / float64(target)
+	}
+}
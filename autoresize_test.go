@@ -0,0 +1,95 @@
+package lfuda
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAutoResize(t *testing.T) {
+	l := New(100)
+	defer l.Close()
+	for i := 0; i < 100; i++ {
+		l.Set(i, i)
+	}
+
+	var pressure atomic.Value
+	pressure.Store(2.0)
+	stop := l.AutoResize(AutoResizeOptions{
+		Min:      50,
+		Max:      120,
+		Interval: time.Millisecond,
+		Step:     0.2,
+		Pressure: func() float64 { return pressure.Load().(float64) },
+	})
+	defer stop()
+
+	waitFor := func(capacity float64) {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for l.Capacity() != capacity {
+			if time.Now().After(deadline) {
+				t.Fatalf("the capacity should reach %v: %v", capacity, l.Capacity())
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	waitFor(50)
+	if l.Len() > 50 {
+		t.Errorf("shrinking should evict: %d", l.Len())
+	}
+	pressure.Store(0.5)
+	waitFor(120)
+
+	// within the hysteresis band the capacity is left alone
+	pressure.Store(0.9)
+	time.Sleep(10 * time.Millisecond)
+	if l.Capacity() != 120 {
+		t.Errorf("the capacity should not change: %v", l.Capacity())
+	}
+}
+
+func TestResizeFor(t *testing.T) {
+	l := New(100)
+	opts := AutoResizeOptions{Min: 10, Max: 200, Step: 0.5}
+	l.resizeFor(1.5, opts)
+	l.resizeFor(1.5, opts)
+	l.resizeFor(1.5, opts)
+	l.resizeFor(1.5, opts)
+	if l.Capacity() != 10 {
+		t.Errorf("the capacity should stop at Min: %v", l.Capacity())
+	}
+	l.resizeFor(0.2, opts)
+	if l.Capacity() != 15 {
+		t.Errorf("the capacity should grow by a step: %v", l.Capacity())
+	}
+
+	// the bounds never move the capacity against the pressure
+	l.Resize(300)
+	l.resizeFor(0.2, opts)
+	if l.Capacity() != 300 {
+		t.Errorf("growing should not shrink past Max: %v", l.Capacity())
+	}
+	l.Resize(5)
+	l.resizeFor(1.5, opts)
+	if l.Capacity() != 5 {
+		t.Errorf("shrinking should not grow to Min: %v", l.Capacity())
+	}
+
+	// without a Max the capacity stays at most where it started
+	l = New(100)
+	defer l.Close()
+	stop := l.AutoResize(AutoResizeOptions{Interval: time.Millisecond, Pressure: func() float64 { return 0 }})
+	time.Sleep(10 * time.Millisecond)
+	stop()
+	if l.Capacity() != 100 {
+		t.Errorf("the capacity should stop at the initial capacity: %v", l.Capacity())
+	}
+
+	if p := heapPressure(0)(); p != 0 {
+		t.Errorf("without a target there is no pressure: %v", p)
+	}
+	if p := heapPressure(1)(); p <= 1 {
+		t.Errorf("the heap should be over a 1 byte target: %v", p)
+	}
+}
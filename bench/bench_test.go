@@ -0,0 +1,140 @@
+package bench
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	lfuda "github.com/bparli/lfuda-go"
+)
+
+func readAll(t *testing.T, r *Reader) []Request {
+	t.Helper()
+	var reqs []Request
+	for {
+		req, err := r.Next()
+		if err == io.EOF {
+			return reqs
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		reqs = append(reqs, req)
+	}
+}
+
+func TestReadARC(t *testing.T) {
+	r, err := Open("testdata/arc.trace", FormatARC, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	var keys []uint64
+	for _, req := range readAll(t, r) {
+		if req.Size != BlockSize {
+			t.Errorf("blocks should be %d bytes: %v", BlockSize, req.Size)
+		}
+		keys = append(keys, req.Key)
+	}
+	if len(keys) != 5 || keys[0] != 0 || keys[1] != 1 || keys[2] != 10 || keys[3] != 0 || keys[4] != 1 {
+		t.Errorf("unexpected blocks: %v", keys)
+	}
+
+	if _, err := NewReader(strings.NewReader("1 x 0 0\n"), FormatARC).Next(); err == nil {
+		t.Errorf("malformed lines should be reported")
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	r, err := Open("testdata/wiki.tr", FormatWiki, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	results, err := Simulate(r, 400, lfuda.PolicyLFUDA, lfuda.PolicyGDSF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, res := range results {
+		if res.Requests != 7 || res.Bytes != 1000 {
+			t.Errorf("%s should see the whole trace: %+v", res.Policy, res)
+		}
+	}
+	// GDSF keeps the two small objects instead of the big one
+	if lfudaRes, gdsf := results[0], results[1]; gdsf.HitRatio() <= lfudaRes.HitRatio() {
+		t.Errorf("GDSF should hit more: %v %v", gdsf.HitRatio(), lfudaRes.HitRatio())
+	}
+
+	var buf bytes.Buffer
+	if err := Print(&buf, results); err != nil || !strings.Contains(buf.String(), "GDSF") {
+		t.Errorf("the results should be printed: %v %q", err, buf.String())
+	}
+}
+
+func TestOpenURL(t *testing.T) {
+	fetched := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetched++
+		gz := gzip.NewWriter(w)
+		gz.Write([]byte("1 7 10\n"))
+		gz.Close()
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	for i := 0; i < 2; i++ {
+		r, err := Open(srv.URL+"/trace.gz", FormatWiki, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reqs := readAll(t, r); len(reqs) != 1 || reqs[0] != (Request{Key: 7, Size: 10}) {
+			t.Errorf("the downloaded trace should be read: %v", reqs)
+		}
+		r.Close()
+	}
+	if fetched != 1 {
+		t.Errorf("the trace should be downloaded once: %d", fetched)
+	}
+}
+
+// BenchmarkTrace replays the trace named by LFUDA_TRACE, a path or URL, in
+// the format named by LFUDA_TRACE_FORMAT ("wiki" or "arc") against a cache of
+// LFUDA_TRACE_CAPACITY bytes, reporting the ratios of every policy.
+func BenchmarkTrace(b *testing.B) {
+	name := os.Getenv("LFUDA_TRACE")
+	if name == "" {
+		b.Skip("LFUDA_TRACE is not set")
+	}
+	format := FormatWiki
+	if os.Getenv("LFUDA_TRACE_FORMAT") == "arc" {
+		format = FormatARC
+	}
+	capacity, err := strconv.ParseFloat(os.Getenv("LFUDA_TRACE_CAPACITY"), 64)
+	if err != nil {
+		b.Fatal("LFUDA_TRACE_CAPACITY: ", err)
+	}
+
+	for _, policy := range []string{lfuda.PolicyLFUDA, lfuda.PolicyGDSF, lfuda.PolicyLFU} {
+		b.Run(policy, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				r, err := Open(name, format, "")
+				if err != nil {
+					b.Fatal(err)
+				}
+				results, err := Simulate(r, capacity, policy)
+				r.Close()
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.ReportMetric(results[0].HitRatio(), "hit-ratio")
+				b.ReportMetric(results[0].ByteHitRatio(), "byte-hit-ratio")
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package bench
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Open opens the trace at name, a file path or an http(s) URL.  URLs are
+// downloaded into dir, or the user cache directory if dir is empty, unless
+// already there, so a trace is only fetched once.  Files ending in .gz are
+// decompressed.
+func Open(name string, format Format, dir string) (*Reader, error) {
+	file := name
+	if u, err := url.Parse(name); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		if file, err = fetch(u, dir); err != nil {
+			return nil, err
+		}
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	var r io.Reader = f
+	if strings.HasSuffix(file, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		r = gz
+	}
+	tr := NewReader(r, format)
+	tr.closer = f
+	return tr, nil
+}
+
+// fetch downloads u into dir, returning the path of the file.
+func fetch(u *url.URL, dir string) (string, error) {
+	if dir == "" {
+		cache, err := os.UserCacheDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(cache, "lfuda-bench")
+	}
+	file := filepath.Join(dir, path.Base(u.Path))
+	if _, err := os.Stat(file); err == nil {
+		return file, nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	resp, err := http.Get(u.String())
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("bench: fetching %s: %s", u, resp.Status)
+	}
+	// download next to the file so a failed download isn't mistaken for it
+	tmp, err := os.CreateTemp(dir, path.Base(u.Path)+".*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, resp.Body); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	return file, os.Rename(tmp.Name(), file)
+}
@@ -0,0 +1,78 @@
+package bench
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	lfuda "github.com/bparli/lfuda-go"
+)
+
+// Result is the outcome of replaying a trace against a policy.
+type Result struct {
+	Policy   string
+	Capacity float64
+	// Requests and Bytes count every request of the trace, Hits and
+	// HitBytes those served from the cache.
+	Requests, Hits  int
+	Bytes, HitBytes float64
+}
+
+// HitRatio returns the fraction of requests served from the cache.
+func (r Result) HitRatio() float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+	return float64(r.Hits) / float64(r.Requests)
+}
+
+// ByteHitRatio returns the fraction of requested bytes served from the
+// cache.
+func (r Result) ByteHitRatio() float64 {
+	if r.Bytes == 0 {
+		return 0
+	}
+	return r.HitBytes / r.Bytes
+}
+
+// Simulate replays the trace r against a cache of capacity bytes for each
+// policy, in a single pass over the trace.  Misses set the requested object
+// with its size.
+func Simulate(r *Reader, capacity float64, policies ...string) ([]Result, error) {
+	caches := make([]*lfuda.Cache, len(policies))
+	results := make([]Result, len(policies))
+	for i, policy := range policies {
+		caches[i] = lfuda.NewWithOptions(capacity, lfuda.WithPolicy(policy))
+		results[i] = Result{Policy: policy, Capacity: capacity}
+	}
+	for {
+		req, err := r.Next()
+		if err == io.EOF {
+			return results, nil
+		}
+		if err != nil {
+			return results, err
+		}
+		for i, c := range caches {
+			res := &results[i]
+			res.Requests++
+			res.Bytes += req.Size
+			if _, ok := c.Get(req.Key); ok {
+				res.Hits++
+				res.HitBytes += req.Size
+			} else {
+				c.SetWithSize(req.Key, struct{}{}, req.Size)
+			}
+		}
+	}
+}
+
+// Print writes the results as a table.
+func Print(w io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "policy\tcapacity\trequests\thit ratio\tbyte hit ratio\t\n")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%.0f\t%d\t%.4f\t%.4f\t\n", r.Policy, r.Capacity, r.Requests, r.HitRatio(), r.ByteHitRatio())
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,122 @@
+// Package bench replays standard cache traces against every cache policy and
+// reports their hit and byte hit ratios, so policy changes can be measured
+// on real workloads:
+//
+//	r, err := bench.Open("wiki2018.tr.gz", bench.FormatWiki, "")
+//	results, err := bench.Simulate(r, 64<<30, lfuda.PolicyLFUDA, lfuda.PolicyGDSF)
+//
+// Traces are read from files, optionally gzipped, or downloaded once into a
+// directory from an http(s) URL.
+package bench
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Format is the layout of a trace file.
+type Format int
+
+const (
+	// FormatARC is the block trace format of the ARC paper: every line is
+	// "start count _ _", requesting count blocks from start.
+	FormatARC Format = iota
+	// FormatWiki is the "timestamp id size" format of the Wikipedia CDN
+	// traces published with LRB, and of other object traces.
+	FormatWiki
+)
+
+// BlockSize is the size in bytes of the blocks of FormatARC traces.
+const BlockSize = 512
+
+// Request is a request of a trace.
+type Request struct {
+	Key  uint64
+	Size float64
+}
+
+// Reader reads the requests of a trace.
+type Reader struct {
+	s      *bufio.Scanner
+	format Format
+	line   int
+	// remaining blocks of the current ARC line
+	next, blocks uint64
+	closer       io.Closer
+}
+
+// NewReader reads a trace of the given format from r.
+func NewReader(r io.Reader, format Format) *Reader {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 4096), 1<<20)
+	return &Reader{s: s, format: format}
+}
+
+// Next returns the next request, or io.EOF after the last one.
+func (r *Reader) Next() (Request, error) {
+	if r.blocks > 0 {
+		r.blocks--
+		r.next++
+		return Request{Key: r.next - 1, Size: BlockSize}, nil
+	}
+	for r.s.Scan() {
+		r.line++
+		fields := strings.Fields(r.s.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		switch r.format {
+		case FormatARC:
+			if len(fields) < 2 {
+				return Request{}, r.errorf("want start and count")
+			}
+			start, err := strconv.ParseUint(fields[0], 10, 64)
+			if err != nil {
+				return Request{}, r.errorf("%v", err)
+			}
+			count, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return Request{}, r.errorf("%v", err)
+			}
+			if count == 0 {
+				continue
+			}
+			r.next, r.blocks = start, count
+			return r.Next()
+		case FormatWiki:
+			if len(fields) < 3 {
+				return Request{}, r.errorf("want timestamp, id and size")
+			}
+			key, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return Request{}, r.errorf("%v", err)
+			}
+			size, err := strconv.ParseFloat(fields[2], 64)
+			if err != nil {
+				return Request{}, r.errorf("%v", err)
+			}
+			return Request{Key: key, Size: size}, nil
+		default:
+			return Request{}, fmt.Errorf("bench: unknown format %d", r.format)
+		}
+	}
+	if err := r.s.Err(); err != nil {
+		return Request{}, err
+	}
+	return Request{}, io.EOF
+}
+
+// Close closes the file the trace was opened from, if any.
+func (r *Reader) Close() error {
+	if r.closer != nil {
+		return r.closer.Close()
+	}
+	return nil
+}
+
+func (r *Reader) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("bench: line %d: %s", r.line, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,112 @@
+// Package blockcache caches the fixed-size blocks read from an io.ReaderAt
+// in a GDSF cache, accelerating random reads over slow sources such as S3 or
+// HTTP range requests, or large local files.  The source must not change
+// while it is cached.
+package blockcache
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	lfuda "github.com/bparli/lfuda-go"
+)
+
+// DefaultBlockSize is the block size without WithBlockSize.
+const DefaultBlockSize = 64 << 10
+
+// Option configures a ReaderAt.
+type Option func(*ReaderAt)
+
+// WithBlockSize sets the size of the blocks read from the source and cached.
+func WithBlockSize(n int) Option {
+	return func(r *ReaderAt) {
+		if n > 0 {
+			r.blockSize = n
+		}
+	}
+}
+
+// WithCache caches the blocks in c, keyed by name and block, so several
+// sources can share one cache.  Names must be unique among them.
+func WithCache(c *lfuda.Cache, name string) Option {
+	return func(r *ReaderAt) {
+		r.cache, r.name = c, name
+	}
+}
+
+// ReaderAt reads from a source through a cache of its blocks.  Concurrent
+// reads of a block missing from the cache share a single read of the source.
+type ReaderAt struct {
+	r         io.ReaderAt
+	cache     *lfuda.Cache
+	name      string
+	blockSize int
+}
+
+// blockKey is the key of a cached block.
+type blockKey struct {
+	name  string
+	index int64
+}
+
+// New returns a ReaderAt caching up to size bytes of blocks read from r.
+func New(r io.ReaderAt, size float64, opts ...Option) *ReaderAt {
+	b := &ReaderAt{r: r, blockSize: DefaultBlockSize}
+	for _, opt := range opts {
+		opt(b)
+	}
+	if b.cache == nil {
+		b.cache = lfuda.NewGDSF(size)
+	}
+	return b
+}
+
+// Cache returns the cache of blocks.
+func (b *ReaderAt) Cache() *lfuda.Cache {
+	return b.cache
+}
+
+// ReadAt reads len(p) bytes at off through the cache, as io.ReaderAt.
+func (b *ReaderAt) ReadAt(p []byte, off int64) (n int, err error) {
+	if off < 0 {
+		return 0, errors.New("blockcache: negative offset")
+	}
+	size := int64(b.blockSize)
+	for n < len(p) {
+		pos := off + int64(n)
+		index := pos / size
+		block, err := b.block(index)
+		if err != nil {
+			return n, err
+		}
+		start := int(pos - index*size)
+		if start >= len(block) {
+			return n, io.EOF
+		}
+		n += copy(p[n:], block[start:])
+		// a short block is the last one
+		if len(block) < b.blockSize && n < len(p) {
+			return n, io.EOF
+		}
+	}
+	return n, nil
+}
+
+// block returns the block at index, reading it from the source if it isn't
+// cached.  Blocks are shorter than the block size at the end of the source.
+func (b *ReaderAt) block(index int64) ([]byte, error) {
+	key := blockKey{name: b.name, index: index}
+	v, err := b.cache.GetOrLoad(context.Background(), key, func(ctx context.Context, _ interface{}) (interface{}, error) {
+		block := make([]byte, b.blockSize)
+		n, err := b.r.ReadAt(block, index*int64(b.blockSize))
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		return block[:n], nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]byte), nil
+}
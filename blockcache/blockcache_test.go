@@ -0,0 +1,68 @@
+package blockcache
+
+import (
+	"bytes"
+	"io"
+	"sync/atomic"
+	"testing"
+	"testing/iotest"
+
+	lfuda "github.com/bparli/lfuda-go"
+)
+
+// countingReaderAt counts the reads of its source.
+type countingReaderAt struct {
+	r     io.ReaderAt
+	reads atomic.Int32
+}
+
+func (c *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	c.reads.Add(1)
+	return c.r.ReadAt(p, off)
+}
+
+func TestReaderAt(t *testing.T) {
+	content := make([]byte, 1000)
+	for i := range content {
+		content[i] = byte(i * 7)
+	}
+	src := &countingReaderAt{r: bytes.NewReader(content)}
+	r := New(src, 1<<20, WithBlockSize(64))
+
+	if err := iotest.TestReader(io.NewSectionReader(r, 0, int64(len(content))), content); err != nil {
+		t.Fatal(err)
+	}
+	reads := src.reads.Load()
+	if reads != 16 {
+		t.Errorf("the source should be read once per block: %d reads", reads)
+	}
+	p := make([]byte, 100)
+	if n, err := r.ReadAt(p, 10); n != 100 || err != nil || !bytes.Equal(p, content[10:110]) || src.reads.Load() != reads {
+		t.Errorf("cached read: %d, %v, %d reads", n, err, src.reads.Load())
+	}
+	if n, err := r.ReadAt(p, 950); n != 50 || err != io.EOF || !bytes.Equal(p[:n], content[950:]) {
+		t.Errorf("read past the end: %d, %v", n, err)
+	}
+	if n, err := r.ReadAt(p, 2000); n != 0 || err != io.EOF {
+		t.Errorf("read after the end: %d, %v", n, err)
+	}
+	if r.Cache().Size() > float64(len(content)) {
+		t.Errorf("blocks should be accounted for by their length: %v", r.Cache().Size())
+	}
+}
+
+func TestSharedCache(t *testing.T) {
+	c := lfuda.NewGDSF(1 << 20)
+	a := New(bytes.NewReader([]byte("aaaa")), 0, WithCache(c, "a"), WithBlockSize(2))
+	b := New(bytes.NewReader([]byte("bbbb")), 0, WithCache(c, "b"), WithBlockSize(2))
+	p := make([]byte, 4)
+	if _, err := a.ReadAt(p, 0); err != nil || string(p) != "aaaa" {
+		t.Fatalf("got %q, %v", p, err)
+	}
+	if _, err := b.ReadAt(p, 0); err != nil || string(p) != "bbbb" {
+		t.Fatalf("got %q, %v", p, err)
+	}
+	if c.Len() != 4 {
+		t.Errorf("got %d blocks, want 4", c.Len())
+	}
+}
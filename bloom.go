@@ -0,0 +1,131 @@
+package lfuda
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// BloomFilter is a probabilistic set of keys.  Test always reports true for
+// keys added to the filter and reports true for other keys with roughly the
+// false positive rate the filter was sized for.  Keys are hashed the same way
+// in every process, so a filter can be marshaled and sent to peers.
+type BloomFilter struct {
+	bits []uint64
+	// number of bits and of hash functions
+	m uint64
+	k uint32
+}
+
+// NewBloomFilter creates a filter sized for n keys with the given false
+// positive rate.
+func NewBloomFilter(n int, fpRate float64) *BloomFilter {
+	if n < 1 {
+		n = 1
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		fpRate = 0.01
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	if m < 64 {
+		m = 64
+	}
+	k := uint32(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return &BloomFilter{
+		bits: make([]uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+	}
+}
+
+// locations returns the two hashes combined to derive the key's k bits.
+func (b *BloomFilter) locations(key interface{}) (h1, h2 uint64) {
+	h1 = hashKey(key)
+	return h1, hashUint64(h1) | 1
+}
+
+// Add adds a key to the filter.
+func (b *BloomFilter) Add(key interface{}) {
+	h1, h2 := b.locations(key)
+	for i := uint32(0); i < b.k; i++ {
+		bit := (h1 + uint64(i)*h2) % b.m
+		b.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+// Test reports whether the key was probably added to the filter.
+func (b *BloomFilter) Test(key interface{}) bool {
+	h1, h2 := b.locations(key)
+	for i := uint32(0); i < b.k; i++ {
+		bit := (h1 + uint64(i)*h2) % b.m
+		if b.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// MarshalBinary encodes the filter.
+func (b *BloomFilter) MarshalBinary() ([]byte, error) {
+	data := make([]byte, 12+8*len(b.bits))
+	binary.LittleEndian.PutUint32(data, b.k)
+	binary.LittleEndian.PutUint64(data[4:], b.m)
+	for i, w := range b.bits {
+		binary.LittleEndian.PutUint64(data[12+8*i:], w)
+	}
+	return data, nil
+}
+
+// UnmarshalBinary decodes a filter encoded by MarshalBinary.
+func (b *BloomFilter) UnmarshalBinary(data []byte) error {
+	if len(data) < 12 {
+		return errors.New("lfuda: bloom filter too short")
+	}
+	k := binary.LittleEndian.Uint32(data)
+	m := binary.LittleEndian.Uint64(data[4:])
+	// bound m by the bits present before rounding it up, which could
+	// overflow
+	if k == 0 || m == 0 || m > uint64(len(data)-12)*8 || uint64(len(data)-12) != 8*((m+63)/64) {
+		return errors.New("lfuda: malformed bloom filter")
+	}
+
+	b.k, b.m = k, m
+	b.bits = make([]uint64, (m+63)/64)
+	for i := range b.bits {
+		b.bits[i] = binary.LittleEndian.Uint64(data[12+8*i:])
+	}
+	return nil
+}
+
+// KeysBloom returns a Bloom filter of the keys currently in the cache with
+// the given false positive rate, so that peers can cheaply check whether this
+// cache probably holds a key.
+func (c *Cache) KeysBloom(fpRate float64) *BloomFilter {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	b := NewBloomFilter(c.lfuda.Len(), fpRate)
+	c.lfuda.Range(func(info EntryInfo) bool {
+		b.Add(info.Key)
+		return true
+	})
+	return b
+}
+
+// KeysBloom returns a Bloom filter of the keys currently in the cache with
+// the given false positive rate.
+func (s *ShardedCache) KeysBloom(fpRate float64) *BloomFilter {
+	b := NewBloomFilter(s.Len(), fpRate)
+	for _, c := range s.shards {
+		c.lock.RLock()
+		c.lfuda.Range(func(info EntryInfo) bool {
+			b.Add(info.Key)
+			return true
+		})
+		c.lock.RUnlock()
+	}
+	return b
+}
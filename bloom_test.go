@@ -0,0 +1,67 @@
+package lfuda
+
+import (
+	"testing"
+)
+
+func TestKeysBloom(t *testing.T) {
+	l := New(100000)
+	for i := 0; i < 10000; i++ {
+		l.Set(i, i)
+	}
+
+	b := l.KeysBloom(0.01)
+	for i := 0; i < 10000; i++ {
+		if !b.Test(i) {
+			t.Fatalf("cached key %d should test positive", i)
+		}
+	}
+
+	falsePositives := 0
+	for i := 10000; i < 20000; i++ {
+		if b.Test(i) {
+			falsePositives++
+		}
+	}
+	if rate := float64(falsePositives) / 10000; rate > 0.02 {
+		t.Errorf("false positive rate too high: %f", rate)
+	}
+}
+
+func TestBloomFilterMarshal(t *testing.T) {
+	b := NewBloomFilter(100, 0.01)
+	b.Add("a")
+	b.Add(1)
+
+	data, err := b.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var peer BloomFilter
+	if err := peer.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !peer.Test("a") || !peer.Test(1) || peer.Test("1") {
+		t.Errorf("decoded filter should match the original")
+	}
+
+	if err := peer.UnmarshalBinary(data[:20]); err == nil {
+		t.Errorf("truncated filter should be rejected")
+	}
+	// a bit count overflowing when rounded up to words
+	bad := []byte{1, 0, 0, 0, 0xf0, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	if err := peer.UnmarshalBinary(bad); err == nil {
+		t.Errorf("filter with more bits than data should be rejected")
+	}
+}
+
+func TestShardedKeysBloom(t *testing.T) {
+	s := NewSharded(1000, WithShards(4))
+	s.Set("a", "a")
+	s.Set("b", "b")
+
+	b := s.KeysBloom(0.01)
+	if !b.Test("a") || !b.Test("b") {
+		t.Errorf("cached keys should test positive")
+	}
+}
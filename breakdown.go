@@ -0,0 +1,45 @@
+package lfuda
+
+// SizeBreakdown splits the entries counted by Len and Size by state.
+type SizeBreakdown struct {
+	// entries served by Get
+	LiveLen  int
+	LiveSize float64
+	// expired entries not removed yet, freed by PurgeExpired or on access
+	ExpiredLen  int
+	ExpiredSize float64
+	// live negative entries set by SetNegative or Cached
+	NegativeLen  int
+	NegativeSize float64
+}
+
+func (b *SizeBreakdown) add(o SizeBreakdown) {
+	b.LiveLen += o.LiveLen
+	b.LiveSize += o.LiveSize
+	b.ExpiredLen += o.ExpiredLen
+	b.ExpiredSize += o.ExpiredSize
+	b.NegativeLen += o.NegativeLen
+	b.NegativeSize += o.NegativeSize
+}
+
+// SizeBreakdown returns the number and size of the cache's entries by state,
+// scanning the cache under the read lock.
+func (c *Cache) SizeBreakdown() (b SizeBreakdown) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	c.lfuda.Range(func(info EntryInfo) bool {
+		value, ok := c.lfuda.Peek(info.Key)
+		if _, isNeg := value.(negativeEntry); !ok {
+			b.ExpiredLen++
+			b.ExpiredSize += info.Size
+		} else if isNeg {
+			b.NegativeLen++
+			b.NegativeSize += info.Size
+		} else {
+			b.LiveLen++
+			b.LiveSize += info.Size
+		}
+		return true
+	})
+	return b
+}
@@ -0,0 +1,42 @@
+package lfuda
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSizeBreakdown(t *testing.T) {
+	l := New(100)
+	l.SetWithSize(1, 1, 10)
+	l.SetWithSize(2, 2, 20)
+	l.SetNegative(3, 0)
+	l.SetWithTTL(4, "expiring", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	b := l.SizeBreakdown()
+	want := SizeBreakdown{
+		LiveLen: 2, LiveSize: 30,
+		ExpiredLen: 1, ExpiredSize: 8,
+		NegativeLen: 1, NegativeSize: negativeSize,
+	}
+	if b != want {
+		t.Errorf("bad breakdown: %+v", b)
+	}
+	if b.LiveSize+b.ExpiredSize+b.NegativeSize != l.Size() {
+		t.Errorf("breakdown should add up to the size")
+	}
+
+	s := NewSharded(1000, WithShards(2))
+	s.SetWithSize(1, 1, 10)
+	s.SetWithSize(2, 2, 20)
+	if b := s.SizeBreakdown(); b.LiveLen != 2 || b.LiveSize != 30 {
+		t.Errorf("bad sharded breakdown: %+v", b)
+	}
+	var total int
+	for _, st := range s.ShardStats() {
+		total += st.Breakdown.LiveLen
+	}
+	if total != 2 {
+		t.Errorf("shard breakdowns should add up: %d", total)
+	}
+}
@@ -0,0 +1,288 @@
+// Package bytescache provides a thread-safe lfuda cache specialized for
+// []byte values with string keys, such as raw response bodies.  Entries are
+// accounted for by the exact bytes they hold, values are never boxed in
+// interface{}, and with slab storage they are copied into large pages so the
+// garbage collector doesn't scan millions of slices.
+package bytescache
+
+import (
+	"sync"
+
+	"github.com/bparli/lfuda-go/internal/typed"
+)
+
+// Option configures a Cache.
+type Option func(*Cache)
+
+// WithSlabs copies values into chunks of SlabSize pages instead of keeping
+// the slices passed to Set, which greatly reduces the objects the garbage
+// collector tracks.  Entries are accounted for by their chunk size, a power
+// of two of at least 64 bytes.  Pages are kept once allocated, so memory use
+// can exceed the cache size by a page per size class.
+func WithSlabs() Option {
+	return func(c *Cache) {
+		c.slabs = newSlabs(nil)
+	}
+}
+
+// WithEvictCallback sets a callback invoked, with the cache lock held, for
+// every entry leaving the cache.  The value must not be retained.
+func WithEvictCallback(onEvicted func(key string, value []byte)) Option {
+	return func(c *Cache) {
+		c.onEvicted = onEvicted
+	}
+}
+
+// Cache is a thread-safe fixed size lfuda cache of []byte values.
+type Cache struct {
+	lfuda     *typed.Cache[string, ref]
+	lock      sync.RWMutex
+	capacity  float64
+	slabs     *slabs
+	onEvicted func(key string, value []byte)
+
+	// leased chunks, taken under the read lock
+	leaseLock sync.Mutex
+	leases    map[[2]int32]int
+	// leased chunks of entries gone, released with their last lease
+	unused map[[2]int32]ref
+}
+
+// New creates an lfuda of the given size.
+func New(size float64, opts ...Option) *Cache {
+	return newCache(size, typed.LFUDA, opts)
+}
+
+// NewGDSF creates an lfuda of the given size and the GDSF cache policy.
+func NewGDSF(size float64, opts ...Option) *Cache {
+	return newCache(size, typed.GDSF, opts)
+}
+
+// NewLFU creates an lfuda of the given size and the LFU cache policy.
+func NewLFU(size float64, opts ...Option) *Cache {
+	return newCache(size, typed.LFU, opts)
+}
+
+func newCache(size float64, policy typed.Policy, opts []Option) *Cache {
+	c := &Cache{capacity: size}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.lfuda = typed.New[string, ref](size, policy, nil, c.evicted)
+	return c
+}
+
+func (c *Cache) evicted(key string, r ref) {
+	if c.onEvicted != nil {
+		c.onEvicted(key, c.bytes(r))
+	}
+	if c.slabs != nil {
+		c.release(r)
+	}
+}
+
+func (c *Cache) bytes(r ref) []byte {
+	if c.slabs != nil {
+		return c.slabs.bytes(r)
+	}
+	return r.heap
+}
+
+// entrySize returns the bytes held by an entry.
+func (c *Cache) entrySize(key string, n int) float64 {
+	if c.slabs != nil {
+		if cl := class(n); cl >= 0 {
+			n = chunkSize(cl)
+		}
+	}
+	return float64(len(key) + n)
+}
+
+// Set adds a value to the cache, accounting for the length of the key and
+// the value.  Without slab storage the value is referenced and must not be
+// modified afterwards.  Returns true if an eviction occurred.
+func (c *Cache) Set(key string, value []byte) (ok bool) {
+	size := c.entrySize(key, len(value))
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if size > c.capacity {
+		// the new value won't fit so drop the stale one
+		c.lfuda.Remove(key)
+		return false
+	}
+	r := ref{heap: value}
+	if c.slabs != nil {
+		var err error
+		if r, err = c.slabs.alloc(value); err != nil {
+			// keep the stale value from being returned
+			c.lfuda.Remove(key)
+			return false
+		}
+	}
+	old, replaced := c.lfuda.Peek(key)
+	ok = c.lfuda.SetWithSize(key, r, size)
+	// replaced values don't go through the eviction callback
+	if replaced && c.slabs != nil {
+		c.release(old)
+	}
+	return ok
+}
+
+// Get appends the key's value to dst and returns the extended slice.
+func (c *Cache) Get(key string, dst []byte) (value []byte, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	r, ok := c.lfuda.Get(key)
+	if !ok {
+		return dst, false
+	}
+	return append(dst, c.bytes(r)...), true
+}
+
+// Peek returns the key's value without copying it or updating its hits.
+// The value must not be modified.  With slab storage it aliases the
+// entry's chunk and is only valid until the key is next set, removed or
+// evicted; use PeekLease, View or Get when that can't be guaranteed.
+func (c *Cache) Peek(key string) (value []byte, ok bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	r, ok := c.lfuda.Peek(key)
+	if !ok {
+		return nil, false
+	}
+	return c.bytes(r), true
+}
+
+// PeekLease returns the key's value without copying it or updating its
+// hits, along with a func releasing it.  With slab storage the value aliases
+// the entry's chunk, which isn't reused before release is called even if the
+// entry leaves the cache.  The value must not be modified nor used after
+// release.  Returns false and a no-op release if the key is not cached.
+func (c *Cache) PeekLease(key string) (value []byte, release func(), ok bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	r, ok := c.lfuda.Peek(key)
+	if !ok {
+		return nil, func() {}, false
+	}
+	if c.slabs == nil || r.heap != nil {
+		// heap values are never reused
+		return c.bytes(r), func() {}, true
+	}
+	chunk := [2]int32{r.page, r.off}
+	c.leaseLock.Lock()
+	if c.leases == nil {
+		c.leases = make(map[[2]int32]int)
+		c.unused = make(map[[2]int32]ref)
+	}
+	c.leases[chunk]++
+	c.leaseLock.Unlock()
+
+	var once sync.Once
+	return c.bytes(r), func() {
+		once.Do(func() { c.unlease(chunk) })
+	}, true
+}
+
+// unlease ends a lease of a chunk, releasing it if it was the last lease of
+// a chunk whose entry is gone.
+func (c *Cache) unlease(chunk [2]int32) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.leaseLock.Lock()
+	defer c.leaseLock.Unlock()
+	if c.leases[chunk]--; c.leases[chunk] > 0 {
+		return
+	}
+	delete(c.leases, chunk)
+	if r, ok := c.unused[chunk]; ok {
+		delete(c.unused, chunk)
+		c.slabs.release(r)
+	}
+}
+
+// release makes the chunk of an entry gone available again, once unleased.
+// The write lock must be held.
+func (c *Cache) release(r ref) {
+	c.leaseLock.Lock()
+	defer c.leaseLock.Unlock()
+	if chunk := [2]int32{r.page, r.off}; r.heap == nil && c.leases[chunk] > 0 {
+		c.unused[chunk] = r
+		return
+	}
+	c.slabs.release(r)
+}
+
+// View calls fn with the key's value, without copying it, while holding the
+// read lock.  fn must not modify or retain the value, nor call back into the
+// cache.  Returns false without calling fn if the key is not cached.
+func (c *Cache) View(key string, fn func(value []byte)) bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	r, ok := c.lfuda.Peek(key)
+	if ok {
+		fn(c.bytes(r))
+	}
+	return ok
+}
+
+// Contains checks if a key is in the cache without updating its hits.
+func (c *Cache) Contains(key string) bool {
+	c.lock.RLock()
+	containKey := c.lfuda.Contains(key)
+	c.lock.RUnlock()
+	return containKey
+}
+
+// Remove removes the provided key from the cache.
+func (c *Cache) Remove(key string) (present bool) {
+	c.lock.Lock()
+	present = c.lfuda.Remove(key)
+	c.lock.Unlock()
+	return
+}
+
+// Len returns the number of items in the cache.
+func (c *Cache) Len() (length int) {
+	c.lock.RLock()
+	length = c.lfuda.Len()
+	c.lock.RUnlock()
+	return length
+}
+
+// Size returns the current size of the cache in bytes.
+func (c *Cache) Size() (size float64) {
+	c.lock.RLock()
+	size = c.lfuda.Size()
+	c.lock.RUnlock()
+	return size
+}
+
+// Allocated returns the bytes held by slab pages, 0 without slab storage.
+func (c *Cache) Allocated() int {
+	if c.slabs == nil {
+		return 0
+	}
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.slabs.allocated()
+}
+
+// Close evicts every entry and releases the cache's storage.  The cache
+// must not be used afterwards.  It is a no-op without WithStorage.
+func (c *Cache) Close() error {
+	if c.slabs == nil || c.slabs.storage == nil {
+		return nil
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.lfuda.Purge()
+	return c.slabs.close()
+}
+
+// Purge is used to completely clear the cache.  Slab pages are kept.
+func (c *Cache) Purge() {
+	c.lock.Lock()
+	c.lfuda.Purge()
+	c.lock.Unlock()
+}
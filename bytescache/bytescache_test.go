@@ -0,0 +1,139 @@
+package bytescache
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	var evicted []string
+	l := New(100, WithEvictCallback(func(key string, value []byte) { evicted = append(evicted, key) }))
+	value := []byte("value")
+	l.Set("a", value)
+	if l.Size() != 6 {
+		t.Errorf("size should be the key and value lengths: %g", l.Size())
+	}
+	if v, ok := l.Peek("a"); !ok || &v[0] != &value[0] {
+		t.Error("Peek should not copy")
+	}
+	if v, ok := l.Get("a", []byte("x")); !ok || string(v) != "xvalue" {
+		t.Errorf("value should be appended to dst: %s", v)
+	}
+	if l.Set("big", make([]byte, 100)) || l.Contains("big") {
+		t.Error("values larger than the cache should be rejected")
+	}
+	l.Remove("a")
+	if len(evicted) != 1 || l.Len() != 0 {
+		t.Errorf("a should have been evicted: %v", evicted)
+	}
+}
+
+func TestSlabs(t *testing.T) {
+	l := New(64*100, WithSlabs())
+	value := []byte("value")
+	l.Set("a", value)
+	value[0] = 'V'
+	if v, _ := l.Peek("a"); string(v) != "value" {
+		t.Errorf("the value should have been copied: %s", v)
+	}
+	if l.Size() != 1+64 {
+		t.Errorf("entries should be accounted for by their chunk: %g", l.Size())
+	}
+	if !l.View("a", func(v []byte) {
+		if string(v) != "value" {
+			t.Errorf("bad value: %s", v)
+		}
+	}) {
+		t.Error("View should find a")
+	}
+
+	// chunks of replaced, removed and evicted values are reused
+	for i := 0; i < 1000; i++ {
+		l.Set(fmt.Sprint(i%300), bytes.Repeat([]byte{byte(i)}, 50))
+	}
+	if l.Allocated() != SlabSize {
+		t.Errorf("expected a single page: %d", l.Allocated())
+	}
+	free := len(l.slabs.free[0])
+	if free+l.Len() != SlabSize/minChunk {
+		t.Errorf("chunks leaked: %d free, %d used", free, l.Len())
+	}
+	for i := 700; i < 1000; i++ {
+		key := fmt.Sprint(i % 300)
+		if v, ok := l.Get(key, nil); ok && v[0] != byte(i) {
+			t.Errorf("bad value for %s", key)
+		}
+	}
+
+	large := make([]byte, SlabSize+1)
+	l = New(2*SlabSize, WithSlabs())
+	l.Set("large", large)
+	if v, _ := l.Peek("large"); len(v) != len(large) || l.Allocated() != 0 {
+		t.Error("values larger than a page should be stored on the heap")
+	}
+}
+
+func TestPeekLease(t *testing.T) {
+	l := New(2*(1+64), WithSlabs())
+	l.Set("a", []byte("a value"))
+	v, release, ok := l.PeekLease("a")
+	if !ok || string(v) != "a value" {
+		t.Fatalf("bad value: %s", v)
+	}
+	_, release2, _ := l.PeekLease("a")
+
+	// the leased chunk isn't reused by later values
+	l.Remove("a")
+	l.Set("b", []byte("b value"))
+	l.Set("c", []byte("c value"))
+	if string(v) != "a value" {
+		t.Errorf("leased value overwritten: %s", v)
+	}
+	free := len(l.slabs.free[0])
+	release()
+	release()
+	if len(l.slabs.free[0]) != free {
+		t.Errorf("chunk released with a lease left")
+	}
+	release2()
+	if len(l.slabs.free[0]) != free+1 {
+		t.Errorf("chunk not released with its last lease")
+	}
+
+	if _, release, ok := l.PeekLease("a"); ok {
+		t.Errorf("removed key leased")
+	} else {
+		release()
+	}
+	if v, release, ok := New(100).PeekLease("a"); ok || v != nil {
+		t.Errorf("bad lease of a missing key")
+	} else {
+		release()
+	}
+}
+
+func TestClass(t *testing.T) {
+	for _, c := range []struct{ n, class int }{{0, 0}, {64, 0}, {65, 1}, {128, 1}, {129, 2}, {SlabSize, 14}, {SlabSize + 1, -1}} {
+		if got := class(c.n); got != c.class {
+			t.Errorf("class(%d) = %d, want %d", c.n, got, c.class)
+		}
+	}
+}
+
+func BenchmarkSet(b *testing.B) {
+	for _, slabs := range []bool{false, true} {
+		b.Run(fmt.Sprintf("slabs=%v", slabs), func(b *testing.B) {
+			var opts []Option
+			if slabs {
+				opts = append(opts, WithSlabs())
+			}
+			l := New(1<<24, opts...)
+			value := make([]byte, 1000)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				l.Set(fmt.Sprint(i%100000), value)
+			}
+		})
+	}
+}
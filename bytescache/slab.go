@@ -0,0 +1,128 @@
+package bytescache
+
+import (
+	"math/bits"
+)
+
+const (
+	// SlabSize is the size of the pages slab storage allocates.  Values
+	// larger than a page are stored on the heap.
+	SlabSize = 1 << 20
+	// minChunk is the smallest chunk handed out by slab storage.
+	minChunk = 64
+)
+
+// ref locates a stored value: a chunk of a slab page, or a heap slice.
+type ref struct {
+	heap  []byte
+	page  int32
+	off   int32
+	n     int32
+	class int8
+}
+
+// slabs stores values in chunks of large pages, grouped in power of two size
+// classes, so millions of values are held by a few allocations the garbage
+// collector can skip instead of millions of slices.  Pages are never freed,
+// only their chunks reused.
+type slabs struct {
+	pages [][]byte
+	// free chunks of each class, as page and offset
+	free [][][2]int32
+	// storage maps pages outside of the heap, nil to allocate them
+	storage Storage
+}
+
+func newSlabs(storage Storage) *slabs {
+	return &slabs{free: make([][][2]int32, bits.Len(SlabSize/minChunk)), storage: storage}
+}
+
+// class returns the size class of n bytes, or -1 if n is larger than a page.
+func class(n int) int {
+	if n > SlabSize {
+		return -1
+	}
+	if n <= minChunk {
+		return 0
+	}
+	return bits.Len(uint(n-1)) - bits.Len(minChunk-1)
+}
+
+func chunkSize(class int) int {
+	return minChunk << class
+}
+
+// alloc copies b into a chunk.  Fails only if storage can't map a page.
+func (s *slabs) alloc(b []byte) (ref, error) {
+	cl := class(len(b))
+	if cl < 0 {
+		return ref{heap: append([]byte(nil), b...)}, nil
+	}
+	if len(s.free[cl]) == 0 {
+		if err := s.grow(cl); err != nil {
+			return ref{}, err
+		}
+	}
+	last := len(s.free[cl]) - 1
+	chunk := s.free[cl][last]
+	s.free[cl] = s.free[cl][:last]
+	copy(s.pages[chunk[0]][chunk[1]:], b)
+	return ref{page: chunk[0], off: chunk[1], n: int32(len(b)), class: int8(cl)}, nil
+}
+
+// grow adds a page carved into chunks of class cl.
+func (s *slabs) grow(cl int) error {
+	var p []byte
+	if s.storage != nil {
+		var err error
+		if p, err = s.storage.Map(SlabSize); err != nil {
+			return err
+		}
+	} else {
+		p = make([]byte, SlabSize)
+	}
+	page := int32(len(s.pages))
+	s.pages = append(s.pages, p)
+	for off := 0; off+chunkSize(cl) <= SlabSize; off += chunkSize(cl) {
+		s.free[cl] = append(s.free[cl], [2]int32{page, int32(off)})
+	}
+	return nil
+}
+
+// release makes the chunk of r available again.
+func (s *slabs) release(r ref) {
+	if r.heap == nil {
+		s.free[r.class] = append(s.free[r.class], [2]int32{r.page, r.off})
+	}
+}
+
+// bytes returns the stored value of r, aliasing its chunk.
+func (s *slabs) bytes(r ref) []byte {
+	if r.heap != nil {
+		return r.heap
+	}
+	return s.pages[r.page][r.off : r.off+r.n : r.off+r.n]
+}
+
+// allocated returns the bytes held by pages.
+func (s *slabs) allocated() int {
+	return len(s.pages) * SlabSize
+}
+
+// close unmaps the pages mapped by storage and closes it.
+func (s *slabs) close() error {
+	if s.storage == nil {
+		return nil
+	}
+	var err error
+	for _, p := range s.pages {
+		if uerr := s.storage.Unmap(p); err == nil {
+			err = uerr
+		}
+	}
+	s.pages = nil
+	if cerr := s.storage.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
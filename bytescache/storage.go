@@ -0,0 +1,30 @@
+package bytescache
+
+import (
+	"errors"
+)
+
+// Storage maps the pages of slab storage outside of the Go heap, so that
+// multi-GiB caches neither grow the heap nor lengthen garbage collection.
+type Storage interface {
+	// Map returns n bytes of zeroed memory.
+	Map(n int) ([]byte, error)
+	// Unmap releases memory returned by Map.
+	Unmap(b []byte) error
+	// Close releases the storage once its memory is unmapped.
+	Close() error
+}
+
+// ErrStorageUnsupported is returned by the storages that can't be created on
+// the current platform.
+var ErrStorageUnsupported = errors.New("bytescache: off-heap storage is not supported on this platform")
+
+// WithStorage stores values in slab pages mapped by storage, which implies
+// WithSlabs.  Only the entries' metadata stays on the heap, as do values
+// larger than SlabSize.  The cache must be closed to release the storage,
+// after which values returned by Peek must not be used.
+func WithStorage(storage Storage) Option {
+	return func(c *Cache) {
+		c.slabs = newSlabs(storage)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package bytescache
+
+// AnonymousStorage returns ErrStorageUnsupported on this platform.
+func AnonymousStorage() (Storage, error) {
+	return nil, ErrStorageUnsupported
+}
+
+// FileStorage returns ErrStorageUnsupported on this platform.
+func FileStorage(path string) (Storage, error) {
+	return nil, ErrStorageUnsupported
+}
@@ -0,0 +1,46 @@
+//go:build unix
+
+package bytescache
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStorage(t *testing.T) {
+	anon, err := AnonymousStorage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "values")
+	file, err := FileStorage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, storage := range map[string]Storage{"anonymous": anon, "file": file} {
+		t.Run(name, func(t *testing.T) {
+			l := New(4*SlabSize, WithStorage(storage))
+			for i := 0; i < 3000; i++ {
+				l.Set(fmt.Sprint(i), bytes.Repeat([]byte{byte(i)}, 1000))
+			}
+			if l.Allocated() == 0 {
+				t.Fatal("expected mapped pages")
+			}
+			for i := 0; i < 3000; i++ {
+				if v, ok := l.Get(fmt.Sprint(i), nil); ok && (len(v) != 1000 || v[999] != byte(i)) {
+					t.Fatalf("bad value for %d", i)
+				}
+			}
+			if err := l.Close(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("the storage file should have been removed: %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+//go:build unix
+
+package bytescache
+
+import (
+	"os"
+	"syscall"
+)
+
+// AnonymousStorage returns a Storage mapping anonymous memory, which the
+// kernel may swap out but the Go runtime doesn't track.
+func AnonymousStorage() (Storage, error) {
+	return anonymousStorage{}, nil
+}
+
+type anonymousStorage struct{}
+
+func (anonymousStorage) Map(n int) ([]byte, error) {
+	return syscall.Mmap(-1, 0, n, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+}
+
+func (anonymousStorage) Unmap(b []byte) error {
+	return syscall.Munmap(b)
+}
+
+func (anonymousStorage) Close() error {
+	return nil
+}
+
+// FileStorage returns a Storage mapping the file at path, which is created
+// or truncated and grows by a page at a time, so the page cache rather than
+// swap backs values.  The file is not a persistent format and is removed on
+// Close.
+func FileStorage(path string) (Storage, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &fileStorage{f: f}, nil
+}
+
+type fileStorage struct {
+	f    *os.File
+	size int64
+}
+
+func (s *fileStorage) Map(n int) ([]byte, error) {
+	// offsets must be multiples of the page size
+	pageSize := int64(os.Getpagesize())
+	length := (int64(n) + pageSize - 1) / pageSize * pageSize
+	if err := s.f.Truncate(s.size + length); err != nil {
+		return nil, err
+	}
+	b, err := syscall.Mmap(int(s.f.Fd()), s.size, n, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, err
+	}
+	s.size += length
+	return b, nil
+}
+
+func (s *fileStorage) Unmap(b []byte) error {
+	return syscall.Munmap(b)
+}
+
+func (s *fileStorage) Close() error {
+	err := s.f.Close()
+	if rerr := os.Remove(s.f.Name()); err == nil {
+		err = rerr
+	}
+	return err
+}
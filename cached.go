@@ -0,0 +1,53 @@
+package lfuda
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNotFound may be returned, possibly wrapped, by a fetch function passed
+// to Cached to report that its key does not exist at the origin.  Such
+// results are cached as negative entries so repeated lookups of missing keys
+// don't reach the origin.
+var ErrNotFound = errors.New("lfuda: not found")
+
+// Cached implements the cache-aside pattern.  It returns the value cached for
+// key or, on a miss, calls fetch and caches its result for ttl (forever if
+// ttl is 0).  Concurrent misses for the same key share a single fetch.
+//
+// Errors wrapping ErrNotFound are cached for ttl as negative entries and
+// returned to later callers, also matching ErrNegativeHit, without calling
+// fetch; other errors are not cached.
+func Cached[T any](c *Cache, key interface{}, ttl time.Duration, fn func() (T, error)) (T, error) {
+	var zero T
+
+	v, ok, err := c.Lookup(key)
+	if err != nil {
+		return zero, err
+	}
+	if ok {
+		// a nil value, cached for a nil T, is the zero T
+		if val, ok := v.(T); ok || v == nil {
+			return val, nil
+		}
+	}
+
+	v, err = c.loads.do(key, func() (interface{}, error) {
+		c.stats.loads.Add(1)
+		val, err := fetch(c, fn)
+		if err != nil {
+			c.stats.loadErrors.Add(1)
+			if errors.Is(err, ErrNotFound) {
+				c.setNegative(key, err, ttl)
+			}
+			return nil, err
+		}
+		c.SetWithTTL(key, val, ttl)
+		return val, nil
+	})
+	if err != nil {
+		return zero, err
+	}
+	val, _ := v.(T)
+	return val, nil
+}
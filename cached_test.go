@@ -0,0 +1,134 @@
+package lfuda
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCached(t *testing.T) {
+	l := New(100)
+
+	fetches := 0
+	fetch := func() (string, error) {
+		fetches++
+		return "value", nil
+	}
+
+	for i := 0; i < 3; i++ {
+		v, err := Cached(l, "key", 0, fetch)
+		if err != nil || v != "value" {
+			t.Fatalf("bad result: %v, %v", v, err)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("value should have been fetched once: %d", fetches)
+	}
+
+	stats := l.Stats()
+	if stats.Loads != 1 || stats.Hits != 2 || stats.Misses != 1 {
+		t.Errorf("bad stats: %+v", stats)
+	}
+}
+
+func TestCachedNil(t *testing.T) {
+	l := New(100)
+	fetches := 0
+	fetch := func() (fmt.Stringer, error) {
+		fetches++
+		return nil, nil
+	}
+	for i := 0; i < 3; i++ {
+		if v, err := Cached(l, "key", 0, fetch); err != nil || v != nil {
+			t.Fatalf("bad result: %v, %v", v, err)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("nil values should be cached: %d fetches", fetches)
+	}
+}
+
+func TestCachedTTL(t *testing.T) {
+	l := New(100)
+
+	fetches := 0
+	fetch := func() (int, error) {
+		fetches++
+		return fetches, nil
+	}
+
+	Cached(l, "key", time.Millisecond, fetch)
+	time.Sleep(5 * time.Millisecond)
+	if v, _ := Cached(l, "key", time.Millisecond, fetch); v != 2 {
+		t.Errorf("expired value should have been refetched: %d", v)
+	}
+}
+
+func TestCachedErrors(t *testing.T) {
+	l := New(100)
+
+	errOrigin := errors.New("origin down")
+	fetches := 0
+	_, err := Cached(l, "down", 0, func() (int, error) {
+		fetches++
+		return 0, errOrigin
+	})
+	if err != errOrigin {
+		t.Errorf("fetch error should be returned: %v", err)
+	}
+	if l.Contains("down") {
+		t.Errorf("errors should not be cached")
+	}
+
+	missing := fmt.Errorf("user 7: %w", ErrNotFound)
+	for i := 0; i < 3; i++ {
+		_, err = Cached(l, "missing", 0, func() (int, error) {
+			fetches++
+			return 0, missing
+		})
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("negative entry should return the original error: %v", err)
+		}
+	}
+	if fetches != 2 {
+		t.Errorf("missing key should have been fetched once: %d", fetches)
+	}
+
+	stats := l.Stats()
+	if stats.LoadErrors != 2 || stats.NegativeHits != 2 {
+		t.Errorf("bad stats: %+v", stats)
+	}
+}
+
+func TestCachedSingleflight(t *testing.T) {
+	l := New(100)
+
+	var fetches int32
+	release := make(chan struct{})
+	fetch := func() (int, error) {
+		atomic.AddInt32(&fetches, 1)
+		<-release
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := Cached(l, "key", 0, fetch); v != 42 || err != nil {
+				t.Errorf("bad result: %v, %v", v, err)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&fetches); n != 1 {
+		t.Errorf("concurrent misses should share one fetch: %d", n)
+	}
+}
@@ -0,0 +1,110 @@
+package lfuda
+
+import (
+	"context"
+	"time"
+)
+
+// Cacher is the surface of Cache, so code can depend on it and tests can
+// substitute a fake.  ShardedCache and NoopCache implement it too.
+type Cacher interface {
+	Set(key, value interface{}) bool
+	SetWithSize(key, value interface{}, size float64) bool
+	SetWithTTL(key, value interface{}, ttl time.Duration) bool
+	Get(key interface{}) (interface{}, bool)
+	GetOrLoad(ctx context.Context, key interface{}, loader Loader) (interface{}, error)
+	Peek(key interface{}) (interface{}, bool)
+	Contains(key interface{}) bool
+	ContainsOrSet(key, value interface{}) (ok, set bool)
+	PeekOrSet(key, value interface{}) (previous interface{}, ok, set bool)
+	Expire(key interface{}, ttl time.Duration) bool
+	TTL(key interface{}) (time.Duration, bool)
+	Remove(key interface{}) bool
+	Keys() []interface{}
+	Len() int
+	Size() float64
+	Capacity() float64
+	Resize(size float64) int
+	Purge()
+	Stats() Stats
+	ResetStats()
+	Close() error
+}
+
+var (
+	_ Cacher = (*Cache)(nil)
+	_ Cacher = (*ShardedCache)(nil)
+	_ Cacher = NoopCache{}
+)
+
+// NoopCache is a Cacher that caches nothing: sets are dropped, lookups miss
+// and GetOrLoad calls the loader every time.  Use it to disable caching
+// without changing the code using the cache.
+type NoopCache struct{}
+
+// Set drops the value.
+func (NoopCache) Set(key, value interface{}) bool { return false }
+
+// SetWithSize drops the value.
+func (NoopCache) SetWithSize(key, value interface{}, size float64) bool { return false }
+
+// SetWithTTL drops the value.
+func (NoopCache) SetWithTTL(key, value interface{}, ttl time.Duration) bool { return false }
+
+// Get misses.
+func (NoopCache) Get(key interface{}) (interface{}, bool) { return nil, false }
+
+// GetOrLoad returns the value loaded by loader.
+func (NoopCache) GetOrLoad(ctx context.Context, key interface{}, loader Loader) (interface{}, error) {
+	return loader(ctx, key)
+}
+
+// Peek misses.
+func (NoopCache) Peek(key interface{}) (interface{}, bool) { return nil, false }
+
+// Contains returns false.
+func (NoopCache) Contains(key interface{}) bool { return false }
+
+// ContainsOrSet drops the value.
+func (NoopCache) ContainsOrSet(key, value interface{}) (ok, set bool) { return false, false }
+
+// PeekOrSet drops the value.
+func (NoopCache) PeekOrSet(key, value interface{}) (previous interface{}, ok, set bool) {
+	return nil, false, false
+}
+
+// Expire returns false.
+func (NoopCache) Expire(key interface{}, ttl time.Duration) bool { return false }
+
+// TTL returns false.
+func (NoopCache) TTL(key interface{}) (time.Duration, bool) { return 0, false }
+
+// Remove returns false.
+func (NoopCache) Remove(key interface{}) bool { return false }
+
+// Keys returns nil.
+func (NoopCache) Keys() []interface{} { return nil }
+
+// Len returns 0.
+func (NoopCache) Len() int { return 0 }
+
+// Size returns 0.
+func (NoopCache) Size() float64 { return 0 }
+
+// Capacity returns 0.
+func (NoopCache) Capacity() float64 { return 0 }
+
+// Resize does nothing.
+func (NoopCache) Resize(size float64) int { return 0 }
+
+// Purge does nothing.
+func (NoopCache) Purge() {}
+
+// Stats returns zero stats.
+func (NoopCache) Stats() Stats { return Stats{} }
+
+// ResetStats does nothing.
+func (NoopCache) ResetStats() {}
+
+// Close does nothing.
+func (NoopCache) Close() error { return nil }
@@ -0,0 +1,29 @@
+package lfuda
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNoopCache(t *testing.T) {
+	var c Cacher = NoopCache{}
+	if c.Set(1, 1) || c.Contains(1) || c.Len() != 0 {
+		t.Errorf("no-op cache cached a value")
+	}
+	if _, ok := c.Get(1); ok {
+		t.Errorf("no-op cache hit")
+	}
+	loads := 0
+	loader := func(ctx context.Context, key interface{}) (interface{}, error) {
+		loads++
+		return key, nil
+	}
+	for i := 0; i < 2; i++ {
+		if v, err := c.GetOrLoad(context.Background(), 2, loader); err != nil || v != 2 {
+			t.Errorf("bad load: %v, %v", v, err)
+		}
+	}
+	if loads != 2 {
+		t.Errorf("loaded %d times, want 2", loads)
+	}
+}
@@ -0,0 +1,87 @@
+package lfuda
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WithAsyncEvictCallback calls the eviction callback on workers goroutines
+// instead of on the goroutine of the operation removing the entry, so slow
+// callbacks don't stall the operations evicting.  Up to queue removed
+// entries wait for a worker; beyond that the operation removing them blocks
+// until one is free.  Callbacks may run in any order and after the operation
+// returned; Close waits for the queued ones, and callbacks of entries removed
+// after Close run on the goroutine removing them.  The option is ignored if
+// workers is not positive.
+func WithAsyncEvictCallback(workers, queue int) Option {
+	return func(o *options) {
+		if workers <= 0 {
+			return
+		}
+		o.callbackWorkers = workers
+		o.callbackQueue = queue
+	}
+}
+
+// callbackPool runs eviction callbacks off the operations removing entries.
+type callbackPool struct {
+	queue chan event
+	wg    sync.WaitGroup
+
+	// sends in progress, which stop waits for before closing queue
+	sending atomic.Int64
+	stopped atomic.Bool
+}
+
+func (c *Cache) startCallbacks(workers, queue int) {
+	if queue < 0 {
+		queue = 0
+	}
+	c.callbacks = &callbackPool{queue: make(chan event, queue)}
+	c.callbacks.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer c.callbacks.wg.Done()
+			for e := range c.callbacks.queue {
+				c.runCallback(e)
+				if e.recycle {
+					c.opts.valuePool.Put(e.value)
+				}
+			}
+		}()
+	}
+}
+
+// runCallback calls the expire callback for expired entries if set, the
+// eviction callback otherwise.
+func (c *Cache) runCallback(e event) {
+	if e.reason == reasonExpired && c.opts.onExpired != nil {
+		c.onExpired(e.key, e.value)
+	} else if c.opts.onEvicted != nil {
+		c.onEvicted(e.key, e.value)
+	}
+}
+
+// send queues an event for the workers.  Returns false if the pool was
+// stopped.
+func (p *callbackPool) send(e event) bool {
+	p.sending.Add(1)
+	defer p.sending.Add(-1)
+	if p.stopped.Load() {
+		return false
+	}
+	p.queue <- e
+	return true
+}
+
+// stop waits for the queued callbacks to run.
+func (p *callbackPool) stop() {
+	p.stopped.Store(true)
+	// the workers keep running the callbacks, so blocked sends go through
+	for p.sending.Load() > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(p.queue)
+	p.wg.Wait()
+}
@@ -0,0 +1,107 @@
+package lfuda
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAsyncEvictCallback(t *testing.T) {
+	var l *Cache
+	release := make(chan struct{})
+	var called, lens int32
+	l = NewWithOptions(2, WithAsyncEvictCallback(1, 10), WithEvictCallback(func(key, value interface{}) {
+		<-release
+		// the cache can be used from the callback
+		atomic.AddInt32(&lens, int32(l.Len()))
+		atomic.AddInt32(&called, 1)
+	}))
+	for i := 0; i < 5; i++ {
+		done := make(chan struct{})
+		go func() {
+			l.Set(i, i)
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("a slow callback should not stall Set")
+		}
+	}
+	if atomic.LoadInt32(&called) != 0 {
+		t.Errorf("the callbacks should be waiting")
+	}
+
+	close(release)
+	l.Close()
+	if called, lens := atomic.LoadInt32(&called), atomic.LoadInt32(&lens); called != 3 || lens != 6 {
+		t.Errorf("Close should wait for the queued callbacks: %d %d", called, lens)
+	}
+
+	// entries removed after Close get their callback synchronously
+	before := atomic.LoadInt32(&called)
+	l.Purge()
+	if n := atomic.LoadInt32(&called) - before; n != 2 {
+		t.Errorf("got %d callbacks after Close, want 2", n)
+	}
+
+	// without workers callbacks stay synchronous
+	var synced int32
+	s := NewWithOptions(1, WithAsyncEvictCallback(0, 10), WithEvictCallback(func(key, value interface{}) {
+		atomic.AddInt32(&synced, 1)
+	}))
+	defer s.Close()
+	s.Set(1, 1)
+	s.Set(2, 2)
+	if atomic.LoadInt32(&synced) != 1 {
+		t.Errorf("the callback should have run")
+	}
+}
+
+func TestReentrantEvictCallback(t *testing.T) {
+	var l *Cache
+	tombstones := 0
+	l = NewWithOptions(2, WithEvictCallback(func(key, value interface{}) {
+		if value != "tombstone" {
+			l.Set(key.(int)+100, "tombstone")
+			tombstones++
+		}
+	}))
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 4; i++ {
+			l.Set(i, i)
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("callbacks calling back into the cache should not deadlock")
+	}
+	if tombstones == 0 {
+		t.Errorf("the callbacks should have set tombstones")
+	}
+}
+
+func TestExpireCallback(t *testing.T) {
+	var evicted, expired []interface{}
+	l := NewWithOptions(2, WithEvictCallback(func(key, value interface{}) {
+		evicted = append(evicted, key)
+	}), WithExpireCallback(func(key, value interface{}) {
+		expired = append(expired, key)
+	}))
+	l.SetWithTTL("stale", 1, time.Nanosecond)
+	l.Set("a", 1)
+	time.Sleep(time.Millisecond)
+	l.PurgeExpired()
+	l.Set("b", 2)
+	l.Set("c", 3)
+
+	if len(expired) != 1 || expired[0] != "stale" {
+		t.Errorf("stale should be reported as expired: %v", expired)
+	}
+	if len(evicted) != 1 {
+		t.Errorf("one entry should be reported as evicted: %v", evicted)
+	}
+}
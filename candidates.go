@@ -0,0 +1,28 @@
+package lfuda
+
+// Candidate is an entry the cache would evict, with its value.
+type Candidate struct {
+	EntryInfo
+	Value interface{}
+}
+
+// PeekEvictionCandidates returns the next n entries the policy would evict,
+// from the first to go, without removing them or updating their hits.
+// Entries with the same priority are evicted in an unspecified order, and
+// later accesses may reorder them.
+func (c *Cache) PeekEvictionCandidates(n int) []Candidate {
+	if n <= 0 {
+		return nil
+	}
+	c.flushReads()
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	var candidates []Candidate
+	c.lfuda.RangeReverse(func(info EntryInfo) bool {
+		value, _ := c.decode(c.lfuda.Peek(info.Key))
+		candidates = append(candidates, Candidate{EntryInfo: info, Value: value})
+		return len(candidates) < n
+	})
+	return candidates
+}
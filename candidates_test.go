@@ -0,0 +1,27 @@
+package lfuda
+
+import "testing"
+
+func TestPeekEvictionCandidates(t *testing.T) {
+	l := New(3)
+	l.Set(1, "a")
+	l.Set(2, "b")
+	l.Set(3, "c")
+	l.Get(1)
+	l.Get(1)
+	l.Get(2)
+
+	candidates := l.PeekEvictionCandidates(2)
+	if len(candidates) != 2 || candidates[0].Key != 3 || candidates[0].Value != "c" || candidates[1].Key != 2 {
+		t.Fatalf("bad candidates: %+v", candidates)
+	}
+	if len(l.PeekEvictionCandidates(10)) != 3 || l.PeekEvictionCandidates(0) != nil {
+		t.Errorf("should return at most every entry")
+	}
+
+	// peeking doesn't update hits, so the first candidate goes first
+	l.Set(4, "d")
+	if l.Contains(3) || !l.Contains(2) {
+		t.Errorf("3 should have been evicted first")
+	}
+}
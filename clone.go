@@ -0,0 +1,63 @@
+package lfuda
+
+// Clone returns a copy of the cache with the same entries, hits and age, e.g.
+// to take a consistent snapshot while the original keeps serving traffic.
+// Values are deep copied by WithCopyOnSet's copy func if set, or marshaled
+// and unmarshaled by the cache's codec, and entries whose values fail to copy
+// are left out.  The copy has the original's options except its tier, and
+// starts with no hooks, no WAL and zero stats.
+func (c *Cache) Clone() *Cache {
+	c.flushReads()
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	// the clone writing to the same tier would race the original's writer
+	opts := c.opts
+	opts.tier = nil
+	// the new cache's entries are replaced by the copy
+	clone := newCache(0, opts)
+	clone.lfuda = c.lfuda.CloneWith(clone.evict, clone.cloneValue)
+	clone.lfuda.SetExpireCallback(clone.expire)
+	clone.lfuda.SetEvictObserver(clone.observeEviction)
+	clone.writes = c.writes
+	if clone.prefixes != nil {
+		for _, key := range clone.lfuda.Keys() {
+			if s, ok := key.(string); ok {
+				clone.prefixes.insert(s)
+			}
+		}
+	}
+	return clone
+}
+
+// cloneValue returns a deep copy of a value stored by another cache, to store
+// in c.  Returns false if it can't be copied.
+func (c *Cache) cloneValue(key, value interface{}) (interface{}, bool) {
+	w, weak := value.(*weakValue)
+	if weak {
+		var ok bool
+		if value, ok = w.get(); !ok {
+			return nil, false
+		}
+	}
+	switch value.(type) {
+	case negativeEntry, encodedValue:
+		// never modified in place
+	default:
+		var err error
+		if value, err = c.copyValue(c.opts.setCopy, value); err != nil {
+			c.debug("lfuda: value copy failed", "key", key, "error", err)
+			return nil, false
+		}
+	}
+	if weak {
+		copied := &weakValue{key: key}
+		copied.value.Store(&value)
+		copied.used.Store(w.used.Load())
+		c.weak.lock.Lock()
+		c.weak.values[copied] = struct{}{}
+		c.weak.lock.Unlock()
+		return copied, true
+	}
+	return value, true
+}
@@ -0,0 +1,74 @@
+package lfuda
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	l := NewWithOptions(3, WithPrefixIndex())
+	l.Set("a", 1)
+	l.Set("b", 2)
+	l.Get("a")
+	l.Set("c", 3)
+	l.Set("d", 4)
+
+	c := l.Clone()
+	if c.Len() != l.Len() || c.Size() != l.Size() || c.Age() != l.Age() {
+		t.Fatalf("clone should have the same state")
+	}
+	_, meta, _ := c.GetWithMeta("a")
+	if meta.Hits != 3 {
+		t.Errorf("clone should keep hits: %+v", meta)
+	}
+
+	c.Set("e", 5)
+	if l.Contains("e") || l.Len() != 3 {
+		t.Errorf("clone should be independent of the original")
+	}
+	l.Remove("a")
+	if !c.Contains("a") {
+		t.Errorf("original should be independent of the clone")
+	}
+	if n := c.RemovePrefix("a"); n != 1 {
+		t.Errorf("clone should index its keys: %d", n)
+	}
+}
+
+func TestCloneDeepCopy(t *testing.T) {
+	tier := newMapTier()
+	l := NewWithOptions(100, WithTier(tier))
+	defer l.Close()
+	l.Set("bytes", []byte("abc"))
+	l.SetNegative("missing", 0)
+
+	c := l.Clone()
+	defer c.Close()
+	if c.tier != nil {
+		t.Errorf("clone should not write to the original's tier")
+	}
+	v, _ := c.Get("bytes")
+	v.([]byte)[0] = 'x'
+	if v, _ := l.Get("bytes"); string(v.([]byte)) != "abc" {
+		t.Errorf("modifying the clone's value should not change the original: %s", v)
+	}
+	if _, _, err := c.Lookup("missing"); !errors.Is(err, ErrNegativeHit) {
+		t.Errorf("clone should keep negative entries: %v", err)
+	}
+
+	// values the codec can't copy are left out
+	l.Set("func", func() {})
+	if c := l.Clone(); c.Contains("func") || !c.Contains("bytes") {
+		t.Errorf("uncopyable values should be left out: %v", c.Keys())
+	}
+
+	copied := NewWithOptions(100, WithCopyOnSet(func(value interface{}) (interface{}, error) {
+		return append([]byte(nil), value.([]byte)...), nil
+	}))
+	copied.Set("bytes", []byte("abc"))
+	v, _ = copied.Clone().Get("bytes")
+	v.([]byte)[0] = 'x'
+	if v, _ := copied.Get("bytes"); string(v.([]byte)) != "abc" {
+		t.Errorf("clone should copy values with the copy func: %s", v)
+	}
+}
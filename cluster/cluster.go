@@ -0,0 +1,236 @@
+// Package cluster spreads keys across a fleet of remote caches, such as
+// lfudad instances, by consistent hashing, storing each key on a
+// configurable number of nodes.  A Cluster offers the same Get, Set and
+// Remove API as lfuda.Cache, so callers can move from a local cache to a
+// fleet without changes.
+//
+// Values travel gob encoded, so custom types must be registered with
+// gob.Register.  Keys are strings as is, other keys are formatted with their
+// type, and must be valid memcached keys when using MemcachedNode.
+package cluster
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"sync"
+	"time"
+
+	lfuda "github.com/bparli/lfuda-go"
+	"github.com/bparli/lfuda-go/internal/ring"
+)
+
+// Node is a remote cache instance.
+type Node interface {
+	// Get returns the value of key, false if it is not cached.
+	Get(key string) (value []byte, ok bool, err error)
+	// Set caches value for ttl, 0 meaning forever.
+	Set(key string, value []byte, ttl time.Duration) error
+	// Delete removes key, returning whether it was cached.
+	Delete(key string) (present bool, err error)
+}
+
+// Option configures a Cluster.
+type Option func(*Cluster)
+
+// WithReplication stores every key on n nodes, 1 by default.  Reads fall
+// back to the next replica when a node fails.
+func WithReplication(n int) Option {
+	return func(c *Cluster) {
+		if n > 0 {
+			c.replication = n
+		}
+	}
+}
+
+// WithReplicas sets the number of points each node gets on the hash ring.
+func WithReplicas(n int) Option {
+	return func(c *Cluster) {
+		c.replicas = n
+	}
+}
+
+// WithErrorHandler sets a function called with the errors of nodes, which
+// the Cache API otherwise reports as misses or failed sets.
+func WithErrorHandler(fn func(node string, err error)) Option {
+	return func(c *Cluster) {
+		c.onError = fn
+	}
+}
+
+// Cluster is a cache spread over several nodes.  It is safe for concurrent
+// use.
+type Cluster struct {
+	replication int
+	replicas    int
+	onError     func(node string, err error)
+
+	mu    sync.RWMutex
+	ring  *ring.Ring
+	nodes map[string]Node
+}
+
+// New creates a cluster of the given nodes, keyed by name.
+func New(nodes map[string]Node, opts ...Option) *Cluster {
+	c := &Cluster{replication: 1}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.SetNodes(nodes)
+	return c
+}
+
+// Dial creates a cluster of lfudad or memcached servers at addrs.
+func Dial(addrs []string, opts ...Option) *Cluster {
+	nodes := make(map[string]Node, len(addrs))
+	for _, addr := range addrs {
+		nodes[addr] = NewMemcachedNode(addr)
+	}
+	return New(nodes, opts...)
+}
+
+// SetNodes replaces the nodes.  Only the keys of added or removed nodes
+// move.
+func (c *Cluster) SetNodes(nodes map[string]Node) {
+	r := ring.New(c.replicas)
+	for name := range nodes {
+		r.Add(name)
+	}
+	c.mu.Lock()
+	c.ring, c.nodes = r, nodes
+	c.mu.Unlock()
+}
+
+type replica struct {
+	name string
+	node Node
+}
+
+// owners returns the nodes storing key, the primary first.
+func (c *Cluster) owners(key string) []replica {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	names := c.ring.GetN(key, c.replication)
+	owners := make([]replica, len(names))
+	for i, name := range names {
+		owners[i] = replica{name, c.nodes[name]}
+	}
+	return owners
+}
+
+func (c *Cluster) fail(node string, err error) {
+	if c.onError != nil {
+		c.onError(node, err)
+	}
+}
+
+// keyString returns the node key of key.
+func keyString(key interface{}) string {
+	if s, ok := key.(string); ok {
+		return s
+	}
+	return fmt.Sprintf("%T:%v", key, key)
+}
+
+// Set caches value for key on its nodes.  Unlike lfuda.Cache, whose Set
+// reports evictions, it returns false if no node stored the value.
+func (c *Cluster) Set(key, value interface{}) (ok bool) {
+	return c.SetWithTTL(key, value, 0)
+}
+
+// SetWithTTL is Set with an expiration, 0 meaning no expiration.
+func (c *Cluster) SetWithTTL(key, value interface{}, ttl time.Duration) (ok bool) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&value); err != nil {
+		c.fail("", err)
+		return false
+	}
+	k := keyString(key)
+	for _, r := range c.owners(k) {
+		if err := r.node.Set(k, buf.Bytes(), ttl); err != nil {
+			c.fail(r.name, err)
+			continue
+		}
+		ok = true
+	}
+	return ok
+}
+
+// Get returns the value of key from the first of its nodes that answers.
+func (c *Cluster) Get(key interface{}) (value interface{}, ok bool) {
+	k := keyString(key)
+	for _, r := range c.owners(k) {
+		b, found, err := r.node.Get(k)
+		if err != nil {
+			c.fail(r.name, err)
+			continue
+		}
+		if !found {
+			return nil, false
+		}
+		if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&value); err != nil {
+			c.fail(r.name, err)
+			return nil, false
+		}
+		return value, true
+	}
+	return nil, false
+}
+
+// Contains reports whether key is cached.
+func (c *Cluster) Contains(key interface{}) bool {
+	_, ok := c.Get(key)
+	return ok
+}
+
+// Remove removes key from all its nodes, returning whether any held it.
+func (c *Cluster) Remove(key interface{}) (present bool) {
+	k := keyString(key)
+	for _, r := range c.owners(k) {
+		p, err := r.node.Delete(k)
+		if err != nil {
+			c.fail(r.name, err)
+		}
+		present = present || p
+	}
+	return present
+}
+
+// Nodes returns the names of the nodes storing key, the primary first.
+func (c *Cluster) Nodes(key interface{}) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.ring.GetN(keyString(key), c.replication)
+}
+
+// LocalNode adapts an in-process cache to a Node, for tests and for
+// processes that are members of their own cluster.
+func LocalNode(c *lfuda.Cache) Node {
+	return localNode{c}
+}
+
+type localNode struct {
+	c *lfuda.Cache
+}
+
+func (n localNode) Get(key string) ([]byte, bool, error) {
+	v, ok := n.c.Get(key)
+	if !ok {
+		return nil, false, nil
+	}
+	return v.([]byte), true, nil
+}
+
+func (n localNode) Set(key string, value []byte, ttl time.Duration) error {
+	// the caller reuses value
+	b := append([]byte(nil), value...)
+	n.c.SetWithTTL(key, b, ttl)
+	if !n.c.Contains(key) {
+		return fmt.Errorf("cluster: %q not stored", key)
+	}
+	return nil
+}
+
+func (n localNode) Delete(key string) (bool, error) {
+	return n.c.Remove(key), nil
+}
@@ -0,0 +1,90 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	lfuda "github.com/bparli/lfuda-go"
+)
+
+// downNode fails every request.
+type downNode struct{}
+
+var errDown = errors.New("node down")
+
+func (downNode) Get(string) ([]byte, bool, error)        { return nil, false, errDown }
+func (downNode) Set(string, []byte, time.Duration) error { return errDown }
+func (downNode) Delete(string) (bool, error)             { return false, errDown }
+
+func localNodes(n int) (map[string]Node, map[string]*lfuda.Cache) {
+	nodes := make(map[string]Node)
+	caches := make(map[string]*lfuda.Cache)
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("node%d", i)
+		caches[name] = lfuda.New(1 << 20)
+		nodes[name] = LocalNode(caches[name])
+	}
+	return nodes, caches
+}
+
+func TestCluster(t *testing.T) {
+	nodes, caches := localNodes(3)
+	c := New(nodes)
+	for i := 0; i < 300; i++ {
+		if !c.Set(i, fmt.Sprint(i)) {
+			t.Fatalf("%d not stored", i)
+		}
+	}
+	for i := 0; i < 300; i++ {
+		if v, ok := c.Get(i); !ok || v != fmt.Sprint(i) {
+			t.Errorf("bad value for %d: %v", i, v)
+		}
+	}
+	for name, cache := range caches {
+		if cache.Len() < 50 {
+			t.Errorf("%s holds too few keys: %d", name, cache.Len())
+		}
+	}
+	if v, ok := c.Get("missing"); ok {
+		t.Errorf("unexpected value: %v", v)
+	}
+	if !c.Remove(1) || c.Contains(1) || c.Remove(1) {
+		t.Error("1 should have been removed once")
+	}
+	// keys are typed
+	c.Set("2", "string")
+	if v, _ := c.Get(2); v != "2" {
+		t.Errorf("int key overwritten by string key: %v", v)
+	}
+}
+
+func TestClusterReplication(t *testing.T) {
+	nodes, caches := localNodes(3)
+	var failures int
+	c := New(nodes, WithReplication(2), WithErrorHandler(func(string, error) { failures++ }))
+	c.Set("a", 1)
+	owners := c.Nodes("a")
+	if len(owners) != 2 {
+		t.Fatalf("expected 2 owners: %v", owners)
+	}
+	for _, name := range owners {
+		if !caches[name].Contains("a") {
+			t.Errorf("%s should hold a replica", name)
+		}
+	}
+
+	// reads fall back to the replica when the primary is down
+	nodes[owners[0]] = downNode{}
+	c.SetNodes(nodes)
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Errorf("expected the replica's value: %v", v)
+	}
+	if failures != 1 {
+		t.Errorf("expected 1 failure reported: %d", failures)
+	}
+	if !c.Set("a", 2) {
+		t.Error("a set should succeed while a replica is up")
+	}
+}
@@ -0,0 +1,207 @@
+package cluster
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMaxIdle is the number of idle connections a MemcachedNode keeps.
+const DefaultMaxIdle = 4
+
+// MemcachedNode is a Node speaking the memcached text protocol, as served
+// by lfudad.
+type MemcachedNode struct {
+	// Timeout bounds each request, none if 0.
+	Timeout time.Duration
+
+	addr string
+	idle chan *memcachedConn
+}
+
+// NewMemcachedNode creates a node for the server at addr.  Connections are
+// dialed on demand.
+func NewMemcachedNode(addr string) *MemcachedNode {
+	return &MemcachedNode{addr: addr, idle: make(chan *memcachedConn, DefaultMaxIdle)}
+}
+
+type memcachedConn struct {
+	net.Conn
+	r *bufio.Reader
+	w *bufio.Writer
+}
+
+func (n *MemcachedNode) conn() (*memcachedConn, error) {
+	select {
+	case c := <-n.idle:
+		return c, nil
+	default:
+	}
+	c, err := net.DialTimeout("tcp", n.addr, n.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &memcachedConn{Conn: c, r: bufio.NewReader(c), w: bufio.NewWriter(c)}, nil
+}
+
+// do runs fn on a connection, which is closed if fn fails since its
+// state is unknown.
+func (n *MemcachedNode) do(fn func(c *memcachedConn) error) error {
+	c, err := n.conn()
+	if err != nil {
+		return err
+	}
+	if n.Timeout > 0 {
+		c.SetDeadline(time.Now().Add(n.Timeout))
+	}
+	if err := fn(c); err != nil {
+		c.Close()
+		return err
+	}
+	if n.Timeout > 0 {
+		c.SetDeadline(time.Time{})
+	}
+	select {
+	case n.idle <- c:
+	default:
+		c.Close()
+	}
+	return nil
+}
+
+func validKey(key string) error {
+	if len(key) == 0 || len(key) > 250 || strings.IndexFunc(key, func(r rune) bool { return r <= ' ' || r == 0x7f }) >= 0 {
+		return fmt.Errorf("cluster: invalid memcached key %q", key)
+	}
+	return nil
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// Get implements Node.
+func (n *MemcachedNode) Get(key string) (value []byte, ok bool, err error) {
+	if err := validKey(key); err != nil {
+		return nil, false, err
+	}
+	err = n.do(func(c *memcachedConn) error {
+		fmt.Fprintf(c.w, "get %s\r\n", key)
+		if err := c.w.Flush(); err != nil {
+			return err
+		}
+		line, err := readLine(c.r)
+		if err != nil {
+			return err
+		}
+		if line == "END" {
+			return nil
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 4 || fields[0] != "VALUE" {
+			return fmt.Errorf("cluster: %s replied %q", n.addr, line)
+		}
+		size, err := strconv.Atoi(fields[3])
+		if err != nil || size < 0 {
+			return fmt.Errorf("cluster: %s replied %q", n.addr, line)
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			return err
+		}
+		if line, err = readLine(c.r); err != nil {
+			return err
+		}
+		if line != "END" {
+			return fmt.Errorf("cluster: %s replied %q", n.addr, line)
+		}
+		value, ok = buf[:size], true
+		return nil
+	})
+	return value, ok, err
+}
+
+// maxRelativeExptime is the longest exptime memcached reads as seconds from
+// now; larger ones are unix timestamps.
+const maxRelativeExptime = 30 * 24 * 60 * 60
+
+// exptime returns the memcached exptime of ttl, 0 if it never expires.
+func exptime(ttl time.Duration, now time.Time) int64 {
+	if ttl <= 0 {
+		return 0
+	}
+	seconds := int64((ttl + time.Second - 1) / time.Second)
+	if seconds > maxRelativeExptime {
+		return now.Add(ttl).Unix()
+	}
+	return seconds
+}
+
+// Set implements Node.
+func (n *MemcachedNode) Set(key string, value []byte, ttl time.Duration) error {
+	if err := validKey(key); err != nil {
+		return err
+	}
+	return n.do(func(c *memcachedConn) error {
+		fmt.Fprintf(c.w, "set %s 0 %d %d\r\n", key, exptime(ttl, time.Now()), len(value))
+		c.w.Write(value)
+		c.w.WriteString("\r\n")
+		if err := c.w.Flush(); err != nil {
+			return err
+		}
+		line, err := readLine(c.r)
+		if err != nil {
+			return err
+		}
+		if line != "STORED" {
+			return fmt.Errorf("cluster: %s replied %q", n.addr, line)
+		}
+		return nil
+	})
+}
+
+// Delete implements Node.
+func (n *MemcachedNode) Delete(key string) (present bool, err error) {
+	if err := validKey(key); err != nil {
+		return false, err
+	}
+	err = n.do(func(c *memcachedConn) error {
+		fmt.Fprintf(c.w, "delete %s\r\n", key)
+		if err := c.w.Flush(); err != nil {
+			return err
+		}
+		line, err := readLine(c.r)
+		if err != nil {
+			return err
+		}
+		switch line {
+		case "DELETED":
+			present = true
+		case "NOT_FOUND":
+		default:
+			return fmt.Errorf("cluster: %s replied %q", n.addr, line)
+		}
+		return nil
+	})
+	return present, err
+}
+
+// Close closes the idle connections.
+func (n *MemcachedNode) Close() error {
+	for {
+		select {
+		case c := <-n.idle:
+			c.Close()
+		default:
+			return nil
+		}
+	}
+}
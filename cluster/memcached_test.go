@@ -0,0 +1,113 @@
+package cluster
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serveMemcached answers get, set and delete from a map, one connection at
+// a time.
+func serveMemcached(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	data := make(map[string]string)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			r, w := bufio.NewReader(conn), bufio.NewWriter(conn)
+			for {
+				line, err := readLine(r)
+				if err != nil {
+					break
+				}
+				f := strings.Fields(line)
+				switch f[0] {
+				case "get":
+					if v, ok := data[f[1]]; ok {
+						w.WriteString("VALUE " + f[1] + " 0 " + strconv.Itoa(len(v)) + "\r\n" + v + "\r\n")
+					}
+					w.WriteString("END\r\n")
+				case "set":
+					size, _ := strconv.Atoi(f[4])
+					buf := make([]byte, size+2)
+					io.ReadFull(r, buf)
+					data[f[1]] = string(buf[:size])
+					w.WriteString("STORED\r\n")
+				case "delete":
+					if _, ok := data[f[1]]; ok {
+						delete(data, f[1])
+						w.WriteString("DELETED\r\n")
+					} else {
+						w.WriteString("NOT_FOUND\r\n")
+					}
+				default:
+					w.WriteString("ERROR\r\n")
+				}
+				w.Flush()
+			}
+			conn.Close()
+		}
+	}()
+	return l.Addr().String()
+}
+
+func TestMemcachedNode(t *testing.T) {
+	n := NewMemcachedNode(serveMemcached(t))
+	n.Timeout = time.Second
+	defer n.Close()
+
+	if _, ok, err := n.Get("a"); ok || err != nil {
+		t.Fatalf("expected a miss: %v, %v", ok, err)
+	}
+	if err := n.Set("a", []byte("x\r\ny"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok, err := n.Get("a"); !ok || err != nil || string(v) != "x\r\ny" {
+		t.Errorf("bad value: %q, %v, %v", v, ok, err)
+	}
+	if present, err := n.Delete("a"); !present || err != nil {
+		t.Errorf("a should have been deleted: %v", err)
+	}
+	if present, _ := n.Delete("a"); present {
+		t.Error("a should be gone")
+	}
+	if err := n.Set("bad key", nil, 0); err == nil {
+		t.Error("keys with spaces should be rejected")
+	}
+}
+
+func TestDial(t *testing.T) {
+	c := Dial([]string{serveMemcached(t)})
+	c.Set("a", []int{1, 2})
+	if v, ok := c.Get("a"); !ok || len(v.([]int)) != 2 {
+		t.Errorf("bad value: %v", v)
+	}
+}
+
+func TestExptime(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	for _, c := range []struct {
+		ttl  time.Duration
+		want int64
+	}{
+		{0, 0},
+		{1500 * time.Millisecond, 2},
+		{30 * 24 * time.Hour, 2592000},
+		{31 * 24 * time.Hour, 1700000000 + 31*24*3600},
+	} {
+		if got := exptime(c.ttl, now); got != c.want {
+			t.Errorf("exptime(%v) = %d, want %d", c.ttl, got, c.want)
+		}
+	}
+}
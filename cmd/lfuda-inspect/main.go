@@ -0,0 +1,145 @@
+// Command lfuda-inspect prints a summary of a snapshot written by
+// Cache.WriteSnapshot: entry counts, the size distribution, a frequency
+// histogram and the most frequently used keys.  Values are not decoded, so
+// snapshots can be inspected without the program's value types.
+//
+// Usage:
+//
+//	lfuda-inspect -top 20 cache.snap
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	lfuda "github.com/bparli/lfuda-go"
+)
+
+func main() {
+	top := flag.Int("top", 10, "number of top keys to print")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: lfuda-inspect [-top n] snapshot\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	f, err := os.Open(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	s, err := inspect(f, *top, time.Now())
+	if err != nil {
+		log.Fatal(err)
+	}
+	s.print(os.Stdout)
+}
+
+// bucket counts the entries whose value is at most max, and above the
+// previous bucket's max.
+type bucket struct {
+	max   float64
+	count int
+}
+
+// summary describes a snapshot.
+type summary struct {
+	header  lfuda.SnapshotHeader
+	entries int
+	expired int
+	size    float64
+	sizes   []bucket
+	hits    []bucket
+	top     []lfuda.EntryInfo
+}
+
+// inspect reads the snapshot r, keeping the top most frequently used keys.
+// Entries expired at now are counted but otherwise ignored.
+func inspect(r io.Reader, top int, now time.Time) (*summary, error) {
+	sr, err := lfuda.NewSnapshotReader(r)
+	if err != nil {
+		return nil, err
+	}
+	s := &summary{header: sr.Header()}
+	for {
+		e, err := sr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return s, err
+		}
+		if e.Expires != 0 && e.Expires <= now.UnixNano() {
+			s.expired++
+			continue
+		}
+		s.entries++
+		s.size += e.Size
+		s.sizes = add(s.sizes, e.Size)
+		s.hits = add(s.hits, e.Hits)
+		s.top = append(s.top, e.EntryInfo)
+	}
+	sort.SliceStable(s.top, func(i, j int) bool { return s.top[i].Hits > s.top[j].Hits })
+	if len(s.top) > top {
+		s.top = s.top[:top]
+	}
+	return s, nil
+}
+
+// add counts v in the power of two bucket holding it.
+func add(buckets []bucket, v float64) []bucket {
+	max := 1.0
+	if v > 1 {
+		max = math.Pow(2, math.Ceil(math.Log2(v)))
+	}
+	i := sort.Search(len(buckets), func(i int) bool { return buckets[i].max >= max })
+	if i == len(buckets) || buckets[i].max != max {
+		buckets = append(buckets, bucket{})
+		copy(buckets[i+1:], buckets[i:])
+		buckets[i] = bucket{max: max}
+	}
+	buckets[i].count++
+	return buckets
+}
+
+func (s *summary) print(w io.Writer) {
+	h := s.header
+	fmt.Fprintf(w, "version:  %d\n", h.Version)
+	fmt.Fprintf(w, "policy:   %s\n", h.Policy)
+	fmt.Fprintf(w, "created:  %s\n", h.Created.Format(time.RFC3339))
+	fmt.Fprintf(w, "capacity: %g\n", h.Capacity)
+	fmt.Fprintf(w, "age:      %g\n", h.Age)
+	fmt.Fprintf(w, "entries:  %d (%d expired)\n", s.entries, s.expired)
+	fmt.Fprintf(w, "size:     %g\n", s.size)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', tabwriter.AlignRight)
+	histogram(tw, "size", s.sizes, s.entries)
+	histogram(tw, "hits", s.hits, s.entries)
+	tw.Flush()
+
+	fmt.Fprintf(w, "\ntop %d keys:\n", len(s.top))
+	tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "key\thits\tsize\tpriority")
+	for _, e := range s.top {
+		fmt.Fprintf(tw, "%v\t%g\t%g\t%g\n", e.Key, e.Hits, e.Size, e.Priority)
+	}
+	tw.Flush()
+}
+
+func histogram(w io.Writer, name string, buckets []bucket, total int) {
+	fmt.Fprintf(w, "\n%s <=\tentries\t%%\t\n", name)
+	for _, b := range buckets {
+		fmt.Fprintf(w, "%g\t%d\t%.1f\t\n", b.max, b.count, 100*float64(b.count)/float64(total))
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	lfuda "github.com/bparli/lfuda-go"
+)
+
+func TestInspect(t *testing.T) {
+	c := lfuda.NewGDSF(1000)
+	c.SetWithSize("a", "a", 1)
+	c.SetWithSize("b", "b", 3)
+	c.SetWithSize("c", "c", 4)
+	c.SetWithSize("d", "d", 100)
+	c.SetWithTTL("gone", "x", time.Nanosecond)
+	for i := 0; i < 5; i++ {
+		c.Get("c")
+	}
+	c.Get("a")
+	time.Sleep(time.Millisecond)
+
+	var buf bytes.Buffer
+	if err := c.WriteSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	s, err := inspect(&buf, 2, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.entries != 4 || s.size != 108 {
+		t.Errorf("expected 4 entries of 108 bytes, got %d of %g", s.entries, s.size)
+	}
+	want := []bucket{{1, 1}, {4, 2}, {128, 1}}
+	if len(s.sizes) != len(want) {
+		t.Fatalf("bad size distribution: %v", s.sizes)
+	}
+	for i := range want {
+		if s.sizes[i] != want[i] {
+			t.Errorf("bad size distribution: %v", s.sizes)
+		}
+	}
+	if len(s.top) != 2 || s.top[0].Key != "c" || s.top[1].Key != "a" {
+		t.Errorf("bad top keys: %v", s.top)
+	}
+
+	var out bytes.Buffer
+	s.print(&out)
+	if !strings.Contains(out.String(), "entries:  4") {
+		t.Errorf("bad output:\n%s", out.String())
+	}
+}
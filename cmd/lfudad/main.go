@@ -0,0 +1,63 @@
+// Command lfudad serves an lfuda cache over the memcached text protocol and,
+// optionally, a subset of the Redis protocol, so that services written in any
+// language can use it as a local sidecar cache.
+//
+// Usage:
+//
+//	lfudad -listen :11211 -redis :6379 -size 67108864 -policy gdsf
+package main
+
+import (
+	"flag"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	lfuda "github.com/bparli/lfuda-go"
+)
+
+func main() {
+	listen := flag.String("listen", ":11211", "memcached protocol listen address")
+	redis := flag.String("redis", "", "Redis protocol listen address, disabled if empty")
+	size := flag.Float64("size", 64<<20, "cache size in bytes")
+	policy := flag.String("policy", "lfuda", "eviction policy: lfuda, gdsf or lfu")
+	maxItemSize := flag.Int("max-item-size", 1<<20, "largest value accepted in bytes")
+	flag.Parse()
+
+	s := newServer(*size, strings.ToUpper(*policy), *maxItemSize)
+	if *redis != "" {
+		go listenAndServe("Redis", *redis, s.serveRESP)
+	}
+	listenAndServe("memcached", *listen, s.serveMemcached)
+}
+
+func listenAndServe(protocol, addr string, handle func(conn net.Conn)) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("lfudad: serving %s protocol on %s", protocol, l.Addr())
+	log.Fatal(serve(l, handle))
+}
+
+// serve accepts connections, handling each in its own goroutine.
+func serve(l net.Listener, handle func(conn net.Conn)) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go handle(conn)
+	}
+}
+
+// newServer creates a server backed by a cache of the given size and policy,
+// one of the lfuda.Policy constants.
+func newServer(size float64, policy string, maxItemSize int) *server {
+	return &server{
+		cache:       lfuda.NewWithOptions(size, lfuda.WithPolicy(policy), lfuda.WithSizeFunc(itemSize)),
+		maxItemSize: maxItemSize,
+		start:       time.Now(),
+	}
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	lfuda "github.com/bparli/lfuda-go"
+)
+
+// version is reported by the version and stats commands.
+const version = "lfudad-1.0"
+
+// item is a value stored through the memcached protocol.
+type item struct {
+	flags uint32
+	data  []byte
+}
+
+// maxLineSize bounds command lines, as memcached does.
+const maxLineSize = 2048
+
+// itemOverhead approximates the memory an entry costs besides its data.
+const itemOverhead = 64
+
+func itemSize(key, value interface{}) float64 {
+	if it, ok := value.(*item); ok {
+		return float64(len(key.(string)) + len(it.data) + itemOverhead)
+	}
+	return itemOverhead
+}
+
+// server holds the state shared by all connections.
+type server struct {
+	cache       *lfuda.Cache
+	maxItemSize int
+	start       time.Time
+}
+
+// relativeLimit is the largest exptime taken as relative to now, larger
+// values are unix timestamps.
+const relativeLimit = 60 * 60 * 24 * 30
+
+// ttl converts a memcached exptime.  Returns false if the item is already
+// expired.
+func ttl(exptime int64) (time.Duration, bool) {
+	switch {
+	case exptime == 0:
+		return 0, true
+	case exptime < 0:
+		return 0, false
+	case exptime > relativeLimit:
+		d := time.Until(time.Unix(exptime, 0))
+		return d, d > 0
+	}
+	return time.Duration(exptime) * time.Second, true
+}
+
+func validKey(key string) bool {
+	if len(key) == 0 || len(key) > 250 {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] <= ' ' || key[i] == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// serveMemcached answers memcached text protocol commands until the client
+// quits or the connection fails.
+func (s *server) serveMemcached(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReaderSize(conn, maxLineSize)
+	w := bufio.NewWriter(conn)
+	for {
+		line, err := r.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			fmt.Fprint(w, "CLIENT_ERROR line too long\r\n")
+			w.Flush()
+			return
+		}
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(string(line))
+		if len(fields) == 0 {
+			fmt.Fprint(w, "ERROR\r\n")
+		} else if fields[0] == "quit" {
+			w.Flush()
+			return
+		} else if err := s.command(r, w, fields); err != nil {
+			return
+		}
+		if r.Buffered() == 0 {
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// command runs one command.  Returns an error only if the connection can't
+// be used anymore.
+func (s *server) command(r *bufio.Reader, w *bufio.Writer, fields []string) error {
+	noreply := len(fields) > 1 && fields[len(fields)-1] == "noreply"
+	if noreply {
+		fields = fields[:len(fields)-1]
+		w = bufio.NewWriter(io.Discard)
+	}
+	switch cmd := fields[0]; cmd {
+	case "get", "gets":
+		s.get(w, fields[1:], cmd == "gets")
+	case "set", "add", "replace", "append", "prepend", "cas":
+		return s.store(r, w, fields)
+	case "delete":
+		if len(fields) != 2 {
+			fmt.Fprint(w, "ERROR\r\n")
+		} else if s.cache.Remove(fields[1]) {
+			fmt.Fprint(w, "DELETED\r\n")
+		} else {
+			fmt.Fprint(w, "NOT_FOUND\r\n")
+		}
+	case "incr", "decr":
+		s.incr(w, fields, cmd == "decr")
+	case "touch":
+		exptime, err := strconv.ParseInt(field(fields, 2), 10, 64)
+		if len(fields) != 3 || err != nil {
+			fmt.Fprint(w, "CLIENT_ERROR bad command line format\r\n")
+			return nil
+		}
+		if d, ok := ttl(exptime); !ok {
+			s.cache.Remove(fields[1])
+			fmt.Fprint(w, "TOUCHED\r\n")
+		} else if s.cache.Expire(fields[1], d) {
+			fmt.Fprint(w, "TOUCHED\r\n")
+		} else {
+			fmt.Fprint(w, "NOT_FOUND\r\n")
+		}
+	case "flush_all":
+		s.cache.Purge()
+		fmt.Fprint(w, "OK\r\n")
+	case "stats":
+		s.stats(w)
+	case "version":
+		fmt.Fprintf(w, "VERSION %s\r\n", version)
+	case "verbosity":
+		fmt.Fprint(w, "OK\r\n")
+	default:
+		fmt.Fprint(w, "ERROR\r\n")
+	}
+	return nil
+}
+
+func field(fields []string, i int) string {
+	if i < len(fields) {
+		return fields[i]
+	}
+	return ""
+}
+
+func (s *server) get(w *bufio.Writer, keys []string, withCas bool) {
+	for _, key := range keys {
+		v, version, ok := s.cache.GetWithVersion(key)
+		if !ok {
+			continue
+		}
+		it := v.(*item)
+		if withCas {
+			fmt.Fprintf(w, "VALUE %s %d %d %d\r\n", key, it.flags, len(it.data), version)
+		} else {
+			fmt.Fprintf(w, "VALUE %s %d %d\r\n", key, it.flags, len(it.data))
+		}
+		w.Write(it.data)
+		w.WriteString("\r\n")
+	}
+	fmt.Fprint(w, "END\r\n")
+}
+
+// store runs the storage commands: <cmd> <key> <flags> <exptime> <bytes>
+// [<cas unique>] [noreply], followed by a data block.
+func (s *server) store(r *bufio.Reader, w *bufio.Writer, fields []string) error {
+	cmd := fields[0]
+	want := 5
+	if cmd == "cas" {
+		want = 6
+	}
+	if len(fields) != want {
+		fmt.Fprint(w, "ERROR\r\n")
+		return nil
+	}
+	key := fields[1]
+	flags, err1 := strconv.ParseUint(fields[2], 10, 32)
+	exptime, err2 := strconv.ParseInt(fields[3], 10, 64)
+	n, err3 := strconv.Atoi(fields[4])
+	var casUnique uint64
+	var err4 error
+	if cmd == "cas" {
+		casUnique, err4 = strconv.ParseUint(fields[5], 10, 64)
+	}
+	if err1 != nil || err2 != nil || err3 != nil || err4 != nil || n < 0 || !validKey(key) {
+		fmt.Fprint(w, "CLIENT_ERROR bad command line format\r\n")
+		return nil
+	}
+
+	if n > s.maxItemSize {
+		// skip the data block without buffering it
+		if _, err := io.CopyN(io.Discard, r, int64(n)); err != nil {
+			return err
+		}
+		if _, err := io.CopyN(io.Discard, r, 2); err != nil {
+			return err
+		}
+		s.cache.Remove(key)
+		fmt.Fprint(w, "SERVER_ERROR object too large for cache\r\n")
+		return nil
+	}
+	data := make([]byte, n+2)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return err
+	}
+	if string(data[n:]) != "\r\n" {
+		fmt.Fprint(w, "CLIENT_ERROR bad data chunk\r\n")
+		return nil
+	}
+	data = data[:n]
+
+	d, live := ttl(exptime)
+	it := &item{flags: uint32(flags), data: data}
+	stored := false
+	switch cmd {
+	case "set":
+		stored = true
+		s.cache.SetWithTTL(key, it, d)
+	case "add", "replace":
+		s.cache.Update(key, func(old interface{}, exists bool) (interface{}, bool) {
+			stored = exists == (cmd == "replace")
+			return it, stored
+		})
+		if stored {
+			s.cache.Expire(key, d)
+		}
+	case "append", "prepend":
+		s.cache.Update(key, func(old interface{}, exists bool) (interface{}, bool) {
+			if !exists {
+				return nil, false
+			}
+			o := old.(*item)
+			joined := make([]byte, 0, len(o.data)+len(data))
+			if cmd == "append" {
+				joined = append(append(joined, o.data...), data...)
+			} else {
+				joined = append(append(joined, data...), o.data...)
+			}
+			stored = true
+			return &item{flags: o.flags, data: joined}, true
+		})
+		live = true
+	case "cas":
+		if _, ok := s.cache.Peek(key); !ok {
+			fmt.Fprint(w, "NOT_FOUND\r\n")
+			return nil
+		}
+		if _, stored = s.cache.SetIfVersion(key, it, casUnique); !stored {
+			fmt.Fprint(w, "EXISTS\r\n")
+			return nil
+		}
+		s.cache.Expire(key, d)
+	}
+
+	if stored && !live {
+		s.cache.Remove(key)
+	}
+	if stored {
+		fmt.Fprint(w, "STORED\r\n")
+	} else {
+		fmt.Fprint(w, "NOT_STORED\r\n")
+	}
+	return nil
+}
+
+// incr runs incr and decr: <cmd> <key> <value> [noreply].  Decrementing
+// stops at 0 and incrementing wraps around at 64 bits.
+func (s *server) incr(w *bufio.Writer, fields []string, decr bool) {
+	if len(fields) != 3 {
+		fmt.Fprint(w, "ERROR\r\n")
+		return
+	}
+	delta, err := strconv.ParseUint(fields[2], 10, 64)
+	if err != nil {
+		fmt.Fprint(w, "CLIENT_ERROR invalid numeric delta argument\r\n")
+		return
+	}
+	var result string
+	found, numeric := false, true
+	s.cache.Update(fields[1], func(old interface{}, exists bool) (interface{}, bool) {
+		if found = exists; !exists {
+			return nil, false
+		}
+		o := old.(*item)
+		n, err := strconv.ParseUint(string(o.data), 10, 64)
+		if numeric = err == nil; !numeric {
+			return nil, false
+		}
+		if !decr {
+			n += delta
+		} else if n < delta {
+			n = 0
+		} else {
+			n -= delta
+		}
+		result = strconv.FormatUint(n, 10)
+		return &item{flags: o.flags, data: []byte(result)}, true
+	})
+	switch {
+	case !found:
+		fmt.Fprint(w, "NOT_FOUND\r\n")
+	case !numeric:
+		fmt.Fprint(w, "CLIENT_ERROR cannot increment or decrement non-numeric value\r\n")
+	default:
+		fmt.Fprintf(w, "%s\r\n", result)
+	}
+}
+
+func (s *server) stats(w *bufio.Writer) {
+	st := s.cache.Stats()
+	stat := func(name string, value interface{}) {
+		fmt.Fprintf(w, "STAT %s %v\r\n", name, value)
+	}
+	stat("pid", os.Getpid())
+	stat("uptime", int64(time.Since(s.start).Seconds()))
+	stat("time", time.Now().Unix())
+	stat("version", version)
+	stat("curr_items", s.cache.Len())
+	stat("bytes", int64(s.cache.Size()))
+	stat("get_hits", st.Hits)
+	stat("get_misses", st.Misses)
+	stat("age", s.cache.Age())
+	fmt.Fprint(w, "END\r\n")
+}
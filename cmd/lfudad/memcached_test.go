@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	lfuda "github.com/bparli/lfuda-go"
+)
+
+// client talks to a server over an in-memory connection.
+type client struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func newClient(t *testing.T, serve func(conn net.Conn)) *client {
+	c1, c2 := net.Pipe()
+	go serve(c2)
+	t.Cleanup(func() { c1.Close() })
+	return &client{t: t, conn: c1, r: bufio.NewReader(c1)}
+}
+
+// do sends a request and reads n lines of response.
+func (c *client) do(req string, n int) string {
+	c.t.Helper()
+	if _, err := fmt.Fprint(c.conn, req); err != nil {
+		c.t.Fatal(err)
+	}
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+func (c *client) expect(req, want string) {
+	c.t.Helper()
+	if got := c.do(req, strings.Count(want, "\n")); got != want {
+		c.t.Errorf("%q: got %q, want %q", req, got, want)
+	}
+}
+
+func TestMemcached(t *testing.T) {
+	s := newServer(1<<20, lfuda.PolicyLFUDA, 1024)
+	c := newClient(t, s.serveMemcached)
+
+	c.expect("set a 5 0 3\r\nfoo\r\n", "STORED\r\n")
+	c.expect("get a b\r\n", "VALUE a 5 3\r\nfoo\r\nEND\r\n")
+	c.expect("add a 0 0 1\r\nx\r\n", "NOT_STORED\r\n")
+	c.expect("replace b 0 0 1\r\nx\r\n", "NOT_STORED\r\n")
+	c.expect("add b 0 0 1\r\nx\r\n", "STORED\r\n")
+	c.expect("append a 0 0 3\r\nbar\r\n", "STORED\r\n")
+	c.expect("prepend a 0 0 1\r\n>\r\n", "STORED\r\n")
+	c.expect("get a\r\n", "VALUE a 5 7\r\n>foobar\r\nEND\r\n")
+	c.expect("append missing 0 0 1\r\nx\r\n", "NOT_STORED\r\n")
+
+	c.expect("delete b\r\n", "DELETED\r\n")
+	c.expect("delete b\r\n", "NOT_FOUND\r\n")
+	c.expect("set quiet 0 0 1 noreply\r\nq\r\nget quiet\r\n", "VALUE quiet 0 1\r\nq\r\nEND\r\n")
+
+	c.expect("set n 0 0 2\r\n10\r\n", "STORED\r\n")
+	c.expect("incr n 5\r\n", "15\r\n")
+	c.expect("decr n 20\r\n", "0\r\n")
+	c.expect("incr a 1\r\n", "CLIENT_ERROR cannot increment or decrement non-numeric value\r\n")
+	c.expect("incr missing 1\r\n", "NOT_FOUND\r\n")
+
+	c.expect("touch n 100\r\n", "TOUCHED\r\n")
+	c.expect("touch missing 100\r\n", "NOT_FOUND\r\n")
+	c.expect("set gone 0 -1 1\r\nx\r\n", "STORED\r\n")
+	c.expect("get gone\r\n", "END\r\n")
+
+	c.expect("set big 0 0 2000\r\n"+strings.Repeat("x", 2000)+"\r\n", "SERVER_ERROR object too large for cache\r\n")
+	// the rest of the bad chunk is read as an empty command
+	c.expect("set bad 0 0 1\r\nxyz\r\n", "CLIENT_ERROR bad data chunk\r\nERROR\r\n")
+	c.expect("bogus\r\n", "ERROR\r\n")
+	c.expect("version\r\n", "VERSION "+version+"\r\n")
+
+	c.expect("flush_all\r\n", "OK\r\n")
+	c.expect("get a\r\n", "END\r\n")
+	// overlong lines close the connection
+	c.expect("get "+strings.Repeat("k", maxLineSize-4), "CLIENT_ERROR line too long\r\n")
+}
+
+func TestMemcachedCas(t *testing.T) {
+	s := newServer(1<<20, lfuda.PolicyGDSF, 1024)
+	c := newClient(t, s.serveMemcached)
+
+	c.expect("cas a 0 0 1 1\r\nx\r\n", "NOT_FOUND\r\n")
+	c.expect("set a 0 0 1\r\nx\r\n", "STORED\r\n")
+	resp := c.do("gets a\r\n", 3)
+	var cas uint64
+	if _, err := fmt.Sscanf(resp, "VALUE a 0 1 %d", &cas); err != nil {
+		t.Fatalf("bad gets response %q: %v", resp, err)
+	}
+	c.expect(fmt.Sprintf("cas a 0 0 1 %d\r\ny\r\n", cas), "STORED\r\n")
+	c.expect(fmt.Sprintf("cas a 0 0 1 %d\r\nz\r\n", cas), "EXISTS\r\n")
+	c.expect("get a\r\n", "VALUE a 0 1\r\ny\r\nEND\r\n")
+}
+
+func TestMemcachedStats(t *testing.T) {
+	s := newServer(1<<20, lfuda.PolicyLFU, 1024)
+	c := newClient(t, s.serveMemcached)
+	c.expect("set a 0 0 1\r\nx\r\n", "STORED\r\n")
+	c.expect("get a\r\n", "VALUE a 0 1\r\nx\r\nEND\r\n")
+
+	fmt.Fprint(c.conn, "stats\r\n")
+	stats := make(map[string]string)
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if line == "END\r\n" {
+			break
+		}
+		fields := strings.Fields(line)
+		stats[fields[1]] = fields[2]
+	}
+	if stats["curr_items"] != "1" || stats["get_hits"] != "1" {
+		t.Errorf("bad stats: %v", stats)
+	}
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errProtocol is returned for malformed RESP requests, after which the
+// connection is closed.
+var errProtocol = errors.New("lfudad: protocol error")
+
+// serveRESP answers a subset of the Redis commands (GET, SET, DEL, EXISTS,
+// TTL, PTTL, INFO, PING, QUIT) until the client quits or the connection
+// fails.  Values are shared with the memcached protocol.
+func (s *server) serveRESP(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			if err == errProtocol {
+				writeError(w, "ERR Protocol error")
+				w.Flush()
+			}
+			return
+		}
+		if len(args) > 0 && strings.EqualFold(args[0], "quit") {
+			writeSimple(w, "OK")
+			w.Flush()
+			return
+		}
+		if len(args) > 0 {
+			s.respCommand(w, args)
+		}
+		if r.Buffered() == 0 {
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// readCommand reads an array of bulk strings, or an inline command.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return strings.Fields(line), nil
+	}
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n < 0 || n > 1024*1024 {
+		return nil, errProtocol
+	}
+	args := make([]string, n)
+	for i := range args {
+		line, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(line, "$") {
+			return nil, errProtocol
+		}
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < 0 || size > 512*1024*1024 {
+			return nil, errProtocol
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		if string(buf[size:]) != "\r\n" {
+			return nil, errProtocol
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func writeSimple(w *bufio.Writer, s string) {
+	fmt.Fprintf(w, "+%s\r\n", s)
+}
+
+func writeError(w *bufio.Writer, msg string) {
+	fmt.Fprintf(w, "-%s\r\n", msg)
+}
+
+func writeInt(w *bufio.Writer, n int64) {
+	fmt.Fprintf(w, ":%d\r\n", n)
+}
+
+func writeBulk(w *bufio.Writer, b []byte) {
+	if b == nil {
+		w.WriteString("$-1\r\n")
+		return
+	}
+	fmt.Fprintf(w, "$%d\r\n", len(b))
+	w.Write(b)
+	w.WriteString("\r\n")
+}
+
+func wrongArgs(w *bufio.Writer, cmd string) {
+	writeError(w, fmt.Sprintf("ERR wrong number of arguments for '%s' command", strings.ToLower(cmd)))
+}
+
+func (s *server) respCommand(w *bufio.Writer, args []string) {
+	switch cmd := strings.ToUpper(args[0]); cmd {
+	case "PING":
+		if len(args) > 1 {
+			writeBulk(w, []byte(args[1]))
+		} else {
+			writeSimple(w, "PONG")
+		}
+	case "GET":
+		if len(args) != 2 {
+			wrongArgs(w, cmd)
+			return
+		}
+		var data []byte
+		if v, ok := s.cache.Get(args[1]); ok {
+			data = v.(*item).data
+		}
+		writeBulk(w, data)
+	case "SET":
+		s.set(w, args)
+	case "DEL", "EXISTS":
+		if len(args) < 2 {
+			wrongArgs(w, cmd)
+			return
+		}
+		var n int64
+		for _, key := range args[1:] {
+			if cmd == "DEL" && s.cache.Remove(key) || cmd == "EXISTS" && s.cache.Contains(key) {
+				n++
+			}
+		}
+		writeInt(w, n)
+	case "TTL", "PTTL":
+		if len(args) != 2 {
+			wrongArgs(w, cmd)
+			return
+		}
+		ttl, ok := s.cache.TTL(args[1])
+		switch {
+		case !ok:
+			writeInt(w, -2)
+		case ttl == 0:
+			writeInt(w, -1)
+		case cmd == "TTL":
+			writeInt(w, int64((ttl+time.Second/2)/time.Second))
+		default:
+			writeInt(w, ttl.Milliseconds())
+		}
+	case "INFO":
+		s.info(w)
+	default:
+		writeError(w, fmt.Sprintf("ERR unknown command '%s'", args[0]))
+	}
+}
+
+// set runs SET key value [EX seconds|PX milliseconds] [NX|XX].
+func (s *server) set(w *bufio.Writer, args []string) {
+	if len(args) < 3 {
+		wrongArgs(w, args[0])
+		return
+	}
+	key, it := args[1], &item{data: []byte(args[2])}
+	var ttl time.Duration
+	var nx, xx bool
+	for i := 3; i < len(args); i++ {
+		switch opt := strings.ToUpper(args[i]); opt {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		case "EX", "PX":
+			if i+1 == len(args) {
+				writeError(w, "ERR syntax error")
+				return
+			}
+			i++
+			n, err := strconv.ParseInt(args[i], 10, 64)
+			if err != nil || n <= 0 {
+				writeError(w, "ERR invalid expire time in 'set' command")
+				return
+			}
+			ttl = time.Duration(n) * time.Millisecond
+			if opt == "EX" {
+				ttl = time.Duration(n) * time.Second
+			}
+		default:
+			writeError(w, "ERR syntax error")
+			return
+		}
+	}
+	if nx && xx {
+		writeError(w, "ERR syntax error")
+		return
+	}
+	if len(it.data) > s.maxItemSize {
+		writeError(w, "ERR value too large")
+		return
+	}
+
+	stored := true
+	if nx || xx {
+		s.cache.Update(key, func(old interface{}, exists bool) (interface{}, bool) {
+			stored = exists == xx
+			return it, stored
+		})
+		if stored {
+			s.cache.Expire(key, ttl)
+		}
+	} else {
+		s.cache.SetWithTTL(key, it, ttl)
+	}
+	if stored {
+		writeSimple(w, "OK")
+	} else {
+		writeBulk(w, nil)
+	}
+}
+
+func (s *server) info(w *bufio.Writer) {
+	st := s.cache.Stats()
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Server\r\nlfudad_version:%s\r\nuptime_in_seconds:%d\r\n", version, int64(time.Since(s.start).Seconds()))
+	fmt.Fprintf(&b, "# Memory\r\nused_memory:%d\r\n", int64(s.cache.Size()))
+	fmt.Fprintf(&b, "# Stats\r\nkeyspace_hits:%d\r\nkeyspace_misses:%d\r\ncache_age:%g\r\n", st.Hits, st.Misses, s.cache.Age())
+	fmt.Fprintf(&b, "# Keyspace\r\ndb0:keys=%d\r\n", s.cache.Len())
+	writeBulk(w, []byte(b.String()))
+}
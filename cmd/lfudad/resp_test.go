@@ -0,0 +1,48 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	lfuda "github.com/bparli/lfuda-go"
+)
+
+func TestRESP(t *testing.T) {
+	s := newServer(1<<20, lfuda.PolicyLFUDA, 1024)
+	c := newClient(t, s.serveRESP)
+
+	c.expect("PING\r\n", "+PONG\r\n")
+	c.expect("*3\r\n$3\r\nSET\r\n$1\r\na\r\n$3\r\nfoo\r\n", "+OK\r\n")
+	c.expect("*2\r\n$3\r\nGET\r\n$1\r\na\r\n", "$3\r\nfoo\r\n")
+	c.expect("GET missing\r\n", "$-1\r\n")
+	c.expect("SET a bar NX\r\n", "$-1\r\n")
+	c.expect("SET b bar XX\r\n", "$-1\r\n")
+	c.expect("SET b bar NX EX 100\r\n", "+OK\r\n")
+	c.expect("TTL b\r\n", ":100\r\n")
+	c.expect("TTL a\r\n", ":-1\r\n")
+	c.expect("TTL missing\r\n", ":-2\r\n")
+	c.expect("SET a baz XX\r\n", "+OK\r\n")
+	c.expect("GET a\r\n", "$3\r\nbaz\r\n")
+	c.expect("EXISTS a b c\r\n", ":2\r\n")
+	c.expect("DEL a b c\r\n", ":2\r\n")
+	c.expect("GET a\r\n", "$-1\r\n")
+
+	c.expect("SET a b EX 0\r\n", "-ERR invalid expire time in 'set' command\r\n")
+	c.expect("SET a b NX XX\r\n", "-ERR syntax error\r\n")
+	c.expect("GET\r\n", "-ERR wrong number of arguments for 'get' command\r\n")
+	c.expect("FLUSHALL\r\n", "-ERR unknown command 'FLUSHALL'\r\n")
+
+	// values are shared with the memcached protocol
+	s.cache.Set("shared", &item{data: []byte("x")})
+	c.expect("GET shared\r\n", "$1\r\nx\r\n")
+
+	resp := c.do("INFO\r\n", 1)
+	if !strings.HasPrefix(resp, "$") {
+		t.Fatalf("INFO should return a bulk string: %q", resp)
+	}
+	info := c.do("", 12)
+	if !strings.Contains(info, "db0:keys=1\r\n") {
+		t.Errorf("bad INFO: %q", info)
+	}
+	c.expect("QUIT\r\n", "+OK\r\n")
+}
@@ -0,0 +1,85 @@
+package lfuda
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"reflect"
+)
+
+// Codec marshals values for the features storing them as bytes: snapshots,
+// compression and encryption.  Implementations for formats such as protocol
+// buffers or msgpack can be plugged in with WithCodec.  It must be safe for
+// concurrent use.
+type Codec interface {
+	Marshal(value interface{}) ([]byte, error)
+	Unmarshal(data []byte) (interface{}, error)
+}
+
+// GobCodec encodes values with encoding/gob, which restores their types.
+// Custom types must be registered with gob.Register.  It is the default
+// codec of snapshots.
+var GobCodec Codec = gobCodec{}
+
+type gobCodec struct{}
+
+func (gobCodec) Marshal(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte) (interface{}, error) {
+	var value interface{}
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&value)
+	return value, err
+}
+
+// JSONCodec returns a codec encoding values with encoding/json, for values
+// that are not gob encodable or snapshots read by other languages.  Values
+// are unmarshaled into a new value of the type of prototype, or into generic
+// JSON values if prototype is nil.
+func JSONCodec(prototype interface{}) Codec {
+	return jsonCodec{reflect.TypeOf(prototype)}
+}
+
+type jsonCodec struct {
+	typ reflect.Type
+}
+
+func (jsonCodec) Marshal(value interface{}) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+func (j jsonCodec) Unmarshal(data []byte) (interface{}, error) {
+	if j.typ == nil {
+		var value interface{}
+		err := json.Unmarshal(data, &value)
+		return value, err
+	}
+	p := reflect.New(j.typ)
+	if err := json.Unmarshal(data, p.Interface()); err != nil {
+		return nil, err
+	}
+	return p.Elem().Interface(), nil
+}
+
+// WithCodec sets the codec of snapshots, GobCodec by default.  With
+// compression or encryption, values other than []byte and strings are also
+// marshaled by codec and compressed or encrypted, and Gets return
+// unmarshaled copies of them.
+func WithCodec(codec Codec) Option {
+	return func(o *options) {
+		o.codec = codec
+	}
+}
+
+// codec returns the cache's codec.
+func (c *Cache) codec() Codec {
+	if c.opts.codec != nil {
+		return c.opts.codec
+	}
+	return GobCodec
+}
@@ -0,0 +1,63 @@
+package lfuda
+
+import (
+	"bytes"
+	"compress/gzip"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type codecUser struct {
+	Name  string
+	Posts []string
+}
+
+func TestJSONCodec(t *testing.T) {
+	u := codecUser{Name: "ann", Posts: []string{"hi"}}
+	typed := JSONCodec(codecUser{})
+	data, err := typed.Marshal(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := typed.Unmarshal(data); err != nil || !reflect.DeepEqual(v, u) {
+		t.Errorf("bad typed value: %v, %v", v, err)
+	}
+	if v, err := JSONCodec(nil).Unmarshal(data); err != nil || v.(map[string]interface{})["Name"] != "ann" {
+		t.Errorf("bad generic value: %v, %v", v, err)
+	}
+}
+
+func TestCodecSnapshot(t *testing.T) {
+	// codecUser is not registered with gob
+	l := NewWithOptions(1000, WithCodec(JSONCodec(codecUser{})))
+	u := codecUser{Name: "bob"}
+	l.Set("bob", u)
+
+	var buf bytes.Buffer
+	if err := l.WriteSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	restored := NewWithOptions(1000, WithCodec(JSONCodec(codecUser{})))
+	if _, err := restored.ReadSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := restored.Get("bob"); !reflect.DeepEqual(v, u) {
+		t.Errorf("bad restored value: %v", v)
+	}
+}
+
+func TestCodecCompression(t *testing.T) {
+	l := NewWithOptions(1000, WithCodec(JSONCodec(codecUser{})), WithCompression(GzipCompressor(gzip.BestSpeed), 100))
+	posts := make([]string, 100)
+	for i := range posts {
+		posts[i] = strings.Repeat("post", 10)
+	}
+	l.Set("u", codecUser{Name: "cat", Posts: posts})
+	if size := l.Size(); size >= 200 {
+		t.Errorf("expected a compressed size, got %g", size)
+	}
+	if v, ok := l.Get("u"); !ok || len(v.(codecUser).Posts) != 100 {
+		t.Errorf("bad value: %v", v)
+	}
+}
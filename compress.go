@@ -0,0 +1,59 @@
+package lfuda
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+)
+
+// Compressor compresses values for WithCompression, e.g. with gzip, snappy or
+// zstd.  It must be safe for concurrent use.
+type Compressor interface {
+	Compress(b []byte) ([]byte, error)
+	Decompress(b []byte) ([]byte, error)
+}
+
+// WithCompression stores []byte and string values of at least threshold
+// bytes compressed by comp, decompressing them on Get, so more values fit in
+// the cache at the cost of CPU.  Compressed values are accounted for by
+// their compressed length, ignoring any SizeFunc, except when set with
+// SetWithSize.  Values that don't shrink are stored as is.
+func WithCompression(comp Compressor, threshold int) Option {
+	return func(o *options) {
+		o.compressor = comp
+		o.compressThreshold = threshold
+	}
+}
+
+// GzipCompressor returns a Compressor using gzip at the given level, such as
+// gzip.BestSpeed.
+func GzipCompressor(level int) Compressor {
+	return gzipCompressor{level}
+}
+
+type gzipCompressor struct {
+	level int
+}
+
+func (g gzipCompressor) Compress(b []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, g.level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(b); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gzipCompressor) Decompress(b []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
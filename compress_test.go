@@ -0,0 +1,51 @@
+package lfuda
+
+import (
+	"bytes"
+	"compress/gzip"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestCompression(t *testing.T) {
+	var evicted []interface{}
+	l := NewWithOptions(1000, WithCompression(GzipCompressor(gzip.BestSpeed), 100),
+		WithEvictCallback(func(key, value interface{}) { evicted = append(evicted, value) }))
+
+	big := strings.Repeat("abc", 1000)
+	l.Set("s", big)
+	l.Set("b", []byte(big))
+	l.Set("small", "abc")
+	if size := l.Size(); size >= 200 {
+		t.Errorf("expected compressed sizes, got %g", size)
+	}
+	if v, ok := l.Get("s"); !ok || v != big {
+		t.Error("bad string value")
+	}
+	if v, ok := l.Peek("b"); !ok || !bytes.Equal(v.([]byte), []byte(big)) {
+		t.Error("bad []byte value")
+	}
+	if v, _ := l.Get("small"); v != "abc" {
+		t.Errorf("bad small value: %v", v)
+	}
+
+	// incompressible values are stored as is
+	random := make([]byte, 200)
+	rand.New(rand.NewSource(1)).Read(random)
+	if v, _, _ := l.encode("random", random); !bytes.Equal(v.([]byte), random) {
+		t.Error("values that don't shrink should not be encoded")
+	}
+
+	l.Update("s", func(old interface{}, exists bool) (interface{}, bool) {
+		return old.(string) + "d", true
+	})
+	if v, _ := l.Get("s"); v != big+"d" {
+		t.Error("bad updated value")
+	}
+
+	l.Remove("s")
+	if len(evicted) != 1 || evicted[0] != big+"d" {
+		t.Error("the eviction callback should receive the decompressed value")
+	}
+}
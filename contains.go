@@ -0,0 +1,37 @@
+package lfuda
+
+// ContainsKeys reports for every key whether it is in the cache, under a
+// single read lock, without updating hits.
+func (c *Cache) ContainsKeys(keys []interface{}) []bool {
+	found := make([]bool, len(keys))
+	c.lock.RLock()
+	for i, key := range keys {
+		found[i] = !c.badKey(key) && c.lfuda.Contains(key)
+	}
+	c.lock.RUnlock()
+	return found
+}
+
+// ContainsAll reports whether every key is in the cache.
+func (c *Cache) ContainsAll(keys []interface{}) bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	for _, key := range keys {
+		if c.badKey(key) || !c.lfuda.Contains(key) {
+			return false
+		}
+	}
+	return true
+}
+
+// ContainsAny reports whether any of the keys is in the cache.
+func (c *Cache) ContainsAny(keys []interface{}) bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	for _, key := range keys {
+		if !c.badKey(key) && c.lfuda.Contains(key) {
+			return true
+		}
+	}
+	return false
+}
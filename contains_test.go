@@ -0,0 +1,42 @@
+package lfuda
+
+import "testing"
+
+func TestContainsKeys(t *testing.T) {
+	l := New(100)
+	l.Set(1, 1)
+	l.Set(3, 3)
+
+	found := l.ContainsKeys([]interface{}{1, 2, 3})
+	if len(found) != 3 || !found[0] || found[1] || !found[2] {
+		t.Errorf("bad membership: %v", found)
+	}
+	if l.ContainsAll([]interface{}{1, 2}) || !l.ContainsAll([]interface{}{1, 3}) {
+		t.Errorf("bad ContainsAll")
+	}
+	if !l.ContainsAny([]interface{}{2, 3}) || l.ContainsAny([]interface{}{2, 4}) {
+		t.Errorf("bad ContainsAny")
+	}
+	if !l.ContainsAll(nil) || l.ContainsAny(nil) {
+		t.Errorf("bad results for no keys")
+	}
+}
+
+func TestShardedContainsKeys(t *testing.T) {
+	s := NewSharded(1000, WithShards(4))
+	keys := make([]interface{}, 20)
+	for i := range keys {
+		keys[i] = i
+		if i%2 == 0 {
+			s.Set(i, i)
+		}
+	}
+	for i, ok := range s.ContainsKeys(keys) {
+		if ok != (i%2 == 0) {
+			t.Errorf("bad membership of %d: %v", i, ok)
+		}
+	}
+	if s.ContainsAll(keys) || !s.ContainsAny(keys) {
+		t.Errorf("bad ContainsAll or ContainsAny")
+	}
+}
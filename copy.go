@@ -0,0 +1,55 @@
+package lfuda
+
+// CopyFunc returns a deep copy of a value.
+type CopyFunc func(value interface{}) (interface{}, error)
+
+// WithCopyOnSet stores copies of the values set, so callers may keep
+// modifying the values they set.  Values are copied by copy, or marshaled
+// and unmarshaled by the cache's codec if it is nil.  Values that fail to
+// copy are not set.
+func WithCopyOnSet(copy CopyFunc) Option {
+	return func(o *options) {
+		o.copyOnSet = true
+		o.setCopy = copy
+	}
+}
+
+// WithCopyOnGet returns copies of the cached values, so callers may modify
+// the values they get without corrupting what other goroutines read.
+// Values are copied by copy, or marshaled and unmarshaled by the cache's
+// codec if it is nil.  Values that fail to copy are reported missing.
+func WithCopyOnGet(copy CopyFunc) Option {
+	return func(o *options) {
+		o.copyOnGet = true
+		o.getCopy = copy
+	}
+}
+
+// copyValue copies a value with fn or the cache's codec.
+func (c *Cache) copyValue(fn CopyFunc, value interface{}) (interface{}, error) {
+	if _, isNeg := value.(negativeEntry); isNeg || value == nil {
+		return value, nil
+	}
+	if fn != nil {
+		return fn(value)
+	}
+	data, err := c.codec().Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return c.codec().Unmarshal(data)
+}
+
+// copyOnSet returns the value to store for a value set.  Returns false if
+// it failed to copy.
+func (c *Cache) copyOnSet(key, value interface{}) (interface{}, bool) {
+	if !c.opts.copyOnSet {
+		return value, true
+	}
+	value, err := c.copyValue(c.opts.setCopy, value)
+	if err != nil {
+		c.debug("lfuda: value copy failed", "key", key, "error", err)
+		return nil, false
+	}
+	return value, true
+}
@@ -0,0 +1,50 @@
+package lfuda
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCopyOnSet(t *testing.T) {
+	l := NewWithOptions(10, WithCopyOnSet(nil))
+	s := []int{1, 2}
+	l.Set(1, s)
+	s[0] = 3
+	if v, ok := l.Get(1); !ok || v.([]int)[0] != 1 {
+		t.Errorf("set value modified: %v", v)
+	}
+
+	// values the codec can't marshal aren't set
+	if l.Set(2, func() {}); l.Contains(2) {
+		t.Errorf("uncopyable value set")
+	}
+}
+
+func TestCopyOnGet(t *testing.T) {
+	calls := 0
+	l := NewWithOptions(10, WithCopyOnGet(func(value interface{}) (interface{}, error) {
+		calls++
+		if value == "bad" {
+			return nil, errors.New("bad value")
+		}
+		return append([]int(nil), value.([]int)...), nil
+	}))
+	l.Set(1, []int{1, 2})
+	v, _ := l.Get(1)
+	v.([]int)[0] = 3
+	if v, _ := l.Peek(1); v.([]int)[0] != 1 {
+		t.Errorf("cached value modified: %v", v)
+	}
+	if calls != 2 {
+		t.Errorf("%d copies", calls)
+	}
+
+	l.Set(2, "bad")
+	if _, ok := l.Get(2); ok {
+		t.Errorf("uncopyable value returned")
+	}
+	l.SetNegative(3, 0)
+	if _, _, err := l.Lookup(3); !errors.Is(err, ErrNegativeHit) {
+		t.Errorf("negative entry copied: %v", err)
+	}
+}
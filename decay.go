@@ -0,0 +1,43 @@
+package lfuda
+
+import "time"
+
+// WithMaxHits caps the hits counted for every entry at n, so entries that
+// were once very popular can't stay ahead of the cache age long after their
+// popularity collapsed.
+func WithMaxHits(n float64) Option {
+	return func(o *options) {
+		o.maxHits = n
+	}
+}
+
+// WithHitHalving halves the hits of every entry each interval, lowering
+// their priorities, so past popularity decays even if nothing is evicted.
+// The halving stops when the cache is closed.
+func WithHitHalving(interval time.Duration) Option {
+	return func(o *options) {
+		o.halving = interval
+	}
+}
+
+// HalveHits halves the hits of every entry, lowering their priorities by the
+// hits they lose.
+func (c *Cache) HalveHits() {
+	c.lockOp()
+	c.lfuda.HalveHits()
+	c.unlockOp()
+	c.debug("lfuda: halved hits")
+}
+
+func (c *Cache) halveEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.HalveHits()
+		case <-c.done:
+			return
+		}
+	}
+}
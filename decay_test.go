@@ -0,0 +1,51 @@
+package lfuda
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMaxHits(t *testing.T) {
+	l := NewWithOptions(2, WithMaxHits(3))
+	l.Set("old", 1)
+	for i := 0; i < 100; i++ {
+		l.Get("old")
+	}
+	if _, meta, _ := l.GetWithMeta("old"); meta.Hits != 3 {
+		t.Errorf("hits should be capped: %v", meta.Hits)
+	}
+
+	// new keys catch up with the capped one as the age rises
+	for i := 0; i < 10; i++ {
+		l.Set(i, i)
+		l.Get(i)
+		l.Get(i)
+		l.Get(i)
+	}
+	if l.Contains("old") {
+		t.Errorf("the capped key should have been evicted")
+	}
+}
+
+func TestHitHalving(t *testing.T) {
+	l := NewWithOptions(10, WithHitHalving(time.Millisecond))
+	defer l.Close()
+	l.Set("a", 1)
+	for i := 0; i < 99; i++ {
+		l.Get("a")
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		_, meta, _ := l.GetWithMeta("a")
+		if meta.Hits < 10 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("hits should have been halved: %v", meta.Hits)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	l.Close()
+	l.Close()
+}
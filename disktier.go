@@ -0,0 +1,215 @@
+package lfuda
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// diskSegments is the number of segments a DiskTier's budget is split in;
+// the oldest segment is dropped as a whole when the budget is exceeded.
+const diskSegments = 8
+
+// DiskTier is a Tier spilling evicted entries to log files on disk, for a
+// RAM cache backed by a larger SSD one:
+//
+//	disk, err := lfuda.OpenDiskTier(dir, 10<<30, nil)
+//	c := lfuda.NewWithOptions(1<<30, lfuda.WithTier(disk))
+//
+// Values are appended to segment files and located through an index kept in
+// memory, so the store doesn't survive restarts.  Once it holds more than
+// its budget the oldest segment is deleted, dropping its entries first in
+// first out.
+type DiskTier struct {
+	dir         string
+	codec       Codec
+	segmentSize int64
+
+	mu       sync.RWMutex
+	index    map[interface{}]diskLocation
+	segments []*diskSegment
+}
+
+type diskSegment struct {
+	id   int
+	f    *os.File
+	size int64
+	// keys whose latest value is in the segment
+	keys map[interface{}]struct{}
+}
+
+type diskLocation struct {
+	segment *diskSegment
+	off     int64
+	n       int
+	// expiration deadline in unix nanoseconds, 0 if the value never expires
+	expires int64
+}
+
+// OpenDiskTier creates a DiskTier storing up to maxBytes of values in dir,
+// which is created if needed.  Files left in dir by a previous DiskTier are
+// removed.  Values are marshaled by codec, GobCodec if nil.
+func OpenDiskTier(dir string, maxBytes int64, codec Codec) (*DiskTier, error) {
+	if codec == nil {
+		codec = GobCodec
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	old, err := filepath.Glob(filepath.Join(dir, "*.seg"))
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range old {
+		if err := os.Remove(name); err != nil {
+			return nil, err
+		}
+	}
+	t := &DiskTier{
+		dir:         dir,
+		codec:       codec,
+		segmentSize: maxBytes / diskSegments,
+		index:       make(map[interface{}]diskLocation),
+	}
+	if err := t.rotate(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// rotate starts a new segment, deleting the oldest ones while over budget.
+func (t *DiskTier) rotate() error {
+	id := 0
+	if n := len(t.segments); n > 0 {
+		id = t.segments[n-1].id + 1
+	}
+	f, err := os.OpenFile(filepath.Join(t.dir, fmt.Sprintf("%08d.seg", id)), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	t.segments = append(t.segments, &diskSegment{id: id, f: f, keys: make(map[interface{}]struct{})})
+	for len(t.segments) > diskSegments {
+		t.drop(t.segments[0])
+		t.segments = t.segments[1:]
+	}
+	return nil
+}
+
+// drop deletes a segment and the entries it holds.
+func (t *DiskTier) drop(s *diskSegment) {
+	for key := range s.keys {
+		delete(t.index, key)
+	}
+	s.f.Close()
+	os.Remove(s.f.Name())
+}
+
+// Get implements Tier.
+func (t *DiskTier) Get(key interface{}) (value interface{}, ok bool, err error) {
+	value, _, ok, err = t.GetWithDeadline(key)
+	return value, ok, err
+}
+
+// GetWithDeadline implements ExpiringTier.  Expired values are not found.
+func (t *DiskTier) GetWithDeadline(key interface{}) (value interface{}, expires time.Time, ok bool, err error) {
+	t.mu.RLock()
+	loc, ok := t.index[key]
+	ok = ok && (loc.expires == 0 || time.Now().UnixNano() < loc.expires)
+	var data []byte
+	if ok {
+		data = make([]byte, loc.n)
+		_, err = loc.segment.f.ReadAt(data, loc.off)
+	}
+	t.mu.RUnlock()
+	if !ok || err != nil {
+		return nil, time.Time{}, false, err
+	}
+	if loc.expires != 0 {
+		expires = time.Unix(0, loc.expires)
+	}
+	value, err = t.codec.Unmarshal(data)
+	return value, expires, err == nil, err
+}
+
+// Set implements Tier.
+func (t *DiskTier) Set(key, value interface{}) error {
+	return t.set(key, value, 0)
+}
+
+// SetWithDeadline implements ExpiringTier.
+func (t *DiskTier) SetWithDeadline(key, value interface{}, expires time.Time) error {
+	return t.set(key, value, expires.UnixNano())
+}
+
+func (t *DiskTier) set(key, value interface{}, expires int64) error {
+	data, err := t.codec.Marshal(value)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := t.segments[len(t.segments)-1]
+	if s.size > 0 && s.size+int64(len(data)) > t.segmentSize {
+		if err := t.rotate(); err != nil {
+			return err
+		}
+		s = t.segments[len(t.segments)-1]
+	}
+	if _, err := s.f.WriteAt(data, s.size); err != nil {
+		return err
+	}
+	t.remove(key)
+	t.index[key] = diskLocation{segment: s, off: s.size, n: len(data), expires: expires}
+	s.keys[key] = struct{}{}
+	s.size += int64(len(data))
+	return nil
+}
+
+// Remove implements Tier.  The space is reclaimed once its segment is
+// dropped.
+func (t *DiskTier) Remove(key interface{}) error {
+	t.mu.Lock()
+	t.remove(key)
+	t.mu.Unlock()
+	return nil
+}
+
+func (t *DiskTier) remove(key interface{}) {
+	if loc, ok := t.index[key]; ok {
+		delete(loc.segment.keys, key)
+		delete(t.index, key)
+	}
+}
+
+// Len returns the number of entries on disk.
+func (t *DiskTier) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.index)
+}
+
+// Size returns the bytes of the segment files, including overwritten and
+// removed values not reclaimed yet.
+func (t *DiskTier) Size() int64 {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var size int64
+	for _, s := range t.segments {
+		size += s.size
+	}
+	return size
+}
+
+// Close deletes the segment files.  The tier must not be used afterwards,
+// so the cache using it must be closed first.
+func (t *DiskTier) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, s := range t.segments {
+		t.drop(s)
+	}
+	t.segments = nil
+	return nil
+}
@@ -0,0 +1,70 @@
+package lfuda
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiskTier(t *testing.T) {
+	dir := t.TempDir()
+	disk, err := OpenDiskTier(dir, 8*100, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		if err := disk.Set(i, fmt.Sprintf("value%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	disk.Set(0, "new")
+	if v, ok, err := disk.Get(0); !ok || err != nil || v != "new" {
+		t.Errorf("bad value: %v, %v", v, err)
+	}
+	disk.Remove(1)
+	if _, ok, _ := disk.Get(1); ok || disk.Len() != 4 {
+		t.Error("1 should have been removed")
+	}
+
+	// old segments are dropped once over budget
+	for i := 0; i < 200; i++ {
+		disk.Set(fmt.Sprint(i), i)
+	}
+	if disk.Size() > 8*100 {
+		t.Errorf("the disk tier exceeded its budget: %d", disk.Size())
+	}
+	if _, ok, _ := disk.Get(2); ok {
+		t.Error("the oldest entries should have been dropped")
+	}
+	if v, ok, _ := disk.Get("199"); !ok || v != 199 {
+		t.Errorf("the newest entries should be kept: %v", v)
+	}
+
+	if err := disk.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 0 {
+		t.Errorf("segments should have been deleted: %v", files)
+	}
+}
+
+func TestDiskSpillover(t *testing.T) {
+	disk, err := OpenDiskTier(filepath.Join(t.TempDir(), "spill"), 1<<20, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer disk.Close()
+	l := NewWithOptions(10, WithTier(disk))
+	for i := 0; i < 10; i++ {
+		l.SetWithSize(i, fmt.Sprint(i), 2)
+	}
+	for i := 0; i < 10; i++ {
+		if v, ok := l.Get(i); !ok || v != fmt.Sprint(i) {
+			t.Errorf("%d should be found in RAM or on disk: %v", i, v)
+		}
+	}
+	l.Close()
+	if disk.Len() < 5 {
+		t.Errorf("expected the evicted entries on disk: %d", disk.Len())
+	}
+}
@@ -0,0 +1,180 @@
+// Package dnscache caches host lookups in an LFUDA cache, since a few hosts
+// account for most lookups.  A Resolver has the lookup methods of
+// net.Resolver and caches each answer for the TTL of its records, read from
+// the DNS responses, and "no such host" answers for the TTL of the zone's
+// SOA record.
+package dnscache
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	lfuda "github.com/bparli/lfuda-go"
+)
+
+// Option configures a Resolver.
+type Option func(*Resolver)
+
+// WithResolver resolves hosts with r instead of net.DefaultResolver.  Its
+// Go resolver is used, so that the TTLs of the answers can be read.
+func WithResolver(r *net.Resolver) Option {
+	return func(res *Resolver) {
+		res.resolver = r
+	}
+}
+
+// WithTTLBounds clamps the TTLs answers are cached for between min and max,
+// 0 for no bound.
+func WithTTLBounds(min, max time.Duration) Option {
+	return func(r *Resolver) {
+		r.minTTL, r.maxTTL = min, max
+	}
+}
+
+// WithDefaultTTL sets how long answers without TTLs, such as those from the
+// hosts file, are cached, one minute by default.
+func WithDefaultTTL(ttl time.Duration) Option {
+	return func(r *Resolver) {
+		r.defaultTTL = ttl
+	}
+}
+
+// Resolver is a caching resolver.  Concurrent lookups of a host missing from
+// the cache share a single query.
+type Resolver struct {
+	cache    *lfuda.Cache
+	resolver *net.Resolver
+
+	minTTL, maxTTL time.Duration
+	defaultTTL     time.Duration
+}
+
+// entry is a cached answer.
+type entry struct {
+	addrs []net.IPAddr
+	// a "no such host" error
+	err error
+	ttl time.Duration
+}
+
+// New creates a resolver caching the answers for up to size hosts.
+func New(size int, opts ...Option) *Resolver {
+	r := &Resolver{defaultTTL: time.Minute}
+	for _, opt := range opts {
+		opt(r)
+	}
+	base := r.resolver
+	if base == nil {
+		base = net.DefaultResolver
+	}
+	r.resolver = &net.Resolver{
+		PreferGo:     true,
+		StrictErrors: base.StrictErrors,
+		Dial:         recordingDial(base.Dial),
+	}
+	r.cache = lfuda.NewWithOptions(float64(size),
+		lfuda.WithSizeFunc(func(key, value interface{}) float64 { return 1 }),
+		lfuda.WithTTLFunc(func(key, value interface{}) time.Duration { return value.(entry).ttl }),
+	)
+	return r
+}
+
+// Cache returns the cache of answers, keyed by lower case host names.
+func (r *Resolver) Cache() *lfuda.Cache {
+	return r.cache
+}
+
+// LookupIPAddr looks up host's IPv4 and IPv6 addresses, as
+// net.Resolver.LookupIPAddr does.
+func (r *Resolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IPAddr{{IP: ip}}, nil
+	}
+	key := strings.ToLower(strings.TrimSuffix(host, "."))
+	v, err := r.cache.GetOrLoad(ctx, key, func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return r.lookup(ctx, host)
+	})
+	if err != nil {
+		return nil, err
+	}
+	e := v.(entry)
+	if e.err != nil {
+		return nil, e.err
+	}
+	addrs := make([]net.IPAddr, len(e.addrs))
+	copy(addrs, e.addrs)
+	return addrs, nil
+}
+
+// LookupIP looks up host's addresses for the network "ip", "ip4" or "ip6",
+// as net.Resolver.LookupIP does.
+func (r *Resolver) LookupIP(ctx context.Context, network, host string) ([]net.IP, error) {
+	var want func(ip net.IP) bool
+	switch network {
+	case "ip":
+		want = func(ip net.IP) bool { return true }
+	case "ip4":
+		want = func(ip net.IP) bool { return ip.To4() != nil }
+	case "ip6":
+		want = func(ip net.IP) bool { return ip.To4() == nil }
+	default:
+		return nil, net.UnknownNetworkError(network)
+	}
+	addrs, err := r.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	var ips []net.IP
+	for _, addr := range addrs {
+		if want(addr.IP) {
+			ips = append(ips, addr.IP)
+		}
+	}
+	if len(ips) == 0 {
+		return nil, &net.DNSError{Err: "no suitable address found", Name: host, IsNotFound: true}
+	}
+	return ips, nil
+}
+
+// LookupHost looks up host's addresses, as net.Resolver.LookupHost does.
+func (r *Resolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	addrs, err := r.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	hosts := make([]string, len(addrs))
+	for i, addr := range addrs {
+		hosts[i] = addr.String()
+	}
+	return hosts, nil
+}
+
+// lookup queries host, returning the entry to cache, or an error if the
+// lookup failed for a reason that shouldn't be cached.
+func (r *Resolver) lookup(ctx context.Context, host string) (interface{}, error) {
+	rec := new(ttlRecorder)
+	addrs, err := r.resolver.LookupIPAddr(withRecorder(ctx, rec), host)
+	var dnsErr *net.DNSError
+	if err != nil && !(errors.As(err, &dnsErr) && dnsErr.IsNotFound) {
+		return nil, err
+	}
+	ttl, ok := rec.ttl()
+	if !ok {
+		ttl = r.defaultTTL
+	}
+	if r.minTTL > 0 && ttl < r.minTTL {
+		ttl = r.minTTL
+	}
+	if r.maxTTL > 0 && ttl > r.maxTTL {
+		ttl = r.maxTTL
+	}
+	if ttl <= 0 {
+		// a TTL of 0 asks not to cache, which the cache takes as never
+		// expiring
+		ttl = time.Nanosecond
+	}
+	return entry{addrs: addrs, err: err, ttl: ttl}, nil
+}
@@ -0,0 +1,102 @@
+package dnscache
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// serve answers A queries for a.test. with 10.0.0.1 and a TTL of 300s, and
+// other names with NXDOMAIN and an SOA of TTL 60s.  Returns its address and
+// the number of queries it answered.
+func serve(t *testing.T) (string, *atomic.Int32) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	queries := new(atomic.Int32)
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			queries.Add(1)
+			conn.WriteTo(answer(buf[:n]), addr)
+		}
+	}()
+	return conn.LocalAddr().String(), queries
+}
+
+func answer(query []byte) []byte {
+	// the question ends after the name, type and class
+	end := skipName(query, 12) + 4
+	name := query[12 : end-4]
+	qtype := binary.BigEndian.Uint16(query[end-4:])
+	msg := append([]byte(nil), query[:end]...)
+	// a response with recursion available, no additional records
+	msg[2], msg[3] = 0x81, 0x80
+	binary.BigEndian.PutUint16(msg[10:], 0)
+	if string(name) == "\x01a\x04test\x00" {
+		if qtype == 1 {
+			binary.BigEndian.PutUint16(msg[6:], 1)
+			msg = append(msg, 0xc0, 12, 0, 1, 0, 1, 0, 0, 1, 44, 0, 4, 10, 0, 0, 1)
+		}
+		return msg
+	}
+	msg[3] |= 3 // NXDOMAIN
+	binary.BigEndian.PutUint16(msg[8:], 1)
+	// an SOA with a TTL of 3600s, root names and a minimum of 60s
+	msg = append(msg, 0xc0, 12, 0, typeSOA, 0, 1, 0, 0, 14, 16, 0, 22, 0, 0)
+	msg = append(msg, make([]byte, 16)...)
+	return append(msg, 0, 0, 0, 60)
+}
+
+func TestResolver(t *testing.T) {
+	addr, queries := serve(t)
+	r := New(10, WithResolver(&net.Resolver{
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}))
+	ctx := context.Background()
+
+	hosts, err := r.LookupHost(ctx, "a.test")
+	if err != nil || len(hosts) != 1 || hosts[0] != "10.0.0.1" {
+		t.Fatalf("got %v, %v", hosts, err)
+	}
+	asked := queries.Load()
+	if ips, err := r.LookupIP(ctx, "ip4", "A.test."); err != nil || len(ips) != 1 || queries.Load() != asked {
+		t.Errorf("the answer should be cached: %v, %v, %d queries", ips, err, queries.Load())
+	}
+	if ttl, ok := r.Cache().TTL("a.test"); !ok || ttl > 300*time.Second || ttl < 290*time.Second {
+		t.Errorf("the answer should be cached for its record's TTL: %v", ttl)
+	}
+	if _, err := r.LookupIP(ctx, "ip6", "a.test"); err == nil {
+		t.Errorf("a.test has no IPv6 address")
+	}
+
+	_, err = r.LookupHost(ctx, "missing.test")
+	var dnsErr *net.DNSError
+	if !errors.As(err, &dnsErr) || !dnsErr.IsNotFound {
+		t.Fatalf("got %v, want no such host", err)
+	}
+	asked = queries.Load()
+	if _, err := r.LookupHost(ctx, "missing.test"); err == nil || queries.Load() != asked {
+		t.Errorf("the negative answer should be cached: %v", err)
+	}
+	if ttl, ok := r.Cache().TTL("missing.test"); !ok || ttl > 60*time.Second || ttl < 50*time.Second {
+		t.Errorf("the negative answer should be cached for the SOA's TTL: %v", ttl)
+	}
+
+	if hosts, err := r.LookupHost(ctx, "127.0.0.1"); err != nil || hosts[0] != "127.0.0.1" || r.Cache().Contains("127.0.0.1") {
+		t.Errorf("IP literals should be returned as is: %v, %v", hosts, err)
+	}
+}
@@ -0,0 +1,180 @@
+package dnscache
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"sync"
+	"time"
+)
+
+// typeSOA is the type of SOA records.
+const typeSOA = 6
+
+// ttlRecorder records the smallest TTL of the responses read during a
+// lookup.
+type ttlRecorder struct {
+	lock  sync.Mutex
+	min   time.Duration
+	found bool
+}
+
+func (r *ttlRecorder) observe(msg []byte) {
+	ttl, ok := minTTL(msg)
+	if !ok {
+		return
+	}
+	r.lock.Lock()
+	if !r.found || ttl < r.min {
+		r.min, r.found = ttl, true
+	}
+	r.lock.Unlock()
+}
+
+func (r *ttlRecorder) ttl() (time.Duration, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.min, r.found
+}
+
+type recorderKey struct{}
+
+func withRecorder(ctx context.Context, r *ttlRecorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, r)
+}
+
+type dialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// recordingDial wraps dial, or a net.Dialer if nil, so the connections of a
+// lookup report the responses read to its recorder.
+func recordingDial(dial dialFunc) dialFunc {
+	if dial == nil {
+		var d net.Dialer
+		dial = d.DialContext
+	}
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		conn, err := dial(ctx, network, address)
+		rec, _ := ctx.Value(recorderKey{}).(*ttlRecorder)
+		if err != nil || rec == nil {
+			return conn, err
+		}
+		// the Go resolver frames messages by length unless the conn is a
+		// PacketConn
+		if pc, ok := conn.(net.PacketConn); ok {
+			return &recordingPacketConn{recordingConn{Conn: conn, rec: rec}, pc}, nil
+		}
+		return &recordingConn{Conn: conn, rec: rec, stream: true}, nil
+	}
+}
+
+// recordingConn reports the DNS messages read from it to rec.
+type recordingConn struct {
+	net.Conn
+	rec *ttlRecorder
+	// messages are prefixed with their length
+	stream bool
+	buf    []byte
+}
+
+func (c *recordingConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if !c.stream {
+		c.rec.observe(p[:n])
+		return n, err
+	}
+	c.buf = append(c.buf, p[:n]...)
+	for len(c.buf) >= 2 {
+		size := int(binary.BigEndian.Uint16(c.buf))
+		if len(c.buf) < 2+size {
+			break
+		}
+		c.rec.observe(c.buf[2 : 2+size])
+		c.buf = c.buf[2+size:]
+	}
+	return n, err
+}
+
+type recordingPacketConn struct {
+	recordingConn
+	pc net.PacketConn
+}
+
+func (c *recordingPacketConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	n, addr, err := c.pc.ReadFrom(p)
+	c.rec.observe(p[:n])
+	return n, addr, err
+}
+
+func (c *recordingPacketConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	return c.pc.WriteTo(p, addr)
+}
+
+// minTTL returns the smallest TTL of the answer records of the DNS message
+// msg and of the SOA records of its authority section, capped by the SOA's
+// negative caching TTL, or false if it has none.
+func minTTL(msg []byte) (time.Duration, bool) {
+	if len(msg) < 12 {
+		return 0, false
+	}
+	questions := int(binary.BigEndian.Uint16(msg[4:]))
+	answers := int(binary.BigEndian.Uint16(msg[6:]))
+	authorities := int(binary.BigEndian.Uint16(msg[8:]))
+	off := 12
+	for i := 0; i < questions; i++ {
+		if off = skipName(msg, off); off < 0 || off+4 > len(msg) {
+			return 0, false
+		}
+		off += 4
+	}
+	var min uint32
+	found := false
+	for i := 0; i < answers+authorities; i++ {
+		if off = skipName(msg, off); off < 0 || off+10 > len(msg) {
+			break
+		}
+		typ := binary.BigEndian.Uint16(msg[off:])
+		ttl := binary.BigEndian.Uint32(msg[off+4:])
+		length := int(binary.BigEndian.Uint16(msg[off+8:]))
+		off += 10
+		if off+length > len(msg) {
+			break
+		}
+		if i >= answers {
+			if typ != typeSOA {
+				off += length
+				continue
+			}
+			// the SOA's minimum field ends its data
+			if length >= 4 {
+				if negative := binary.BigEndian.Uint32(msg[off+length-4:]); negative < ttl {
+					ttl = negative
+				}
+			}
+		}
+		if !found || ttl < min {
+			min, found = ttl, true
+		}
+		off += length
+	}
+	return time.Duration(min) * time.Second, found
+}
+
+// skipName returns the offset past the name at off, or -1 if it is
+// truncated.
+func skipName(msg []byte, off int) int {
+	for off < len(msg) {
+		n := int(msg[off])
+		switch {
+		case n == 0:
+			return off + 1
+		case n&0xc0 == 0xc0:
+			// a compression pointer ends the name
+			if off+2 > len(msg) {
+				return -1
+			}
+			return off + 2
+		}
+		off += 1 + n
+	}
+	return -1
+}
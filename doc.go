@@ -8,5 +8,7 @@
 //
 // The cache in this package take locks while operating.  Its therefore thread-safe and can be used with multiple goroutines
 //
+// For write-heavy concurrent workloads, ShardedCache spreads keys over independently locked caches
+//
 // For use with a single goroutine (to avoid the locking overhead), the simplelfuda package can be used
 package lfuda
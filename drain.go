@@ -0,0 +1,45 @@
+package lfuda
+
+// DrainOptions configures Drain.
+type DrainOptions struct {
+	// Limit caps the number of entries handed off by one call to Drain.  0
+	// drains the whole cache.
+	Limit int
+}
+
+// Drain hands entries to fn from least to most valuable, removing each one
+// once fn returns successfully, e.g. to persist the hot set or pass it to a
+// replacement node before shutting down.  Expired entries and keys recorded
+// as missing by SetNegative are removed without calling fn.
+//
+// Drain stops at the first error returned by fn, leaving that entry in the
+// cache, so calling Drain again resumes where it stopped.  fn is called
+// without holding the lock; a value set concurrently for a key being drained
+// may be removed without being handed off.  Returns the number of entries
+// handed off.
+func (c *Cache) Drain(fn func(key, value interface{}) error, opts DrainOptions) (drained int, err error) {
+	for opts.Limit <= 0 || drained < opts.Limit {
+		c.flushReads()
+		c.lock.RLock()
+		info, found := c.lfuda.Lowest()
+		var value interface{}
+		live := false
+		if found {
+			value, live = present(c.decode(c.lfuda.Peek(info.Key)))
+		}
+		c.lock.RUnlock()
+
+		if !found {
+			break
+		}
+		key := info.Key
+		if live {
+			if err := fn(key, value); err != nil {
+				return drained, err
+			}
+			drained++
+		}
+		c.Remove(key)
+	}
+	return drained, nil
+}
@@ -0,0 +1,76 @@
+package lfuda
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDrain(t *testing.T) {
+	l := New(10)
+	for i := 0; i < 5; i++ {
+		l.Set(i, i)
+		for j := 0; j < i; j++ {
+			l.Get(i)
+		}
+	}
+
+	var order []interface{}
+	n, err := l.Drain(func(key, value interface{}) error {
+		if key != value {
+			t.Errorf("bad entry: %v, %v", key, value)
+		}
+		order = append(order, key)
+		return nil
+	}, DrainOptions{Limit: 2})
+	if err != nil || n != 2 {
+		t.Fatalf("bad drain: %d, %v", n, err)
+	}
+	if order[0] != 0 || order[1] != 1 || l.Len() != 3 {
+		t.Errorf("least valuable entries should be drained first: %v", order)
+	}
+
+	// stop on errors and resume afterwards
+	errDisk := errors.New("disk full")
+	n, err = l.Drain(func(key, value interface{}) error {
+		if key == 3 {
+			return errDisk
+		}
+		order = append(order, key)
+		return nil
+	}, DrainOptions{})
+	if err != errDisk || n != 1 || !l.Contains(3) {
+		t.Errorf("drain should have stopped at 3: %d, %v", n, err)
+	}
+
+	n, err = l.Drain(func(key, value interface{}) error {
+		order = append(order, key)
+		return nil
+	}, DrainOptions{})
+	if err != nil || n != 2 || l.Len() != 0 {
+		t.Errorf("drain should have resumed: %d, %v", n, err)
+	}
+	for i, k := range order {
+		if k != i {
+			t.Errorf("bad drain order: %v", order)
+			break
+		}
+	}
+}
+
+func TestDrainExpired(t *testing.T) {
+	l := New(10)
+	l.SetWithTTL(1, 1, time.Millisecond)
+	l.Set(2, 2)
+	time.Sleep(5 * time.Millisecond)
+
+	n, _ := l.Drain(func(key, value interface{}) error {
+		if key == 1 {
+			t.Errorf("expired entry should not be handed off")
+		}
+		return nil
+	}, DrainOptions{})
+	if n != 1 || l.Len() != 0 {
+		t.Errorf("bad drain: %d", n)
+	}
+}
@@ -0,0 +1,34 @@
+package lfuda
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// Dump writes a table of the entries' keys, sizes, hits, priorities and ages
+// to w, ordered by priority from the next to be evicted, e.g. to debug
+// evictions from a debug endpoint.  Entries are copied under the read lock,
+// then written without it.
+func (c *Cache) Dump(w io.Writer) error {
+	c.flushReads()
+	c.lock.RLock()
+	age, size, capacity := c.lfuda.Age(), c.lfuda.Size(), c.capacity()
+	infos := make([]EntryInfo, 0, c.lfuda.Len())
+	c.lfuda.RangeReverse(func(info EntryInfo) bool {
+		infos = append(infos, info)
+		return true
+	})
+	c.lock.RUnlock()
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "# %d entries, size %g of %g, cache age %g\n", len(infos), size, capacity, age)
+	fmt.Fprintln(tw, "key\tsize\thits\tpriority\tage")
+	now := time.Now()
+	for _, info := range infos {
+		entryAge := now.Sub(time.Unix(0, info.Created)).Round(time.Millisecond)
+		fmt.Fprintf(tw, "%v\t%g\t%g\t%g\t%v\n", info.Key, info.Size, info.Hits, info.Priority, entryAge)
+	}
+	return tw.Flush()
+}
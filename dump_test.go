@@ -0,0 +1,28 @@
+package lfuda
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDump(t *testing.T) {
+	l := New(100)
+	l.Set("cold", 1)
+	l.Set("hot", 22)
+	l.Get("hot")
+
+	var buf bytes.Buffer
+	if err := l.Dump(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "# 2 entries") || strings.Fields(lines[1])[0] != "key" {
+		t.Fatalf("bad dump:\n%s", buf.String())
+	}
+	// the next to be evicted first
+	cold, hot := strings.Fields(lines[2]), strings.Fields(lines[3])
+	if cold[0] != "cold" || hot[0] != "hot" || hot[1] != "2" || hot[2] != "2" {
+		t.Errorf("bad rows:\n%s", buf.String())
+	}
+}
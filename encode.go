@@ -0,0 +1,136 @@
+package lfuda
+
+// encodedValue is a value stored compressed or encrypted.  Values other
+// than []byte and strings are marshaled by the cache's codec.
+type encodedValue struct {
+	data       []byte
+	str        bool
+	marshaled  bool
+	compressed bool
+	encrypted  bool
+}
+
+// encode returns the value to store in place of value and its size.
+// Returns false if the value can't be stored.
+func (c *Cache) encode(key, value interface{}) (interface{}, float64, bool) {
+	encoded, size, err := c.encodeValue(value)
+	if err != nil {
+		c.debug("lfuda: value encoding failed", "key", key, "error", err)
+		return nil, 0, false
+	}
+	if size < 0 {
+		var ok bool
+		if size, ok = c.valueSize(key, value); !ok {
+			return nil, 0, false
+		}
+	}
+	return c.weaken(key, encoded, size), size, true
+}
+
+// encodeValue compresses and encrypts []byte and string values when
+// configured, and values marshaled by WithCodec's codec if set.  Returns the
+// value to store and, if it was compressed, its compressed size, otherwise
+// -1.
+func (c *Cache) encodeValue(value interface{}) (interface{}, float64, error) {
+	if c.opts.compressor == nil && c.opts.aead == nil {
+		return value, -1, nil
+	}
+	var b []byte
+	e := encodedValue{}
+	switch v := value.(type) {
+	case []byte:
+		b = v
+	case string:
+		b, e.str = []byte(v), true
+	case negativeEntry:
+		// a sentinel the codec can't restore
+		return value, -1, nil
+	default:
+		if c.opts.codec == nil {
+			return value, -1, nil
+		}
+		var err error
+		if b, err = c.opts.codec.Marshal(value); err != nil {
+			return nil, 0, err
+		}
+		e.marshaled = true
+	}
+	size := -1.0
+	if c.opts.compressor != nil && len(b) >= c.opts.compressThreshold {
+		if data, err := c.opts.compressor.Compress(b); err == nil && len(data) < len(b) {
+			b, e.compressed, size = data, true, float64(len(data))
+		}
+	}
+	if c.opts.aead != nil {
+		data, err := c.seal(b)
+		if err != nil {
+			return nil, 0, err
+		}
+		b, e.encrypted = data, true
+	}
+	if !e.compressed && !e.encrypted {
+		return value, -1, nil
+	}
+	e.data = b
+	return e, size, nil
+}
+
+// decode returns the value stored as value, for use on the results of
+// simplelfuda lookups, copied if WithCopyOnGet is set.  Values that fail to
+// decode or were released by the garbage collector are reported missing.
+func (c *Cache) decode(value interface{}, ok bool) (interface{}, bool) {
+	value, ok = c.decodeValue(value, ok)
+	if !ok || !c.opts.copyOnGet {
+		return value, ok
+	}
+	value, err := c.copyValue(c.opts.getCopy, value)
+	if err != nil {
+		c.debug("lfuda: value copy failed", "error", err)
+		return nil, false
+	}
+	return value, true
+}
+
+// decodeHit is decode for reads counting as an access, which keep weak
+// values from being released.
+func (c *Cache) decodeHit(value interface{}, ok bool) (interface{}, bool) {
+	if w, weak := value.(*weakValue); ok && weak {
+		w.used.Store(true)
+	}
+	return c.decode(value, ok)
+}
+
+// decodeValue is decode without the copy.
+func (c *Cache) decodeValue(value interface{}, ok bool) (interface{}, bool) {
+	if w, weak := value.(*weakValue); ok && weak {
+		if value, ok = w.get(); !ok {
+			return nil, false
+		}
+	}
+	e, encoded := value.(encodedValue)
+	if !ok || !encoded {
+		return value, ok
+	}
+	b, err := e.data, error(nil)
+	if e.encrypted {
+		b, err = c.open(b)
+	}
+	if err == nil && e.compressed {
+		b, err = c.opts.compressor.Decompress(b)
+	}
+	if err != nil {
+		c.debug("lfuda: value decoding failed", "error", err)
+		return nil, false
+	}
+	switch {
+	case e.str:
+		return string(b), true
+	case e.marshaled:
+		if value, err = c.opts.codec.Unmarshal(b); err != nil {
+			c.debug("lfuda: value decoding failed", "error", err)
+			return nil, false
+		}
+		return value, true
+	}
+	return b, true
+}
@@ -0,0 +1,39 @@
+package lfuda
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+)
+
+// WithEncryption stores []byte and string values encrypted with aead, e.g.
+// AES-GCM with a key supplied by the caller, and snapshots written by
+// WriteSnapshot encrypt every value, so sensitive data is not kept in
+// plaintext in memory dumps or on disk.  Values are compressed before being
+// encrypted.  Other values, keys and metadata are not encrypted.
+func WithEncryption(aead cipher.AEAD) Option {
+	return func(o *options) {
+		o.aead = aead
+	}
+}
+
+// errCiphertext is returned for values too short to have been sealed.
+var errCiphertext = errors.New("lfuda: ciphertext too short")
+
+// seal encrypts b with a random nonce, which it prepends.
+func (c *Cache) seal(b []byte) ([]byte, error) {
+	nonce := make([]byte, c.opts.aead.NonceSize(), c.opts.aead.NonceSize()+len(b)+c.opts.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return c.opts.aead.Seal(nonce, nonce, b, nil), nil
+}
+
+// open decrypts b sealed by seal.
+func (c *Cache) open(b []byte) ([]byte, error) {
+	n := c.opts.aead.NonceSize()
+	if len(b) < n {
+		return nil, errCiphertext
+	}
+	return c.opts.aead.Open(nil, b[:n], b[n:], nil)
+}
@@ -0,0 +1,70 @@
+package lfuda
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"strings"
+	"testing"
+)
+
+func newAEAD(t *testing.T, key string) cipher.AEAD {
+	block, err := aes.NewCipher([]byte(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return aead
+}
+
+func TestEncryption(t *testing.T) {
+	aead := newAEAD(t, "0123456789abcdef")
+	l := NewWithOptions(10000, WithEncryption(aead), WithCompression(GzipCompressor(gzip.BestSpeed), 100))
+	secret := strings.Repeat("secret", 100)
+	l.Set("s", secret)
+	l.Set("b", []byte("short secret"))
+	l.Set("n", 1)
+
+	l.Range(func(info EntryInfo) bool {
+		stored, _ := l.lfuda.Peek(info.Key)
+		if e, ok := stored.(encodedValue); ok && bytes.Contains(e.data, []byte("secret")) {
+			t.Errorf("%v is stored in plaintext", info.Key)
+		}
+		return true
+	})
+	if v, _ := l.Get("s"); v != secret {
+		t.Error("bad string value")
+	}
+	if v, _ := l.Get("b"); !bytes.Equal(v.([]byte), []byte("short secret")) {
+		t.Error("bad []byte value")
+	}
+	if v, _ := l.Get("n"); v != 1 {
+		t.Errorf("bad int value: %v", v)
+	}
+
+	var buf bytes.Buffer
+	if err := l.WriteSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("short secret")) {
+		t.Error("the snapshot holds plaintext values")
+	}
+	if _, err := New(10000).ReadSnapshot(bytes.NewReader(buf.Bytes())); err != ErrSnapshotEncrypted {
+		t.Errorf("expected ErrSnapshotEncrypted, got %v", err)
+	}
+	restored := NewWithOptions(10000, WithEncryption(aead))
+	if loaded, err := restored.ReadSnapshot(bytes.NewReader(buf.Bytes())); err != nil || loaded != 3 {
+		t.Fatalf("expected 3 entries loaded, got %d, %v", loaded, err)
+	}
+	if v, _ := restored.Get("s"); v != secret {
+		t.Error("bad restored value")
+	}
+	other := NewWithOptions(10000, WithEncryption(newAEAD(t, "fedcba9876543210")))
+	if _, err := other.ReadSnapshot(bytes.NewReader(buf.Bytes())); err == nil {
+		t.Error("a snapshot should not be readable with another key")
+	}
+}
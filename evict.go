@@ -0,0 +1,48 @@
+package lfuda
+
+// Evicted is an entry evicted to make room for another.
+type Evicted struct {
+	Key, Value interface{}
+}
+
+// SetEvicting adds a value to the cache like Set and returns the entries
+// evicted to make room for it, least valuable first.  The victims are all
+// picked before any eviction callback runs.
+func (c *Cache) SetEvicting(key, value interface{}) (evicted []Evicted) {
+	if c.badKey(key) || c.dropSet() {
+		return
+	}
+	c.lockOp()
+	c.opCollect = true
+	c.set(key, value)
+	evicted = c.opEvicted
+	c.opCollect = false
+	c.opEvicted = nil
+	c.unlockOp()
+	return evicted
+}
+
+// EvictBytes evicts the least valuable entries until at least n bytes are
+// freed or the cache is empty, e.g. in response to memory pressure reported
+// by the runtime or a cgroup.  Evictions raise the cache age and are reported
+// like any other.  Returns the number of bytes freed.
+func (c *Cache) EvictBytes(n float64) (freed float64) {
+	c.lockOp()
+	defer c.unlockOp()
+	size := c.lfuda.Size()
+	for freed < n && c.lfuda.Evict() {
+		freed = size - c.lfuda.Size()
+	}
+	return freed
+}
+
+// EvictEntries evicts the n least valuable entries, or all of them if there
+// are fewer.  Returns the number of entries evicted.
+func (c *Cache) EvictEntries(n int) (evicted int) {
+	c.lockOp()
+	defer c.unlockOp()
+	for evicted < n && c.lfuda.Evict() {
+		evicted++
+	}
+	return evicted
+}
@@ -0,0 +1,70 @@
+package lfuda
+
+import "testing"
+
+func TestEvictBytes(t *testing.T) {
+	var evicted []interface{}
+	l := NewWithEvict(100, func(key, value interface{}) {
+		evicted = append(evicted, key)
+	})
+	for i := 0; i < 5; i++ {
+		l.SetWithSize(i, i, 10)
+		for j := 0; j < i; j++ {
+			l.Get(i)
+		}
+	}
+
+	if freed := l.EvictBytes(15); freed != 20 {
+		t.Errorf("should free whole entries until enough bytes are freed: %v", freed)
+	}
+	if len(evicted) != 2 || evicted[0] != 0 || evicted[1] != 1 {
+		t.Errorf("least valuable entries should be evicted first: %v", evicted)
+	}
+	if l.Age() == 0 {
+		t.Errorf("forced evictions should age the cache")
+	}
+	if freed := l.EvictBytes(1000); freed != 30 || l.Len() != 0 {
+		t.Errorf("should stop once the cache is empty: %v", freed)
+	}
+}
+
+func TestEvictEntries(t *testing.T) {
+	l := New(100)
+	for i := 0; i < 5; i++ {
+		l.Set(i, i)
+	}
+	if n := l.EvictEntries(2); n != 2 || l.Len() != 3 {
+		t.Errorf("should evict 2 entries: %d, %d", n, l.Len())
+	}
+	if n := l.EvictEntries(10); n != 3 || l.Len() != 0 {
+		t.Errorf("should evict the remaining entries: %d", n)
+	}
+}
+
+func TestSetEvicting(t *testing.T) {
+	l := NewWithOptions(10, WithSizeFunc(func(key, value interface{}) float64 {
+		return float64(value.(int))
+	}))
+	for i := 0; i < 5; i++ {
+		l.Set(i, 2)
+		for j := 0; j < i; j++ {
+			l.Get(i)
+		}
+	}
+	if evicted := l.SetEvicting("small", 1); len(evicted) != 1 || evicted[0] != (Evicted{0, 2}) {
+		t.Errorf("the least valuable entry should be returned: %v", evicted)
+	}
+	freed := 0
+	for _, e := range l.SetEvicting("big", 7) {
+		if l.Contains(e.Key) {
+			t.Errorf("%v should have been evicted", e.Key)
+		}
+		freed += e.Value.(int)
+	}
+	if freed < 6 || l.Size() > 10 {
+		t.Errorf("enough bytes should be freed: %v", freed)
+	}
+	if evicted := l.SetEvicting("big", 1); len(evicted) != 0 {
+		t.Errorf("nothing should be evicted: %v", evicted)
+	}
+}
@@ -0,0 +1,11 @@
+package lfuda
+
+// PurgeExpired removes every expired entry now rather than when it is next
+// accessed or evicted, reporting each as expired.  Returns the number of
+// entries removed.
+func (c *Cache) PurgeExpired() (removed int) {
+	c.lockOp()
+	removed = c.lfuda.PurgeExpired()
+	c.unlockOp()
+	return removed
+}
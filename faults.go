@@ -0,0 +1,85 @@
+package lfuda
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Faults configures the faults a cache injects, to test that applications
+// degrade gracefully when the cache misbehaves.  Faults apply to Get and to
+// Set and its variants.
+type Faults struct {
+	// Delay is added to every operation, plus a random duration up to
+	// Jitter.
+	Delay  time.Duration
+	Jitter time.Duration
+	// DropSetRate is the fraction of sets silently dropped.
+	DropSetRate float64
+	// MissRate is the fraction of Gets reported as misses whether or not
+	// the key is cached.
+	MissRate float64
+	// Seed makes the injected faults reproducible.  0 picks a random seed.
+	Seed int64
+}
+
+// faultInjector draws the faults of operations.
+type faultInjector struct {
+	Faults
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+func newFaultInjector(f Faults) *faultInjector {
+	seed := f.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &faultInjector{Faults: f, rnd: rand.New(rand.NewSource(seed))}
+}
+
+// inject delays the operation and reports whether it fails, with the given
+// probability.
+func (f *faultInjector) inject(rate float64) bool {
+	f.mu.Lock()
+	delay := f.Delay
+	if f.Jitter > 0 {
+		delay += time.Duration(f.rnd.Int63n(int64(f.Jitter)))
+	}
+	fail := rate > 0 && f.rnd.Float64() < rate
+	f.mu.Unlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+	return fail
+}
+
+// WithFaults makes the cache inject faults.  Only meant for tests.
+func WithFaults(f Faults) Option {
+	return func(o *options) {
+		o.faults = &f
+	}
+}
+
+// SetFaults starts injecting faults, replacing the ones set before, or stops
+// if f is nil.  Only meant for tests.
+func (c *Cache) SetFaults(f *Faults) {
+	if f == nil {
+		c.faults.Store(nil)
+		return
+	}
+	c.faults.Store(newFaultInjector(*f))
+}
+
+// dropSet delays a set and reports whether to drop it.
+func (c *Cache) dropSet() bool {
+	f := c.faults.Load()
+	return f != nil && f.inject(f.DropSetRate)
+}
+
+// forceMiss delays a Get and reports whether to miss.
+func (c *Cache) forceMiss() bool {
+	f := c.faults.Load()
+	return f != nil && f.inject(f.MissRate)
+}
@@ -0,0 +1,59 @@
+package lfuda
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFaults(t *testing.T) {
+	l := NewWithOptions(1000, WithFaults(Faults{DropSetRate: 1}))
+	l.Set("a", 1)
+	l.SetWithTTL("b", 1, time.Hour)
+	if l.Len() != 0 {
+		t.Errorf("all sets should be dropped: %d", l.Len())
+	}
+
+	l.SetFaults(&Faults{MissRate: 0.5, Seed: 1})
+	for i := 0; i < 100; i++ {
+		l.Set(i, i)
+	}
+	if l.Len() != 100 {
+		t.Errorf("sets should no longer be dropped: %d", l.Len())
+	}
+	for i := 0; i < 100; i++ {
+		l.Get(i)
+	}
+	if st := l.Stats(); st.Misses < 25 || st.Misses > 75 {
+		t.Errorf("about half the gets should miss: %+v", st)
+	}
+
+	l.SetFaults(&Faults{Delay: 5 * time.Millisecond})
+	start := time.Now()
+	l.Get(1)
+	if time.Since(start) < 5*time.Millisecond {
+		t.Errorf("get should have been delayed")
+	}
+
+	l.SetFaults(nil)
+	if _, ok := l.Get(1); !ok {
+		t.Errorf("faults should be disabled")
+	}
+}
+
+func TestFaultsReproducible(t *testing.T) {
+	misses := func() (n []bool) {
+		l := NewWithOptions(1000, WithFaults(Faults{MissRate: 0.5, Seed: 42}))
+		l.Set("a", 1)
+		for i := 0; i < 20; i++ {
+			_, ok := l.Get("a")
+			n = append(n, ok)
+		}
+		return n
+	}
+	a, b := misses(), misses()
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("faults with the same seed should be identical")
+		}
+	}
+}
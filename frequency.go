@@ -0,0 +1,24 @@
+package lfuda
+
+import "sort"
+
+// FrequencyHistogram counts the live entries by hits, to show how skewed the
+// working set is.  buckets are ascending upper bounds: counts[0] counts the
+// entries with at most buckets[0] hits, counts[i] those with more than
+// buckets[i-1] and at most buckets[i], and the extra counts[len(buckets)]
+// those with more than the last bound.  The cache is scanned under the read
+// lock.
+func (c *Cache) FrequencyHistogram(buckets []float64) (counts []int) {
+	counts = make([]int, len(buckets)+1)
+	c.flushReads()
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	c.lfuda.Range(func(info EntryInfo) bool {
+		value, ok := c.lfuda.Peek(info.Key)
+		if _, isNeg := value.(negativeEntry); ok && !isNeg {
+			counts[sort.SearchFloat64s(buckets, info.Hits)]++
+		}
+		return true
+	})
+	return counts
+}
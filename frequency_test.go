@@ -0,0 +1,35 @@
+package lfuda
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFrequencyHistogram(t *testing.T) {
+	l := New(1000)
+	for i := 0; i < 10; i++ {
+		l.Set(i, i)
+		for j := 0; j < i; j++ {
+			l.Get(i)
+		}
+	}
+	l.SetNegative("missing", time.Minute)
+
+	// hits are 1 to 10
+	counts := l.FrequencyHistogram([]float64{1, 2, 5})
+	if want := []int{1, 1, 3, 5}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("got counts %v, want %v", counts, want)
+	}
+	if counts := l.FrequencyHistogram(nil); !reflect.DeepEqual(counts, []int{10}) {
+		t.Errorf("got counts %v without buckets", counts)
+	}
+
+	s := NewSharded(1000, WithShards(4))
+	for i := 0; i < 8; i++ {
+		s.Set(i, i)
+	}
+	if counts := s.FrequencyHistogram([]float64{1}); !reflect.DeepEqual(counts, []int{8, 0}) {
+		t.Errorf("got sharded counts %v", counts)
+	}
+}
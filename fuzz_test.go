@@ -0,0 +1,52 @@
+package lfuda
+
+import (
+	"testing"
+	"time"
+)
+
+// FuzzCache interleaves operations decoded from the input, checking the
+// cache's accounting after each one.
+func FuzzCache(f *testing.F) {
+	f.Add([]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, uint8(0))
+	f.Add([]byte{0, 1, 200, 0, 2, 100, 1, 1, 3, 50, 5, 6, 7}, uint8(1))
+	f.Add([]byte{9, 3, 30, 8, 1, 4, 11, 10, 2, 0, 0, 12}, uint8(3))
+	f.Fuzz(func(t *testing.T, ops []byte, policy uint8) {
+		policies := []string{PolicyLFUDA, PolicyGDSF, PolicyLFU, PolicyHybrid}
+		l := NewWithOptions(256, WithPolicy(policies[policy%4]))
+		defer l.Close()
+		for len(ops) >= 3 {
+			op, key, arg := ops[0], ops[1]%16, ops[2]
+			ops = ops[3:]
+			switch op % 12 {
+			case 0, 1:
+				l.SetWithSize(key, arg, float64(arg))
+			case 2, 3:
+				l.Get(key)
+			case 4:
+				l.Remove(key)
+			case 5:
+				l.Resize(float64(arg) * 2)
+			case 6:
+				l.Purge()
+			case 7:
+				l.SetWithTTL(key, arg, time.Duration(arg%2)*time.Nanosecond)
+			case 8:
+				l.EvictBytes(float64(arg))
+			case 9:
+				l.SetEvicting(key, arg)
+			case 10:
+				l.SwitchPolicy(policies[arg%4])
+			case 11:
+				l.HalveHits()
+			}
+
+			if size := l.Size(); size < 0 || size > l.Capacity() {
+				t.Fatalf("size %v out of [0, %v]", size, l.Capacity())
+			}
+			if keys := l.Keys(); len(keys) != l.Len() {
+				t.Fatalf("%d keys for %d entries", len(keys), l.Len())
+			}
+		}
+	})
+}
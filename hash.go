@@ -0,0 +1,104 @@
+package lfuda
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+)
+
+const (
+	fnvOffset = 14695981039346656037
+	fnvPrime  = 1099511628211
+)
+
+// Hasher hashes keys, e.g. to pick their shard.  Equal keys must have equal
+// hashes.
+type Hasher interface {
+	Hash(key interface{}) uint64
+}
+
+// HasherFunc adapts a function to a Hasher.
+type HasherFunc func(key interface{}) uint64
+
+// Hash calls f(key).
+func (f HasherFunc) Hash(key interface{}) uint64 {
+	return f(key)
+}
+
+// EqualHasher hashes and compares keys that can't be compared with ==, such
+// as slices or structs holding maps.
+type EqualHasher interface {
+	Hasher
+	Equal(a, b interface{}) bool
+}
+
+// KeyFuncs is an EqualHasher made of a hash and an equal func.
+type KeyFuncs struct {
+	HashFunc  func(key interface{}) uint64
+	EqualFunc func(a, b interface{}) bool
+}
+
+// Hash calls k.HashFunc(key).
+func (k KeyFuncs) Hash(key interface{}) uint64 {
+	return k.HashFunc(key)
+}
+
+// Equal calls k.EqualFunc(a, b).
+func (k KeyFuncs) Equal(a, b interface{}) bool {
+	return k.EqualFunc(a, b)
+}
+
+// WithHasher sets the hasher picking the shard of keys in a ShardedCache,
+// instead of hashing common key types and the formatted value of others.
+func WithHasher(hasher Hasher) Option {
+	return func(o *options) {
+		o.hasher = hasher
+	}
+}
+
+// hashKey returns a hash of key that is stable across processes, so it can
+// be used both to pick shards and to build digests shared with peers.
+func hashKey(key interface{}) uint64 {
+	switch k := key.(type) {
+	case string:
+		return hashString(k)
+	case CompositeKey:
+		return hashString(k.enc)
+	case int:
+		return hashUint64(uint64(k))
+	case int64:
+		return hashUint64(uint64(k))
+	case int32:
+		return hashUint64(uint64(k))
+	case uint:
+		return hashUint64(uint64(k))
+	case uint64:
+		return hashUint64(k)
+	case uint32:
+		return hashUint64(uint64(k))
+	case float64:
+		return hashUint64(math.Float64bits(k))
+	}
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%T:%v", key, key)
+	return h.Sum64()
+}
+
+func hashString(s string) uint64 {
+	h := uint64(fnvOffset)
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= fnvPrime
+	}
+	return h
+}
+
+// hashUint64 is the splitmix64 finalizer, spreading sequential integers.
+func hashUint64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,196 @@
+package lfuda
+
+import (
+	"sync"
+	"time"
+)
+
+// HashedCache is a cache whose keys are identified by an EqualHasher rather
+// than ==, so they need not be comparable.  Its operations are serialized by
+// a lock of its own on top of the cache's.
+type HashedCache struct {
+	cache  *Cache
+	hasher EqualHasher
+	lock   sync.Mutex
+	// keys in the cache by hash
+	refs   map[uint64][]hashedKey
+	nextID uint64
+	// original keys by hashedKey, read by callbacks without the lock
+	keys sync.Map
+
+	// keys removed from the cache, forgotten by the next operation since
+	// callbacks may run while the lock is held
+	removedLock sync.Mutex
+	removed     []hashedKey
+}
+
+// hashedKey stands for an original key in the cache.
+type hashedKey struct {
+	hash, id uint64
+}
+
+// NewHashed creates a cache of the given size whose keys are hashed and
+// compared by hasher.  The options are those of NewWithOptions; size, TTL
+// and cost funcs and callbacks are given the original keys.
+func NewHashed(size float64, hasher EqualHasher, opts ...Option) *HashedCache {
+	h := &HashedCache{
+		hasher: hasher,
+		refs:   make(map[uint64][]hashedKey),
+	}
+	o := applyOptions(opts)
+	if sizeFunc := o.sizeFunc; sizeFunc != nil {
+		o.sizeFunc = func(key, value interface{}) float64 {
+			return sizeFunc(h.original(key), value)
+		}
+	}
+	if ttlFunc := o.ttlFunc; ttlFunc != nil {
+		o.ttlFunc = func(key, value interface{}) time.Duration {
+			return ttlFunc(h.original(key), value)
+		}
+	}
+	if costFunc := o.costFunc; costFunc != nil {
+		o.costFunc = func(key, value interface{}) float64 {
+			return costFunc(h.original(key), value)
+		}
+	}
+	onEvicted, onExpired := o.onEvicted, o.onExpired
+	o.onEvicted = func(key, value interface{}) {
+		if onEvicted != nil {
+			onEvicted(h.original(key), value)
+		}
+		h.remove(key.(hashedKey))
+	}
+	if onExpired != nil {
+		o.onExpired = func(key, value interface{}) {
+			onExpired(h.original(key), value)
+			h.remove(key.(hashedKey))
+		}
+	}
+	h.cache = newCache(size, o)
+	return h
+}
+
+func (h *HashedCache) original(key interface{}) interface{} {
+	k, _ := h.keys.Load(key)
+	return k
+}
+
+func (h *HashedCache) remove(ref hashedKey) {
+	h.removedLock.Lock()
+	h.removed = append(h.removed, ref)
+	h.removedLock.Unlock()
+}
+
+// lookup returns the key standing for key, if any, with the lock held.
+func (h *HashedCache) lookup(key interface{}) (ref hashedKey, ok bool) {
+	h.removedLock.Lock()
+	removed := h.removed
+	h.removed = nil
+	h.removedLock.Unlock()
+	for _, ref := range removed {
+		h.forget(ref)
+	}
+
+	hash := h.hasher.Hash(key)
+	for _, ref := range h.refs[hash] {
+		if h.hasher.Equal(h.original(ref), key) {
+			return ref, true
+		}
+	}
+	return hashedKey{hash: hash}, false
+}
+
+// forget drops a key no longer in the cache.
+func (h *HashedCache) forget(ref hashedKey) {
+	if h.cache.Contains(ref) {
+		return
+	}
+	refs := h.refs[ref.hash]
+	for i := range refs {
+		if refs[i] == ref {
+			refs = append(refs[:i], refs[i+1:]...)
+			break
+		}
+	}
+	if len(refs) == 0 {
+		delete(h.refs, ref.hash)
+	} else {
+		h.refs[ref.hash] = refs
+	}
+	h.keys.Delete(ref)
+}
+
+// Set adds a value to the cache. Returns true if an eviction occurred.
+func (h *HashedCache) Set(key, value interface{}) bool {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	ref, ok := h.lookup(key)
+	if !ok {
+		h.nextID++
+		ref.id = h.nextID
+		h.refs[ref.hash] = append(h.refs[ref.hash], ref)
+		h.keys.Store(ref, key)
+	}
+	evicted := h.cache.Set(ref, value)
+	if !ok {
+		// the value may have been rejected
+		h.forget(ref)
+	}
+	return evicted
+}
+
+// Get looks up a key's value from the cache.
+func (h *HashedCache) Get(key interface{}) (interface{}, bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	// unknown keys are looked up too, counting the miss
+	ref, _ := h.lookup(key)
+	return h.cache.Get(ref)
+}
+
+// Peek returns a key's value without updating its hits.
+func (h *HashedCache) Peek(key interface{}) (interface{}, bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	ref, ok := h.lookup(key)
+	if !ok {
+		return nil, false
+	}
+	return h.cache.Peek(ref)
+}
+
+// Contains checks if a key is in the cache, without updating its hits.
+func (h *HashedCache) Contains(key interface{}) bool {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	ref, ok := h.lookup(key)
+	return ok && h.cache.Contains(ref)
+}
+
+// Remove removes a key from the cache.  Returns true if it was present.
+func (h *HashedCache) Remove(key interface{}) bool {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	ref, ok := h.lookup(key)
+	if !ok {
+		return false
+	}
+	present := h.cache.Remove(ref)
+	h.forget(ref)
+	return present
+}
+
+// Len returns the number of items in the cache.
+func (h *HashedCache) Len() int {
+	return h.cache.Len()
+}
+
+// Stats returns a snapshot of the cache's counters.
+func (h *HashedCache) Stats() Stats {
+	return h.cache.Stats()
+}
+
+// Close stops the cache's background work.
+func (h *HashedCache) Close() error {
+	return h.cache.Close()
+}
@@ -0,0 +1,73 @@
+package lfuda
+
+import (
+	"reflect"
+	"testing"
+)
+
+// sliceKeys hashes []int keys to their length, so keys of the same length
+// collide.
+var sliceKeys = KeyFuncs{
+	HashFunc: func(key interface{}) uint64 {
+		return uint64(len(key.([]int)))
+	},
+	EqualFunc: reflect.DeepEqual,
+}
+
+func TestHashed(t *testing.T) {
+	var evicted [][]int
+	l := NewHashed(3, sliceKeys, WithEvictCallback(func(key, value interface{}) {
+		evicted = append(evicted, key.([]int))
+	}))
+
+	l.Set([]int{1}, "a")
+	l.Set([]int{2}, "b")
+	l.Set([]int{1, 2}, "c")
+	l.Set([]int{1}, "A")
+	if l.Len() != 3 {
+		t.Fatalf("bad len: %d", l.Len())
+	}
+	if v, ok := l.Get([]int{1}); !ok || v != "A" {
+		t.Errorf("bad value: %v", v)
+	}
+	if v, ok := l.Peek([]int{2}); !ok || v != "b" {
+		t.Errorf("bad value: %v", v)
+	}
+	if l.Contains([]int{3}) {
+		t.Errorf("unexpected key")
+	}
+
+	l.Get([]int{1, 2})
+	l.Set([]int{3}, "d")
+	if !reflect.DeepEqual(evicted, [][]int{{2}}) {
+		t.Errorf("bad evictions: %v", evicted)
+	}
+	if l.Contains([]int{2}) || !l.Contains([]int{3}) {
+		t.Errorf("bad keys after eviction")
+	}
+
+	if !l.Remove([]int{1, 2}) || l.Remove([]int{1, 2}) {
+		t.Errorf("bad removal")
+	}
+	l.Contains([]int(nil))
+	if len(l.refs) != 1 || len(l.refs[1]) != 2 {
+		t.Errorf("stale keys: %v", l.refs)
+	}
+	if stats := l.Stats(); stats.Hits != 2 {
+		t.Errorf("bad stats: %+v", stats)
+	}
+}
+
+func TestHasher(t *testing.T) {
+	l := NewSharded(100, WithShards(4), WithHasher(HasherFunc(func(key interface{}) uint64 {
+		return uint64(key.(int) / 10)
+	})))
+	for i := 0; i < 10; i++ {
+		l.Set(20+i, i)
+	}
+	for i, shard := range l.ShardStats() {
+		if want := map[bool]int{true: 10}[i == 2]; shard.Len != want {
+			t.Errorf("shard %d has %d keys", i, shard.Len)
+		}
+	}
+}
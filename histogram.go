@@ -0,0 +1,123 @@
+package lfuda
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// HistogramBuckets is the number of buckets of a Histogram.
+const HistogramBuckets = 32
+
+// Histogram is a distribution of values in power of two buckets: Counts[0]
+// counts the values up to 1, Counts[i] those in (2^(i-1), 2^i] and the last
+// bucket all larger ones.
+type Histogram struct {
+	Counts [HistogramBuckets]uint64
+	// sum of the values
+	Sum float64
+}
+
+// Count returns the number of values.
+func (h Histogram) Count() (n uint64) {
+	for _, c := range h.Counts {
+		n += c
+	}
+	return n
+}
+
+// Mean returns the mean of the values, 0 if there are none.
+func (h Histogram) Mean() float64 {
+	n := h.Count()
+	if n == 0 {
+		return 0
+	}
+	return h.Sum / float64(n)
+}
+
+// Quantile returns the upper bound of the bucket holding the q quantile,
+// e.g. 0.5 for the median, or 0 if there are no values.  The last bucket has
+// no upper bound and returns +Inf.
+func (h Histogram) Quantile(q float64) float64 {
+	n := h.Count()
+	if n == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(q * float64(n)))
+	if rank == 0 {
+		rank = 1
+	}
+	var seen uint64
+	for i, c := range h.Counts {
+		seen += c
+		if seen >= rank {
+			if i == HistogramBuckets-1 {
+				return math.Inf(1)
+			}
+			return math.Ldexp(1, i)
+		}
+	}
+	return math.Inf(1)
+}
+
+// merge adds the values of o to h.
+func (h *Histogram) merge(o Histogram) {
+	for i, c := range o.Counts {
+		h.Counts[i] += c
+	}
+	h.Sum += o.Sum
+}
+
+// histogram is a Histogram updated atomically.
+type histogram struct {
+	counts [HistogramBuckets]atomic.Uint64
+	// float64 bits of the sum
+	sum atomic.Uint64
+}
+
+func (h *histogram) add(v float64) {
+	i := 0
+	if v > 1 {
+		_, exp := math.Frexp(v)
+		// v is in [2^(exp-1), 2^exp)
+		i = exp
+		if v == math.Ldexp(1, exp-1) {
+			i--
+		}
+		if i >= HistogramBuckets {
+			i = HistogramBuckets - 1
+		}
+	}
+	h.counts[i].Add(1)
+	for {
+		old := h.sum.Load()
+		if h.sum.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+func (h *histogram) snapshot() (s Histogram) {
+	for i := range h.counts {
+		s.Counts[i] = h.counts[i].Load()
+	}
+	s.Sum = math.Float64frombits(h.sum.Load())
+	return s
+}
+
+func (h *histogram) reset() {
+	for i := range h.counts {
+		h.counts[i].Store(0)
+	}
+	h.sum.Store(0)
+}
+
+// observeEviction records the lifetime and hits of an evicted entry.
+func (c *Cache) observeEviction(info EntryInfo) {
+	c.opExpires = info.Expires
+	if info.Created != 0 {
+		lifetime := time.Since(time.Unix(0, info.Created))
+		c.stats.lifetimes.add(float64(lifetime) / float64(time.Millisecond))
+	}
+	c.stats.evictionHits.add(info.Hits)
+}
@@ -0,0 +1,45 @@
+package lfuda
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHistogram(t *testing.T) {
+	var h histogram
+	for _, v := range []float64{0, 0.5, 1, 2, 3, 4, 5, 1 << 40} {
+		h.add(v)
+	}
+	s := h.snapshot()
+	want := map[int]uint64{0: 3, 1: 1, 2: 2, 3: 1, HistogramBuckets - 1: 1}
+	for i, c := range s.Counts {
+		if c != want[i] {
+			t.Errorf("bucket %d should count %d values: %d", i, want[i], c)
+		}
+	}
+	if s.Count() != 8 || s.Mean() != (15.5+1<<40)/8 {
+		t.Errorf("unexpected count or mean: %d %v", s.Count(), s.Mean())
+	}
+	if s.Quantile(0.5) != 2 || s.Quantile(0.75) != 4 || !math.IsInf(s.Quantile(1), 1) {
+		t.Errorf("unexpected quantiles: %v %v %v", s.Quantile(0.5), s.Quantile(0.75), s.Quantile(1))
+	}
+	if (Histogram{}).Quantile(0.5) != 0 {
+		t.Errorf("an empty histogram has no quantiles")
+	}
+}
+
+func TestEvictionStats(t *testing.T) {
+	l := New(2)
+	l.Set("a", 1)
+	for i := 0; i < 5; i++ {
+		l.Get("a")
+	}
+	l.Set("b", 2)
+	l.Set("c", 3)
+	l.Remove("a")
+
+	st := l.Stats()
+	if st.Lifetimes.Count() != 1 || st.EvictionHits.Count() != 1 || st.EvictionHits.Sum != 1 {
+		t.Errorf("only the eviction of b should be recorded: %+v %+v", st.Lifetimes, st.EvictionHits)
+	}
+}
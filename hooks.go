@@ -0,0 +1,150 @@
+package lfuda
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// HitHook is called with the key and value of every Get that finds its key.
+type HitHook func(key, value interface{})
+
+// MissHook is called with the key of every Get that does not find its key.
+type MissHook func(key interface{})
+
+// SetHook is called with the key and value of every entry stored in the
+// cache.
+type SetHook func(key, value interface{})
+
+// EvictHook is called with the key and value of every entry leaving the
+// cache, whether evicted, removed or purged.
+type EvictHook func(key, value interface{})
+
+// AgeHook is called whenever the cache age changes.
+type AgeHook func(oldAge, newAge float64)
+
+type hookEvent int
+
+const (
+	hookHit hookEvent = iota
+	hookMiss
+	hookSet
+	hookEvict
+	hookAge
+	numHookEvents
+)
+
+type hookEntry struct {
+	id int
+	fn interface{}
+}
+
+// hooks is a copy-on-write registry so the hot path only pays for an atomic
+// load when no hooks are registered.
+type hooks struct {
+	mu     sync.Mutex
+	nextID int
+	fns    atomic.Value // [numHookEvents][]hookEntry
+}
+
+func (h *hooks) load() (fns [numHookEvents][]hookEntry) {
+	if v := h.fns.Load(); v != nil {
+		fns = v.([numHookEvents][]hookEntry)
+	}
+	return fns
+}
+
+func (h *hooks) has(ev hookEvent) bool {
+	fns := h.load()
+	return len(fns[ev]) > 0
+}
+
+func (h *hooks) add(ev hookEvent, fn interface{}) (remove func()) {
+	h.mu.Lock()
+	id := h.nextID
+	h.nextID++
+	fns := h.load()
+	fns[ev] = append(fns[ev][:len(fns[ev]):len(fns[ev])], hookEntry{id: id, fn: fn})
+	h.fns.Store(fns)
+	h.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { h.remove(ev, id) })
+	}
+}
+
+func (h *hooks) remove(ev hookEvent, id int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	fns := h.load()
+	kept := make([]hookEntry, 0, len(fns[ev]))
+	for _, e := range fns[ev] {
+		if e.id != id {
+			kept = append(kept, e)
+		}
+	}
+	fns[ev] = kept
+	h.fns.Store(fns)
+}
+
+func (h *hooks) hit(key, value interface{}) {
+	for _, e := range h.load()[hookHit] {
+		e.fn.(HitHook)(key, value)
+	}
+}
+
+func (h *hooks) miss(key interface{}) {
+	for _, e := range h.load()[hookMiss] {
+		e.fn.(MissHook)(key)
+	}
+}
+
+func (h *hooks) set(key, value interface{}) {
+	for _, e := range h.load()[hookSet] {
+		e.fn.(SetHook)(key, value)
+	}
+}
+
+func (h *hooks) evict(key, value interface{}) {
+	for _, e := range h.load()[hookEvict] {
+		e.fn.(EvictHook)(key, value)
+	}
+}
+
+func (h *hooks) age(oldAge, newAge float64) {
+	for _, e := range h.load()[hookAge] {
+		e.fn.(AgeHook)(oldAge, newAge)
+	}
+}
+
+// OnHit registers fn to be called after every cache hit.  The returned
+// function unregisters it.
+func (c *Cache) OnHit(fn HitHook) (remove func()) {
+	return c.hooks.add(hookHit, fn)
+}
+
+// OnMiss registers fn to be called after every cache miss.  The returned
+// function unregisters it.
+func (c *Cache) OnMiss(fn MissHook) (remove func()) {
+	return c.hooks.add(hookMiss, fn)
+}
+
+// OnSet registers fn to be called after every entry stored in the cache.  The
+// returned function unregisters it.
+func (c *Cache) OnSet(fn SetHook) (remove func()) {
+	return c.hooks.add(hookSet, fn)
+}
+
+// OnEvict registers fn to be called after every entry leaving the cache.
+// Unlike the eviction callback given to the constructor, hooks run after the
+// cache lock is released.  The returned function unregisters it.
+func (c *Cache) OnEvict(fn EvictHook) (remove func()) {
+	return c.hooks.add(hookEvict, fn)
+}
+
+// OnAgeBump registers fn to be called after every change of the cache age.
+// The returned function unregisters it.
+func (c *Cache) OnAgeBump(fn AgeHook) (remove func()) {
+	return c.hooks.add(hookAge, fn)
+}
@@ -0,0 +1,54 @@
+package lfuda
+
+import (
+	"testing"
+)
+
+func TestHooks(t *testing.T) {
+	l := New(2)
+
+	var hits, misses, sets, evicts int
+	var ages []float64
+	l.OnHit(func(k, v interface{}) { hits++ })
+	l.OnMiss(func(k interface{}) { misses++ })
+	l.OnSet(func(k, v interface{}) { sets++ })
+	removeEvict := l.OnEvict(func(k, v interface{}) {
+		// hooks run outside the lock so calling back into the cache is safe
+		if l.Contains(k) {
+			t.Errorf("evicted key %v should no longer be in the cache", k)
+		}
+		evicts++
+	})
+	l.OnAgeBump(func(oldAge, newAge float64) { ages = append(ages, newAge) })
+
+	l.Set(1, 1)
+	l.Set(2, 2)
+	l.Get(1)
+	l.Get(3)
+	l.Set(3, 3)
+	l.Set("too big", "too big")
+
+	if hits != 1 || misses != 1 {
+		t.Errorf("bad hit/miss counts: %d/%d", hits, misses)
+	}
+	if sets != 3 {
+		t.Errorf("oversized values should not trigger set hooks: %d", sets)
+	}
+	if evicts != 1 {
+		t.Errorf("bad evict count: %d", evicts)
+	}
+	if len(ages) != 1 || ages[0] != 1 {
+		t.Errorf("bad age bumps: %v", ages)
+	}
+
+	removeEvict()
+	l.Remove(1)
+	if evicts != 1 {
+		t.Errorf("removed hook should not have been called")
+	}
+
+	l.Purge()
+	if len(ages) != 2 || ages[1] != 0 {
+		t.Errorf("purge should reset age: %v", ages)
+	}
+}
@@ -0,0 +1,324 @@
+// Package httpcache provides net/http middleware caching responses in a GDSF
+// lfuda cache.  Responses are keyed by method, URL and the request headers
+// named by their Vary header, and cost their size in bytes, so small, popular
+// responses are preferred over large ones.
+package httpcache
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	lfuda "github.com/bparli/lfuda-go"
+)
+
+// DefaultMaxBodySize caps the size of a cached response body.
+const DefaultMaxBodySize = 1 << 20
+
+// Cache caches responses of the handlers it wraps.
+type Cache struct {
+	cache       *lfuda.Cache
+	ttl         time.Duration
+	maxBodySize int
+	request     func(r *http.Request) bool
+	response    func(status int, header http.Header) bool
+}
+
+// Option configures a Cache.
+type Option func(*Cache)
+
+// WithTTL sets how long responses without a max-age are cached, forever by
+// default.
+func WithTTL(ttl time.Duration) Option {
+	return func(c *Cache) {
+		c.ttl = ttl
+	}
+}
+
+// WithMaxBodySize sets the size in bytes of the largest body cached.
+func WithMaxBodySize(n int) Option {
+	return func(c *Cache) {
+		c.maxBodySize = n
+	}
+}
+
+// WithRequestRule replaces the rule deciding whether a request may be served
+// from and stored in the cache.  See CacheableRequest.
+func WithRequestRule(rule func(r *http.Request) bool) Option {
+	return func(c *Cache) {
+		c.request = rule
+	}
+}
+
+// WithResponseRule replaces the rule deciding whether a response may be
+// stored.  See CacheableResponse.
+func WithResponseRule(rule func(status int, header http.Header) bool) Option {
+	return func(c *Cache) {
+		c.response = rule
+	}
+}
+
+// New creates a response cache of the given size in bytes.
+func New(size float64, opts ...Option) *Cache {
+	c := &Cache{
+		cache:       lfuda.NewGDSF(size),
+		maxBodySize: DefaultMaxBodySize,
+		request:     CacheableRequest,
+		response:    CacheableResponse,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Cache returns the underlying cache.
+func (c *Cache) Cache() *lfuda.Cache {
+	return c.cache
+}
+
+// CacheableRequest is the default request rule: GET and HEAD requests
+// without credentials or a no-store directive.
+func CacheableRequest(r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if r.Header.Get("Authorization") != "" {
+		return false
+	}
+	_, noStore := directives(r.Header)["no-store"]
+	return !noStore
+}
+
+// CacheableResponse is the default response rule: 200, 203, 301, 404 and 410
+// responses that set no cookie, are not private, no-store or no-cache and
+// don't vary on everything.  No-cache responses would need revalidating,
+// which the cache doesn't do.
+func CacheableResponse(status int, header http.Header) bool {
+	switch status {
+	case http.StatusOK, http.StatusNonAuthoritativeInfo, http.StatusMovedPermanently,
+		http.StatusNotFound, http.StatusGone:
+	default:
+		return false
+	}
+	if header.Get("Set-Cookie") != "" || header.Get("Vary") == "*" {
+		return false
+	}
+	d := directives(header)
+	_, private := d["private"]
+	_, noStore := d["no-store"]
+	_, noCache := d["no-cache"]
+	return !private && !noStore && !noCache
+}
+
+// Middleware returns a handler serving cached responses of next.
+func (c *Cache) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !c.request(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		base := baseKey(r)
+		// no-cache requests skip the cache but refresh it
+		if _, noCache := directives(r.Header)["no-cache"]; !noCache {
+			if e, ok := c.lookup(base, r); ok {
+				e.serve(w, r)
+				return
+			}
+		}
+
+		rec := &recorder{ResponseWriter: w, max: c.maxBodySize}
+		next.ServeHTTP(rec, r)
+		c.store(base, r, rec)
+	})
+}
+
+// Purge drops every cached response.
+func (c *Cache) Purge() {
+	c.cache.Purge()
+}
+
+// entry is a cached response.
+type entry struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+func (e *entry) serve(w http.ResponseWriter, r *http.Request) {
+	h := w.Header()
+	for k, v := range e.header {
+		h[k] = v
+	}
+	h.Set("X-Cache", "HIT")
+	w.WriteHeader(e.status)
+	if r.Method != http.MethodHead {
+		w.Write(e.body)
+	}
+}
+
+func (e *entry) size() float64 {
+	size := len(e.body)
+	for k, v := range e.header {
+		size += len(k)
+		for _, s := range v {
+			size += len(s)
+		}
+	}
+	return float64(size)
+}
+
+// varyKey caches the request headers a URL's responses vary on.
+type varyKey struct {
+	base string
+}
+
+// responseKey caches a response for a URL and the values of the headers it
+// varies on.
+type responseKey struct {
+	base string
+	vary string
+}
+
+// baseKey identifies a request's method and absolute URL, so virtual hosts
+// and schemes served by the same handler don't share responses.
+func baseKey(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return r.Method + " " + scheme + "://" + r.Host + r.URL.RequestURI()
+}
+
+func (c *Cache) lookup(base string, r *http.Request) (*entry, bool) {
+	var vary []string
+	if v, ok := c.cache.Get(varyKey{base}); ok {
+		vary = v.([]string)
+	}
+	v, ok := c.cache.Get(responseKey{base, varyValues(vary, r)})
+	if !ok {
+		return nil, false
+	}
+	return v.(*entry), true
+}
+
+func (c *Cache) store(base string, r *http.Request, rec *recorder) {
+	if rec.overflow || !c.response(rec.status(), rec.Header()) {
+		return
+	}
+	e := &entry{
+		status: rec.status(),
+		header: rec.Header().Clone(),
+		body:   rec.body.Bytes(),
+	}
+	e.header.Del("X-Cache")
+
+	ttl := c.ttl
+	if maxAge, ok := maxAge(e.header); ok {
+		if maxAge <= 0 {
+			return
+		}
+		ttl = maxAge
+	}
+
+	var vary []string
+	for _, v := range e.header.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				vary = append(vary, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	if len(vary) > 0 {
+		c.cache.SetWithSize(varyKey{base}, vary, float64(len(vary)))
+		c.cache.Expire(varyKey{base}, ttl)
+	} else {
+		c.cache.Remove(varyKey{base})
+	}
+
+	key := responseKey{base, varyValues(vary, r)}
+	c.cache.SetWithSize(key, e, e.size())
+	c.cache.Expire(key, ttl)
+}
+
+func varyValues(vary []string, r *http.Request) string {
+	var b strings.Builder
+	for _, name := range vary {
+		b.WriteString(name)
+		b.WriteByte(':')
+		b.WriteString(strings.Join(r.Header.Values(name), ","))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// directives parses a Cache-Control header.
+func directives(h http.Header) map[string]string {
+	d := make(map[string]string)
+	for _, v := range h.Values("Cache-Control") {
+		for _, part := range strings.Split(v, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+			if name != "" {
+				d[strings.ToLower(name)] = strings.Trim(value, `"`)
+			}
+		}
+	}
+	return d
+}
+
+// maxAge returns the lifetime set by a response's s-maxage or max-age.
+func maxAge(h http.Header) (time.Duration, bool) {
+	d := directives(h)
+	for _, name := range []string{"s-maxage", "max-age"} {
+		if v, ok := d[name]; ok {
+			seconds, err := strconv.Atoi(v)
+			if err != nil {
+				return 0, true
+			}
+			return time.Duration(seconds) * time.Second, true
+		}
+	}
+	return 0, false
+}
+
+// recorder passes a response through while keeping a copy of it, up to max
+// bytes of body.
+type recorder struct {
+	http.ResponseWriter
+	code     int
+	body     bytes.Buffer
+	max      int
+	overflow bool
+}
+
+func (r *recorder) status() int {
+	if r.code == 0 {
+		return http.StatusOK
+	}
+	return r.code
+}
+
+func (r *recorder) WriteHeader(code int) {
+	if r.code == 0 {
+		r.code = code
+		r.Header().Set("X-Cache", "MISS")
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *recorder) Write(p []byte) (int, error) {
+	if r.code == 0 {
+		r.WriteHeader(http.StatusOK)
+	}
+	if !r.overflow {
+		if r.body.Len()+len(p) > r.max {
+			r.overflow = true
+			r.body = bytes.Buffer{}
+		} else {
+			r.body.Write(p)
+		}
+	}
+	return r.ResponseWriter.Write(p)
+}
@@ -0,0 +1,166 @@
+package httpcache
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// countingHandler answers with the request path and a counter of the
+// requests it served.
+type countingHandler struct {
+	served int
+	header http.Header
+	status int
+}
+
+func (h *countingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.served++
+	for k, v := range h.header {
+		w.Header()[k] = v
+	}
+	if h.status != 0 {
+		w.WriteHeader(h.status)
+	}
+	fmt.Fprintf(w, "%s %s %d", r.URL.Path, r.Header.Get("Accept-Language"), h.served)
+}
+
+func get(t *testing.T, h http.Handler, path string, header ...string) *httptest.ResponseRecorder {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		r.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestMiddleware(t *testing.T) {
+	origin := &countingHandler{}
+	h := New(1 << 20).Middleware(origin)
+
+	first := get(t, h, "/a")
+	second := get(t, h, "/a")
+	if origin.served != 1 || second.Body.String() != first.Body.String() {
+		t.Errorf("second request should be served from the cache: %q", second.Body.String())
+	}
+	if first.Header().Get("X-Cache") != "MISS" || second.Header().Get("X-Cache") != "HIT" {
+		t.Errorf("bad X-Cache headers")
+	}
+
+	get(t, h, "/b")
+	if origin.served != 2 {
+		t.Errorf("other URLs should not be served from the cache")
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/a", strings.NewReader("x"))
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	if origin.served != 3 {
+		t.Errorf("POST requests should not be cached")
+	}
+	get(t, h, "/a", "Authorization", "Bearer x")
+	if origin.served != 4 {
+		t.Errorf("requests with credentials should not be cached")
+	}
+
+	if w := get(t, h, "/a", "Cache-Control", "no-cache"); origin.served != 5 || w.Header().Get("X-Cache") != "MISS" {
+		t.Errorf("no-cache requests should skip the cache")
+	}
+	if w := get(t, h, "/a"); origin.served != 5 || w.Body.String() != "/a  5" {
+		t.Errorf("no-cache requests should refresh the cache: %q", w.Body.String())
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "http://other.example/a", nil)
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	if origin.served != 6 {
+		t.Errorf("other hosts should not share responses")
+	}
+	r = httptest.NewRequest(http.MethodGet, "https://example.com/a", nil)
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	if origin.served != 7 {
+		t.Errorf("other schemes should not share responses")
+	}
+}
+
+func TestMiddlewareVary(t *testing.T) {
+	origin := &countingHandler{header: http.Header{"Vary": {"Accept-Language"}}}
+	h := New(1 << 20).Middleware(origin)
+
+	en := get(t, h, "/a", "Accept-Language", "en")
+	fr := get(t, h, "/a", "Accept-Language", "fr")
+	if origin.served != 2 || en.Body.String() == fr.Body.String() {
+		t.Fatalf("responses should vary on Accept-Language")
+	}
+	if again := get(t, h, "/a", "Accept-Language", "en"); again.Body.String() != en.Body.String() || origin.served != 2 {
+		t.Errorf("each variant should be cached: %q", again.Body.String())
+	}
+}
+
+func TestMiddlewareRules(t *testing.T) {
+	for _, header := range []http.Header{
+		{"Cache-Control": {"no-store"}},
+		{"Cache-Control": {"no-cache"}},
+		{"Cache-Control": {"private, max-age=60"}},
+		{"Cache-Control": {"max-age=0"}},
+		{"Set-Cookie": {"session=1"}},
+	} {
+		origin := &countingHandler{header: header}
+		h := New(1 << 20).Middleware(origin)
+		get(t, h, "/a")
+		get(t, h, "/a")
+		if origin.served != 2 {
+			t.Errorf("response with %v should not be cached", header)
+		}
+	}
+
+	origin := &countingHandler{status: http.StatusInternalServerError}
+	h := New(1 << 20).Middleware(origin)
+	get(t, h, "/a")
+	get(t, h, "/a")
+	if origin.served != 2 {
+		t.Errorf("errors should not be cached")
+	}
+
+	origin = &countingHandler{}
+	h = New(1<<20, WithMaxBodySize(4)).Middleware(origin)
+	get(t, h, "/long")
+	if w := get(t, h, "/long"); origin.served != 2 || w.Body.String() != "/long  2" {
+		t.Errorf("bodies over the limit should be passed through without caching: %q", w.Body.String())
+	}
+}
+
+func TestMiddlewareMaxAge(t *testing.T) {
+	origin := &countingHandler{header: http.Header{"Cache-Control": {"public, max-age=1"}}}
+	c := New(1 << 20)
+	h := c.Middleware(origin)
+	get(t, h, "/a")
+	get(t, h, "/a")
+	if origin.served != 1 {
+		t.Errorf("response should be cached")
+	}
+	time.Sleep(1100 * time.Millisecond)
+	get(t, h, "/a")
+	if origin.served != 2 {
+		t.Errorf("response should expire after max-age")
+	}
+}
+
+func TestMiddlewareHead(t *testing.T) {
+	origin := &countingHandler{}
+	h := New(1 << 20).Middleware(origin)
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/a", nil))
+		if body, _ := io.ReadAll(w.Body); i == 1 && len(body) != 0 {
+			t.Errorf("HEAD responses should have no body")
+		}
+	}
+	if origin.served != 1 {
+		t.Errorf("HEAD requests should be cached")
+	}
+}
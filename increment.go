@@ -0,0 +1,63 @@
+package lfuda
+
+import "errors"
+
+// ErrNotNumeric is returned when incrementing a key whose value is not a
+// number of the incremented type.
+var ErrNotNumeric = errors.New("lfuda: value is not numeric")
+
+// Increment atomically adds delta to an int64 or int value and returns the
+// result, setting the key to delta if it is absent.  The entry's hits are
+// updated as by a Get followed by a Set, and its TTL is kept.
+func (c *Cache) Increment(key interface{}, delta int64) (int64, error) {
+	if c.badKey(key) {
+		return 0, ErrNotNumeric
+	}
+	c.lockOp()
+	defer c.unlockOp()
+
+	old, ok := present(c.decodeHit(c.lfuda.Get(key)))
+	if !ok {
+		c.set(key, delta)
+		return delta, nil
+	}
+	var n int64
+	switch v := old.(type) {
+	case int64:
+		n = v + delta
+		c.replace(key, n)
+	case int:
+		n = int64(v) + delta
+		c.replace(key, int(n))
+	default:
+		return 0, ErrNotNumeric
+	}
+	return n, nil
+}
+
+// Decrement atomically subtracts delta from an int64 or int value.  See
+// Increment.
+func (c *Cache) Decrement(key interface{}, delta int64) (int64, error) {
+	return c.Increment(key, -delta)
+}
+
+// IncrementFloat is Increment for float64 values.
+func (c *Cache) IncrementFloat(key interface{}, delta float64) (float64, error) {
+	if c.badKey(key) {
+		return 0, ErrNotNumeric
+	}
+	c.lockOp()
+	defer c.unlockOp()
+
+	old, ok := present(c.decodeHit(c.lfuda.Get(key)))
+	if !ok {
+		c.set(key, delta)
+		return delta, nil
+	}
+	v, isFloat := old.(float64)
+	if !isFloat {
+		return 0, ErrNotNumeric
+	}
+	c.replace(key, v+delta)
+	return v + delta, nil
+}
@@ -0,0 +1,71 @@
+package lfuda
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestIncrement(t *testing.T) {
+	l := New(100)
+	if n, err := l.Increment("a", 5); err != nil || n != 5 {
+		t.Errorf("absent key should be set to delta: %d, %v", n, err)
+	}
+	if n, err := l.Decrement("a", 2); err != nil || n != 3 {
+		t.Errorf("bad decrement: %d, %v", n, err)
+	}
+	if v, _ := l.Peek("a"); v != int64(3) {
+		t.Errorf("value should be stored as int64: %#v", v)
+	}
+
+	l.Set("b", 1)
+	if n, err := l.Increment("b", 1); err != nil || n != 2 {
+		t.Errorf("int values should be incremented: %d, %v", n, err)
+	}
+	if v, _ := l.Peek("b"); v != 2 {
+		t.Errorf("int values should stay int: %#v", v)
+	}
+
+	l.Set("c", "x")
+	if _, err := l.Increment("c", 1); !errors.Is(err, ErrNotNumeric) {
+		t.Errorf("expected ErrNotNumeric, got %v", err)
+	}
+	if _, err := l.IncrementFloat("a", 1); !errors.Is(err, ErrNotNumeric) {
+		t.Errorf("expected ErrNotNumeric, got %v", err)
+	}
+	if f, err := l.IncrementFloat("d", 0.5); err != nil || f != 0.5 {
+		t.Errorf("bad float increment: %v, %v", f, err)
+	}
+	if f, _ := l.IncrementFloat("d", 0.25); f != 0.75 {
+		t.Errorf("bad float increment: %v", f)
+	}
+}
+
+func TestIncrementKeepsTTL(t *testing.T) {
+	l := New(100)
+	l.SetWithTTL("a", int64(1), 20*time.Millisecond)
+	l.Increment("a", 1)
+	time.Sleep(30 * time.Millisecond)
+	if l.Contains("a") {
+		t.Errorf("increment should keep the entry's TTL")
+	}
+}
+
+func TestIncrementConcurrent(t *testing.T) {
+	l := New(100)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 100; n++ {
+				l.Increment("counter", 1)
+			}
+		}()
+	}
+	wg.Wait()
+	if v, _ := l.Peek("counter"); v != int64(800) {
+		t.Errorf("increments were lost: %v", v)
+	}
+}
@@ -0,0 +1,28 @@
+package lfuda
+
+// WithAccessTimes records the time of every access to an entry, returned by
+// Inspect as EntryInfo.Accessed, e.g. to report stale entries.  Creation
+// times are always recorded.
+func WithAccessTimes() Option {
+	return func(o *options) {
+		o.accessTimes = true
+	}
+}
+
+// Inspect returns the key's metadata, including when it was added and, with
+// WithAccessTimes, last accessed, without counting an access.  Returns false
+// if the key is not cached.
+func (c *Cache) Inspect(key interface{}) (info EntryInfo, ok bool) {
+	if c.badKey(key) {
+		return
+	}
+	c.flushReads()
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if value, _ := c.lfuda.Peek(key); value != nil {
+		if _, isNeg := value.(negativeEntry); isNeg {
+			return EntryInfo{}, false
+		}
+	}
+	return c.lfuda.Info(key)
+}
@@ -0,0 +1,35 @@
+package lfuda
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInspect(t *testing.T) {
+	l := NewWithOptions(100, WithAccessTimes())
+	before := time.Now().UnixNano()
+	l.Set(1, 1)
+	info, ok := l.Inspect(1)
+	if !ok || info.Created < before || info.Accessed < info.Created {
+		t.Fatalf("bad timestamps: %+v", info)
+	}
+	time.Sleep(time.Millisecond)
+	l.Get(1)
+	again, _ := l.Inspect(1)
+	if again.Accessed <= info.Accessed || again.Created != info.Created || again.Hits != info.Hits+1 {
+		t.Errorf("access not recorded: %+v after %+v", again, info)
+	}
+	if idle, ok := again.Idle(); !ok || idle < 0 || again.Age() < idle {
+		t.Errorf("bad age %v or idle time %v", again.Age(), idle)
+	}
+	if _, ok := l.Inspect(2); ok {
+		t.Errorf("inspected a missing key")
+	}
+
+	plain := New(100)
+	plain.Set(1, 1)
+	plain.Get(1)
+	if info, _ := plain.Inspect(1); info.Created == 0 || info.Accessed != 0 {
+		t.Errorf("access times recorded without the option: %+v", info)
+	}
+}
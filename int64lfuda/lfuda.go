@@ -0,0 +1,51 @@
+// Package int64lfuda provides a thread-safe lfuda cache specialized for int64
+// keys, as used by ID-keyed databases.  The keys are stored unboxed, and
+// setting string, []byte or numeric values doesn't allocate once the cache is
+// warm.
+package int64lfuda
+
+import (
+	"github.com/bparli/lfuda-go/internal/typed"
+)
+
+// Cache is a thread-safe fixed size lfuda cache with int64 keys.
+type Cache[V any] struct {
+	*typed.Locked[int64, V]
+}
+
+// New creates an lfuda of the given size.
+func New[V any](size float64) *Cache[V] {
+	return newWithEvict[V](size, typed.LFUDA, nil)
+}
+
+// NewGDSF creates an lfuda of the given size and the GDSF cache policy.
+func NewGDSF[V any](size float64) *Cache[V] {
+	return newWithEvict[V](size, typed.GDSF, nil)
+}
+
+// NewLFU creates an lfuda of the given size and the LFU cache policy.
+func NewLFU[V any](size float64) *Cache[V] {
+	return newWithEvict[V](size, typed.LFU, nil)
+}
+
+// NewWithEvict constructs a fixed size LFUDA cache with the given eviction
+// callback.
+func NewWithEvict[V any](size float64, onEvicted func(key int64, value V)) *Cache[V] {
+	return newWithEvict(size, typed.LFUDA, onEvicted)
+}
+
+// NewGDSFWithEvict constructs a fixed size GDSF cache with the given eviction
+// callback.
+func NewGDSFWithEvict[V any](size float64, onEvicted func(key int64, value V)) *Cache[V] {
+	return newWithEvict(size, typed.GDSF, onEvicted)
+}
+
+// NewLFUWithEvict constructs a fixed size LFU cache with the given eviction
+// callback.
+func NewLFUWithEvict[V any](size float64, onEvicted func(key int64, value V)) *Cache[V] {
+	return newWithEvict(size, typed.LFU, onEvicted)
+}
+
+func newWithEvict[V any](size float64, policy typed.Policy, onEvicted func(key int64, value V)) *Cache[V] {
+	return &Cache[V]{typed.NewLocked[int64, V](size, policy, onEvicted)}
+}
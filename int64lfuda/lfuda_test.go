@@ -0,0 +1,87 @@
+package int64lfuda
+
+import (
+	"math/rand"
+	"testing"
+
+	lfuda "github.com/bparli/lfuda-go"
+)
+
+func TestCache(t *testing.T) {
+	evicted := 0
+	l := NewWithEvict(666, func(k int64, v []byte) {
+		if int64(len(v)) != 3 {
+			t.Errorf("bad evicted value: %v", v)
+		}
+		evicted++
+	})
+
+	for i := int64(100); i < 1000; i++ {
+		l.Set(i, []byte("abc"))
+	}
+	if l.Len() != 222 || len(l.Keys()) != 222 || l.Size() != 666 {
+		t.Errorf("bad len: %d", l.Len())
+	}
+	if evicted != 900-222 {
+		t.Errorf("bad evict count: %d", evicted)
+	}
+
+	if _, ok := l.Get(999); !ok {
+		t.Errorf("999 should be in the cache")
+	}
+	if l.Keys()[0] != 999 {
+		t.Errorf("999 should be the most valuable key")
+	}
+
+	if ok, _ := l.ContainsOrSet(999, nil); !ok {
+		t.Errorf("999 should be contained")
+	}
+	if prev, ok, _ := l.PeekOrSet(5, []byte("xyz")); ok || prev != nil {
+		t.Errorf("5 should not be contained")
+	}
+	if v, ok := l.Peek(5); !ok || string(v) != "xyz" {
+		t.Errorf("5 should have been set")
+	}
+
+	l.Remove(5)
+	if l.Contains(5) {
+		t.Errorf("5 should have been removed")
+	}
+
+	l.Purge()
+	if l.Len() != 0 || l.Age() != 0 {
+		t.Errorf("cache should be empty")
+	}
+}
+
+func trace(n int) []int64 {
+	keys := make([]int64, n)
+	for i := range keys {
+		keys[i] = rand.Int63() % 32768
+	}
+	return keys
+}
+
+func BenchmarkInt64(b *testing.B) {
+	l := New[int64](8192)
+	keys := trace(b.N)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for _, k := range keys {
+		l.Set(k, k)
+		l.Get(k ^ 1)
+	}
+}
+
+func BenchmarkGeneric(b *testing.B) {
+	l := lfuda.New(8192)
+	keys := trace(b.N)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for _, k := range keys {
+		l.Set(k, k)
+		l.Get(k ^ 1)
+	}
+}
@@ -0,0 +1,99 @@
+// Package ring implements a consistent hash ring mapping keys to nodes, so
+// that adding or removing a node only moves the keys of its neighbours.
+package ring
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+)
+
+// DefaultReplicas is the number of points each node gets on the ring when
+// the given replicas is not positive.
+const DefaultReplicas = 50
+
+// Ring maps keys to nodes.  It is not safe for concurrent use while nodes
+// are added.
+type Ring struct {
+	replicas int
+	points   []uint64
+	nodes    map[uint64]string
+}
+
+// New creates an empty ring placing each node at replicas points.
+func New(replicas int) *Ring {
+	if replicas <= 0 {
+		replicas = DefaultReplicas
+	}
+	return &Ring{replicas: replicas, nodes: make(map[uint64]string)}
+}
+
+// Add adds nodes to the ring.
+func (r *Ring) Add(nodes ...string) {
+	for _, node := range nodes {
+		for i := 0; i < r.replicas; i++ {
+			h := hash(strconv.Itoa(i) + node)
+			if _, ok := r.nodes[h]; !ok {
+				r.points = append(r.points, h)
+			}
+			r.nodes[h] = node
+		}
+	}
+	sort.Slice(r.points, func(i, j int) bool { return r.points[i] < r.points[j] })
+}
+
+// Len returns the number of points on the ring.
+func (r *Ring) Len() int {
+	return len(r.points)
+}
+
+// Get returns the node owning key, or "" if the ring is empty.
+func (r *Ring) Get(key string) string {
+	if len(r.points) == 0 {
+		return ""
+	}
+	return r.nodes[r.points[r.search(key)]]
+}
+
+// GetN returns up to n distinct nodes for key, the owner first and then the
+// following nodes clockwise, for replication.
+func (r *Ring) GetN(key string, n int) []string {
+	if len(r.points) == 0 || n <= 0 {
+		return nil
+	}
+	nodes := make([]string, 0, n)
+	seen := make(map[string]bool, n)
+	start := r.search(key)
+	for i := 0; i < len(r.points) && len(nodes) < n; i++ {
+		node := r.nodes[r.points[(start+i)%len(r.points)]]
+		if !seen[node] {
+			seen[node] = true
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// search returns the index of the first point at or after key's hash.
+func (r *Ring) search(key string) int {
+	h := hash(key)
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= h })
+	if i == len(r.points) {
+		i = 0
+	}
+	return i
+}
+
+// hash is FNV-1a followed by the splitmix64 finalizer, since FNV alone
+// leaves the high bits of similar keys close together on the ring.
+func hash(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,53 @@
+package ring
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRing(t *testing.T) {
+	r := New(0)
+	if r.Get("a") != "" {
+		t.Error("an empty ring should return no node")
+	}
+	r.Add("n1", "n2", "n3")
+	if r.Len() != 3*DefaultReplicas {
+		t.Errorf("expected %d points: %d", 3*DefaultReplicas, r.Len())
+	}
+
+	owners := make(map[string]string)
+	counts := make(map[string]int)
+	for i := 0; i < 3000; i++ {
+		key := fmt.Sprintf("key%d", i)
+		owners[key] = r.Get(key)
+		counts[owners[key]]++
+	}
+	for node, n := range counts {
+		if n < 500 {
+			t.Errorf("%s owns too few keys: %d", node, n)
+		}
+	}
+
+	// adding a node only moves keys to it
+	r.Add("n4")
+	for key, owner := range owners {
+		if got := r.Get(key); got != owner && got != "n4" {
+			t.Errorf("%s moved from %s to %s", key, owner, got)
+		}
+	}
+}
+
+func TestGetN(t *testing.T) {
+	r := New(10)
+	r.Add("n1", "n2", "n3")
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprint(i)
+		nodes := r.GetN(key, 2)
+		if len(nodes) != 2 || nodes[0] != r.Get(key) || nodes[0] == nodes[1] {
+			t.Fatalf("bad nodes for %s: %v", key, nodes)
+		}
+	}
+	if nodes := r.GetN("a", 5); len(nodes) != 3 {
+		t.Errorf("expected every node: %v", nodes)
+	}
+}
@@ -0,0 +1,377 @@
+// Package typed implements the simplelfuda algorithm for concrete key and
+// value types.  Frequency nodes and their entries are intrusive linked lists
+// and freed items are reused, so the hot path neither boxes keys and values in
+// interface{} nor allocates once the cache is warm, unless values are sized by
+// their default format.
+package typed
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Policy selects how an item's priority is computed.
+type Policy int
+
+const (
+	// LFUDA is LFU with dynamic aging: Ki = Fi + L
+	LFUDA Policy = iota
+	// GDSF is GreedyDual-Size with frequency: Ki = Fi / Si + L
+	GDSF
+	// LFU ignores the cache age: Ki = Fi
+	LFU
+)
+
+// PriorityFunc computes an item's priority from its hits, its size and the
+// cache age.  The items with the lowest priority are evicted first.
+type PriorityFunc func(hits, size, age float64) float64
+
+type item[K comparable, V any] struct {
+	key      K
+	value    V
+	size     float64
+	hits     float64
+	priority float64
+	node     *node[K, V]
+	// neighbours within node, or the next free item
+	prev, next *item[K, V]
+}
+
+type node[K comparable, V any] struct {
+	priority   float64
+	head, tail *item[K, V]
+	// neighbours in the frequency list, or the next free node
+	prev, next *node[K, V]
+}
+
+// Cache is a non-threadsafe fixed size LFU with Dynamic Aging cache.
+type Cache[K comparable, V any] struct {
+	size     float64
+	currSize float64
+	items    map[K]*item[K, V]
+	// least and most valuable frequency nodes
+	front, back *node[K, V]
+	onEvict     func(key K, value V)
+	sizeOf      func(value V) float64
+	age         float64
+	policy      Policy
+	priorityFn  PriorityFunc
+
+	freeItems *item[K, V]
+	freeNodes *node[K, V]
+}
+
+// New constructs a cache of the given size in bytes.  sizeOf returns the size
+// of a value and defaults to the length of []byte and string values, the
+// width of booleans and numbers and the length of the default format of any
+// other value, which allocates.  onEvict may be nil.
+func New[K comparable, V any](size float64, policy Policy, sizeOf func(value V) float64, onEvict func(key K, value V)) *Cache[K, V] {
+	if sizeOf == nil {
+		sizeOf = defaultSizer[V]()
+	}
+	return &Cache[K, V]{
+		size:    size,
+		items:   make(map[K]*item[K, V]),
+		onEvict: onEvict,
+		sizeOf:  sizeOf,
+		policy:  policy,
+	}
+}
+
+// defaultSizer returns the default sizeOf for values of type V, sizing
+// fixed-width values by their type alone so that they aren't boxed.
+func defaultSizer[V any]() func(value V) float64 {
+	t := reflect.TypeOf((*V)(nil)).Elem()
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		size := float64(t.Size())
+		return func(V) float64 { return size }
+	}
+	return defaultSize[V]
+}
+
+func defaultSize[V any](value V) float64 {
+	switch v := any(value).(type) {
+	case []byte:
+		return float64(len(v))
+	case string:
+		return float64(len(v))
+	}
+	return float64(len(fmt.Sprintf("%v", value)))
+}
+
+// Get looks up a key's value from the cache.
+func (c *Cache[K, V]) Get(key K) (value V, ok bool) {
+	if e, ok := c.items[key]; ok {
+		e.hits++
+		c.move(e)
+		return e.value, true
+	}
+	return value, false
+}
+
+// Peek looks up a key's value from the cache without incrementing its hits.
+func (c *Cache[K, V]) Peek(key K) (value V, ok bool) {
+	if e, ok := c.items[key]; ok {
+		return e.value, true
+	}
+	return value, false
+}
+
+// Contains checks if a key is in the cache without incrementing its hits.
+func (c *Cache[K, V]) Contains(key K) bool {
+	_, ok := c.items[key]
+	return ok
+}
+
+// Set adds a value to the cache.  Returns true if an eviction occurred.
+func (c *Cache[K, V]) Set(key K, value V) bool {
+	return c.SetWithSize(key, value, c.sizeOf(value))
+}
+
+// SetWithSize adds a value to the cache, accounting for it as size bytes.
+// Returns true if an eviction occurred.
+func (c *Cache[K, V]) SetWithSize(key K, value V, size float64) bool {
+	evicted := false
+	if e, ok := c.items[key]; ok {
+		if c.size < size {
+			// the new value won't fit so drop the stale one
+			c.Remove(key)
+			return false
+		}
+		e.value = value
+		c.currSize += size - e.size
+		e.size = size
+		e.hits++
+		c.move(e)
+		for c.currSize > c.size && c.evict() {
+			evicted = true
+		}
+		return evicted
+	}
+
+	if c.size < size {
+		return false
+	}
+	for c.currSize+size > c.size && c.evict() {
+		evicted = true
+	}
+
+	e := c.newItem()
+	e.key = key
+	e.value = value
+	e.size = size
+	e.hits = 1
+	c.items[key] = e
+	c.currSize += size
+	c.move(e)
+	return evicted
+}
+
+// Remove removes the provided key from the cache, returning if the key was
+// contained.
+func (c *Cache[K, V]) Remove(key K) bool {
+	e, ok := c.items[key]
+	if !ok {
+		return false
+	}
+	if c.onEvict != nil {
+		c.onEvict(e.key, e.value)
+	}
+	delete(c.items, key)
+	c.unlink(e)
+	c.currSize -= e.size
+	c.freeItem(e)
+	return true
+}
+
+// Keys returns the keys in the cache, from most to least valuable.
+func (c *Cache[K, V]) Keys() []K {
+	keys := make([]K, 0, len(c.items))
+	for n := c.back; n != nil; n = n.prev {
+		for e := n.head; e != nil; e = e.next {
+			keys = append(keys, e.key)
+		}
+	}
+	return keys
+}
+
+// Len returns the number of items in the cache.
+func (c *Cache[K, V]) Len() int {
+	return len(c.items)
+}
+
+// Size returns the current size of the cache in bytes.
+func (c *Cache[K, V]) Size() float64 {
+	return c.currSize
+}
+
+// Age returns the cache age factor.
+func (c *Cache[K, V]) Age() float64 {
+	return c.age
+}
+
+// Purge completely clears the cache.
+func (c *Cache[K, V]) Purge() {
+	for k, e := range c.items {
+		if c.onEvict != nil {
+			c.onEvict(k, e.value)
+		}
+	}
+	c.items = make(map[K]*item[K, V])
+	c.front, c.back = nil, nil
+	c.freeItems, c.freeNodes = nil, nil
+	c.currSize = 0
+	c.age = 0
+}
+
+// evict removes the least valuable item, the oldest of the front node.
+func (c *Cache[K, V]) evict() bool {
+	if c.front == nil {
+		return false
+	}
+	e := c.front.head
+	if c.age < e.priority {
+		c.age = e.priority
+	}
+	return c.Remove(e.key)
+}
+
+// SetPriorityFunc replaces the policy with fn.  It must be called while the
+// cache is empty.
+func (c *Cache[K, V]) SetPriorityFunc(fn PriorityFunc) {
+	c.priorityFn = fn
+}
+
+func (c *Cache[K, V]) priority(e *item[K, V]) float64 {
+	if c.priorityFn != nil {
+		return c.priorityFn(e.hits, e.size, c.age)
+	}
+	switch c.policy {
+	case GDSF:
+		return e.hits/e.size + c.age
+	case LFU:
+		return e.hits
+	}
+	return e.hits + c.age
+}
+
+// move recomputes the item's priority and moves it to the matching frequency
+// node, searching from its old position.
+func (c *Cache[K, V]) move(e *item[K, V]) {
+	old := e.node
+	p := c.priority(e)
+	e.priority = p
+	if old != nil && old.priority == p {
+		return
+	}
+
+	// find the last node with a priority <= p
+	var prev *node[K, V]
+	if old == nil || p > old.priority {
+		prev = old
+		next := c.front
+		if prev != nil {
+			next = prev.next
+		}
+		for next != nil && next.priority <= p {
+			prev = next
+			next = next.next
+		}
+	} else {
+		prev = old.prev
+		for prev != nil && prev.priority > p {
+			prev = prev.prev
+		}
+	}
+
+	target := prev
+	if target == nil || target.priority != p {
+		target = c.newNode(p, prev)
+	}
+	if old != nil {
+		c.unlink(e)
+	}
+
+	// append to the target node
+	e.node = target
+	e.prev, e.next = target.tail, nil
+	if target.tail != nil {
+		target.tail.next = e
+	} else {
+		target.head = e
+	}
+	target.tail = e
+}
+
+// unlink removes the item from its node, dropping the node once empty.
+func (c *Cache[K, V]) unlink(e *item[K, V]) {
+	n := e.node
+	if e.prev != nil {
+		e.prev.next = e.next
+	} else {
+		n.head = e.next
+	}
+	if e.next != nil {
+		e.next.prev = e.prev
+	} else {
+		n.tail = e.prev
+	}
+	e.node, e.prev, e.next = nil, nil, nil
+
+	if n.head == nil {
+		if n.prev != nil {
+			n.prev.next = n.next
+		} else {
+			c.front = n.next
+		}
+		if n.next != nil {
+			n.next.prev = n.prev
+		} else {
+			c.back = n.prev
+		}
+		*n = node[K, V]{next: c.freeNodes}
+		c.freeNodes = n
+	}
+}
+
+// newNode inserts an empty node after prev, or at the front if prev is nil.
+func (c *Cache[K, V]) newNode(priority float64, prev *node[K, V]) *node[K, V] {
+	n := c.freeNodes
+	if n != nil {
+		c.freeNodes = n.next
+	} else {
+		n = new(node[K, V])
+	}
+	n.priority = priority
+	n.prev = prev
+	if prev != nil {
+		n.next = prev.next
+		prev.next = n
+	} else {
+		n.next = c.front
+		c.front = n
+	}
+	if n.next != nil {
+		n.next.prev = n
+	} else {
+		c.back = n
+	}
+	return n
+}
+
+func (c *Cache[K, V]) newItem() *item[K, V] {
+	if e := c.freeItems; e != nil {
+		c.freeItems = e.next
+		e.next = nil
+		return e
+	}
+	return new(item[K, V])
+}
+
+func (c *Cache[K, V]) freeItem(e *item[K, V]) {
+	*e = item[K, V]{next: c.freeItems}
+	c.freeItems = e
+}
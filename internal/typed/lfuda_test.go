@@ -0,0 +1,118 @@
+package typed
+
+import (
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	evicted := 0
+	c := New[string, string](3, LFUDA, nil, func(k, v string) { evicted++ })
+	c.Set("a", "a")
+	c.Set("b", "b")
+	c.Set("c", "c")
+
+	for i := 0; i < 3; i++ {
+		c.Get("a")
+	}
+	c.Get("b")
+
+	if keys := c.Keys(); len(keys) != 3 || keys[0] != "a" || keys[1] != "b" || keys[2] != "c" {
+		t.Errorf("keys should be ordered by priority: %v", keys)
+	}
+
+	if !c.Set("d", "d") || c.Contains("c") || evicted != 1 {
+		t.Errorf("c should have been evicted")
+	}
+	if c.Age() != 1 {
+		t.Errorf("cache should have aged: %f", c.Age())
+	}
+
+	if c.Set("e", "too big") || c.Contains("e") {
+		t.Errorf("oversized value should not be set")
+	}
+
+	if !c.Remove("a") || c.Len() != 2 || c.Size() != 2 {
+		t.Errorf("a should have been removed")
+	}
+
+	c.Purge()
+	if c.Len() != 0 || c.Size() != 0 || c.Age() != 0 || evicted != 4 {
+		t.Errorf("cache should be empty")
+	}
+}
+
+func TestEvictOrder(t *testing.T) {
+	c := New[int, int](4, LFU, func(int) float64 { return 1 }, nil)
+	for i := 0; i < 4; i++ {
+		c.Set(i, i)
+	}
+
+	// items sharing a priority are evicted oldest first
+	for i := 4; i < 8; i++ {
+		c.Set(i, i)
+		if c.Contains(i - 4) {
+			t.Errorf("%d should have been evicted", i-4)
+		}
+	}
+}
+
+func TestGDSF(t *testing.T) {
+	c := New[string, string](10, GDSF, nil, nil)
+	c.Set("a", "aaaaaaaa")
+	c.Set("b", "b")
+	for i := 0; i < 3; i++ {
+		c.Get("a")
+	}
+	c.Set("c", "cc")
+
+	if c.Contains("a") {
+		t.Errorf("large value should have been evicted")
+	}
+}
+
+func TestSetWithSize(t *testing.T) {
+	c := New[string, int](10, LFUDA, nil, nil)
+	c.SetWithSize("a", 1, 6)
+	c.SetWithSize("b", 2, 4)
+	c.Get("a")
+
+	if !c.SetWithSize("a", 1, 8) || c.Contains("b") || c.Size() != 8 {
+		t.Errorf("growing a should have evicted b: %f", c.Size())
+	}
+	c.SetWithSize("a", 1, 20)
+	if c.Contains("a") || c.Size() != 0 {
+		t.Errorf("stale value should have been dropped")
+	}
+}
+
+func TestPriorityFunc(t *testing.T) {
+	// evict the largest item first
+	c := New[string, string](10, LFUDA, nil, nil)
+	c.SetPriorityFunc(func(hits, size, age float64) float64 {
+		return -size
+	})
+	c.Set("big", "aaaa")
+	c.Set("small", "a")
+	c.Get("big")
+	c.Set("other", "aaaaaa")
+	if c.Contains("big") || !c.Contains("small") {
+		t.Errorf("priority func should have evicted the largest item: %v", c.Keys())
+	}
+}
+
+func TestDefaultSizeAllocs(t *testing.T) {
+	c := New[int, int64](800, LFUDA, nil, nil)
+	for i := 0; i < 100; i++ {
+		c.Set(i, int64(i))
+	}
+	if c.Size() != 800 {
+		t.Errorf("int64 values should be sized by their width: %f", c.Size())
+	}
+	i := 0
+	if allocs := testing.AllocsPerRun(100, func() {
+		i++
+		c.Set(i, int64(i)<<20)
+	}); allocs != 0 {
+		t.Errorf("setting a number should not allocate: %f", allocs)
+	}
+}
@@ -0,0 +1,135 @@
+package typed
+
+import "sync"
+
+// Locked is a thread-safe Cache, which the key specialized packages
+// instantiate.
+type Locked[K comparable, V any] struct {
+	lfuda *Cache[K, V]
+	lock  sync.RWMutex
+}
+
+// NewLocked constructs a thread-safe cache of the given size in bytes, sizing
+// values as New does.  onEvict may be nil.
+func NewLocked[K comparable, V any](size float64, policy Policy, onEvict func(key K, value V)) *Locked[K, V] {
+	return &Locked[K, V]{
+		lfuda: New[K, V](size, policy, nil, onEvict),
+	}
+}
+
+// Purge is used to completely clear the cache.
+func (c *Locked[K, V]) Purge() {
+	c.lock.Lock()
+	c.lfuda.Purge()
+	c.lock.Unlock()
+}
+
+// Set adds a value to the cache. Returns true if an eviction occurred.
+func (c *Locked[K, V]) Set(key K, value V) (ok bool) {
+	c.lock.Lock()
+	ok = c.lfuda.Set(key, value)
+	c.lock.Unlock()
+	return ok
+}
+
+// SetWithSize adds a value to the cache, accounting for it as size bytes.
+// Returns true if an eviction occurred.
+func (c *Locked[K, V]) SetWithSize(key K, value V, size float64) (ok bool) {
+	c.lock.Lock()
+	ok = c.lfuda.SetWithSize(key, value, size)
+	c.lock.Unlock()
+	return ok
+}
+
+// Get looks up a key's value from the cache.
+func (c *Locked[K, V]) Get(key K) (value V, ok bool) {
+	c.lock.Lock()
+	value, ok = c.lfuda.Get(key)
+	c.lock.Unlock()
+	return value, ok
+}
+
+// Contains checks if a key is in the cache without updating its hits.
+func (c *Locked[K, V]) Contains(key K) bool {
+	c.lock.RLock()
+	containKey := c.lfuda.Contains(key)
+	c.lock.RUnlock()
+	return containKey
+}
+
+// Peek returns the key value without updating its hits.
+func (c *Locked[K, V]) Peek(key K) (value V, ok bool) {
+	c.lock.RLock()
+	value, ok = c.lfuda.Peek(key)
+	c.lock.RUnlock()
+	return value, ok
+}
+
+// ContainsOrSet checks if a key is in the cache without updating its hits,
+// and if not, adds the value.  Returns whether found and whether an eviction
+// occurred.
+func (c *Locked[K, V]) ContainsOrSet(key K, value V) (ok, set bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.lfuda.Contains(key) {
+		return true, false
+	}
+	set = c.lfuda.Set(key, value)
+	return false, set
+}
+
+// PeekOrSet checks if a key is in the cache without updating its hits, and
+// if not, adds the value.  Returns the previous value, whether found and
+// whether an eviction occurred.
+func (c *Locked[K, V]) PeekOrSet(key K, value V) (previous V, ok, set bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	previous, ok = c.lfuda.Peek(key)
+	if ok {
+		return previous, true, false
+	}
+	set = c.lfuda.Set(key, value)
+	return previous, false, set
+}
+
+// Remove removes the provided key from the cache.
+func (c *Locked[K, V]) Remove(key K) (present bool) {
+	c.lock.Lock()
+	present = c.lfuda.Remove(key)
+	c.lock.Unlock()
+	return
+}
+
+// Keys returns a slice of the keys in the cache, from most to least valuable.
+func (c *Locked[K, V]) Keys() []K {
+	c.lock.RLock()
+	keys := c.lfuda.Keys()
+	c.lock.RUnlock()
+	return keys
+}
+
+// Len returns the number of items in the cache.
+func (c *Locked[K, V]) Len() (length int) {
+	c.lock.RLock()
+	length = c.lfuda.Len()
+	c.lock.RUnlock()
+	return length
+}
+
+// Size returns the current size of the cache in bytes.
+func (c *Locked[K, V]) Size() (size float64) {
+	c.lock.RLock()
+	size = c.lfuda.Size()
+	c.lock.RUnlock()
+	return size
+}
+
+// Age returns the cache's current age.
+func (c *Locked[K, V]) Age() (age float64) {
+	c.lock.RLock()
+	age = c.lfuda.Age()
+	c.lock.RUnlock()
+	return age
+}
@@ -0,0 +1,31 @@
+package typed
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestLockedConcurrent(t *testing.T) {
+	c := NewLocked[int, int](8000, LFUDA, nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.Set(i*100+j, j)
+				c.Get(j)
+				c.PeekOrSet(j, j)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if c.Len() != len(c.Keys()) || c.Size() > 8000 {
+		t.Errorf("inconsistent cache: len %d, keys %d, size %f", c.Len(), len(c.Keys()), c.Size())
+	}
+	if prev, ok, _ := c.PeekOrSet(0, 5); !ok || prev != 0 {
+		t.Errorf("PeekOrSet should return the existing value: %d %v", prev, ok)
+	}
+}
@@ -0,0 +1,140 @@
+package lfuda
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// CompositeKey is a comparable key made of several parts, built by Key.
+type CompositeKey struct {
+	// the parts, each tagged with its type and strings prefixed with their
+	// length, so distinct parts never encode alike
+	enc string
+}
+
+// Tags of the encoded parts.
+const (
+	partNil byte = iota
+	partString
+	partBytes
+	partInt
+	partUint
+	partFloat
+	partBool
+	partOther
+)
+
+// Key returns a key made of parts, such as Key("user", id, "avatar"), that
+// can't collide with a key of other parts the way joining strings with a
+// separator can.  Parts other than strings, byte slices, numbers and bools
+// are compared by their type and formatted value.
+func Key(parts ...interface{}) CompositeKey {
+	buf := make([]byte, 0, 16*len(parts))
+	for _, part := range parts {
+		switch p := part.(type) {
+		case nil:
+			buf = append(buf, partNil)
+		case string:
+			buf = appendString(append(buf, partString), p)
+		case []byte:
+			buf = appendString(append(buf, partBytes), string(p))
+		case int:
+			buf = binary.AppendVarint(append(buf, partInt), int64(p))
+		case int8:
+			buf = binary.AppendVarint(append(buf, partInt), int64(p))
+		case int16:
+			buf = binary.AppendVarint(append(buf, partInt), int64(p))
+		case int32:
+			buf = binary.AppendVarint(append(buf, partInt), int64(p))
+		case int64:
+			buf = binary.AppendVarint(append(buf, partInt), p)
+		case uint:
+			buf = binary.AppendUvarint(append(buf, partUint), uint64(p))
+		case uint8:
+			buf = binary.AppendUvarint(append(buf, partUint), uint64(p))
+		case uint16:
+			buf = binary.AppendUvarint(append(buf, partUint), uint64(p))
+		case uint32:
+			buf = binary.AppendUvarint(append(buf, partUint), uint64(p))
+		case uint64:
+			buf = binary.AppendUvarint(append(buf, partUint), p)
+		case float32:
+			buf = binary.BigEndian.AppendUint64(append(buf, partFloat), math.Float64bits(float64(p)))
+		case float64:
+			buf = binary.BigEndian.AppendUint64(append(buf, partFloat), math.Float64bits(p))
+		case bool:
+			b := byte(0)
+			if p {
+				b = 1
+			}
+			buf = append(buf, partBool, b)
+		default:
+			buf = appendString(append(buf, partOther), fmt.Sprintf("%T:%v", p, p))
+		}
+	}
+	return CompositeKey{enc: string(buf)}
+}
+
+func appendString(buf []byte, s string) []byte {
+	return append(binary.AppendUvarint(buf, uint64(len(s))), s...)
+}
+
+// Parts returns the parts of the key.  Integers are returned as int64,
+// unsigned integers as uint64, floats as float64 and parts of other types
+// as their formatted value.
+func (k CompositeKey) Parts() []interface{} {
+	var parts []interface{}
+	s := k.enc
+	for len(s) > 0 {
+		tag := s[0]
+		s = s[1:]
+		switch tag {
+		case partNil:
+			parts = append(parts, nil)
+		case partString, partBytes, partOther:
+			n, w := binary.Uvarint(varintBytes(s))
+			p := s[w : w+int(n)]
+			s = s[w+int(n):]
+			if tag == partBytes {
+				parts = append(parts, []byte(p))
+			} else {
+				parts = append(parts, p)
+			}
+		case partInt:
+			v, w := binary.Varint(varintBytes(s))
+			parts = append(parts, v)
+			s = s[w:]
+		case partUint:
+			v, w := binary.Uvarint(varintBytes(s))
+			parts = append(parts, v)
+			s = s[w:]
+		case partFloat:
+			parts = append(parts, math.Float64frombits(binary.BigEndian.Uint64([]byte(s[:8]))))
+			s = s[8:]
+		case partBool:
+			parts = append(parts, s[0] == 1)
+			s = s[1:]
+		}
+	}
+	return parts
+}
+
+// varintBytes returns the bytes a varint at the start of s may span.
+func varintBytes(s string) []byte {
+	if len(s) > binary.MaxVarintLen64 {
+		s = s[:binary.MaxVarintLen64]
+	}
+	return []byte(s)
+}
+
+// String returns the parts of the key separated by slashes.
+func (k CompositeKey) String() string {
+	parts := k.Parts()
+	strs := make([]string, len(parts))
+	for i, part := range parts {
+		strs[i] = fmt.Sprint(part)
+	}
+	return strings.Join(strs, "/")
+}
@@ -0,0 +1,55 @@
+package lfuda
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKey(t *testing.T) {
+	l := New(10)
+	l.Set(Key("user", 42, "avatar"), 1)
+	if v, ok := l.Get(Key("user", 42, "avatar")); !ok || v != 1 {
+		t.Errorf("bad value: %v", v)
+	}
+
+	// joined with a separator, these would collide
+	distinct := []CompositeKey{
+		Key("a/b", "c"),
+		Key("a", "b/c"),
+		Key("a", "b", "c"),
+		Key(1),
+		Key(uint(1)),
+		Key("1"),
+		Key([]byte("1")),
+		Key(1.0),
+		Key(true),
+		Key(nil),
+		Key(),
+	}
+	seen := make(map[CompositeKey]int)
+	for i, k := range distinct {
+		if j, ok := seen[k]; ok {
+			t.Errorf("keys %d and %d collide", j, i)
+		}
+		seen[k] = i
+	}
+
+	parts := Key("user", -42, uint8(7), []byte("x"), 0.5, false, nil, struct{ A int }{1}).Parts()
+	want := []interface{}{"user", int64(-42), uint64(7), []byte("x"), 0.5, false, nil, "struct { A int }:{1}"}
+	if !reflect.DeepEqual(parts, want) {
+		t.Errorf("bad parts: %#v", parts)
+	}
+	if s := Key("user", 42).String(); s != "user/42" {
+		t.Errorf("bad string: %s", s)
+	}
+	if hashKey(Key("a", 1)) != hashKey(Key("a", 1)) || hashKey(Key("a", 1)) == hashKey(Key("a", 2)) {
+		t.Errorf("bad hashes")
+	}
+}
+
+func BenchmarkKey(b *testing.B) {
+	l := New(1024)
+	for i := 0; i < b.N; i++ {
+		l.Set(Key("user", i%2048, "avatar"), i)
+	}
+}
@@ -0,0 +1,62 @@
+package lfuda
+
+// KeyStat describes the popularity of a cached key.
+type KeyStat struct {
+	Key      interface{} `json:"key"`
+	Hits     float64     `json:"hits"`
+	Size     float64     `json:"size"`
+	Priority float64     `json:"priority"`
+}
+
+func keyStat(info EntryInfo) KeyStat {
+	return KeyStat{Key: info.Key, Hits: info.Hits, Size: info.Size, Priority: info.Priority}
+}
+
+// TopKeys returns up to k of the highest priority keys, from the most
+// valuable, without updating their hits.  They are selected with a heap, so
+// it costs much less than sorting Keys when k is small.  Negative entries
+// are skipped.
+func (c *Cache) TopKeys(k int) []KeyStat {
+	if k <= 0 {
+		return nil
+	}
+	c.flushReads()
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	infos := c.lfuda.TopK(k)
+	stats := make([]KeyStat, 0, len(infos))
+	for _, info := range infos {
+		if value, _ := c.lfuda.Peek(info.Key); value != nil {
+			if _, isNeg := value.(negativeEntry); isNeg {
+				continue
+			}
+		}
+		stats = append(stats, keyStat(info))
+	}
+	return stats
+}
+
+// ColdestKeys returns up to k of the lowest priority keys, from the next to
+// be evicted, without updating their hits, e.g. to demote them to another
+// tier ahead of eviction.  Negative entries are skipped.
+func (c *Cache) ColdestKeys(k int) []KeyStat {
+	if k <= 0 {
+		return nil
+	}
+	c.flushReads()
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	stats := make([]KeyStat, 0, k)
+	c.lfuda.RangeReverse(func(info EntryInfo) bool {
+		if value, _ := c.lfuda.Peek(info.Key); value != nil {
+			if _, isNeg := value.(negativeEntry); isNeg {
+				return true
+			}
+		}
+		stats = append(stats, keyStat(info))
+		return len(stats) < k
+	})
+	return stats
+}
@@ -0,0 +1,58 @@
+package lfuda
+
+import "testing"
+
+func TestTopKeys(t *testing.T) {
+	l := New(100)
+	for i := 0; i < 20; i++ {
+		l.Set(i, i)
+		for j := 0; j < i%10; j++ {
+			l.Get(i)
+		}
+	}
+	l.SetNegative("missing", 0)
+
+	top := l.TopKeys(3)
+	if len(top) != 3 {
+		t.Fatalf("3 keys should be returned: %v", top)
+	}
+	for _, s := range top[:2] {
+		if s.Hits != 10 || (s.Key != 9 && s.Key != 19) {
+			t.Errorf("9 and 19 have the most hits: %+v", top)
+		}
+	}
+	if top[2].Hits != 9 || top[2].Priority > top[1].Priority {
+		t.Errorf("the third key should have 9 hits: %+v", top)
+	}
+	if hits, _ := l.Hits(9); hits != 10 {
+		t.Errorf("TopKeys should not count accesses: %v", hits)
+	}
+	if top := l.TopKeys(100); len(top) != 20 {
+		t.Errorf("all live keys should be returned: %d", len(top))
+	}
+	if top := l.TopKeys(0); len(top) != 0 {
+		t.Errorf("no keys should be returned: %v", top)
+	}
+}
+
+func TestColdestKeys(t *testing.T) {
+	l := New(100)
+	l.SetNegative("missing", 0)
+	for i := 0; i < 20; i++ {
+		l.Set(i, i)
+		for j := 0; j < i; j++ {
+			l.Get(i)
+		}
+	}
+
+	cold := l.ColdestKeys(3)
+	if len(cold) != 3 || cold[0].Key != 0 || cold[1].Key != 1 || cold[2].Key != 2 {
+		t.Errorf("the least used keys should be returned first: %+v", cold)
+	}
+	if cold := l.ColdestKeys(100); len(cold) != 20 {
+		t.Errorf("all live keys should be returned: %d", len(cold))
+	}
+	if cold := l.ColdestKeys(-1); len(cold) != 0 {
+		t.Errorf("no keys should be returned: %v", cold)
+	}
+}
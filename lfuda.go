@@ -2,6 +2,8 @@ package lfuda
 
 import (
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/bparli/lfuda-go/simplelfuda"
 )
@@ -10,85 +12,736 @@ import (
 type Cache struct {
 	lfuda simplelfuda.LFUDACache
 	lock  sync.RWMutex
+	opts  options
+	hooks hooks
+	stats stats
+	loads group
+	// recent hits and misses, if tracked
+	window *hitWindow
+	// values held weakly, if enabled
+	weak *weakValues
+
+	// *Namespace by name
+	namespaces sync.Map
+
+	// hits recorded by Get under the read lock, applied under the write lock
+	reads readBuffer
+
+	tier      *tierWriter
+	callbacks *callbackPool
+	// started by the first SetAsync
+	sets     atomic.Pointer[setQueue]
+	setsOnce sync.Once
+	faults   atomic.Pointer[faultInjector]
+	// string keys, if indexed
+	prefixes *prefixIndex
+	// count of sets, removes, evictions and purges, fencing values read
+	// from the tier and telling snapshotting whether the cache changed
+	writes uint64
+	// log of sets and removes, if open
+	wal atomic.Pointer[WAL]
+
+	// closed by Close to stop background work
+	done      chan struct{}
+	closeOnce sync.Once
+	// wakes the trimmer, if there is a soft limit
+	trims chan struct{}
+
+	// state of the operation holding the write lock, reported to hooks and
+	// the logger once the lock is released
+	opAge    float64
+	opReason removalReason
+	// the operation stores a value read from the tier
+	opPromote bool
+	// entries stored by the operation, written through to the tier with
+	// their deadline once it's set
+	opThrough []Evicted
+	// deadline of the entry being evicted
+	opExpires int64
+	// the operation applies values queued by SetAsync
+	opAsync bool
+	// when the lock was taken, with lock metrics
+	opLocked time.Time
+	// entries evicted by the operation, collected if opCollect is set
+	opCollect bool
+	opEvicted []Evicted
+	// records of the operation to log, queued once the deadlines they set
+	// are known
+	opJournal []walPending
+	events    []event
 }
 
 // New creates an lfuda of the given size.
 func New(size float64) *Cache {
-	return newWithEvict(size, "LFUDA", nil)
+	return newWithEvict(size, PolicyLFUDA, nil)
 }
 
 // NewGDSF creates an lfuda of the given size and the GDSF cache policy.
 func NewGDSF(size float64) *Cache {
-	return newWithEvict(size, "GDSF", nil)
+	return newWithEvict(size, PolicyGDSF, nil)
 }
 
 // NewLFU creates an lfuda of the given size.
 func NewLFU(size float64) *Cache {
-	return newWithEvict(size, "LFU", nil)
+	return newWithEvict(size, PolicyLFU, nil)
 }
 
 // NewWithEvict constructs a fixed size LFUDA cache with the given eviction
 // callback.
 func NewWithEvict(size float64, onEvicted func(key interface{}, value interface{})) *Cache {
-	return newWithEvict(size, "LFUDA", onEvicted)
+	return newWithEvict(size, PolicyLFUDA, onEvicted)
 }
 
 // NewGDSFWithEvict constructs a fixed GDSF size cache with the given eviction
 // callback.
 func NewGDSFWithEvict(size float64, onEvicted func(key interface{}, value interface{})) *Cache {
-	return newWithEvict(size, "GDSF", onEvicted)
+	return newWithEvict(size, PolicyGDSF, onEvicted)
 }
 
 // NewLFUWithEvict constructs a fixed size LFU cache with the given eviction
 // callback.
 func NewLFUWithEvict(size float64, onEvicted func(key interface{}, value interface{})) *Cache {
-	return newWithEvict(size, "LFU", onEvicted)
+	return newWithEvict(size, PolicyLFU, onEvicted)
 }
 
 func newWithEvict(size float64, policy string, onEvicted func(key interface{}, value interface{})) *Cache {
-	if policy == "GDSF" {
-		gdsf := simplelfuda.NewGDSF(size, simplelfuda.EvictCallback(onEvicted))
-		return &Cache{
-			lfuda: gdsf,
+	return NewWithOptions(size, WithPolicy(policy), WithEvictCallback(onEvicted))
+}
+
+// NewWithOptions constructs a fixed size cache configured by opts.  Without
+// options it is equivalent to New.
+func NewWithOptions(size float64, opts ...Option) *Cache {
+	return newCache(size, applyOptions(opts))
+}
+
+func applyOptions(opts []Option) options {
+	o := options{
+		policy: PolicyLFUDA,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.sizeFunc == nil && o.deepSize {
+		o.sizeFunc = func(key, value interface{}) float64 {
+			return EstimateSize(value)
+		}
+	}
+	return o
+}
+
+func newCache(size float64, o options) *Cache {
+	c := &Cache{
+		opts: o,
+		done: make(chan struct{}),
+	}
+	// entries past the capacity are evicted in the background
+	size *= 1 + o.headroom
+	if c.opts.policy == PolicyGDSF {
+		c.lfuda = simplelfuda.NewGDSF(size, c.evict)
+	} else if c.opts.policy == PolicyLFU {
+		c.lfuda = simplelfuda.NewLFU(size, c.evict)
+	} else if c.opts.policy == PolicyHybrid {
+		c.lfuda = simplelfuda.NewHybrid(size, c.opts.alpha, c.evict)
+	} else {
+		c.opts.policy = PolicyLFUDA
+		c.lfuda = simplelfuda.NewLFUDA(size, c.evict)
+	}
+	c.lfuda.SetExpireCallback(c.expire)
+	c.lfuda.SetEvictObserver(c.observeEviction)
+	c.lfuda.SetMaxEntries(c.opts.maxItems)
+	c.lfuda.SetAging(c.opts.aging)
+	c.lfuda.SetHybridAlpha(c.opts.alpha)
+	c.lfuda.SetMaxHits(c.opts.maxHits)
+	c.lfuda.SetAccessTimes(c.opts.accessTimes)
+	c.SetFaults(c.opts.faults)
+	if c.opts.prefixIndex {
+		c.prefixes = new(prefixIndex)
+	}
+	if c.opts.hitWindow {
+		c.window = newHitWindow()
+	}
+	if c.opts.weakValues {
+		c.startWeakValues()
+	}
+
+	if c.opts.tier != nil {
+		attempts, backoff := c.opts.tierAttempts, c.opts.tierBackoff
+		if attempts <= 0 {
+			attempts = 5
+		}
+		if backoff <= 0 {
+			backoff = 100 * time.Millisecond
+		}
+		var onPanic func(op string, r interface{})
+		if c.opts.recover != nil {
+			onPanic = c.reportPanic
+		}
+		c.tier = newTierWriter(c.opts.tier, attempts, backoff, c.opts.readAfterWrite, onPanic)
+	}
+	if c.opts.halving > 0 {
+		go c.halveEvery(c.opts.halving)
+	}
+	if (c.opts.softLimit > 0 && c.opts.softLimit < 1) || c.opts.headroom > 0 {
+		c.trims = make(chan struct{}, 1)
+		go c.trimmer()
+	}
+	if (c.opts.onEvicted != nil || c.opts.onExpired != nil) && c.opts.callbackWorkers > 0 {
+		c.startCallbacks(c.opts.callbackWorkers, c.opts.callbackQueue)
+	}
+	return c
+}
+
+// Close stops the cache's background work, waiting for queued eviction
+// callbacks and making a last attempt at writing pending entries to the
+// tier.  The cache must not be used afterwards.
+func (c *Cache) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		// wait for a SetAsync starting the queue, and keep later ones
+		// from starting it
+		c.setsOnce.Do(func() {})
+		if q := c.sets.Load(); q != nil {
+			q.close()
+			q.wg.Wait()
+		}
+		if c.callbacks != nil {
+			c.callbacks.stop()
+		}
+		if c.tier != nil {
+			c.tier.close()
+		}
+	})
+	return nil
+}
+
+type eventKind int
+
+const (
+	eventEvict eventKind = iota
+	eventSet
+	eventReject
+)
+
+type removalReason int
+
+const (
+	reasonEvicted removalReason = iota
+	reasonRemoved
+	reasonPurged
+	reasonExpired
+	// a weak value was released
+	reasonReleased
+)
+
+func (r removalReason) String() string {
+	switch r {
+	case reasonRemoved:
+		return "removed"
+	case reasonPurged:
+		return "purged"
+	case reasonExpired:
+		return "expired"
+	case reasonReleased:
+		return "released"
+	}
+	return "evicted"
+}
+
+type event struct {
+	kind       eventKind
+	reason     removalReason
+	key, value interface{}
+	// expiration deadline of an evicted entry in unix nanoseconds, 0 if it
+	// never expires
+	expires int64
+	// the eviction callback is pending
+	callback bool
+	// the value goes back to the value pool once reported
+	recycle bool
+}
+
+// evict is the simplelfuda eviction callback.  It runs with the lock held, so
+// the eviction is only queued here and reported by unlockOp.
+func (c *Cache) evict(key, value interface{}) {
+	c.removed(key, value, c.opReason)
+}
+
+// expire is the simplelfuda callback for expired entries.
+func (c *Cache) expire(key, value interface{}) {
+	c.removed(key, value, reasonExpired)
+}
+
+func (c *Cache) removed(key, value interface{}, reason removalReason) {
+	c.writes++
+	c.forgetWeak(value)
+	value, _ = c.decodeValue(value, true)
+	if s, ok := key.(string); ok && c.prefixes != nil {
+		c.prefixes.remove(s)
+	}
+	// released values are gone, so they are neither reported nor kept
+	callback := reason != reasonReleased && (c.opts.onEvicted != nil || (reason == reasonExpired && c.opts.onExpired != nil))
+	if c.opCollect && (reason == reasonEvicted || reason == reasonExpired) {
+		c.opEvicted = append(c.opEvicted, Evicted{Key: key, Value: value})
+	}
+	if reason == reasonExpired && c.writeThrough() {
+		c.tier.remove(key)
+	}
+	c.journal(key, nil, false)
+	if reason == reasonRemoved || reason == reasonPurged {
+		c.written(key)
+	}
+	if callback || c.hooks.has(hookEvict) || c.opts.logger != nil || c.tier != nil || c.opts.valuePool != nil {
+		e := event{kind: eventEvict, reason: reason, key: key, value: value, callback: callback}
+		if reason == reasonEvicted {
+			e.expires = c.opExpires
+		}
+		c.events = append(c.events, e)
+	}
+}
+
+// lockOp takes the write lock for an operation that may change the cache
+// and applies the hits recorded since it was last held.
+func (c *Cache) lockOp() {
+	if c.opts.lockMetrics {
+		start := time.Now()
+		c.lock.Lock()
+		c.opLocked = time.Now()
+		c.stats.lockWaits.add(micros(c.opLocked.Sub(start)))
+	} else {
+		c.lock.Lock()
+	}
+	c.opAge = c.lfuda.Age()
+	c.opReason = reasonEvicted
+	c.opPromote = false
+	c.opThrough = nil
+	c.opCollect = false
+	c.opEvicted = nil
+	c.opJournal = nil
+	c.applyReads()
+}
+
+// recordHit defers updating the key's hits until the write lock is next
+// taken.  Once its stripe of the buffer is full, the hit is applied right
+// away along with the buffered ones.
+func (c *Cache) recordHit(key interface{}) {
+	if !c.reads.add(key) {
+		c.lockOp()
+		c.lfuda.Get(key)
+		c.unlockOp()
+	}
+}
+
+// flushReads applies the recorded hits so that operations taking only the
+// read lock observe them.
+func (c *Cache) flushReads() {
+	if c.reads.pending() {
+		c.lockOp()
+		c.unlockOp()
+	}
+}
+
+// applyReads updates the hits recorded by Get, with the write lock held.
+func (c *Cache) applyReads() {
+	c.reads.drain(func(key interface{}) { c.lfuda.Get(key) })
+}
+
+// unlockOp releases the write lock, then reports the evictions, sets and age
+// change that happened while it was held.
+func (c *Cache) unlockOp() {
+	c.writeThroughOp()
+	c.journalOp()
+	c.wakeTrimmer()
+	events := c.events
+	c.events = nil
+	age, newAge := c.opAge, c.lfuda.Age()
+	var held time.Duration
+	if c.opts.lockMetrics {
+		held = time.Since(c.opLocked)
+	}
+	c.lock.Unlock()
+	if c.opts.lockMetrics {
+		c.stats.lockHolds.add(micros(held))
+	}
+
+	for _, e := range events {
+		c.deliver(e, newAge)
+	}
+	if newAge != age {
+		c.ageChanged(age, newAge)
+	}
+	c.flushWAL()
+}
+
+// deliver reports an event to the eviction callback, tier, hooks and logger.
+func (c *Cache) deliver(e event, age float64) {
+	if c.opts.recover != nil {
+		defer c.recoverPanic("hook")
+	}
+	switch e.kind {
+	case eventEvict:
+		async := e.callback && c.callbacks != nil
+		if e.callback && !async {
+			c.runCallback(e)
+		}
+		// written through entries are already in the tier
+		toTier := c.tier != nil && e.reason == reasonEvicted && !c.opts.readAfterWrite
+		if toTier {
+			c.tier.enqueue(e.key, e.value, e.expires)
+		}
+		c.hooks.evict(e.key, e.value)
+		c.debug("lfuda: entry "+e.reason.String(), "key", e.key, "age", age)
+		e.recycle = !toTier && e.reason != reasonReleased && c.recyclable(e.value)
+		if async && !c.callbacks.send(e) {
+			// the cache is closed
+			async = false
+			c.runCallback(e)
+		}
+		if !async && e.recycle {
+			c.opts.valuePool.Put(e.value)
+		}
+	case eventSet:
+		c.hooks.set(e.key, e.value)
+	case eventReject:
+		c.debug("lfuda: value too large for cache", "key", e.key)
+	}
+}
+
+func (c *Cache) ageChanged(age, newAge float64) {
+	if c.opts.recover != nil {
+		defer c.recoverPanic("hook")
+	}
+	c.hooks.age(age, newAge)
+	if newAge < age {
+		c.debug("lfuda: age reset", "old_age", age, "age", newAge)
+	}
+}
+
+// set adds a value to the cache with the lock held.  Returns true if an
+// eviction occurred.
+func (c *Cache) set(key, value interface{}) (evicted bool) {
+	value, ok := c.copyOnSet(key, value)
+	if !ok {
+		return false
+	}
+	encoded, size, ok := c.encode(key, value)
+	if !ok {
+		return false
+	}
+	return c.setEncoded(key, value, encoded, size)
+}
+
+// setWithSize is set for values with an explicit size.
+func (c *Cache) setWithSize(key, value interface{}, size float64) (evicted bool) {
+	value, ok := c.copyOnSet(key, value)
+	if !ok {
+		return false
+	}
+	encoded, _, err := c.encodeValue(value)
+	if err != nil {
+		c.debug("lfuda: value encoding failed", "key", key, "error", err)
+		return false
+	}
+	return c.setEncoded(key, value, c.weaken(key, encoded, size), size)
+}
+
+// setEncoded is set for values stored as encoded.
+func (c *Cache) setEncoded(key, value, encoded interface{}, size float64) (evicted bool) {
+	evicted = c.lfuda.SetWithSize(key, encoded, c.entrySize(key, size))
+	if c.opts.ttlFunc != nil && c.lfuda.Contains(key) {
+		if ttl, ok := c.ttlOf(key, value); ok {
+			c.lfuda.Expire(key, ttl)
+		}
+	}
+	c.setCost(key, value)
+	c.stored(key, value)
+	return evicted
+}
+
+// replace overwrites the value of a key keeping its expiration, or sets it
+// if absent, with the lock held.  Returns true if an eviction occurred.
+func (c *Cache) replace(key, value interface{}) (evicted bool) {
+	value, ok := c.copyOnSet(key, value)
+	if !ok {
+		return false
+	}
+	encoded, size, ok := c.encode(key, value)
+	if !ok {
+		return false
+	}
+	replaced, evicted := c.lfuda.Replace(key, encoded, c.entrySize(key, size))
+	if !replaced {
+		return c.setEncoded(key, value, encoded, size)
+	}
+	c.setCost(key, value)
+	c.stored(key, value)
+	return evicted
+}
+
+// setCost applies the cost func to a stored value.
+func (c *Cache) setCost(key, value interface{}) {
+	if c.opts.costFunc != nil && c.lfuda.Contains(key) {
+		if cost, ok := c.costOf(key, value); ok {
+			c.lfuda.SetCost(key, cost)
+		}
+	}
+}
+
+// valueSize returns the size of a value.  Returns false if the size func
+// panicked.
+func (c *Cache) valueSize(key, value interface{}) (float64, bool) {
+	if c.opts.sizeFunc != nil {
+		return c.sizeOf(key, value)
+	}
+	return defaultSize(value), true
+}
+
+// entrySize adds the key size and entry overhead to the size of a value when
+// configured.
+func (c *Cache) entrySize(key interface{}, size float64) float64 {
+	size += c.opts.overhead
+	if c.opts.keySize {
+		if c.opts.deepSize {
+			size += EstimateSize(key)
+		} else {
+			size += defaultSize(key)
+		}
+	}
+	return size
+}
+
+// stored writes a stored value through to the tier and records the set
+// event.
+func (c *Cache) stored(key, value interface{}) {
+	c.writes++
+	c.written(key)
+	if c.writeThrough() && !c.opPromote {
+		c.opThrough = append(c.opThrough, Evicted{Key: key, Value: value})
+	}
+	if c.lfuda.Contains(key) {
+		c.journal(key, value, true)
+		if s, ok := key.(string); ok && c.prefixes != nil {
+			c.prefixes.insert(s)
+		}
+		if c.hooks.has(hookSet) {
+			c.events = append(c.events, event{kind: eventSet, key: key, value: value})
+		}
+	} else if c.opts.logger != nil {
+		c.events = append(c.events, event{kind: eventReject, key: key})
+	}
+}
+
+// writeThrough reports whether writes go to the tier synchronously.
+func (c *Cache) writeThrough() bool {
+	return c.tier != nil && c.opts.readAfterWrite
+}
+
+// writeThroughOp writes the entries stored by the operation through to the
+// tier, with the write lock held so writes land in order.
+func (c *Cache) writeThroughOp() {
+	for _, e := range c.opThrough {
+		if ttl, ok := c.lfuda.TTL(e.Key); ok {
+			c.tier.writeThrough(e.Key, e.Value, deadline(ttl))
 		}
-	} else if policy == "LFU" {
-		lfu := simplelfuda.NewLFU(size, simplelfuda.EvictCallback(onEvicted))
-		return &Cache{
-			lfuda: lfu,
+	}
+	c.opThrough = nil
+}
+
+// deadline returns the unix nanoseconds ttl from now, 0 if ttl is 0.
+func deadline(ttl time.Duration) int64 {
+	if ttl <= 0 {
+		return 0
+	}
+	return time.Now().Add(ttl).UnixNano()
+}
+
+// promote stores a value read from the tier with the TTL left before its
+// deadline, unless the cache was written since writes was read so a newer
+// value isn't overwritten.
+func (c *Cache) promote(key, value interface{}, expires int64, writes uint64) {
+	c.lockOp()
+	if c.writes == writes {
+		c.opPromote = true
+		c.set(key, value)
+		if expires != 0 {
+			c.lfuda.Expire(key, time.Until(time.Unix(0, expires)))
 		}
 	}
-	lfuda := simplelfuda.NewLFUDA(size, simplelfuda.EvictCallback(onEvicted))
-	return &Cache{
-		lfuda: lfuda,
+	c.unlockOp()
+}
+
+// Capacity returns the size of the cache in bytes.
+func (c *Cache) Capacity() (capacity float64) {
+	c.lock.RLock()
+	capacity = c.capacity()
+	c.lock.RUnlock()
+	return capacity
+}
+
+// Resize changes the size of the cache, evicting the least valuable entries
+// until the remaining ones fit.  Returns the number of entries evicted.
+func (c *Cache) Resize(size float64) (evicted int) {
+	c.lockOp()
+	evicted = c.lfuda.Resize(size * (1 + c.opts.headroom))
+	for c.lfuda.Size() > size && c.lfuda.Evict() {
+		evicted++
 	}
+	c.unlockOp()
+	c.debug("lfuda: resized", "size", size, "evicted", evicted)
+	return evicted
 }
 
 // Purge is used to completely clear the cache.
 func (c *Cache) Purge() {
-	c.lock.Lock()
+	c.lockOp()
+	c.opReason = reasonPurged
+	length, size := c.lfuda.Len(), c.lfuda.Size()
 	c.lfuda.Purge()
-	c.lock.Unlock()
+	if q := c.sets.Load(); q != nil {
+		q.supersedeAll()
+	}
+	c.unlockOp()
+
+	c.debug("lfuda: purged", "len", length, "size", size)
 }
 
 // Set adds a value to the cache. Returns true if an eviction occurred.
 func (c *Cache) Set(key, value interface{}) (ok bool) {
-	c.lock.Lock()
-	ok = c.lfuda.Set(key, value)
-	c.lock.Unlock()
+	if c.badKey(key) || c.dropSet() {
+		return
+	}
+	c.lockOp()
+	ok = c.set(key, value)
+	c.unlockOp()
+	return ok
+}
+
+// SetWithSize adds a value to the cache, accounting for it as size bytes
+// instead of deriving its size from the value.  Returns true if an eviction
+// occurred.
+func (c *Cache) SetWithSize(key, value interface{}, size float64) (ok bool) {
+	if c.badKey(key) || c.dropSet() {
+		return
+	}
+	c.lockOp()
+	ok = c.setWithSize(key, value, size)
+	c.unlockOp()
+	return ok
+}
+
+// SetStored is Set returning whether the value was stored rather than
+// whether an eviction occurred, so that values too large for the cache are
+// detected atomically.
+func (c *Cache) SetStored(key, value interface{}) (stored bool) {
+	if c.badKey(key) || c.dropSet() {
+		return
+	}
+	c.lockOp()
+	c.set(key, value)
+	stored = c.lfuda.Contains(key)
+	c.unlockOp()
+	return stored
+}
+
+// SetWithSizeStored is SetWithSize returning whether the value was stored.
+func (c *Cache) SetWithSizeStored(key, value interface{}, size float64) (stored bool) {
+	if c.badKey(key) || c.dropSet() {
+		return
+	}
+	c.lockOp()
+	c.setWithSize(key, value, size)
+	stored = c.lfuda.Contains(key)
+	c.unlockOp()
+	return stored
+}
+
+// SetWithTTL adds a value to the cache that expires after ttl, or never if
+// ttl is not positive, overriding any TTL func.  Returns true if an eviction
+// occurred.
+func (c *Cache) SetWithTTL(key, value interface{}, ttl time.Duration) (ok bool) {
+	if c.badKey(key) || c.dropSet() {
+		return
+	}
+	c.lockOp()
+	ok = c.set(key, value)
+	c.lfuda.Expire(key, ttl)
+	c.unlockOp()
 	return ok
 }
 
-// Get looks up a key's value from the cache.
+// Get looks up a key's value from the cache.  Only the read lock is taken;
+// the key's hits are updated before the next operation needing the write
+// lock, so concurrent Gets don't block each other.  Keys recorded as missing
+// by SetNegative are not found; use Lookup to tell them apart.
 func (c *Cache) Get(key interface{}) (value interface{}, ok bool) {
-	c.lock.Lock()
-	value, ok = c.lfuda.Get(key)
-	c.lock.Unlock()
+	value, ok, _ = c.Lookup(key)
 	return value, ok
 }
 
+// Lookup is Get for caches holding negative entries.  For a key recorded as
+// missing it returns an error matching ErrNegativeHit with errors.Is, and
+// counts a negative hit instead of a hit.
+func (c *Cache) Lookup(key interface{}) (value interface{}, ok bool, err error) {
+	if c.badKey(key) {
+		return
+	}
+	if c.forceMiss() {
+		c.reportGet(key, nil, false)
+		return nil, false, nil
+	}
+	c.rlockGet()
+	value, ok = c.decodeHit(c.lfuda.Peek(key))
+	writes := c.writes
+	c.lock.RUnlock()
+
+	if ok {
+		c.recordHit(key)
+	} else if c.tier != nil {
+		if c.opts.readAfterWrite {
+			// remove the key if it expired so the tier doesn't resurrect it
+			c.lockOp()
+			value, ok = c.decodeHit(c.lfuda.Get(key))
+			writes = c.writes
+			c.unlockOp()
+		}
+		if !ok {
+			var expires int64
+			if value, expires, ok = c.tier.get(key); ok {
+				c.promote(key, value, expires, writes)
+			}
+		}
+	}
+
+	if neg, isNeg := value.(negativeEntry); ok && isNeg {
+		c.stats.negativeHits.Add(1)
+		return nil, false, neg.error()
+	}
+	c.reportGet(key, value, ok)
+	return value, ok, nil
+}
+
+// reportGet counts a Get and reports it to the hooks.
+func (c *Cache) reportGet(key, value interface{}, ok bool) {
+	if c.opts.recover != nil {
+		defer c.recoverPanic("hook")
+	}
+	if c.window != nil {
+		c.window.record(ok)
+	}
+	if ok {
+		c.stats.hits.Add(1)
+		c.hooks.hit(key, value)
+	} else {
+		c.stats.misses.Add(1)
+		c.hooks.miss(key)
+	}
+}
+
 // Contains checks if a key is in the cache, without updating the
 // recent-ness or deleting it for being stale.
 func (c *Cache) Contains(key interface{}) bool {
+	if c.badKey(key) {
+		return false
+	}
 	c.lock.RLock()
 	containKey := c.lfuda.Contains(key)
 	c.lock.RUnlock()
@@ -96,10 +749,14 @@ func (c *Cache) Contains(key interface{}) bool {
 }
 
 // Peek returns the key value (or undefined if not found) without updating
-// the "recently used"-ness of the key.
+// the "recently used"-ness of the key.  Keys recorded as missing by
+// SetNegative are not found.
 func (c *Cache) Peek(key interface{}) (value interface{}, ok bool) {
+	if c.badKey(key) {
+		return
+	}
 	c.lock.RLock()
-	value, ok = c.lfuda.Peek(key)
+	value, ok = present(c.decode(c.lfuda.Peek(key)))
 	c.lock.RUnlock()
 	return value, ok
 }
@@ -108,48 +765,183 @@ func (c *Cache) Peek(key interface{}) (value interface{}, ok bool) {
 // recent-ness or deleting it for being stale, and if not, adds the value.
 // Returns whether found and whether the key/value was set or not.
 func (c *Cache) ContainsOrSet(key, value interface{}) (ok, set bool) {
-	c.lock.Lock()
-	defer c.lock.Unlock()
+	if c.badKey(key) {
+		return
+	}
+	c.lockOp()
+	defer c.unlockOp()
+
+	if c.lfuda.Contains(key) {
+		return true, false
+	}
+	set = c.set(key, value)
+	return false, set
+}
+
+// ContainsOrSetWithTTL is ContainsOrSet for a value that expires after ttl,
+// or never if ttl is not positive.
+func (c *Cache) ContainsOrSetWithTTL(key, value interface{}, ttl time.Duration) (ok, set bool) {
+	if c.badKey(key) {
+		return
+	}
+	c.lockOp()
+	defer c.unlockOp()
 
 	if c.lfuda.Contains(key) {
 		return true, false
 	}
-	set = c.lfuda.Set(key, value)
+	set = c.set(key, value)
+	c.lfuda.Expire(key, ttl)
 	return false, set
 }
 
 // PeekOrSet checks if a key is in the cache without updating the
-// hits or deleting it for being stale, and if not, adds the value.
+// hits or deleting it for being stale, and if not, adds the value.  Keys
+// recorded as missing by SetNegative are not found and get the value.
 // Returns whether found and whether the key/value was set or not.
 func (c *Cache) PeekOrSet(key, value interface{}) (previous interface{}, ok, set bool) {
-	c.lock.Lock()
-	defer c.lock.Unlock()
+	if c.badKey(key) {
+		return
+	}
+	c.lockOp()
+	defer c.unlockOp()
 
-	previous, ok = c.lfuda.Peek(key)
+	previous, ok = present(c.decode(c.lfuda.Peek(key)))
 	if ok {
 		return previous, true, false
 	}
 
-	set = c.lfuda.Set(key, value)
+	set = c.set(key, value)
 	return nil, false, set
 }
 
-// Remove removes the provided key from the cache.
+// Boost adds delta to a key's hits to account for popularity known outside
+// of the cache, e.g. trending content, so it is favored before organic hits
+// accumulate.  A negative delta demotes the key.  Returns false if the key is
+// not in the cache.
+func (c *Cache) Boost(key interface{}, delta float64) (ok bool) {
+	if c.badKey(key) {
+		return
+	}
+	c.lockOp()
+	ok = c.lfuda.Boost(key, delta)
+	c.unlockOp()
+	return ok
+}
+
+// Expire sets a key to expire after ttl, or never if ttl is not positive,
+// without changing its value or hits.  Returns false if the key is not in the
+// cache.
+func (c *Cache) Expire(key interface{}, ttl time.Duration) (ok bool) {
+	if c.badKey(key) {
+		return
+	}
+	c.lockOp()
+	ok = c.lfuda.Expire(key, ttl)
+	if ok && (c.writeThrough() || c.wal.Load() != nil) {
+		// the tier and the log keep the new deadline
+		if value, present := c.decode(c.lfuda.Peek(key)); present {
+			if c.writeThrough() {
+				c.opThrough = append(c.opThrough, Evicted{Key: key, Value: value})
+			}
+			c.journal(key, value, true)
+		}
+	}
+	c.unlockOp()
+	return ok
+}
+
+// TTL returns the time left before a key expires, 0 if it never expires,
+// without updating its hits.  Returns false if the key is not in the cache.
+func (c *Cache) TTL(key interface{}) (ttl time.Duration, ok bool) {
+	if c.badKey(key) {
+		return
+	}
+	c.lock.RLock()
+	ttl, ok = c.lfuda.TTL(key)
+	c.lock.RUnlock()
+	return ttl, ok
+}
+
+// Touch counts an access to a key without fetching its value, e.g. once an
+// external validator confirmed it is fresh, and renews its deadline with the
+// TTL it was last given.  Returns false if the key is not in the cache.
+func (c *Cache) Touch(key interface{}) (ok bool) {
+	if c.badKey(key) {
+		return
+	}
+	c.lockOp()
+	ok = c.lfuda.Touch(key)
+	c.unlockOp()
+	return ok
+}
+
+// Remove removes the provided key from the cache and from its tier.
 func (c *Cache) Remove(key interface{}) (present bool) {
-	c.lock.Lock()
+	if c.badKey(key) {
+		return
+	}
+	c.lockOp()
+	c.opReason = reasonRemoved
 	present = c.lfuda.Remove(key)
-	c.lock.Unlock()
+	c.writes++
+	c.written(key)
+	writeThrough := c.writeThrough()
+	if writeThrough {
+		c.tier.remove(key)
+	}
+	c.unlockOp()
+
+	if c.tier != nil && !writeThrough {
+		c.tier.remove(key)
+	}
 	return
 }
 
-// Keys returns a slice of the keys in the cache, from oldest to newest.
+// Keys returns a slice of the keys in the cache, from most to least valuable.
+// The order of keys with the same priority is unspecified; use KeysByPriority
+// for a deterministic order.
 func (c *Cache) Keys() []interface{} {
+	return c.keys(simplelfuda.LFUDACache.Keys)
+}
+
+// KeysByFrequency returns a slice of the keys in the cache, from most to
+// least hits, with ties broken by priority and then recency.
+func (c *Cache) KeysByFrequency() []interface{} {
+	return c.keys(simplelfuda.LFUDACache.KeysByFrequency)
+}
+
+// KeysByRecency returns a slice of the keys in the cache, from most to least
+// recently set or read.
+func (c *Cache) KeysByRecency() []interface{} {
+	return c.keys(simplelfuda.LFUDACache.KeysByRecency)
+}
+
+// KeysByPriority returns a slice of the keys in the cache, from most to least
+// valuable under the cache's policy, with ties broken by recency.  The last
+// keys are the next to be evicted.
+func (c *Cache) KeysByPriority() []interface{} {
+	return c.keys(simplelfuda.LFUDACache.KeysByPriority)
+}
+
+func (c *Cache) keys(fn func(l simplelfuda.LFUDACache) []interface{}) []interface{} {
+	c.flushReads()
 	c.lock.RLock()
-	keys := c.lfuda.Keys()
+	keys := fn(c.lfuda)
 	c.lock.RUnlock()
 	return keys
 }
 
+// Range calls fn with the metadata of every entry, from most to least
+// valuable, until fn returns false.  The read lock is held while iterating,
+// so fn must not modify the cache.
+func (c *Cache) Range(fn func(info EntryInfo) bool) {
+	c.flushReads()
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	c.lfuda.Range(fn)
+}
+
 // Len returns the number of items in the cache.
 func (c *Cache) Len() (length int) {
 	c.lock.RLock()
@@ -4,6 +4,7 @@ import (
 	"math"
 	"math/rand"
 	"testing"
+	"time"
 )
 
 func BenchmarkLFUDA(b *testing.B) {
@@ -200,6 +201,19 @@ func TestLFUDASet(t *testing.T) {
 }
 
 // test that Contains doesn't update recent-ness
+func TestSetStored(t *testing.T) {
+	l := New(10)
+	if !l.SetStored("a", "aaa") || !l.Contains("a") {
+		t.Errorf("a should have been stored")
+	}
+	if l.SetStored("big", "aaaaaaaaaaaa") || l.Contains("big") {
+		t.Errorf("oversized value should not be stored")
+	}
+	if !l.SetWithSizeStored("b", 1, 7) || l.SetWithSizeStored("c", 1, 11) {
+		t.Errorf("values should be stored by their given size")
+	}
+}
+
 func TestLFUDAContains(t *testing.T) {
 	l := NewWithEvict(2, nil)
 	l.Set(1, 1)
@@ -361,3 +375,235 @@ func TestLFUDASize(t *testing.T) {
 		t.Errorf("Cache size should be reset to 0 (but it wasn't)")
 	}
 }
+
+func TestLFUDAMaxEntries(t *testing.T) {
+	evicted := 0
+	l := NewWithOptions(1000, WithMaxEntries(10), WithEvictCallback(func(k, v interface{}) {
+		evicted++
+	}))
+	for i := 0; i < 20; i++ {
+		l.Set(i, i)
+	}
+	if l.Len() != 10 || evicted != 10 {
+		t.Errorf("entry count should be capped: %d entries, %d evicted", l.Len(), evicted)
+	}
+
+	// the byte budget still applies
+	l = NewWithOptions(5, WithMaxEntries(10))
+	for i := 0; i < 10; i++ {
+		l.Set(i, i)
+	}
+	if l.Len() != 5 {
+		t.Errorf("size should be capped: %d", l.Len())
+	}
+}
+
+func TestLFUDASetWithTTL(t *testing.T) {
+	l := New(10)
+
+	l.SetWithTTL(1, 1, time.Millisecond)
+	l.SetWithTTL(2, 2, 0)
+	if !l.Contains(1) {
+		t.Errorf("1 should not have expired yet")
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := l.Get(1); ok {
+		t.Errorf("1 should have expired")
+	}
+	if _, ok := l.Get(2); !ok {
+		t.Errorf("2 should not expire")
+	}
+}
+
+func TestLFUDATTLFunc(t *testing.T) {
+	l := NewWithOptions(100, WithTTLFunc(func(key, value interface{}) time.Duration {
+		return value.(time.Duration)
+	}))
+	l.Set("short", time.Millisecond)
+	l.Set("never", time.Duration(0))
+	l.SetWithTTL("override", time.Millisecond, time.Hour)
+	l.PeekOrSet("peekorset", time.Millisecond)
+
+	time.Sleep(5 * time.Millisecond)
+	if l.Contains("short") || l.Contains("peekorset") {
+		t.Errorf("entries should have expired by their TTL func")
+	}
+	if !l.Contains("never") {
+		t.Errorf("non positive TTL should never expire")
+	}
+	if !l.Contains("override") {
+		t.Errorf("SetWithTTL should override the TTL func")
+	}
+}
+
+func TestLFUDACostFunc(t *testing.T) {
+	// values are the milliseconds it took to fetch them
+	l := NewWithOptions(3, WithPolicy(PolicyGDSF), WithCostFunc(func(key, value interface{}) float64 {
+		return float64(value.(int))
+	}))
+	l.SetWithSize("slow", 9, 2)
+	l.SetWithSize("fast", 1, 1)
+	l.Get("fast")
+	l.Set("new", 5)
+
+	// fast's 2 hits at cost 1 per byte are worth less than slow's hit at 9
+	if l.Contains("fast") || !l.Contains("slow") {
+		t.Errorf("the cheap entry should have been evicted: %v", l.Keys())
+	}
+	l.Update("slow", func(old interface{}, exists bool) (interface{}, bool) { return 1, true })
+	if _, meta, _ := l.GetWithMeta("slow"); meta.Cost != 1 {
+		t.Errorf("the cost should follow updates: %v", meta.Cost)
+	}
+}
+
+func TestLFUDAAging(t *testing.T) {
+	l := NewWithOptions(1, WithAging(Aging{Factor: 0.5}))
+	l.Set("a", 1)
+	l.Get("a")
+	l.Set("b", 2)
+	if age := l.Age(); age != 1 {
+		t.Errorf("the age should be half the evicted priority: %g", age)
+	}
+}
+
+func TestLFUDAExpireTouch(t *testing.T) {
+	l := New(100)
+	l.Set("a", 1)
+	l.Set("b", 2)
+	if l.Expire("missing", time.Millisecond) || l.Touch("missing") {
+		t.Errorf("missing keys can't be expired or touched")
+	}
+
+	if !l.Expire("a", time.Millisecond) {
+		t.Errorf("a should be expired")
+	}
+	time.Sleep(5 * time.Millisecond)
+	if l.Contains("a") {
+		t.Errorf("a should have expired")
+	}
+
+	l.SetWithTTL("c", 3, time.Hour)
+	if ttl, ok := l.TTL("c"); !ok || ttl <= 59*time.Minute || ttl > time.Hour {
+		t.Errorf("bad TTL: %v", ttl)
+	}
+	if !l.Expire("c", 0) {
+		t.Errorf("c should be updated")
+	}
+	if ttl, ok := l.TTL("c"); !ok || ttl != 0 {
+		t.Errorf("c should never expire: %v", ttl)
+	}
+	if _, ok := l.TTL("a"); ok {
+		t.Errorf("expired keys have no TTL")
+	}
+	if !l.Touch("b") || !l.Touch("b") {
+		t.Errorf("b should be touched")
+	}
+	if keys := l.Keys(); keys[0] != "b" {
+		t.Errorf("touch should count as an access: %v", keys)
+	}
+}
+
+func TestLFUDABoost(t *testing.T) {
+	l := New(2)
+	l.Set(1, 1)
+	l.Get(1)
+	l.Set(2, 2)
+
+	if !l.Boost(2, 10) {
+		t.Errorf("2 should be in the cache")
+	}
+	l.Set(3, 3)
+	if !l.Contains(2) || l.Contains(1) {
+		t.Errorf("boosted key should have been kept")
+	}
+}
+
+func BenchmarkLFUDA_ParallelGet(b *testing.B) {
+	l := New(8192)
+	for i := 0; i < 1024; i++ {
+		l.Set(i, i)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := rand.Int()
+		for pb.Next() {
+			l.Get(i % 1024)
+			i++
+		}
+	})
+}
+
+// test that hits recorded under the read lock are applied before eviction
+func TestLFUDADeferredHits(t *testing.T) {
+	l := New(2)
+	l.Set(1, 1)
+	l.Set(2, 2)
+	for i := 0; i < 5; i++ {
+		l.Get(2)
+	}
+
+	l.Set(3, 3)
+	if !l.Contains(2) || l.Contains(1) {
+		t.Errorf("2 should have been kept")
+	}
+
+	// overflowing the buffer applies hits right away
+	for i := 0; i < 3*readBufferSize; i++ {
+		l.Get(3)
+	}
+	l.Set(4, 4)
+	if !l.Contains(3) {
+		t.Errorf("3 should have been kept")
+	}
+}
+
+func TestLFUDAKeysOrdering(t *testing.T) {
+	l := New(10)
+	l.Set("a", 1)
+	l.Set("b", 2)
+	l.Get("a")
+	l.Get("a")
+
+	if keys := l.KeysByFrequency(); keys[0] != "a" || keys[1] != "b" {
+		t.Errorf("bad frequency order: %v", keys)
+	}
+	// hits recorded by Get must be applied before ordering
+	l.Get("b")
+	if keys := l.KeysByRecency(); keys[0] != "b" || keys[1] != "a" {
+		t.Errorf("bad recency order: %v", keys)
+	}
+	if keys := l.KeysByPriority(); keys[0] != "a" || keys[1] != "b" {
+		t.Errorf("bad priority order: %v", keys)
+	}
+}
+
+func TestLFUDAPurgeExpired(t *testing.T) {
+	var removed []interface{}
+	l := NewWithEvict(100, func(key, value interface{}) {
+		removed = append(removed, key)
+	})
+	l.SetWithTTL(1, 1, time.Millisecond)
+	l.SetWithTTL(2, 2, time.Hour)
+	l.Set(3, 3)
+	time.Sleep(5 * time.Millisecond)
+
+	if n := l.PurgeExpired(); n != 1 || l.Len() != 2 {
+		t.Errorf("only the expired entry should be removed: %d", n)
+	}
+	if len(removed) != 1 || removed[0] != 1 {
+		t.Errorf("removal should be reported: %v", removed)
+	}
+}
+
+func TestLFUDAResize(t *testing.T) {
+	var evicted int
+	l := NewWithEvict(4, func(key, value interface{}) { evicted++ })
+	for i := 0; i < 4; i++ {
+		l.Set(i, i)
+	}
+	if n := l.Resize(1); n != 3 || evicted != 3 || l.Capacity() != 1 {
+		t.Errorf("shrinking should evict: %d, %d", n, evicted)
+	}
+}
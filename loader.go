@@ -0,0 +1,44 @@
+package lfuda
+
+import (
+	"context"
+)
+
+// Loader loads the value of a key missing from the cache.  It should return
+// early once ctx is done.
+type Loader func(ctx context.Context, key interface{}) (interface{}, error)
+
+// GetOrLoad returns the value cached for key or, on a miss, loads it with
+// loader and caches it.  Concurrent misses for the same key share a single
+// load.  Each caller stops waiting with ctx's error once ctx is done, and
+// the load's context is canceled when no caller waits for it anymore, so a
+// slow origin can't hang its callers.  Errors are not cached, and keys
+// recorded as missing return an error matching ErrNegativeHit.
+func (c *Cache) GetOrLoad(ctx context.Context, key interface{}, loader Loader) (interface{}, error) {
+	if v, ok, err := c.Lookup(key); ok || err != nil {
+		return v, err
+	}
+	return c.load(ctx, key, loader)
+}
+
+// Warm loads the keys missing from the cache one at a time, stopping at the
+// first error or once ctx is done.  Returns the number of keys loaded.
+func (c *Cache) Warm(ctx context.Context, keys []interface{}, loader Loader) (loaded int, err error) {
+	progress, err := c.WarmWithOptions(ctx, keys, loader, WarmOptions{})
+	return progress.Loaded, err
+}
+
+func (c *Cache) load(ctx context.Context, key interface{}, loader Loader) (interface{}, error) {
+	return c.loads.doContext(ctx, key, func(ctx context.Context) (interface{}, error) {
+		c.stats.loads.Add(1)
+		v, err := fetch(c, func() (interface{}, error) {
+			return loader(ctx, key)
+		})
+		if err != nil {
+			c.stats.loadErrors.Add(1)
+			return nil, err
+		}
+		c.Set(key, v)
+		return v, nil
+	})
+}
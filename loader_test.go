@@ -0,0 +1,206 @@
+package lfuda
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetOrLoad(t *testing.T) {
+	l := New(100)
+	var loads int32
+	loader := func(ctx context.Context, key interface{}) (interface{}, error) {
+		atomic.AddInt32(&loads, 1)
+		return key.(string) + "!", nil
+	}
+
+	for i := 0; i < 3; i++ {
+		v, err := l.GetOrLoad(context.Background(), "a", loader)
+		if err != nil || v != "a!" {
+			t.Fatalf("bad result: %v, %v", v, err)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("value should have been loaded once: %d", loads)
+	}
+
+	failing := func(ctx context.Context, key interface{}) (interface{}, error) {
+		return nil, errors.New("origin down")
+	}
+	if _, err := l.GetOrLoad(context.Background(), "b", failing); err == nil || l.Contains("b") {
+		t.Errorf("errors should be returned and not cached")
+	}
+	if st := l.Stats(); st.Loads != 2 || st.LoadErrors != 1 {
+		t.Errorf("bad stats: %+v", st)
+	}
+}
+
+func TestGetOrLoadCoalesced(t *testing.T) {
+	l := New(100)
+	var loads int32
+	release := make(chan struct{})
+	loader := func(ctx context.Context, key interface{}) (interface{}, error) {
+		atomic.AddInt32(&loads, 1)
+		<-release
+		return 1, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := l.GetOrLoad(context.Background(), "a", loader); err != nil || v != 1 {
+				t.Errorf("bad result: %v, %v", v, err)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if loads != 1 {
+		t.Errorf("concurrent misses should share a load: %d", loads)
+	}
+}
+
+type ctxKey struct{}
+
+func TestGetOrLoadCanceled(t *testing.T) {
+	l := New(100)
+	canceled := make(chan struct{})
+	loader := func(ctx context.Context, key interface{}) (interface{}, error) {
+		if ctx.Value(ctxKey{}) != "v" {
+			t.Errorf("loader context should carry the caller's values")
+		}
+		<-ctx.Done()
+		close(canceled)
+		return nil, ctx.Err()
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), ctxKey{}, "v"), 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.GetOrLoad(ctx, "a", loader); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("caller should stop waiting at its deadline: %v", err)
+	}
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatalf("load should be canceled once no caller waits")
+	}
+}
+
+func TestGetOrLoadWaiterLeaves(t *testing.T) {
+	l := New(100)
+	release := make(chan struct{})
+	loader := func(ctx context.Context, key interface{}) (interface{}, error) {
+		select {
+		case <-release:
+			return 1, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	result := make(chan error)
+	go func() {
+		_, err := l.GetOrLoad(context.Background(), "a", loader)
+		result <- err
+	}()
+	time.Sleep(5 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := l.GetOrLoad(ctx, "a", loader); err != context.Canceled {
+		t.Errorf("canceled caller should return: %v", err)
+	}
+	close(release)
+	if err := <-result; err != nil {
+		t.Errorf("remaining caller should get the value: %v", err)
+	}
+}
+
+func TestWarm(t *testing.T) {
+	l := New(100)
+	l.Set("a", "cached")
+	loader := func(ctx context.Context, key interface{}) (interface{}, error) {
+		if key == "bad" {
+			return nil, errors.New("bad key")
+		}
+		return key, nil
+	}
+
+	loaded, err := l.Warm(context.Background(), []interface{}{"a", "b", "c"}, loader)
+	if err != nil || loaded != 2 {
+		t.Errorf("missing keys should be loaded: %d, %v", loaded, err)
+	}
+	if v, _ := l.Peek("a"); v != "cached" {
+		t.Errorf("cached keys should not be reloaded: %v", v)
+	}
+
+	loaded, err = l.Warm(context.Background(), []interface{}{"d", "bad", "e"}, loader)
+	if err == nil || loaded != 1 || l.Contains("e") {
+		t.Errorf("warming should stop at the first error: %d, %v", loaded, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if loaded, err := l.Warm(ctx, []interface{}{"f"}, loader); err != context.Canceled || loaded != 0 {
+		t.Errorf("warming should stop once ctx is done: %d, %v", loaded, err)
+	}
+}
+
+func TestWarmWithOptions(t *testing.T) {
+	l := New(1000)
+	l.Set(0, "cached")
+	keys := make([]interface{}, 20)
+	for i := range keys {
+		keys[i] = i
+	}
+	loader := func(ctx context.Context, key interface{}) (interface{}, error) {
+		if key.(int)%5 == 4 {
+			return nil, errors.New("bad key")
+		}
+		return "value", nil
+	}
+
+	var reports int
+	progress, err := l.WarmWithOptions(context.Background(), keys, loader, WarmOptions{
+		Concurrency:     4,
+		ContinueOnError: true,
+		Progress: func(p WarmProgress) {
+			reports++
+		},
+	})
+	if err != nil || progress.Loaded != 15 || progress.Skipped != 1 || progress.Failed != 4 {
+		t.Errorf("bad progress: %+v, %v", progress, err)
+	}
+	if progress.Bytes != 15*5 || progress.Next != len(keys) || reports != len(keys) {
+		t.Errorf("bad progress: %+v, %d reports", progress, reports)
+	}
+}
+
+func TestWarmResume(t *testing.T) {
+	l := New(1000)
+	keys := []interface{}{"a", "b", "c", "d"}
+	failing := true
+	loader := func(ctx context.Context, key interface{}) (interface{}, error) {
+		if key == "c" && failing {
+			return nil, errors.New("unavailable")
+		}
+		return key, nil
+	}
+
+	progress, err := l.WarmWithOptions(context.Background(), keys, loader, WarmOptions{})
+	if err == nil || progress.Loaded != 2 || progress.Next != 2 || l.Contains("d") {
+		t.Fatalf("warming should stop at the failed key: %+v, %v", progress, err)
+	}
+
+	failing = false
+	progress, err = l.WarmWithOptions(context.Background(), keys, loader, WarmOptions{Start: progress.Next})
+	if err != nil || progress.Loaded != 2 || progress.Next != 4 || !l.Contains("d") {
+		t.Errorf("warming should resume at the failed key: %+v, %v", progress, err)
+	}
+}
@@ -0,0 +1,30 @@
+package lfuda
+
+import "time"
+
+// WithLockMetrics records how long operations wait for the cache's lock and
+// hold the write lock, in the LockWaits, ReadLockWaits and LockHolds
+// histograms of Stats, to tell whether contention or the policy's work is
+// the bottleneck before sharding.  It costs two clock reads per lock.
+func WithLockMetrics() Option {
+	return func(o *options) {
+		o.lockMetrics = true
+	}
+}
+
+// micros returns d in microseconds.
+func micros(d time.Duration) float64 {
+	return float64(d) / float64(time.Microsecond)
+}
+
+// rlockGet takes the read lock for Get, recording the wait if lock metrics
+// are enabled.
+func (c *Cache) rlockGet() {
+	if !c.opts.lockMetrics {
+		c.lock.RLock()
+		return
+	}
+	start := time.Now()
+	c.lock.RLock()
+	c.stats.readLockWaits.add(micros(time.Since(start)))
+}
@@ -0,0 +1,22 @@
+package lfuda
+
+import "testing"
+
+func TestLockMetrics(t *testing.T) {
+	l := NewWithOptions(100, WithLockMetrics())
+	l.Set(1, 1)
+	l.Set(2, 2)
+	l.Get(1)
+	st := l.Stats()
+	if st.LockWaits.Count() != 2 || st.LockHolds.Count() != 2 || st.ReadLockWaits.Count() != 1 {
+		t.Errorf("bad lock metrics: %d waits, %d holds, %d read waits",
+			st.LockWaits.Count(), st.LockHolds.Count(), st.ReadLockWaits.Count())
+	}
+
+	plain := New(100)
+	plain.Set(1, 1)
+	plain.Get(1)
+	if st := plain.Stats(); st.LockWaits.Count() != 0 || st.LockHolds.Count() != 0 || st.ReadLockWaits.Count() != 0 {
+		t.Errorf("lock metrics recorded without the option")
+	}
+}
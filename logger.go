@@ -0,0 +1,16 @@
+package lfuda
+
+// Logger is the structured logger accepted by WithLogger.  Arguments are
+// alternating keys and values, so a *slog.Logger can be used directly.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+}
+
+func (c *Cache) debug(msg string, args ...interface{}) {
+	if c.opts.logger != nil {
+		if c.opts.recover != nil {
+			defer c.recoverPanic("logger")
+		}
+		c.opts.logger.Debug(msg, args...)
+	}
+}
@@ -0,0 +1,60 @@
+package lfuda
+
+import (
+	"testing"
+)
+
+type recordingLogger struct {
+	msgs []string
+}
+
+func (l *recordingLogger) Debug(msg string, args ...interface{}) {
+	if len(args)%2 != 0 {
+		panic("odd number of structured fields")
+	}
+	l.msgs = append(l.msgs, msg)
+}
+
+func TestLogger(t *testing.T) {
+	logger := &recordingLogger{}
+	l := NewWithOptions(2, WithPolicy(PolicyLFU), WithLogger(logger))
+
+	l.Set(1, 1)
+	l.Set(2, 2)
+	l.Set(3, 3)
+	l.Set("too big", "too big")
+	l.Remove(3)
+	l.Purge()
+
+	expected := []string{
+		"lfuda: entry evicted",
+		"lfuda: value too large for cache",
+		"lfuda: entry removed",
+		"lfuda: entry purged",
+		"lfuda: age reset",
+		"lfuda: purged",
+	}
+	if len(logger.msgs) != len(expected) {
+		t.Fatalf("unexpected log records: %q", logger.msgs)
+	}
+	for i, msg := range expected {
+		if logger.msgs[i] != msg {
+			t.Errorf("record %d: expected %q, got %q", i, msg, logger.msgs[i])
+		}
+	}
+}
+
+func TestWithPolicy(t *testing.T) {
+	l := NewWithOptions(10, WithPolicy(PolicyGDSF))
+	l.Set("a", "aaaaaaaa")
+	l.Set("b", "b")
+	for i := 0; i < 3; i++ {
+		l.Get("a")
+	}
+	l.Set("c", "cc")
+
+	// a is frequently used but large so GDSF evicts it first
+	if l.Contains("a") {
+		t.Errorf("GDSF policy should have evicted the large value")
+	}
+}
@@ -0,0 +1,153 @@
+// Package lrucompat adapts an lfuda cache to the API of
+// github.com/hashicorp/golang-lru, so code written against it can switch to
+// LFUDA eviction without touching its call sites.  The cache holds a number
+// of entries, and its "oldest" entry is the next one the policy evicts.
+package lrucompat
+
+import (
+	"errors"
+
+	lfuda "github.com/bparli/lfuda-go"
+)
+
+// LRUCache is the interface of golang-lru's simplelru.LRUCache.
+type LRUCache interface {
+	Add(key, value interface{}) bool
+	Get(key interface{}) (value interface{}, ok bool)
+	Contains(key interface{}) (ok bool)
+	Peek(key interface{}) (value interface{}, ok bool)
+	Remove(key interface{}) bool
+	RemoveOldest() (interface{}, interface{}, bool)
+	GetOldest() (interface{}, interface{}, bool)
+	Keys() []interface{}
+	Len() int
+	Purge()
+	Resize(int) int
+}
+
+var _ LRUCache = (*Cache)(nil)
+
+// Cache is a thread-safe cache of a fixed number of entries with the methods
+// of golang-lru's lru.Cache.
+type Cache struct {
+	cache *lfuda.Cache
+}
+
+// New creates a cache of the given number of entries.
+func New(size int) (*Cache, error) {
+	return NewWithEvict(size, nil)
+}
+
+// NewWithEvict creates a cache of the given number of entries, calling
+// onEvicted for every entry leaving it.
+func NewWithEvict(size int, onEvicted func(key, value interface{})) (*Cache, error) {
+	return NewWithOptions(size, lfuda.WithEvictCallback(onEvicted))
+}
+
+// NewWithOptions creates a cache of the given number of entries with the
+// options of lfuda.NewWithOptions, such as its policy.  Size funcs are
+// overridden, every entry counting as 1.
+func NewWithOptions(size int, opts ...lfuda.Option) (*Cache, error) {
+	if size <= 0 {
+		return nil, errors.New("must provide a positive size")
+	}
+	opts = append(opts[:len(opts):len(opts)], lfuda.WithSizeFunc(func(key, value interface{}) float64 {
+		return 1
+	}))
+	return &Cache{cache: lfuda.NewWithOptions(float64(size), opts...)}, nil
+}
+
+// Add adds a value to the cache.  Returns true if an eviction occurred.
+func (c *Cache) Add(key, value interface{}) (evicted bool) {
+	return c.cache.Set(key, value)
+}
+
+// Get looks up a key's value from the cache.
+func (c *Cache) Get(key interface{}) (value interface{}, ok bool) {
+	return c.cache.Get(key)
+}
+
+// Contains checks if a key is in the cache, without updating its hits.
+func (c *Cache) Contains(key interface{}) bool {
+	return c.cache.Contains(key)
+}
+
+// Peek returns the key's value without updating its hits.
+func (c *Cache) Peek(key interface{}) (value interface{}, ok bool) {
+	return c.cache.Peek(key)
+}
+
+// ContainsOrAdd checks if a key is in the cache without updating its hits,
+// adding the value if not.  Returns whether the key was found and whether an
+// eviction occurred.
+func (c *Cache) ContainsOrAdd(key, value interface{}) (ok, evicted bool) {
+	// the cache reports evictions as set
+	return c.cache.ContainsOrSet(key, value)
+}
+
+// PeekOrAdd returns the key's value without updating its hits if it is in
+// the cache, adding the value if not.  Returns the previous value, whether
+// it was found and whether an eviction occurred.
+func (c *Cache) PeekOrAdd(key, value interface{}) (previous interface{}, ok, evicted bool) {
+	return c.cache.PeekOrSet(key, value)
+}
+
+// Remove removes the provided key from the cache.  Returns true if it was
+// present.
+func (c *Cache) Remove(key interface{}) (present bool) {
+	return c.cache.Remove(key)
+}
+
+// Resize changes the number of entries the cache holds.  Returns the number
+// of entries evicted.
+func (c *Cache) Resize(size int) (evicted int) {
+	return c.cache.Resize(float64(size))
+}
+
+// RemoveOldest removes the entry the policy would evict next.
+func (c *Cache) RemoveOldest() (key, value interface{}, ok bool) {
+	for {
+		candidates := c.cache.PeekEvictionCandidates(1)
+		if len(candidates) == 0 {
+			return nil, nil, false
+		}
+		// retry if another goroutine removed it first
+		if c.cache.Remove(candidates[0].Key) {
+			return candidates[0].Key, candidates[0].Value, true
+		}
+	}
+}
+
+// GetOldest returns the entry the policy would evict next.
+func (c *Cache) GetOldest() (key, value interface{}, ok bool) {
+	candidates := c.cache.PeekEvictionCandidates(1)
+	if len(candidates) == 0 {
+		return nil, nil, false
+	}
+	return candidates[0].Key, candidates[0].Value, true
+}
+
+// Keys returns the keys in the cache, from the next to be evicted to the
+// last.
+func (c *Cache) Keys() []interface{} {
+	keys := c.cache.Keys()
+	for i, j := 0, len(keys)-1; i < j; i, j = i+1, j-1 {
+		keys[i], keys[j] = keys[j], keys[i]
+	}
+	return keys
+}
+
+// Len returns the number of items in the cache.
+func (c *Cache) Len() int {
+	return c.cache.Len()
+}
+
+// Purge is used to completely clear the cache.
+func (c *Cache) Purge() {
+	c.cache.Purge()
+}
+
+// Unwrap returns the underlying cache.
+func (c *Cache) Unwrap() *lfuda.Cache {
+	return c.cache
+}
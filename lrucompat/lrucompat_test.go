@@ -0,0 +1,64 @@
+package lrucompat
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	if _, err := New(0); err == nil {
+		t.Errorf("expected an error for a zero size")
+	}
+
+	var evicted []interface{}
+	l, err := NewWithEvict(3, func(key, value interface{}) {
+		evicted = append(evicted, key)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 3; i++ {
+		if l.Add(i, i*10) {
+			t.Errorf("unexpected eviction adding %d", i)
+		}
+	}
+	l.Get(1)
+	l.Get(1)
+	l.Get(3)
+	if !reflect.DeepEqual(l.Keys(), []interface{}{2, 3, 1}) {
+		t.Errorf("bad keys: %v", l.Keys())
+	}
+	if k, v, ok := l.GetOldest(); !ok || k != 2 || v != 20 {
+		t.Errorf("bad oldest: %v %v", k, v)
+	}
+	if !l.Add(4, 40) || !reflect.DeepEqual(evicted, []interface{}{2}) {
+		t.Errorf("bad eviction: %v", evicted)
+	}
+
+	if ok, evicted := l.ContainsOrAdd(4, 0); !ok || evicted {
+		t.Errorf("bad ContainsOrAdd of a cached key")
+	}
+	if prev, ok, evicted := l.PeekOrAdd(5, 50); ok || prev != nil || !evicted {
+		t.Errorf("bad PeekOrAdd of a new key")
+	}
+	if v, ok := l.Peek(5); !ok || v != 50 {
+		t.Errorf("bad value: %v", v)
+	}
+	if k, _, ok := l.RemoveOldest(); !ok || l.Contains(k) || l.Len() != 2 {
+		t.Errorf("bad RemoveOldest: %v", k)
+	}
+	if l.Resize(1) != 1 || l.Len() != 1 {
+		t.Errorf("bad resize: %d", l.Len())
+	}
+	if k := l.Keys()[0]; !l.Contains(k) || !l.Remove(k) || l.Remove(k) {
+		t.Errorf("bad removal")
+	}
+	l.Add(6, 60)
+	l.Purge()
+	if l.Len() != 0 {
+		t.Errorf("bad purge")
+	}
+	if _, _, ok := l.RemoveOldest(); ok {
+		t.Errorf("removed from an empty cache")
+	}
+}
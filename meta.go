@@ -0,0 +1,45 @@
+package lfuda
+
+// GetWithMeta is Get also returning the entry's metadata after the access:
+// its hits, size and priority under the cache's policy, which tells how
+// likely it is to stay cached.  Keys are not looked up in the tier.
+func (c *Cache) GetWithMeta(key interface{}) (value interface{}, meta EntryInfo, ok bool) {
+	if c.badKey(key) {
+		return
+	}
+	if c.forceMiss() {
+		c.reportGet(key, nil, false)
+		return
+	}
+	c.lockOp()
+	if value, ok = c.decodeHit(c.lfuda.Get(key)); ok {
+		meta, _ = c.lfuda.Info(key)
+	}
+	c.unlockOp()
+
+	if _, isNeg := value.(negativeEntry); ok && isNeg {
+		c.stats.negativeHits.Add(1)
+		return nil, EntryInfo{}, false
+	}
+	c.reportGet(key, value, ok)
+	return value, meta, ok
+}
+
+// Hits returns the key's hits without counting an access, e.g. for a
+// prefetcher reading popularity signals.  Hits recorded by concurrent Gets
+// are applied first.  Returns false if the key is not cached.
+func (c *Cache) Hits(key interface{}) (hits float64, ok bool) {
+	if c.badKey(key) {
+		return
+	}
+	c.flushReads()
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if value, _ := c.lfuda.Peek(key); value != nil {
+		if _, isNeg := value.(negativeEntry); isNeg {
+			return 0, false
+		}
+	}
+	info, ok := c.lfuda.Info(key)
+	return info.Hits, ok
+}
@@ -0,0 +1,49 @@
+package lfuda
+
+import "testing"
+
+func TestGetWithMeta(t *testing.T) {
+	l := New(100)
+	l.SetWithSize("a", 1, 4)
+	l.Get("a")
+
+	v, meta, ok := l.GetWithMeta("a")
+	if !ok || v != 1 || meta.Key != "a" || meta.Size != 4 {
+		t.Fatalf("bad value or metadata: %v, %+v", v, meta)
+	}
+	if meta.Hits != 3 || meta.Priority != meta.Hits+l.Age() {
+		t.Errorf("metadata should include every access: %+v", meta)
+	}
+	if _, _, ok := l.GetWithMeta("b"); ok {
+		t.Errorf("b was never set")
+	}
+	if st := l.Stats(); st.Hits != 2 || st.Misses != 1 {
+		t.Errorf("GetWithMeta should count as a Get: %+v", st)
+	}
+
+	g := NewGDSF(100)
+	g.SetWithSize("a", 1, 4)
+	if _, meta, _ := g.GetWithMeta("a"); meta.Priority != meta.Hits/4 {
+		t.Errorf("priority should follow the policy: %+v", meta)
+	}
+}
+
+func TestHits(t *testing.T) {
+	l := New(10)
+	l.Set("a", 1)
+	l.Get("a")
+	l.Get("a")
+	if hits, ok := l.Hits("a"); !ok || hits != 3 {
+		t.Errorf("a should have 3 hits: %v %v", hits, ok)
+	}
+	if hits, _ := l.Hits("a"); hits != 3 {
+		t.Errorf("Hits should not count an access: %v", hits)
+	}
+	l.SetNegative("missing", 0)
+	if _, ok := l.Hits("missing"); ok {
+		t.Errorf("negative entries have no hits")
+	}
+	if _, ok := l.Hits("b"); ok {
+		t.Errorf("b is not cached")
+	}
+}
@@ -0,0 +1,152 @@
+package lfuda
+
+import (
+	"time"
+)
+
+// NamespaceKey is the key under which a Namespace stores its entries in the
+// parent cache, as seen by eviction callbacks, hooks and the parent's Keys.
+type NamespaceKey struct {
+	Namespace string
+	Key       interface{}
+}
+
+// Namespace is a logical cache with its own key space and stats, sharing the
+// byte budget and aging clock of its parent cache.  Entries of all namespaces
+// compete for space under the parent's policy.
+type Namespace struct {
+	c     *Cache
+	name  string
+	stats stats
+}
+
+// Namespace returns the namespace with the given name, creating it on first
+// use.  Every call with the same name returns the same Namespace.
+func (c *Cache) Namespace(name string) *Namespace {
+	if ns, ok := c.namespaces.Load(name); ok {
+		return ns.(*Namespace)
+	}
+	ns, _ := c.namespaces.LoadOrStore(name, &Namespace{c: c, name: name})
+	return ns.(*Namespace)
+}
+
+// Name returns the namespace's name.
+func (n *Namespace) Name() string {
+	return n.name
+}
+
+func (n *Namespace) key(key interface{}) NamespaceKey {
+	return NamespaceKey{Namespace: n.name, Key: key}
+}
+
+// Set adds a value to the namespace.  Returns true if an eviction occurred.
+func (n *Namespace) Set(key, value interface{}) bool {
+	return n.c.Set(n.key(key), value)
+}
+
+// SetWithSize adds a value to the namespace, accounting for it as size
+// bytes.  Returns true if an eviction occurred.
+func (n *Namespace) SetWithSize(key, value interface{}, size float64) bool {
+	return n.c.SetWithSize(n.key(key), value, size)
+}
+
+// SetWithTTL adds a value to the namespace that expires after ttl.  Returns
+// true if an eviction occurred.
+func (n *Namespace) SetWithTTL(key, value interface{}, ttl time.Duration) bool {
+	return n.c.SetWithTTL(n.key(key), value, ttl)
+}
+
+// Get looks up a key's value from the namespace.
+func (n *Namespace) Get(key interface{}) (interface{}, bool) {
+	value, ok := n.c.Get(n.key(key))
+	if ok {
+		n.stats.hits.Add(1)
+	} else {
+		n.stats.misses.Add(1)
+	}
+	return value, ok
+}
+
+// Peek returns the key value without updating its hits.
+func (n *Namespace) Peek(key interface{}) (interface{}, bool) {
+	return n.c.Peek(n.key(key))
+}
+
+// Contains checks if a key is in the namespace without updating its hits.
+func (n *Namespace) Contains(key interface{}) bool {
+	return n.c.Contains(n.key(key))
+}
+
+// Remove removes the provided key from the namespace.
+func (n *Namespace) Remove(key interface{}) bool {
+	return n.c.Remove(n.key(key))
+}
+
+// Keys returns the keys in the namespace, from most to least valuable.
+func (n *Namespace) Keys() []interface{} {
+	var keys []interface{}
+	n.rangeEntries(func(info EntryInfo) {
+		keys = append(keys, info.Key.(NamespaceKey).Key)
+	})
+	return keys
+}
+
+// Len returns the number of entries in the namespace.
+func (n *Namespace) Len() int {
+	length := 0
+	n.rangeEntries(func(info EntryInfo) {
+		length++
+	})
+	return length
+}
+
+// Size returns the number of bytes used by the namespace's entries.
+func (n *Namespace) Size() float64 {
+	var size float64
+	n.rangeEntries(func(info EntryInfo) {
+		size += info.Size
+	})
+	return size
+}
+
+// Stats returns the hits and misses of the namespace's Gets.
+func (n *Namespace) Stats() Stats {
+	return n.stats.snapshot()
+}
+
+// ResetStats zeroes the namespace's counters.
+func (n *Namespace) ResetStats() {
+	n.stats.reset()
+}
+
+// Purge removes every entry of the namespace, leaving other namespaces and
+// the cache age untouched.
+func (n *Namespace) Purge() {
+	c := n.c
+	c.lockOp()
+	c.opReason = reasonPurged
+	var keys []interface{}
+	c.lfuda.Range(func(info EntryInfo) bool {
+		if k, ok := info.Key.(NamespaceKey); ok && k.Namespace == n.name {
+			keys = append(keys, k)
+		}
+		return true
+	})
+	for _, k := range keys {
+		c.lfuda.Remove(k)
+	}
+	c.writes++
+	c.unlockOp()
+
+	c.debug("lfuda: purged namespace", "namespace", n.name, "len", len(keys))
+}
+
+// rangeEntries calls fn for every entry of the namespace.
+func (n *Namespace) rangeEntries(fn func(info EntryInfo)) {
+	n.c.Range(func(info EntryInfo) bool {
+		if k, ok := info.Key.(NamespaceKey); ok && k.Namespace == n.name {
+			fn(info)
+		}
+		return true
+	})
+}
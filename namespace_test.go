@@ -0,0 +1,70 @@
+package lfuda
+
+import (
+	"testing"
+)
+
+func TestNamespace(t *testing.T) {
+	l := New(100)
+	a := l.Namespace("a")
+	b := l.Namespace("b")
+	if l.Namespace("a") != a {
+		t.Errorf("namespaces should be reused by name")
+	}
+
+	a.Set(1, "a1")
+	a.Set(2, "a2")
+	b.Set(1, "b1")
+	l.Set(1, "root")
+
+	if v, ok := a.Get(1); !ok || v != "a1" {
+		t.Errorf("bad value in a: %v", v)
+	}
+	if v, ok := b.Get(1); !ok || v != "b1" {
+		t.Errorf("bad value in b: %v", v)
+	}
+	if v, _ := l.Peek(1); v != "root" {
+		t.Errorf("namespaces should not shadow the parent's keys: %v", v)
+	}
+	b.Get(2)
+
+	if a.Len() != 2 || a.Size() != 4 || b.Len() != 1 || l.Len() != 4 {
+		t.Errorf("bad lengths: a %d (%f bytes), b %d, total %d", a.Len(), a.Size(), b.Len(), l.Len())
+	}
+	if keys := a.Keys(); keys[0] != 1 || len(keys) != 2 {
+		t.Errorf("bad keys: %v", keys)
+	}
+	if st := b.Stats(); st.Hits != 1 || st.Misses != 1 {
+		t.Errorf("bad namespace stats: %+v", st)
+	}
+
+	a.Purge()
+	if a.Len() != 0 || a.Contains(2) {
+		t.Errorf("namespace should be empty")
+	}
+	if !b.Contains(1) || !l.Contains(1) {
+		t.Errorf("other namespaces should survive a purge")
+	}
+	if !b.Remove(1) || b.Len() != 0 {
+		t.Errorf("1 should have been removed from b")
+	}
+}
+
+func TestNamespaceSharedBudget(t *testing.T) {
+	l := New(4)
+	a := l.Namespace("a")
+	b := l.Namespace("b")
+	a.Set(1, 1)
+	a.Get(1)
+	a.Set(2, 2)
+	a.Get(2)
+	b.Set(1, 1)
+	b.Set(2, 2)
+	b.Set(3, 3)
+	if l.Size() > 4 {
+		t.Errorf("namespaces should share the budget: %f", l.Size())
+	}
+	if !a.Contains(1) || !a.Contains(2) {
+		t.Errorf("hot entries of a should have been kept")
+	}
+}
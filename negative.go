@@ -0,0 +1,72 @@
+package lfuda
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNegativeHit is matched by the error Lookup returns for keys recorded as
+// missing by SetNegative or Cached.
+var ErrNegativeHit = errors.New("lfuda: key known to be missing")
+
+// negativeEntry is cached in place of a value known to be missing, along
+// with the error the origin reported, if any.
+type negativeEntry struct {
+	err error
+}
+
+func (e negativeEntry) error() error {
+	return &negativeHitError{err: e.err}
+}
+
+// negativeHitError matches ErrNegativeHit and wraps the origin's error.
+type negativeHitError struct {
+	err error
+}
+
+func (e *negativeHitError) Error() string {
+	if e.err == nil {
+		return ErrNegativeHit.Error()
+	}
+	return e.err.Error()
+}
+
+func (e *negativeHitError) Unwrap() error {
+	return e.err
+}
+
+func (e *negativeHitError) Is(target error) bool {
+	return target == ErrNegativeHit
+}
+
+// present drops negative entries from a lookup's result, for reads that
+// report them as absent.
+func present(value interface{}, ok bool) (interface{}, bool) {
+	if _, isNeg := value.(negativeEntry); isNeg {
+		return nil, false
+	}
+	return value, ok
+}
+
+// negativeSize is the size accounted for a negative entry.
+const negativeSize = 1
+
+// SetNegative records that key is known to be missing at the origin for
+// ttl, or until removed or overwritten if ttl is not positive, so that
+// repeated lookups of nonexistent objects can be answered by the cache.
+// Returns true if an eviction occurred.
+func (c *Cache) SetNegative(key interface{}, ttl time.Duration) bool {
+	return c.setNegative(key, nil, ttl)
+}
+
+// setNegative stores a negative entry carrying the origin's error.
+func (c *Cache) setNegative(key interface{}, err error, ttl time.Duration) (ok bool) {
+	if c.badKey(key) || c.dropSet() {
+		return
+	}
+	c.lockOp()
+	ok = c.setWithSize(key, negativeEntry{err: err}, negativeSize)
+	c.lfuda.Expire(key, ttl)
+	c.unlockOp()
+	return ok
+}
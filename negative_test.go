@@ -0,0 +1,113 @@
+package lfuda
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSetNegative(t *testing.T) {
+	l := New(100)
+	l.SetNegative("missing", time.Hour)
+	l.Set("present", 1)
+
+	if _, ok := l.Get("missing"); ok {
+		t.Errorf("negative entry should not be found by Get")
+	}
+	if _, ok, err := l.Lookup("missing"); ok || !errors.Is(err, ErrNegativeHit) {
+		t.Errorf("lookup should report the negative hit: %v", err)
+	}
+	if v, ok, err := l.Lookup("present"); !ok || err != nil || v != 1 {
+		t.Errorf("lookup should find present keys: %v, %v", v, err)
+	}
+	if _, ok, err := l.Lookup("unknown"); ok || err != nil {
+		t.Errorf("lookup should miss unknown keys: %v", err)
+	}
+	if _, err := l.GetOrLoad(context.Background(), "missing", nil); !errors.Is(err, ErrNegativeHit) {
+		t.Errorf("GetOrLoad should not load keys known to be missing: %v", err)
+	}
+	if st := l.Stats(); st.NegativeHits != 3 || st.Hits != 1 || st.Misses != 1 {
+		t.Errorf("bad stats: %+v", st)
+	}
+
+	// overwriting replaces the negative entry
+	l.Set("missing", 2)
+	if v, ok := l.Get("missing"); !ok || v != 2 {
+		t.Errorf("negative entry should have been overwritten: %v", v)
+	}
+
+	l.SetNegative("short", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if _, _, err := l.Lookup("short"); err != nil {
+		t.Errorf("negative entry should have expired: %v", err)
+	}
+}
+
+func TestNegativeAbsent(t *testing.T) {
+	l := New(100)
+	l.SetNegative("a", 0)
+	if v, ok := l.Peek("a"); ok {
+		t.Errorf("Peek should not find negative entries: %v", v)
+	}
+	if prev, ok, _ := l.PeekOrSet("a", 1); ok || prev != nil {
+		t.Errorf("PeekOrSet should not find negative entries: %v", prev)
+	}
+	if v, _ := l.Peek("a"); v != 1 {
+		t.Errorf("PeekOrSet should replace negative entries: %v", v)
+	}
+
+	l.SetNegative("b", 0)
+	var drained []interface{}
+	l.Drain(func(key, value interface{}) error {
+		drained = append(drained, key)
+		return nil
+	}, DrainOptions{})
+	if len(drained) != 1 || drained[0] != "a" || l.Len() != 0 {
+		t.Errorf("Drain should drop negative entries: %v", drained)
+	}
+}
+
+func TestNegativeIncrement(t *testing.T) {
+	l := New(100)
+	l.SetNegative("a", 0)
+	if n, err := l.Increment("a", 2); err != nil || n != 2 {
+		t.Errorf("Increment should treat negative entries as absent: %d %v", n, err)
+	}
+	l.SetNegative("b", 0)
+	if f, err := l.IncrementFloat("b", 1.5); err != nil || f != 1.5 {
+		t.Errorf("IncrementFloat should treat negative entries as absent: %f %v", f, err)
+	}
+	if v, _ := l.Peek("a"); v != int64(2) {
+		t.Errorf("Increment should replace negative entries: %v", v)
+	}
+}
+
+func TestNegativeEncoded(t *testing.T) {
+	for name, codec := range map[string]Codec{"gob": GobCodec, "json": JSONCodec(nil)} {
+		for _, opt := range []Option{
+			WithCompression(GzipCompressor(gzip.BestSpeed), 0),
+			WithEncryption(newAEAD(t, "0123456789abcdef")),
+		} {
+			l := NewWithOptions(100, WithCodec(codec), opt)
+			l.SetNegative("missing", 0)
+			if v, ok, err := l.Lookup("missing"); ok || !errors.Is(err, ErrNegativeHit) {
+				t.Errorf("%s: negative entry should be a negative hit: %v, %v", name, v, err)
+			}
+		}
+	}
+}
+
+func TestCachedNegativeHit(t *testing.T) {
+	l := New(100)
+	Cached(l, "k", 0, func() (int, error) {
+		return 0, ErrNotFound
+	})
+	_, err := Cached(l, "k", 0, func() (int, error) {
+		return 1, nil
+	})
+	if !errors.Is(err, ErrNotFound) || !errors.Is(err, ErrNegativeHit) {
+		t.Errorf("negative entry from Cached should match both errors: %v", err)
+	}
+}
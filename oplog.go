@@ -0,0 +1,135 @@
+package lfuda
+
+import (
+	"encoding/gob"
+	"errors"
+	"io"
+	"sort"
+	"sync"
+)
+
+type opKind byte
+
+const (
+	opSet opKind = iota
+	opHit
+	opEvict
+)
+
+type opRecord struct {
+	Op  opKind
+	Key interface{}
+}
+
+// OpLog records the keys of a cache's operations, without their values, so
+// that its hot set can be rebuilt after a crash with Rebuild.  Keys are gob
+// encoded, so custom key types must be registered with gob.Register.
+type OpLog struct {
+	mu      sync.Mutex
+	enc     *gob.Encoder
+	err     error
+	removes []func()
+}
+
+// RecordOps starts recording the operations of the cache to w until the
+// returned OpLog is closed.
+func (c *Cache) RecordOps(w io.Writer) *OpLog {
+	l := &OpLog{enc: gob.NewEncoder(w)}
+	l.removes = []func(){
+		c.OnSet(func(key, value interface{}) { l.record(opSet, key) }),
+		c.OnHit(func(key, value interface{}) { l.record(opHit, key) }),
+		c.OnEvict(func(key, value interface{}) { l.record(opEvict, key) }),
+	}
+	return l
+}
+
+func (l *OpLog) record(op opKind, key interface{}) {
+	l.mu.Lock()
+	if l.err == nil {
+		l.err = l.enc.Encode(opRecord{Op: op, Key: key})
+	}
+	l.mu.Unlock()
+}
+
+// Err returns the first error encountered while writing the log.
+func (l *OpLog) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+// Close stops recording and returns the first error encountered while
+// writing the log.
+func (l *OpLog) Close() error {
+	for _, remove := range l.removes {
+		remove()
+	}
+	return l.Err()
+}
+
+// HotKey is a key recovered from an operation log.
+type HotKey struct {
+	Key  interface{}
+	Hits float64
+}
+
+// ReadOpLog replays a log written by an OpLog and returns the keys that were
+// still cached when it ends, hottest first.  A log truncated by a crash is
+// read up to its last complete record.
+func ReadOpLog(r io.Reader) ([]HotKey, error) {
+	dec := gob.NewDecoder(r)
+	hits := make(map[interface{}]float64)
+	for {
+		var rec opRecord
+		err := dec.Decode(&rec)
+		if err == io.EOF || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch rec.Op {
+		case opSet, opHit:
+			hits[rec.Key]++
+		case opEvict:
+			delete(hits, rec.Key)
+		}
+	}
+
+	keys := make([]HotKey, 0, len(hits))
+	for k, h := range hits {
+		keys = append(keys, HotKey{Key: k, Hits: h})
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].Hits > keys[j].Hits
+	})
+	return keys, nil
+}
+
+// Rebuild restores the hot set recorded in the operation log r.  The value of
+// each key is fetched with loader, hottest first, and its hit count restored.
+// Loading stops once the cache is full; keys that fail to load are skipped.
+// Rebuild blocks until done, so run it in its own goroutine to refill the
+// cache in the background.  Returns the number of keys loaded.
+func (c *Cache) Rebuild(r io.Reader, loader func(key interface{}) (interface{}, error)) (loaded int, err error) {
+	keys, err := ReadOpLog(r)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, k := range keys {
+		value, err := loader(k.Key)
+		if err != nil {
+			continue
+		}
+		evicted := c.Set(k.Key, value)
+		if c.Boost(k.Key, k.Hits-1) {
+			loaded++
+		}
+		if evicted {
+			break
+		}
+	}
+	return loaded, nil
+}
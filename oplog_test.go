@@ -0,0 +1,78 @@
+package lfuda
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestOpLog(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(3)
+	log := l.RecordOps(&buf)
+
+	l.Set("a", "a")
+	l.Set("b", "b")
+	l.Set("c", "c")
+	for i := 0; i < 3; i++ {
+		l.Get("a")
+	}
+	l.Get("c")
+	l.Set(1, 1)
+	l.Remove(1)
+	if err := log.Close(); err != nil {
+		t.Fatal(err)
+	}
+	l.Set("d", "d")
+
+	keys, err := ReadOpLog(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 {
+		t.Fatalf("expected the 2 keys cached when the log ended: %v", keys)
+	}
+	if keys[0].Key != "a" || keys[0].Hits != 4 || keys[1].Key != "c" || keys[1].Hits != 2 {
+		t.Errorf("bad hot keys: %v", keys)
+	}
+
+	// a truncated log is read up to its last complete record
+	keys, err = ReadOpLog(bytes.NewReader(buf.Bytes()[:buf.Len()-3]))
+	if err != nil || len(keys) == 0 {
+		t.Errorf("truncated log should be readable: %v, %v", keys, err)
+	}
+}
+
+func TestRebuild(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(2)
+	log := l.RecordOps(&buf)
+	l.Set("a", "a")
+	l.Set("b", "b")
+	l.Get("a")
+	l.Set("c", "c")
+	l.Get("c")
+	l.Get("c")
+	log.Close()
+
+	r := New(2)
+	loaded, err := r.Rebuild(&buf, func(key interface{}) (interface{}, error) {
+		if key == "a" {
+			return nil, errors.New("gone")
+		}
+		return key, nil
+	})
+	if err != nil || loaded != 1 {
+		t.Fatalf("bad rebuild: %d, %v", loaded, err)
+	}
+	if v, ok := r.Peek("c"); !ok || v != "c" {
+		t.Errorf("c should have been reloaded")
+	}
+
+	// restored hits keep c ahead of new keys
+	r.Set("x", "x")
+	r.Set("y", "y")
+	if !r.Contains("c") {
+		t.Errorf("rebuilt key should have kept its hits")
+	}
+}
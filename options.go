@@ -0,0 +1,265 @@
+package lfuda
+
+import (
+	"crypto/cipher"
+	"time"
+
+	"github.com/bparli/lfuda-go/simplelfuda"
+)
+
+// Policy names accepted by WithPolicy.
+const (
+	PolicyLFUDA = "LFUDA"
+	PolicyGDSF  = "GDSF"
+	PolicyLFU   = "LFU"
+	// PolicyHybrid divides hits by the size to the power of the alpha set
+	// by WithHybridAlpha, between PolicyLFUDA (0) and PolicyGDSF (1).
+	PolicyHybrid = "Hybrid"
+)
+
+// Option configures a cache built with NewWithOptions.
+type Option func(*options)
+
+type options struct {
+	policy      string
+	onEvicted   func(key interface{}, value interface{})
+	onExpired   func(key interface{}, value interface{})
+	logger      Logger
+	shards      int
+	hasher      Hasher
+	sizeFunc    SizeFunc
+	deepSize    bool
+	keySize     bool
+	overhead    float64
+	maxItems    int
+	ttlFunc     TTLFunc
+	costFunc    CostFunc
+	aging       Aging
+	alpha       float64
+	maxHits     float64
+	halving     time.Duration
+	softLimit   float64
+	headroom    float64
+	hitWindow   bool
+	accessTimes bool
+	lockMetrics bool
+
+	weakValues bool
+	weakSize   float64
+
+	compressor        Compressor
+	compressThreshold int
+	aead              cipher.AEAD
+	codec             Codec
+
+	prefixIndex bool
+
+	tier           Tier
+	tierAttempts   int
+	tierBackoff    time.Duration
+	readAfterWrite bool
+
+	callbackWorkers int
+	callbackQueue   int
+	valuePool       ValuePool
+
+	setQueue     int
+	setQueueFull QueueFull
+
+	copyOnSet bool
+	setCopy   CopyFunc
+	copyOnGet bool
+	getCopy   CopyFunc
+
+	recover func(op string, r interface{})
+	faults  *Faults
+}
+
+// SizeFunc returns the size in bytes to account for an entry.
+type SizeFunc func(key, value interface{}) float64
+
+// TTLFunc returns how long an entry lives, or a non positive duration if it
+// never expires.
+type TTLFunc func(key, value interface{}) time.Duration
+
+// CostFunc returns the cost of regenerating an entry, such as the latency or
+// compute time of fetching it from the origin.
+type CostFunc func(key, value interface{}) float64
+
+// WithPolicy selects the cache policy, one of PolicyLFUDA (the default),
+// PolicyGDSF, PolicyLFU or PolicyHybrid.
+func WithPolicy(policy string) Option {
+	return func(o *options) {
+		o.policy = policy
+	}
+}
+
+// WithEvictCallback sets a callback invoked for every entry leaving the
+// cache.  It runs once the cache lock is released, so it may call back into
+// the cache, e.g. to set a tombstone, but other operations may have changed
+// the cache in between.  It runs before the operation removing the entry
+// returns, on its goroutine, unless WithAsyncEvictCallback is set.
+func WithEvictCallback(onEvicted func(key interface{}, value interface{})) Option {
+	return func(o *options) {
+		o.onEvicted = onEvicted
+	}
+}
+
+// WithExpireCallback sets a callback invoked instead of the eviction
+// callback for entries removed because they expired, e.g. to revalidate them
+// rather than discard them.  It runs like the eviction callback, including on
+// the pool set by WithAsyncEvictCallback.
+func WithExpireCallback(onExpired func(key interface{}, value interface{})) Option {
+	return func(o *options) {
+		o.onExpired = onExpired
+	}
+}
+
+// WithLogger sets a logger receiving debug level records of evictions, age
+// resets, oversized values and purges.
+func WithLogger(logger Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithShards sets the number of shards of a ShardedCache.  It is ignored by
+// NewWithOptions.
+func WithShards(n int) Option {
+	return func(o *options) {
+		o.shards = n
+	}
+}
+
+// WithSizeFunc sets the function computing the size of entries.  By default
+// the size is the length of []byte values and of the default format of other
+// values.
+func WithSizeFunc(sizeFunc SizeFunc) Option {
+	return func(o *options) {
+		o.sizeFunc = sizeFunc
+	}
+}
+
+// WithDeepSize estimates the size of values with EstimateSize when no
+// SizeFunc is set, so that the size of structs, slices and maps reflects the
+// memory they hold.  Estimating walks the whole value on every Set.
+func WithDeepSize() Option {
+	return func(o *options) {
+		o.deepSize = true
+	}
+}
+
+// WithMaxEntries limits the number of entries besides their total size.  When
+// either limit would be exceeded entries are evicted by policy.  A
+// ShardedCache splits the limit between its shards.
+func WithMaxEntries(n int) Option {
+	return func(o *options) {
+		o.maxItems = n
+	}
+}
+
+// WithTTLFunc sets the function computing the TTL of every value stored by
+// Set and its variants, so expiry can be derived from the value itself.
+// SetWithTTL overrides it.
+func WithTTLFunc(ttlFunc TTLFunc) Option {
+	return func(o *options) {
+		o.ttlFunc = ttlFunc
+	}
+}
+
+// WithCostFunc sets the function computing the cost of every value stored
+// by Set and its variants.  Under PolicyGDSF an entry's priority is its hits
+// times its cost divided by its size, so expensive to regenerate entries are
+// kept over cheap ones rather than assuming cost follows size.  Other
+// policies ignore costs.
+func WithCostFunc(costFunc CostFunc) Option {
+	return func(o *options) {
+		o.costFunc = costFunc
+	}
+}
+
+// WithHybridAlpha sets the exponent of the entry size under PolicyHybrid,
+// where an entry's priority is its hits divided by its size to the power of
+// alpha, plus the age.  0 weighs entries like PolicyLFUDA, 1 like PolicyGDSF,
+// and values in between favor small entries less than GDSF does.
+func WithHybridAlpha(alpha float64) Option {
+	return func(o *options) {
+		o.alpha = alpha
+	}
+}
+
+// Aging tunes how the cache age follows evictions, see WithAging.
+type Aging = simplelfuda.Aging
+
+// WithAging sets how the cache age follows evictions.  By default it jumps
+// to the priority of every evicted entry; a Factor below 1 or a Damping
+// slows aging down, so older popular entries decay more slowly relative to
+// new ones.  PolicyLFU ignores the age.
+func WithAging(aging Aging) Option {
+	return func(o *options) {
+		o.aging = aging
+	}
+}
+
+// EntryOverhead approximates the bytes of bookkeeping the cache holds for
+// every entry besides its key and value, for use with WithEntryOverhead.
+const EntryOverhead = 128
+
+// WithKeySize adds the size of keys to the size of entries, measured like
+// values are when no SizeFunc is set.
+func WithKeySize() Option {
+	return func(o *options) {
+		o.keySize = true
+	}
+}
+
+// WithEntryOverhead adds a fixed number of bytes to the size of every entry,
+// so that the cache size tracks memory use rather than only payloads.
+func WithEntryOverhead(bytes float64) Option {
+	return func(o *options) {
+		o.overhead = bytes
+	}
+}
+
+// WithTier backs the cache with a slower tier: evicted entries are written to
+// it in the background and Gets missing the cache are looked up in it.  The
+// cache must be closed to stop the writer.
+func WithTier(tier Tier) Option {
+	return func(o *options) {
+		o.tier = tier
+	}
+}
+
+// WithTierRetry sets how many times writing an evicted entry to the tier is
+// attempted before dropping it, and the delay before the first retry, which
+// doubles with every attempt.  Defaults to 5 attempts and 100ms.
+func WithTierRetry(maxAttempts int, backoff time.Duration) Option {
+	return func(o *options) {
+		o.tierAttempts = maxAttempts
+		o.tierBackoff = backoff
+	}
+}
+
+// WithReadAfterWrite guarantees that once a Set or Remove returns, Gets
+// observe it even after the entry left the cache for its tier.  Values are
+// written through to the tier, and removed and expired keys deleted from it,
+// before the cache lock is released, so writes pay the tier's latency.
+// Values read back from the tier never overwrite newer writes.  It has no
+// effect without WithTier.
+func WithReadAfterWrite() Option {
+	return func(o *options) {
+		o.readAfterWrite = true
+	}
+}
+
+// WithRecover recovers panics raised by size, TTL and fetch functions,
+// eviction callbacks, hooks, loggers, tiers and unhashable keys, and passes
+// them to handler with the name of the failing operation instead of crashing
+// the program or leaving the cache locked.  The operation is abandoned:
+// fetches and tier reads fail with a *PanicError and sets of such values or
+// keys are dropped.  Recovered panics are counted in Stats.Panics.
+func WithRecover(handler func(op string, r interface{})) Option {
+	return func(o *options) {
+		o.recover = handler
+	}
+}
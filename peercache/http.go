@@ -0,0 +1,145 @@
+package peercache
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/bparli/lfuda-go/internal/ring"
+)
+
+// DefaultBasePath is the path an HTTPPool serves peers under.
+const DefaultBasePath = "/_peercache/"
+
+// HTTPPool is a PeerPicker whose peers are reached over HTTP, and the
+// http.Handler answering them.
+type HTTPPool struct {
+	// Replicas is the number of points each peer gets on the hash ring,
+	// ring.DefaultReplicas if not positive.  Changes apply on the next Set.
+	Replicas int
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+
+	self     string
+	basePath string
+
+	mu      sync.RWMutex
+	ring    *ring.Ring
+	getters map[string]*httpGetter
+	groups  map[string]*Group
+}
+
+// NewHTTPPool creates a pool for the process reachable at the base URL self,
+// such as "http://10.0.0.1:8080".  The pool should be served at
+// DefaultBasePath.
+func NewHTTPPool(self string) *HTTPPool {
+	return &HTTPPool{
+		self:     self,
+		basePath: DefaultBasePath,
+		ring:     ring.New(0),
+		groups:   make(map[string]*Group),
+	}
+}
+
+// Set replaces the peers, given as base URLs.  It should include self.
+func (p *HTTPPool) Set(peers ...string) {
+	r := ring.New(p.Replicas)
+	r.Add(peers...)
+	getters := make(map[string]*httpGetter, len(peers))
+	for _, peer := range peers {
+		getters[peer] = &httpGetter{pool: p, baseURL: strings.TrimSuffix(peer, "/") + p.basePath}
+	}
+	p.mu.Lock()
+	p.ring, p.getters = r, getters
+	p.mu.Unlock()
+}
+
+// PickPeer returns the peer owning key, or false if it is self.
+func (p *HTTPPool) PickPeer(key string) (ProtoGetter, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	peer := p.ring.Get(key)
+	if peer == "" || peer == p.self {
+		return nil, false
+	}
+	return p.getters[peer], true
+}
+
+// NewGroup creates a group using the pool's peers and serves it to them.
+func (p *HTTPPool) NewGroup(name string, size float64, getter Getter) *Group {
+	g := NewGroup(name, size, getter, p)
+	p.mu.Lock()
+	p.groups[name] = g
+	p.mu.Unlock()
+	return g
+}
+
+// ServeHTTP answers GET basePath/group/key with the value of key, loaded
+// locally on a miss.
+func (p *HTTPPool) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	path := r.URL.EscapedPath()
+	if !strings.HasPrefix(path, p.basePath) {
+		http.NotFound(w, r)
+		return
+	}
+	parts := strings.SplitN(path[len(p.basePath):], "/", 2)
+	if len(parts) != 2 {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	name, err1 := url.PathUnescape(parts[0])
+	key, err2 := url.PathUnescape(parts[1])
+	if err1 != nil || err2 != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	p.mu.RLock()
+	g := p.groups[name]
+	p.mu.RUnlock()
+	if g == nil {
+		http.Error(w, "no such group: "+name, http.StatusNotFound)
+		return
+	}
+	b, err := g.getLocal(r.Context(), key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(b)
+}
+
+type httpGetter struct {
+	pool    *HTTPPool
+	baseURL string
+}
+
+func (h *httpGetter) Get(ctx context.Context, group, key string) ([]byte, error) {
+	u := h.baseURL + url.PathEscape(group) + "/" + url.PathEscape(key)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := h.pool.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("peercache: %s returned %s", u, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,153 @@
+// Package peercache fills cache misses from peer processes, mirroring
+// groupcache's Getter and peer model but evicting with LFUDA instead of LRU.
+// Every key is owned by one peer, picked by consistent hashing.  A miss for
+// a key owned by another peer is fetched from it, and only the owner loads
+// the key with the Getter, so a fleet loads each key from the origin once.
+//
+//	pool := peercache.NewHTTPPool("http://10.0.0.1:8080")
+//	pool.Set("http://10.0.0.1:8080", "http://10.0.0.2:8080")
+//	http.Handle(peercache.DefaultBasePath, pool)
+//	thumbs := pool.NewGroup("thumbs", 64<<20, peercache.GetterFunc(load))
+//	b, err := thumbs.Get(ctx, "photo.jpg")
+package peercache
+
+import (
+	"context"
+	"sync/atomic"
+
+	lfuda "github.com/bparli/lfuda-go"
+)
+
+// Getter loads the value of a key from the origin.
+type Getter interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+// GetterFunc adapts a function to a Getter.
+type GetterFunc func(ctx context.Context, key string) ([]byte, error)
+
+// Get calls f.
+func (f GetterFunc) Get(ctx context.Context, key string) ([]byte, error) {
+	return f(ctx, key)
+}
+
+// PeerPicker picks the peer owning a key.
+type PeerPicker interface {
+	// PickPeer returns the peer owning key, or false if it is owned by the
+	// current process.
+	PickPeer(key string) (ProtoGetter, bool)
+}
+
+// ProtoGetter fetches a key of a group from a peer.
+type ProtoGetter interface {
+	Get(ctx context.Context, group, key string) ([]byte, error)
+}
+
+// Stats are a group's counters.
+type Stats struct {
+	Gets       uint64 `json:"gets"`
+	CacheHits  uint64 `json:"cache_hits"`
+	PeerLoads  uint64 `json:"peer_loads"`
+	PeerErrors uint64 `json:"peer_errors"`
+	LocalLoads uint64 `json:"local_loads"`
+}
+
+// Group is a cache namespace whose misses are filled from peers or its
+// Getter.
+type Group struct {
+	name   string
+	getter Getter
+	peers  PeerPicker
+	// main holds the keys owned by this process and hot those owned by
+	// peers, so popular remote keys don't cross the network on every get.
+	main *lfuda.Cache
+	hot  *lfuda.Cache
+
+	gets, hits, peerLoads, peerErrors, localLoads atomic.Uint64
+}
+
+// NewGroup creates a group caching up to size bytes, an eighth of which
+// holds keys owned by peers.  A nil peers loads every key locally.
+func NewGroup(name string, size float64, getter Getter, peers PeerPicker) *Group {
+	return &Group{
+		name:   name,
+		getter: getter,
+		peers:  peers,
+		main:   lfuda.New(size - size/8),
+		hot:    lfuda.New(size / 8),
+	}
+}
+
+// Name returns the group's name.
+func (g *Group) Name() string {
+	return g.name
+}
+
+// Get returns the value of key, from the cache, the owning peer or the
+// Getter.  Concurrent misses for the same key share a single load.  If the
+// owning peer fails the key is loaded locally.  The returned slice is shared
+// and must not be modified.
+func (g *Group) Get(ctx context.Context, key string) ([]byte, error) {
+	var peer ProtoGetter
+	remote := false
+	if g.peers != nil {
+		peer, remote = g.peers.PickPeer(key)
+	}
+	return g.get(ctx, key, peer, remote)
+}
+
+// getLocal returns the value of key without asking peers, for keys peers
+// ask this process for.
+func (g *Group) getLocal(ctx context.Context, key string) ([]byte, error) {
+	return g.get(ctx, key, nil, false)
+}
+
+func (g *Group) get(ctx context.Context, key string, peer ProtoGetter, remote bool) ([]byte, error) {
+	g.gets.Add(1)
+	c, other := g.main, g.hot
+	if remote {
+		c, other = g.hot, g.main
+	}
+	// the owner changes when peers are added or removed
+	if v, ok := other.Get(key); ok {
+		g.hits.Add(1)
+		return v.([]byte), nil
+	}
+	if v, ok := c.Get(key); ok {
+		g.hits.Add(1)
+		return v.([]byte), nil
+	}
+	v, err := c.GetOrLoad(ctx, key, func(ctx context.Context, _ interface{}) (interface{}, error) {
+		if remote {
+			g.peerLoads.Add(1)
+			b, err := peer.Get(ctx, g.name, key)
+			if err == nil {
+				return b, nil
+			}
+			g.peerErrors.Add(1)
+		}
+		g.localLoads.Add(1)
+		return g.getter.Get(ctx, key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]byte), nil
+}
+
+// Stats returns the group's counters.
+func (g *Group) Stats() Stats {
+	return Stats{
+		Gets:       g.gets.Load(),
+		CacheHits:  g.hits.Load(),
+		PeerLoads:  g.peerLoads.Load(),
+		PeerErrors: g.peerErrors.Load(),
+		LocalLoads: g.localLoads.Load(),
+	}
+}
+
+// Remove removes key from this process's caches.  Peers keep their copies.
+func (g *Group) Remove(key string) {
+	g.main.Remove(key)
+	g.hot.Remove(key)
+}
@@ -0,0 +1,135 @@
+package peercache
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// origin counts the loads of each key.
+type origin struct {
+	mu    sync.Mutex
+	loads map[string]int
+}
+
+func (o *origin) Get(ctx context.Context, key string) ([]byte, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.loads == nil {
+		o.loads = make(map[string]int)
+	}
+	o.loads[key]++
+	return []byte("value of " + key), nil
+}
+
+// fleet starts n pools sharing an origin, each with a group named "g".
+func fleet(t *testing.T, n int, o *origin) ([]*HTTPPool, []*Group, []*httptest.Server) {
+	pools := make([]*HTTPPool, n)
+	groups := make([]*Group, n)
+	servers := make([]*httptest.Server, n)
+	urls := make([]string, n)
+	for i := range pools {
+		mux := http.NewServeMux()
+		servers[i] = httptest.NewServer(mux)
+		t.Cleanup(servers[i].Close)
+		urls[i] = servers[i].URL
+		pools[i] = NewHTTPPool(urls[i])
+		mux.Handle(DefaultBasePath, pools[i])
+		groups[i] = pools[i].NewGroup("g", 1<<20, o)
+	}
+	for _, p := range pools {
+		p.Set(urls...)
+	}
+	return pools, groups, servers
+}
+
+func TestGroup(t *testing.T) {
+	o := &origin{}
+	_, groups, _ := fleet(t, 3, o)
+	ctx := context.Background()
+
+	for i := 0; i < 30; i++ {
+		key := fmt.Sprintf("key/%d", i)
+		for _, g := range groups {
+			b, err := g.Get(ctx, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != "value of "+key {
+				t.Errorf("bad value for %s: %q", key, b)
+			}
+		}
+	}
+	for key, n := range o.loads {
+		if n != 1 {
+			t.Errorf("%s loaded %d times from the origin", key, n)
+		}
+	}
+
+	var st Stats
+	for _, g := range groups {
+		s := g.Stats()
+		st.PeerLoads += s.PeerLoads
+		st.LocalLoads += s.LocalLoads
+	}
+	if st.LocalLoads != 30 || st.PeerLoads == 0 {
+		t.Errorf("bad stats: %+v", st)
+	}
+
+	// remote keys are cached in the hot cache
+	before := groups[0].Stats()
+	for i := 0; i < 30; i++ {
+		groups[0].Get(ctx, fmt.Sprintf("key/%d", i))
+	}
+	if after := groups[0].Stats(); after.CacheHits-before.CacheHits != 30 {
+		t.Errorf("expected 30 hits: %+v", after)
+	}
+}
+
+func TestGroupPeerDown(t *testing.T) {
+	o := &origin{}
+	pools, groups, servers := fleet(t, 2, o)
+	servers[1].Close()
+
+	// find a key owned by the closed peer
+	key := ""
+	for i := 0; key == ""; i++ {
+		k := fmt.Sprint(i)
+		if _, remote := pools[0].PickPeer(k); remote {
+			key = k
+		}
+	}
+	b, err := groups[0].Get(context.Background(), key)
+	if err != nil || string(b) != "value of "+key {
+		t.Fatalf("expected a local load, got %q, %v", b, err)
+	}
+	if st := groups[0].Stats(); st.PeerErrors != 1 || st.LocalLoads != 1 {
+		t.Errorf("bad stats: %+v", st)
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	p := NewHTTPPool("http://self")
+	p.NewGroup("g", 100, &origin{})
+	for _, c := range []struct {
+		method, path string
+		status       int
+	}{
+		{http.MethodGet, "/_peercache/g/a%2Fb", http.StatusOK},
+		{http.MethodGet, "/_peercache/missing/a", http.StatusNotFound},
+		{http.MethodGet, "/_peercache/g", http.StatusBadRequest},
+		{http.MethodPost, "/_peercache/g/a", http.StatusMethodNotAllowed},
+	} {
+		w := httptest.NewRecorder()
+		p.ServeHTTP(w, httptest.NewRequest(c.method, c.path, nil))
+		if w.Code != c.status {
+			t.Errorf("%s %s: got %d, want %d", c.method, c.path, w.Code, c.status)
+		}
+		if c.status == http.StatusOK && w.Body.String() != "value of a/b" {
+			t.Errorf("bad body: %q", w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,229 @@
+// Package peersync keeps a small cluster of caches loosely converged without
+// a central coordinator.  On an interval every node sends each peer a Bloom
+// filter of its keys and pulls back the peer's hottest entries it is missing.
+//
+// Keys and values travel gob encoded, so custom types must be registered with
+// gob.Register on every node.  Entries keep their expiration deadline, which
+// assumes the nodes' clocks agree.
+package peersync
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	lfuda "github.com/bparli/lfuda-go"
+)
+
+// Entry is a cache entry sent to a peer.
+type Entry struct {
+	Key   interface{}
+	Value interface{}
+	// Expires is when the entry expires, zero if it never does.
+	Expires time.Time
+}
+
+// DefaultMaxEntries caps the entries a Handler sends in one response.
+const DefaultMaxEntries = 1000
+
+// MaxFilterSize caps the bytes of the Bloom filter a Handler reads, enough
+// for about 10 million keys at a 1% false positive rate.
+const MaxFilterSize = 12 << 20
+
+// DefaultInterval is the interval between syncs if Syncer.Interval is not
+// positive.
+const DefaultInterval = time.Minute
+
+// Handler serves the peer side of the protocol: a POST of a marshaled
+// lfuda.BloomFilter, of at most MaxFilterSize bytes, is answered with up to n
+// (query parameter, at most maxEntries) of the cache's hottest entries that
+// are not in the filter.
+func Handler(c *lfuda.Cache, maxEntries int) http.Handler {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		n := maxEntries
+		if v := r.URL.Query().Get("n"); v != "" {
+			if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 && parsed < n {
+				n = parsed
+			}
+		}
+
+		data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxFilterSize))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var have lfuda.BloomFilter
+		if err := have.UnmarshalBinary(data); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var keys []interface{}
+		c.Range(func(info lfuda.EntryInfo) bool {
+			if !have.Test(info.Key) {
+				keys = append(keys, info.Key)
+			}
+			return len(keys) < n
+		})
+
+		entries := make([]Entry, 0, len(keys))
+		now := time.Now()
+		for _, k := range keys {
+			v, ok := c.Peek(k)
+			if !ok {
+				continue
+			}
+			e := Entry{Key: k, Value: v}
+			if ttl, ok := c.TTL(k); !ok {
+				continue
+			} else if ttl > 0 {
+				e.Expires = now.Add(ttl)
+			}
+			entries = append(entries, e)
+		}
+
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(entries); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(buf.Bytes())
+	})
+}
+
+// Syncer pulls missing hot entries from peers into a cache.
+type Syncer struct {
+	// Cache receives the pulled entries.
+	Cache *lfuda.Cache
+	// Peers are the URLs of the peers' Handlers.
+	Peers []string
+	// Interval between syncs started by Start, DefaultInterval if not
+	// positive.
+	Interval time.Duration
+	// TopN caps the entries pulled from each peer per sync, 0 leaves it to
+	// the peer.
+	TopN int
+	// FPRate is the false positive rate of the Bloom filter sent to peers.
+	// Keys colliding in the filter are not pulled.  Defaults to 0.01.
+	FPRate float64
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+
+	stop chan struct{}
+	once sync.Once
+}
+
+// SyncOnce pulls missing entries from every peer, keeping their expiration
+// deadlines and skipping those already expired.  It returns the number of
+// entries pulled and the first error encountered, after trying all peers.
+func (s *Syncer) SyncOnce(ctx context.Context) (pulled int, err error) {
+	fpRate := s.FPRate
+	if fpRate <= 0 {
+		fpRate = 0.01
+	}
+	filter, err := s.Cache.KeysBloom(fpRate).MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, peer := range s.Peers {
+		entries, perr := s.pull(ctx, peer, filter)
+		if perr != nil {
+			if err == nil {
+				err = perr
+			}
+			continue
+		}
+		for _, e := range entries {
+			var ttl time.Duration
+			if !e.Expires.IsZero() {
+				if ttl = time.Until(e.Expires); ttl <= 0 {
+					continue
+				}
+			}
+			if ok, _ := s.Cache.ContainsOrSetWithTTL(e.Key, e.Value, ttl); !ok {
+				pulled++
+			}
+		}
+	}
+	return pulled, err
+}
+
+func (s *Syncer) pull(ctx context.Context, peer string, filter []byte) ([]Entry, error) {
+	url := peer
+	if s.TopN > 0 {
+		url += "?n=" + strconv.Itoa(s.TopN)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(filter))
+	if err != nil {
+		return nil, err
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("peersync: %s returned %s", peer, resp.Status)
+	}
+
+	var entries []Entry
+	if err := gob.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Start syncs every Interval in the background until Stop is called.
+func (s *Syncer) Start() {
+	s.stop = make(chan struct{})
+	go func() {
+		interval := s.Interval
+		if interval <= 0 {
+			interval = DefaultInterval
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.SyncOnce(context.Background())
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the background syncing started by Start.
+func (s *Syncer) Stop() {
+	s.once.Do(func() {
+		if s.stop != nil {
+			close(s.stop)
+		}
+	})
+}
@@ -0,0 +1,127 @@
+package peersync
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	lfuda "github.com/bparli/lfuda-go"
+)
+
+func TestSyncOnce(t *testing.T) {
+	peer := lfuda.New(1000)
+	for i := 0; i < 20; i++ {
+		peer.Set(fmt.Sprintf("key%d", i), i)
+	}
+	// key0..key4 are the peer's hottest keys
+	for i := 0; i < 5; i++ {
+		for j := 0; j < 10; j++ {
+			peer.Get(fmt.Sprintf("key%d", i))
+		}
+	}
+	srv := httptest.NewServer(Handler(peer, 0))
+	defer srv.Close()
+
+	local := lfuda.New(1000)
+	local.Set("key0", "local")
+
+	// keep Bloom filter false positives from holding back keys
+	s := &Syncer{Cache: local, Peers: []string{srv.URL}, TopN: 5, FPRate: 1e-9}
+	pulled, err := s.SyncOnce(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pulled != 5 {
+		t.Errorf("expected 5 entries pulled: %d", pulled)
+	}
+	for i := 1; i < 5; i++ {
+		if v, ok := local.Peek(fmt.Sprintf("key%d", i)); !ok || v != i {
+			t.Errorf("hot key%d should have been pulled: %v", i, v)
+		}
+	}
+	if v, _ := local.Peek("key0"); v != "local" {
+		t.Errorf("local entries should not be overwritten: %v", v)
+	}
+
+	// once converged nothing is pulled
+	s.TopN = 0
+	if pulled, _ := s.SyncOnce(context.Background()); pulled != 14 {
+		t.Errorf("the remaining keys should have been pulled: %d", pulled)
+	}
+	if pulled, _ := s.SyncOnce(context.Background()); pulled != 0 {
+		t.Errorf("nothing should be left to pull: %d", pulled)
+	}
+}
+
+func TestSyncTTL(t *testing.T) {
+	peer := lfuda.New(1000)
+	peer.SetWithTTL("short", 1, 50*time.Millisecond)
+	peer.SetWithTTL("long", 2, time.Hour)
+	peer.Set("forever", 3)
+	srv := httptest.NewServer(Handler(peer, 0))
+	defer srv.Close()
+
+	local := lfuda.New(1000)
+	s := &Syncer{Cache: local, Peers: []string{srv.URL}, FPRate: 1e-9}
+	if pulled, err := s.SyncOnce(context.Background()); err != nil || pulled != 3 {
+		t.Fatalf("expected 3 entries pulled: %d, %v", pulled, err)
+	}
+	if ttl, _ := local.TTL("long"); ttl <= 59*time.Minute || ttl > time.Hour {
+		t.Errorf("synced entry should keep its TTL: %v", ttl)
+	}
+	if ttl, ok := local.TTL("forever"); !ok || ttl != 0 {
+		t.Errorf("synced entry without TTL should not expire: %v", ttl)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if _, ok := local.Get("short"); ok {
+		t.Errorf("synced entry should have expired")
+	}
+}
+
+func TestSyncErrors(t *testing.T) {
+	srv := httptest.NewServer(Handler(lfuda.New(10), 0))
+	defer srv.Close()
+
+	s := &Syncer{Cache: lfuda.New(10), Peers: []string{srv.URL + "/missing-method", "http://127.0.0.1:1"}}
+	if _, err := s.SyncOnce(context.Background()); err == nil {
+		t.Errorf("unreachable peer should be reported")
+	}
+
+	resp, err := http.Post(srv.URL, "application/octet-stream", bytes.NewReader(make([]byte, MaxFilterSize+1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized filters should be rejected: %s", resp.Status)
+	}
+}
+
+func TestStartStop(t *testing.T) {
+	peer := lfuda.New(100)
+	peer.Set("a", "a")
+	srv := httptest.NewServer(Handler(peer, 0))
+	defer srv.Close()
+
+	local := lfuda.New(100)
+	s := &Syncer{Cache: local, Peers: []string{srv.URL}, Interval: time.Millisecond}
+	s.Start()
+	defer s.Stop()
+
+	deadline := time.Now().Add(time.Second)
+	for !local.Contains("a") && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if !local.Contains("a") {
+		t.Errorf("background sync should have pulled a")
+	}
+
+	// no interval falls back to the default
+	idle := &Syncer{Cache: local, Peers: []string{srv.URL}}
+	idle.Start()
+	idle.Stop()
+}
@@ -0,0 +1,41 @@
+package lfuda
+
+import "errors"
+
+// ErrUnknownPolicy is returned by SwitchPolicy for names other than
+// PolicyLFUDA, PolicyGDSF, PolicyLFU and PolicyHybrid.
+var ErrUnknownPolicy = errors.New("lfuda: unknown policy")
+
+// SwitchPolicy converts the cache to policy, recomputing every entry's
+// priority from its hits and size without dropping any, e.g. to change the
+// policy during an incident without a cold start.  Entries keep the age
+// they were last accessed at; switching from PolicyLFU resets the age.
+func (c *Cache) SwitchPolicy(policy string) error {
+	c.lockOp()
+	ok := c.lfuda.SwitchPolicy(policy)
+	if ok {
+		c.opts.policy = policy
+	}
+	c.unlockOp()
+	if !ok {
+		return ErrUnknownPolicy
+	}
+	c.debug("lfuda: switched policy", "policy", policy)
+	return nil
+}
+
+// SetHybridAlpha sets the exponent of the entry size under PolicyHybrid, see
+// WithHybridAlpha, recomputing the priorities if the cache uses it.
+func (c *Cache) SetHybridAlpha(alpha float64) {
+	c.lockOp()
+	c.opts.alpha = alpha
+	c.lfuda.SetHybridAlpha(alpha)
+	c.unlockOp()
+}
+
+// Policy returns the name of the cache policy.
+func (c *Cache) Policy() string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.opts.policy
+}
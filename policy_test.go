@@ -0,0 +1,52 @@
+package lfuda
+
+import "testing"
+
+func TestSwitchPolicy(t *testing.T) {
+	l := New(10)
+	l.SetWithSize("big", 1, 8)
+	l.Get("big")
+	l.SetWithSize("small", 2, 1)
+	if err := l.SwitchPolicy("ARC"); err != ErrUnknownPolicy {
+		t.Errorf("unknown policies should be rejected: %v", err)
+	}
+	if err := l.SwitchPolicy(PolicyGDSF); err != nil {
+		t.Fatal(err)
+	}
+	if l.Policy() != PolicyGDSF || l.Len() != 2 {
+		t.Errorf("the cache should be GDSF with its entries: %s %v", l.Policy(), l.Keys())
+	}
+
+	// big's 2 hits over 8 bytes are worth less than small's hit over 1
+	l.SetWithSize("new", 3, 2)
+	if l.Contains("big") || !l.Contains("small") {
+		t.Errorf("the big entry should have been evicted: %v", l.Keys())
+	}
+}
+
+func TestHybridPolicy(t *testing.T) {
+	fill := func(alpha float64) *Cache {
+		l := NewWithOptions(10, WithPolicy(PolicyHybrid), WithHybridAlpha(alpha))
+		l.SetWithSize("big", 1, 4)
+		l.Get("big")
+		l.Get("big")
+		l.SetWithSize("small", 2, 1)
+		l.SetWithSize("new", 3, 6)
+		return l
+	}
+
+	// big's 3 hits over 4 bytes are worth less than small's 1 under GDSF
+	if l := fill(1); l.Contains("big") || !l.Contains("small") {
+		t.Errorf("alpha 1 should evict the big entry: %v", l.Keys())
+	}
+	// but more than it by hits alone, and 3/4^0.5 = 1.5
+	if l := fill(0.5); !l.Contains("big") || l.Contains("small") {
+		t.Errorf("alpha 0.5 should evict the small entry: %v", l.Keys())
+	}
+
+	l := fill(0)
+	l.SetHybridAlpha(1)
+	if _, meta, _ := l.GetWithMeta("new"); l.Len() != 2 || meta.Priority != 2.0/6+l.Age() {
+		t.Errorf("changing alpha should recompute priorities: %v %+v", l.Keys(), meta)
+	}
+}
@@ -0,0 +1,50 @@
+package lfuda
+
+import "sync"
+
+// ValuePool takes back values that left a cache, such as a *sync.Pool.
+type ValuePool interface {
+	Put(value interface{})
+}
+
+// WithValuePool puts the values leaving the cache into pool once the
+// eviction callback and hooks saw them, so buffers are reused instead of
+// garbage collected.  Values written to the tier are not recycled.  Values
+// returned by Get must not be used once they may have left the cache, and
+// a key must not be set to the value it already holds.
+func WithValuePool(pool ValuePool) Option {
+	return func(o *options) {
+		o.valuePool = pool
+	}
+}
+
+// recyclable reports whether a value leaving the cache goes to the pool.
+func (c *Cache) recyclable(value interface{}) bool {
+	if c.opts.valuePool == nil || value == nil {
+		return false
+	}
+	_, isNeg := value.(negativeEntry)
+	return !isNeg
+}
+
+// BytePool is a ValuePool of byte slices, handing evicted buffers back to
+// callers preparing values to set.
+type BytePool struct {
+	pool sync.Pool
+}
+
+// Get returns a slice of n bytes, reusing a pooled one if it is large
+// enough.
+func (p *BytePool) Get(n int) []byte {
+	if b, ok := p.pool.Get().([]byte); ok && cap(b) >= n {
+		return b[:n]
+	}
+	return make([]byte, n)
+}
+
+// Put pools value if it is a byte slice.
+func (p *BytePool) Put(value interface{}) {
+	if b, ok := value.([]byte); ok {
+		p.pool.Put(b[:0])
+	}
+}
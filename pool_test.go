@@ -0,0 +1,58 @@
+package lfuda
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type recordingPool struct {
+	lock   sync.Mutex
+	values []interface{}
+}
+
+func (p *recordingPool) Put(value interface{}) {
+	p.lock.Lock()
+	p.values = append(p.values, value)
+	p.lock.Unlock()
+}
+
+func TestValuePool(t *testing.T) {
+	pool := new(recordingPool)
+	l := NewWithOptions(2, WithValuePool(pool), WithEvictCallback(func(key, value interface{}) {
+		for _, v := range pool.values {
+			if v == value {
+				t.Errorf("%v recycled before the callback", value)
+			}
+		}
+	}))
+	l.Set(1, "a")
+	l.Set(2, "b")
+	l.Get(2)
+	l.Set(3, "c")
+	l.Remove(2)
+	l.SetNegative(4, 0)
+	l.Purge()
+
+	if want := []interface{}{"a", "b", "c"}; !reflect.DeepEqual(pool.values, want) {
+		t.Errorf("bad recycled values: %v", pool.values)
+	}
+}
+
+func TestBytePool(t *testing.T) {
+	var pool BytePool
+	l := NewWithOptions(2, WithValuePool(&pool), WithAsyncEvictCallback(1, 0), WithEvictCallback(func(key, value interface{}) {}))
+	b := pool.Get(8)
+	copy(b, "buffered")
+	l.Set(1, b)
+	l.Remove(1)
+	l.Close()
+
+	// the pool may drop values, e.g. under the race detector
+	if reused := pool.Get(4); len(reused) != 4 || (cap(reused) == 8 && &reused[0] != &b[0]) {
+		t.Errorf("bad buffer: %q", reused)
+	}
+	if b := pool.Get(16); len(b) != 16 {
+		t.Errorf("bad buffer length")
+	}
+}
@@ -0,0 +1,146 @@
+package lfuda
+
+import "strings"
+
+// WithPrefixIndex maintains an index of the cache's string keys so that
+// RemovePrefix only visits matching keys instead of scanning the whole cache.
+// It costs a radix tree node per key.
+func WithPrefixIndex() Option {
+	return func(o *options) {
+		o.prefixIndex = true
+	}
+}
+
+// RemovePrefix removes every entry whose key is a string starting with
+// prefix, e.g. "/videos/123/", under a single lock.  Without WithPrefixIndex
+// the whole cache is scanned.  Returns the number of entries removed.
+func (c *Cache) RemovePrefix(prefix string) int {
+	c.lockOp()
+	var keys []interface{}
+	if c.prefixes != nil {
+		c.prefixes.walk(prefix, func(key string) {
+			keys = append(keys, key)
+		})
+	} else {
+		c.lfuda.Range(func(info EntryInfo) bool {
+			if s, ok := info.Key.(string); ok && strings.HasPrefix(s, prefix) {
+				keys = append(keys, info.Key)
+			}
+			return true
+		})
+	}
+	c.removeKeys(keys)
+	return len(keys)
+}
+
+// prefixIndex is a radix tree of string keys.
+type prefixIndex struct {
+	root radixNode
+}
+
+type radixNode struct {
+	// label of the edge leading to the node
+	label    string
+	leaf     bool
+	children []*radixNode
+}
+
+func (n *radixNode) child(b byte) (int, *radixNode) {
+	for i, child := range n.children {
+		if child.label[0] == b {
+			return i, child
+		}
+	}
+	return -1, nil
+}
+
+func (t *prefixIndex) insert(key string) {
+	n := &t.root
+	for key != "" {
+		i, child := n.child(key[0])
+		if child == nil {
+			n.children = append(n.children, &radixNode{label: key, leaf: true})
+			return
+		}
+		p := commonPrefix(key, child.label)
+		if p < len(child.label) {
+			// split the edge where the key diverges
+			split := &radixNode{label: child.label[:p], children: []*radixNode{child}}
+			child.label = child.label[p:]
+			n.children[i] = split
+			child = split
+		}
+		key = key[p:]
+		n = child
+	}
+	n.leaf = true
+}
+
+func (t *prefixIndex) remove(key string) {
+	var parent *radixNode
+	n := &t.root
+	for key != "" {
+		_, child := n.child(key[0])
+		if child == nil || !strings.HasPrefix(key, child.label) {
+			return
+		}
+		key = key[len(child.label):]
+		parent, n = n, child
+	}
+	if parent == nil || !n.leaf {
+		return
+	}
+	n.leaf = false
+	if len(n.children) == 0 {
+		i, _ := parent.child(n.label[0])
+		parent.children = append(parent.children[:i], parent.children[i+1:]...)
+		n = parent
+	}
+	// merge a node left with a single child into it
+	if n != &t.root && !n.leaf && len(n.children) == 1 {
+		child := n.children[0]
+		n.label += child.label
+		n.leaf = child.leaf
+		n.children = child.children
+	}
+}
+
+// walk calls fn for every key starting with prefix.
+func (t *prefixIndex) walk(prefix string, fn func(key string)) {
+	n := &t.root
+	var path strings.Builder
+	for prefix != "" {
+		_, child := n.child(prefix[0])
+		if child == nil {
+			return
+		}
+		switch {
+		case strings.HasPrefix(prefix, child.label):
+			prefix = prefix[len(child.label):]
+		case strings.HasPrefix(child.label, prefix):
+			prefix = ""
+		default:
+			return
+		}
+		path.WriteString(child.label)
+		n = child
+	}
+	n.walk(path.String(), fn)
+}
+
+func (n *radixNode) walk(key string, fn func(key string)) {
+	if n.leaf {
+		fn(key)
+	}
+	for _, child := range n.children {
+		child.walk(key+child.label, fn)
+	}
+}
+
+func commonPrefix(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
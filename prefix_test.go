@@ -0,0 +1,94 @@
+package lfuda
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestRemovePrefix(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithPrefixIndex()}} {
+		l := NewWithOptions(1000, opts...)
+		l.Set("/videos/123/a", 1)
+		l.Set("/videos/123/b", 2)
+		l.Set("/videos/1234", 3)
+		l.Set("/images/123", 4)
+		l.Set(123, 5)
+
+		if n := l.RemovePrefix("/videos/123/"); n != 2 || l.Len() != 3 {
+			t.Errorf("matching keys should be removed: %d, %v", n, l.Keys())
+		}
+		if n := l.RemovePrefix("/videos/12"); n != 1 || l.Contains("/videos/1234") {
+			t.Errorf("prefix should match within a segment: %d", n)
+		}
+		if n := l.RemovePrefix("/nothing"); n != 0 {
+			t.Errorf("nothing should match: %d", n)
+		}
+		if n := l.RemovePrefix(""); n != 1 || !l.Contains(123) {
+			t.Errorf("empty prefix should match every string key: %d", n)
+		}
+	}
+}
+
+func TestPrefixIndexEvictions(t *testing.T) {
+	l := NewWithOptions(2, WithPrefixIndex())
+	l.Set("a1", 1)
+	l.Set("a2", 2)
+	l.Set("b1", 3)
+	l.Remove("a2")
+	l.Purge()
+	l.Set("a3", 4)
+	if n := l.RemovePrefix("a"); n != 1 {
+		t.Errorf("evicted and removed keys should leave the index: %d", n)
+	}
+}
+
+func TestPrefixIndex(t *testing.T) {
+	var idx prefixIndex
+	keys := make(map[string]bool)
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 5000; i++ {
+		key := fmt.Sprintf("/%d/%d", r.Intn(10), r.Intn(50))
+		if r.Intn(3) == 0 {
+			idx.remove(key)
+			delete(keys, key)
+		} else {
+			idx.insert(key)
+			keys[key] = true
+		}
+	}
+
+	for _, prefix := range []string{"", "/", "/1", "/1/", "/1/2", "/9/49", "/x"} {
+		var got, want []string
+		idx.walk(prefix, func(key string) {
+			got = append(got, key)
+		})
+		for key := range keys {
+			if strings.HasPrefix(key, prefix) {
+				want = append(want, key)
+			}
+		}
+		sort.Strings(got)
+		sort.Strings(want)
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("prefix %q: got %v, want %v", prefix, got, want)
+		}
+	}
+}
+
+func BenchmarkRemovePrefix(b *testing.B) {
+	l := NewWithOptions(1<<30, WithPrefixIndex())
+	for i := 0; i < 100000; i++ {
+		l.Set(fmt.Sprintf("/videos/%d/%d", i/10, i%10), i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		id := i % 10000
+		l.RemovePrefix(fmt.Sprintf("/videos/%d/", id))
+		for j := 0; j < 10; j++ {
+			l.Set(fmt.Sprintf("/videos/%d/%d", id, j), j)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package lfuda
+
+import "github.com/bparli/lfuda-go/simplelfuda"
+
+// PriorityClass scales the hits an entry's priority is computed from.
+type PriorityClass = simplelfuda.PriorityClass
+
+const (
+	// PriorityLow halves an entry's hits.
+	PriorityLow = simplelfuda.PriorityLow
+	// PriorityNormal is the class entries are set with.
+	PriorityNormal = simplelfuda.PriorityNormal
+	// PriorityHigh doubles an entry's hits.
+	PriorityHigh = simplelfuda.PriorityHigh
+)
+
+// SetPriority puts a key in a priority class, so business critical entries
+// outlive others with the same hits without being pinned.  The class is kept
+// when the key is overwritten and dropped once it leaves the cache.  Returns
+// false if the key is not in the cache.
+func (c *Cache) SetPriority(key interface{}, class PriorityClass) (ok bool) {
+	if c.badKey(key) {
+		return
+	}
+	c.lockOp()
+	ok = c.lfuda.SetPriorityClass(key, class)
+	c.unlockOp()
+	return ok
+}
@@ -0,0 +1,36 @@
+package lfuda
+
+import "testing"
+
+func TestSetPriority(t *testing.T) {
+	l := New(3)
+	l.Set(1, 1)
+	l.Set(2, 2)
+	l.Set(3, 3)
+	if !l.SetPriority(1, PriorityHigh) || !l.SetPriority(3, PriorityLow) {
+		t.Fatalf("keys should be in the cache")
+	}
+	if l.SetPriority(4, PriorityHigh) {
+		t.Errorf("4 was never set")
+	}
+	l.Get(2)
+	l.Get(3)
+
+	// 3 has as many hits as 2 but a lower class
+	l.Set(4, 4)
+	if l.Contains(3) || !l.Contains(1) || !l.Contains(2) {
+		t.Errorf("low priority entry should be evicted first: %v", l.Keys())
+	}
+
+	_, meta, _ := l.GetWithMeta(1)
+	if meta.Class != PriorityHigh || meta.Priority != 2*meta.Hits+l.Age() {
+		t.Errorf("class should scale the priority: %+v", meta)
+	}
+	l.Set(1, 10)
+	if _, meta, _ := l.GetWithMeta(1); meta.Class != PriorityHigh {
+		t.Errorf("class should be kept on overwrite")
+	}
+	if PriorityLow.String() != "low" || PriorityHigh.String() != "high" {
+		t.Errorf("bad class names")
+	}
+}
@@ -0,0 +1,89 @@
+package lfuda
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// readStripes is the number of buffers Get records hits in, so
+	// concurrent Gets rarely wait on the same lock.
+	readStripes = 16
+	// readBufferSize is the number of hits Get can record before they have
+	// to be applied.
+	readBufferSize = 2048
+	// readStripeSize is the number of hits a stripe holds.
+	readStripeSize = readBufferSize / readStripes
+)
+
+// readBuffer holds the hits recorded by Get under the read lock, striped so
+// recording them doesn't serialize readers.
+type readBuffer struct {
+	// picks the stripe to try first
+	next    atomic.Uint32
+	stripes [readStripes]readStripe
+}
+
+type readStripe struct {
+	lock sync.Mutex
+	keys []interface{}
+	// keep stripes on their own cache lines
+	_ [32]byte
+}
+
+// add records a hit to key, returning false if the stripe it picked is full.
+// Stripes are tried from a rotating start, so a hot key doesn't contend on
+// one of them.
+func (b *readBuffer) add(key interface{}) bool {
+	start := b.next.Add(1)
+	for i := uint32(0); i < readStripes; i++ {
+		s := &b.stripes[(start+i)%readStripes]
+		if s.lock.TryLock() {
+			return s.add(key)
+		}
+	}
+	s := &b.stripes[start%readStripes]
+	s.lock.Lock()
+	return s.add(key)
+}
+
+// add appends key to the stripe, whose lock is held, and unlocks it.
+func (s *readStripe) add(key interface{}) bool {
+	defer s.lock.Unlock()
+	if len(s.keys) == readStripeSize {
+		return false
+	}
+	if s.keys == nil {
+		s.keys = make([]interface{}, 0, readStripeSize)
+	}
+	s.keys = append(s.keys, key)
+	return true
+}
+
+// pending reports whether any hits are recorded.
+func (b *readBuffer) pending() bool {
+	for i := range b.stripes {
+		s := &b.stripes[i]
+		s.lock.Lock()
+		n := len(s.keys)
+		s.lock.Unlock()
+		if n > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// drain calls fn with every recorded hit and empties the buffer.
+func (b *readBuffer) drain(fn func(key interface{})) {
+	for i := range b.stripes {
+		s := &b.stripes[i]
+		s.lock.Lock()
+		for j, key := range s.keys {
+			fn(key)
+			s.keys[j] = nil
+		}
+		s.keys = s.keys[:0]
+		s.lock.Unlock()
+	}
+}
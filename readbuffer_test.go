@@ -0,0 +1,26 @@
+package lfuda
+
+import (
+	"sync"
+	"testing"
+)
+
+// test that no hit recorded by concurrent Gets is lost
+func TestReadBufferConcurrentHits(t *testing.T) {
+	l := New(100)
+	l.Set(1, 1)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < readBufferSize; i++ {
+				l.Get(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if hits, _ := l.Hits(1); hits != 1+8*readBufferSize {
+		t.Errorf("got %g hits, want %d", hits, 1+8*readBufferSize)
+	}
+}
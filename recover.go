@@ -0,0 +1,99 @@
+package lfuda
+
+import (
+	"fmt"
+	"time"
+)
+
+// PanicError is returned in place of a panic recovered by a cache built with
+// WithRecover, e.g. from a Tier or a fetch function.
+type PanicError struct {
+	// the operation that panicked
+	Op string
+	// the value passed to panic
+	Value interface{}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("lfuda: panic in %s: %v", e.Op, e.Value)
+}
+
+// reportPanic counts a recovered panic and passes it to the handler.
+func (c *Cache) reportPanic(op string, r interface{}) {
+	c.stats.panics.Add(1)
+	c.opts.recover(op, r)
+}
+
+// recoverPanic must be deferred directly, and only when recovery is enabled.
+func (c *Cache) recoverPanic(op string) {
+	if r := recover(); r != nil {
+		c.reportPanic(op, r)
+	}
+}
+
+// badKey reports whether key panicked when hashed, e.g. a slice, when
+// recovery is enabled.  Operations on such keys do nothing.
+func (c *Cache) badKey(key interface{}) (bad bool) {
+	if c.opts.recover == nil {
+		return false
+	}
+	defer c.recoverPanic("key")
+	bad = true
+	var probe map[interface{}]struct{}
+	_ = probe[key]
+	return false
+}
+
+// sizeOf calls the size func.  Returns false if it panicked.
+func (c *Cache) sizeOf(key, value interface{}) (size float64, ok bool) {
+	if c.opts.recover != nil {
+		defer c.recoverPanic("size func")
+	}
+	return c.opts.sizeFunc(key, value), true
+}
+
+// ttlOf calls the TTL func.  Returns false if it panicked.
+func (c *Cache) ttlOf(key, value interface{}) (ttl time.Duration, ok bool) {
+	if c.opts.recover != nil {
+		defer c.recoverPanic("ttl func")
+	}
+	return c.opts.ttlFunc(key, value), true
+}
+
+// costOf calls the cost func.  Returns false if it panicked.
+func (c *Cache) costOf(key, value interface{}) (cost float64, ok bool) {
+	if c.opts.recover != nil {
+		defer c.recoverPanic("cost func")
+	}
+	return c.opts.costFunc(key, value), true
+}
+
+// onEvicted calls the eviction callback.
+func (c *Cache) onEvicted(key, value interface{}) {
+	if c.opts.recover != nil {
+		defer c.recoverPanic("evict callback")
+	}
+	c.opts.onEvicted(key, value)
+}
+
+// onExpired calls the expire callback.
+func (c *Cache) onExpired(key, value interface{}) {
+	if c.opts.recover != nil {
+		defer c.recoverPanic("expire callback")
+	}
+	c.opts.onExpired(key, value)
+}
+
+// fetch calls a fetch function passed to Cached, returning a recovered panic
+// as a *PanicError.
+func fetch[T any](c *Cache, fn func() (T, error)) (val T, err error) {
+	if c.opts.recover != nil {
+		defer func() {
+			if r := recover(); r != nil {
+				c.reportPanic("fetch", r)
+				err = &PanicError{Op: "fetch", Value: r}
+			}
+		}()
+	}
+	return fn()
+}
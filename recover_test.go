@@ -0,0 +1,99 @@
+package lfuda
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWithRecover(t *testing.T) {
+	var ops []string
+	handler := func(op string, r interface{}) {
+		ops = append(ops, op)
+	}
+	l := NewWithOptions(100, WithRecover(handler), WithSizeFunc(func(key, value interface{}) float64 {
+		if value == "bad" {
+			panic("bad size")
+		}
+		return 1
+	}), WithEvictCallback(func(key, value interface{}) {
+		panic("bad callback")
+	}))
+
+	if l.Set("a", "bad") || l.Contains("a") {
+		t.Errorf("value whose size panicked should be dropped")
+	}
+	if l.Set([]int{1}, 1) {
+		t.Errorf("unhashable key should be dropped")
+	}
+	if _, ok := l.Get([]int{1}); ok {
+		t.Errorf("unhashable key should miss")
+	}
+
+	l.OnHit(func(key, value interface{}) {
+		panic("bad hook")
+	})
+	l.Set("b", "good")
+	if v, ok := l.Get("b"); !ok || v != "good" {
+		t.Errorf("panicking hook should not fail the get: %v", v)
+	}
+	if !l.Remove("b") || l.Contains("b") {
+		t.Errorf("panicking callback should not prevent removal")
+	}
+
+	_, err := Cached(l, "c", 0, func() (int, error) {
+		panic("bad fetch")
+	})
+	var perr *PanicError
+	if !errors.As(err, &perr) || perr.Op != "fetch" {
+		t.Errorf("fetch panic should be returned as an error: %v", err)
+	}
+
+	want := []string{"size func", "key", "key", "hook", "evict callback", "fetch"}
+	if len(ops) != len(want) {
+		t.Fatalf("bad recovered ops: %v", ops)
+	}
+	for i := range want {
+		if ops[i] != want[i] {
+			t.Errorf("bad recovered ops: %v", ops)
+		}
+	}
+	if st := l.Stats(); st.Panics != uint64(len(want)) {
+		t.Errorf("panics should be counted: %d", st.Panics)
+	}
+
+	// the lock was never left held
+	l.Set("d", "good")
+	if l.Len() != 1 {
+		t.Errorf("cache should still be usable: %d", l.Len())
+	}
+}
+
+func TestWithRecoverTier(t *testing.T) {
+	var recovered int
+	l := NewWithOptions(100, WithTier(panicTier{}), WithRecover(func(op string, r interface{}) {
+		recovered++
+	}))
+	defer l.Close()
+
+	if _, ok := l.Get("a"); ok {
+		t.Errorf("panicking tier should miss")
+	}
+	if recovered != 1 {
+		t.Errorf("tier panic should be recovered: %d", recovered)
+	}
+}
+
+type panicTier struct{}
+
+func (panicTier) Get(key interface{}) (interface{}, bool, error) { panic("bad tier") }
+func (panicTier) Set(key, value interface{}) error               { panic("bad tier") }
+func (panicTier) Remove(key interface{}) error                   { panic("bad tier") }
+
+func TestWithoutRecover(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("panics should propagate without WithRecover")
+		}
+	}()
+	New(100).Set([]int{1}, 1)
+}
@@ -0,0 +1,41 @@
+package lfuda
+
+// RemoveFunc removes every entry for which fn returns true, e.g. everything
+// belonging to a user, under a single lock so no matching entry set in
+// between is left behind.  fn runs with the cache locked and so must not call
+// back into the cache.  Returns the number of entries removed.
+func (c *Cache) RemoveFunc(fn func(key, value interface{}) bool) int {
+	c.lockOp()
+	var keys []interface{}
+	c.lfuda.Range(func(info EntryInfo) bool {
+		if value, ok := c.decode(c.lfuda.Peek(info.Key)); ok && fn(info.Key, value) {
+			keys = append(keys, info.Key)
+		}
+		return true
+	})
+	c.removeKeys(keys)
+	return len(keys)
+}
+
+// removeKeys removes keys found with the lock held, from the tier too, and
+// releases the lock.
+func (c *Cache) removeKeys(keys []interface{}) {
+	c.opReason = reasonRemoved
+	for _, key := range keys {
+		c.lfuda.Remove(key)
+	}
+	c.writes++
+	writeThrough := c.writeThrough()
+	if writeThrough {
+		for _, key := range keys {
+			c.tier.remove(key)
+		}
+	}
+	c.unlockOp()
+
+	if c.tier != nil && !writeThrough {
+		for _, key := range keys {
+			c.tier.remove(key)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package lfuda
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRemoveFunc(t *testing.T) {
+	var removed []interface{}
+	l := NewWithEvict(100, func(key, value interface{}) {
+		removed = append(removed, key)
+	})
+	l.Set("user:1:a", 1)
+	l.Set("user:1:b", 2)
+	l.Set("user:2:a", 3)
+	l.Set(42, 4)
+
+	n := l.RemoveFunc(func(key, value interface{}) bool {
+		s, ok := key.(string)
+		return ok && strings.HasPrefix(s, "user:1:")
+	})
+	if n != 2 || l.Len() != 2 || l.Contains("user:1:a") || !l.Contains("user:2:a") {
+		t.Errorf("matching entries should be removed: %d, %v", n, l.Keys())
+	}
+	if len(removed) != 2 {
+		t.Errorf("removals should be reported: %v", removed)
+	}
+	if n := l.RemoveFunc(func(key, value interface{}) bool { return value == 4 }); n != 1 || l.Contains(42) {
+		t.Errorf("fn should be given values")
+	}
+}
+
+func TestRemoveFuncTier(t *testing.T) {
+	tier := newMapTier()
+	tier.Set("a", 1)
+	l := NewWithOptions(10, WithTier(tier))
+	defer l.Close()
+	l.Set("a", 1)
+
+	l.RemoveFunc(func(key, value interface{}) bool { return true })
+	if tier.has("a") {
+		t.Errorf("removed entries should be deleted from the tier")
+	}
+}
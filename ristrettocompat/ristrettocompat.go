@@ -0,0 +1,173 @@
+// Package ristrettocompat adapts an lfuda cache to the API of
+// github.com/dgraph-io/ristretto, so projects comparing both can swap
+// implementations behind one interface.  As in ristretto, entries have a
+// cost and a new entry is only admitted if it was accessed more often,
+// according to a count-min sketch, than the entries it would evict; those
+// are picked by the LFUDA policy.  Unlike ristretto, sets are applied
+// synchronously.
+package ristrettocompat
+
+import (
+	"errors"
+	"time"
+
+	lfuda "github.com/bparli/lfuda-go"
+)
+
+// victimSample bounds the eviction candidates an admission is compared to.
+const victimSample = 16
+
+// Config configures a Cache.
+type Config struct {
+	// NumCounters is the number of keys whose frequency is tracked for
+	// admission, ideally 10 times the number of entries the cache holds.
+	NumCounters int64
+	// MaxCost is the total cost of the entries the cache holds.
+	MaxCost int64
+	// Cost returns the cost of values set with a cost of 0, which then
+	// cost 1 if it is nil.
+	Cost func(value interface{}) int64
+	// OnEvict is called for every entry leaving the cache.
+	OnEvict func(key, value interface{})
+}
+
+// Cache is a thread-safe cache bounded by the cost of its entries.
+type Cache struct {
+	cache  *lfuda.Cache
+	sketch *sketch
+	cost   func(value interface{}) int64
+}
+
+// NewCache creates a cache from config.
+func NewCache(config *Config) (*Cache, error) {
+	switch {
+	case config.NumCounters <= 0:
+		return nil, errors.New("NumCounters can't be zero")
+	case config.MaxCost <= 0:
+		return nil, errors.New("MaxCost can't be zero")
+	}
+	return &Cache{
+		cache:  lfuda.NewWithOptions(float64(config.MaxCost), lfuda.WithEvictCallback(config.OnEvict)),
+		sketch: newSketch(config.NumCounters),
+		cost:   config.Cost,
+	}, nil
+}
+
+// key returns the cache key of a key, []byte keys being stored as strings.
+func key(k interface{}) interface{} {
+	if b, ok := k.([]byte); ok {
+		return string(b)
+	}
+	return k
+}
+
+// Get looks up a key's value from the cache.
+func (c *Cache) Get(k interface{}) (interface{}, bool) {
+	if k == nil {
+		return nil, false
+	}
+	k = key(k)
+	c.sketch.increment(k)
+	return c.cache.Get(k)
+}
+
+// Set adds a value to the cache with the given cost, computed by the
+// config's Cost func if 0.  Returns false if the value was not admitted
+// because it costs more than the cache holds, or the entries it would evict
+// were accessed more often than its key.
+func (c *Cache) Set(k, value interface{}, cost int64) bool {
+	return c.SetWithTTL(k, value, cost, 0)
+}
+
+// SetWithTTL is Set for a value expiring after ttl, or never if ttl is 0.
+func (c *Cache) SetWithTTL(k, value interface{}, cost int64, ttl time.Duration) bool {
+	if k == nil || ttl < 0 {
+		return false
+	}
+	k = key(k)
+	if cost == 0 {
+		cost = 1
+		if c.cost != nil {
+			cost = c.cost(value)
+		}
+	}
+	c.sketch.increment(k)
+	if !c.cache.Contains(k) && !c.admit(k, cost) {
+		return false
+	}
+	c.cache.SetWithSize(k, value, float64(cost))
+	if ttl > 0 {
+		c.cache.Expire(k, ttl)
+	}
+	return c.cache.Contains(k)
+}
+
+// admit reports whether a new key costing cost should replace the entries
+// it would evict.
+func (c *Cache) admit(k interface{}, cost int64) bool {
+	capacity := c.cache.Capacity()
+	if float64(cost) > capacity {
+		return false
+	}
+	need := float64(cost) - (capacity - c.cache.Size())
+	if need <= 0 {
+		return true
+	}
+	freq := c.sketch.estimate(k)
+	for _, victim := range c.cache.ColdestKeys(victimSample) {
+		if c.sketch.estimate(victim.Key) > freq {
+			return false
+		}
+		if need -= victim.Size; need <= 0 {
+			break
+		}
+	}
+	return true
+}
+
+// Del removes a key from the cache.
+func (c *Cache) Del(k interface{}) {
+	if k != nil {
+		c.cache.Remove(key(k))
+	}
+}
+
+// GetTTL returns the time left before a key expires, 0 if it never does.
+// Returns false if the key is not in the cache.
+func (c *Cache) GetTTL(k interface{}) (time.Duration, bool) {
+	if k == nil {
+		return 0, false
+	}
+	return c.cache.TTL(key(k))
+}
+
+// MaxCost returns the total cost of the entries the cache holds.
+func (c *Cache) MaxCost() int64 {
+	return int64(c.cache.Capacity())
+}
+
+// UpdateMaxCost changes the total cost of the entries the cache holds,
+// evicting entries if it shrinks.
+func (c *Cache) UpdateMaxCost(maxCost int64) {
+	c.cache.Resize(float64(maxCost))
+}
+
+// Clear removes every entry and forgets the frequencies of keys.
+func (c *Cache) Clear() {
+	c.cache.Purge()
+	c.sketch.clear()
+}
+
+// Wait returns immediately, sets being synchronous; it exists for
+// compatibility with ristretto.
+func (c *Cache) Wait() {}
+
+// Close stops the cache's background work.
+func (c *Cache) Close() {
+	c.cache.Close()
+}
+
+// Unwrap returns the underlying cache.
+func (c *Cache) Unwrap() *lfuda.Cache {
+	return c.cache
+}
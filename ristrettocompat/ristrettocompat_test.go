@@ -0,0 +1,82 @@
+package ristrettocompat
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	if _, err := NewCache(&Config{NumCounters: 100}); err == nil {
+		t.Errorf("expected an error without MaxCost")
+	}
+	var evicted []interface{}
+	c, err := NewCache(&Config{
+		NumCounters: 100,
+		MaxCost:     10,
+		OnEvict:     func(key, value interface{}) { evicted = append(evicted, key) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if !c.Set("a", 1, 4) || !c.Set([]byte("b"), 2, 4) {
+		t.Fatalf("entries that fit should be admitted")
+	}
+	if v, ok := c.Get("b"); !ok || v != 2 {
+		t.Errorf("bad value: %v", v)
+	}
+	if c.Set("huge", 3, 11) {
+		t.Errorf("an entry costing more than the cache should be rejected")
+	}
+
+	// a key seen once can't evict popular ones
+	for i := 0; i < 5; i++ {
+		c.Get("a")
+		c.Get("b")
+	}
+	if c.Set("cold", 4, 4) {
+		t.Errorf("cold key admitted over hot ones")
+	}
+	// until it becomes popular
+	for i := 0; i < 10; i++ {
+		c.Get("hot")
+	}
+	if !c.Set("hot", 5, 4) || len(evicted) != 1 {
+		t.Errorf("hot key not admitted: %v", evicted)
+	}
+
+	c.SetWithTTL("a", 1, 0, time.Hour)
+	if ttl, ok := c.GetTTL("a"); !ok || ttl <= 0 || ttl > time.Hour {
+		t.Errorf("bad ttl: %v", ttl)
+	}
+	c.Del([]byte("a"))
+	if _, ok := c.Get("a"); ok {
+		t.Errorf("deleted key found")
+	}
+	c.UpdateMaxCost(20)
+	if c.MaxCost() != 20 {
+		t.Errorf("bad max cost: %d", c.MaxCost())
+	}
+	c.Clear()
+	if _, ok := c.Get("hot"); ok || c.Unwrap().Len() != 0 {
+		t.Errorf("cache not cleared")
+	}
+}
+
+func TestSketch(t *testing.T) {
+	s := newSketch(64)
+	for i := 0; i < 20; i++ {
+		s.increment("a")
+	}
+	s.increment("b")
+	if s.estimate("a") != 15 || s.estimate("b") < 1 || s.estimate("c") > 1 {
+		t.Errorf("bad estimates: %d %d %d", s.estimate("a"), s.estimate("b"), s.estimate("c"))
+	}
+	for i := 0; i < s.resetAt; i++ {
+		s.increment(i)
+	}
+	if s.estimate("a") >= 15 {
+		t.Errorf("counters not halved: %d", s.estimate("a"))
+	}
+}
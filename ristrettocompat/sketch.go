@@ -0,0 +1,111 @@
+package ristrettocompat
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/maphash"
+	"math/bits"
+	"sync"
+)
+
+// sketchRows is the number of rows of the count-min sketch.
+const sketchRows = 4
+
+// sketch is a count-min sketch of 4 bit counters estimating how often keys
+// were accessed, halved every resetAt increments so it follows changes in
+// popularity.
+type sketch struct {
+	mu      sync.Mutex
+	seed    maphash.Seed
+	rows    [sketchRows][]uint8
+	mask    uint64
+	added   int
+	resetAt int
+}
+
+func newSketch(counters int64) *sketch {
+	if counters < 64 {
+		counters = 64
+	}
+	width := uint64(1) << bits.Len64(uint64(counters-1))
+	s := &sketch{seed: maphash.MakeSeed(), mask: width - 1, resetAt: int(10 * counters)}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, width)
+	}
+	return s
+}
+
+func (s *sketch) hash(key interface{}) uint64 {
+	var h maphash.Hash
+	h.SetSeed(s.seed)
+	switch k := key.(type) {
+	case string:
+		h.WriteString(k)
+	case int:
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], uint64(k))
+		h.Write(b[:])
+	case uint64:
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], k)
+		h.Write(b[:])
+	default:
+		fmt.Fprintf(&h, "%T:%v", key, key)
+	}
+	return h.Sum64()
+}
+
+// index returns the counter of row i for the hash h, combining two halves of
+// h into independent hashes.
+func (s *sketch) index(h uint64, i int) uint64 {
+	return (h + uint64(i)*(h>>32|1)) & s.mask
+}
+
+// increment counts an access to key.
+func (s *sketch) increment(key interface{}) {
+	h := s.hash(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.rows {
+		if c := &s.rows[i][s.index(h, i)]; *c < 15 {
+			*c++
+		}
+	}
+	if s.added++; s.added >= s.resetAt {
+		s.reset()
+	}
+}
+
+// estimate returns how often key was accessed, recently.
+func (s *sketch) estimate(key interface{}) uint8 {
+	h := s.hash(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	min := uint8(15)
+	for i := range s.rows {
+		if c := s.rows[i][s.index(h, i)]; c < min {
+			min = c
+		}
+	}
+	return min
+}
+
+func (s *sketch) reset() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] /= 2
+		}
+	}
+	s.added /= 2
+}
+
+func (s *sketch) clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] = 0
+		}
+	}
+	s.added = 0
+}
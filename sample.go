@@ -0,0 +1,130 @@
+package lfuda
+
+import (
+	"container/heap"
+	"encoding/json"
+	"io"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/bparli/lfuda-go/simplelfuda"
+)
+
+// EntryInfo describes a cache entry without its value.
+type EntryInfo = simplelfuda.EntryInfo
+
+// SampleMode selects how Sample picks entries.
+type SampleMode int
+
+const (
+	// SampleUniform picks every entry with the same probability.
+	SampleUniform SampleMode = iota
+	// SamplePriority picks entries with a probability proportional to their
+	// priority under the cache's policy.
+	SamplePriority
+)
+
+// Sample returns the metadata of up to n randomly chosen entries.  Values are
+// never included so large caches can be modeled offline cheaply.
+func (c *Cache) Sample(n int, mode SampleMode) []EntryInfo {
+	if n <= 0 {
+		return nil
+	}
+	r := &reservoir{size: n}
+
+	c.flushReads()
+	c.lock.RLock()
+	c.lfuda.Range(func(info EntryInfo) bool {
+		weight := 1.0
+		if mode == SamplePriority {
+			weight = info.Priority
+		}
+		r.add(info, weight)
+		return true
+	})
+	c.lock.RUnlock()
+
+	samples := make([]EntryInfo, len(r.items))
+	for i, s := range r.items {
+		samples[i] = s.info
+	}
+	return samples
+}
+
+// ExportSample writes a sample of up to n entries to w, one JSON object per
+// line.
+func (c *Cache) ExportSample(w io.Writer, n int, mode SampleMode) error {
+	enc := json.NewEncoder(w)
+	for _, info := range c.Sample(n, mode) {
+		if err := enc.Encode(info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StartSampleExport calls ExportSample every interval until the returned stop
+// function is called or a write to w fails.
+func (c *Cache) StartSampleExport(w io.Writer, n int, mode SampleMode, interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := c.ExportSample(w, n, mode); err != nil {
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// reservoir implements weighted reservoir sampling (Efraimidis-Spirakis): each
+// candidate gets the key u^(1/weight) and the size largest keys are kept.
+type reservoir struct {
+	size  int
+	items []sampled
+}
+
+type sampled struct {
+	info EntryInfo
+	key  float64
+}
+
+func (r *reservoir) add(info EntryInfo, weight float64) {
+	key := 0.0
+	if weight > 0 {
+		key = math.Pow(rand.Float64(), 1/weight)
+	}
+	if len(r.items) < r.size {
+		heap.Push(r, sampled{info: info, key: key})
+	} else if key > r.items[0].key {
+		r.items[0] = sampled{info: info, key: key}
+		heap.Fix(r, 0)
+	}
+}
+
+func (r *reservoir) Len() int           { return len(r.items) }
+func (r *reservoir) Less(i, j int) bool { return r.items[i].key < r.items[j].key }
+func (r *reservoir) Swap(i, j int)      { r.items[i], r.items[j] = r.items[j], r.items[i] }
+
+func (r *reservoir) Push(x interface{}) {
+	r.items = append(r.items, x.(sampled))
+}
+
+func (r *reservoir) Pop() interface{} {
+	last := r.items[len(r.items)-1]
+	r.items = r.items[:len(r.items)-1]
+	return last
+}
@@ -0,0 +1,79 @@
+package lfuda
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestSample(t *testing.T) {
+	l := New(100)
+	for i := 0; i < 50; i++ {
+		l.Set(i, i)
+	}
+
+	if s := l.Sample(10, SampleUniform); len(s) != 10 {
+		t.Errorf("expected 10 samples: %d", len(s))
+	}
+
+	if s := l.Sample(100, SamplePriority); len(s) != l.Len() {
+		t.Errorf("sample should be capped at the number of entries: %d", len(s))
+	}
+
+	seen := make(map[interface{}]bool)
+	for _, info := range l.Sample(20, SampleUniform) {
+		if seen[info.Key] {
+			t.Errorf("key sampled twice: %v", info.Key)
+		}
+		seen[info.Key] = true
+		if info.Size == 0 || info.Hits != 1 {
+			t.Errorf("bad entry metadata: %+v", info)
+		}
+	}
+}
+
+func TestSamplePriority(t *testing.T) {
+	l := New(100)
+	for i := 0; i < 50; i++ {
+		l.Set(i, i)
+	}
+	for i := 0; i < 1000; i++ {
+		l.Get(7)
+	}
+
+	hot := 0
+	for i := 0; i < 100; i++ {
+		if l.Sample(1, SamplePriority)[0].Key == 7 {
+			hot++
+		}
+	}
+	if hot < 50 {
+		t.Errorf("hot key should dominate a priority weighted sample: %d", hot)
+	}
+}
+
+func TestExportSample(t *testing.T) {
+	l := New(100)
+	l.Set("a", "a")
+	l.Set("b", "b")
+
+	var buf bytes.Buffer
+	if err := l.ExportSample(&buf, 5, SampleUniform); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines: %q", buf.String())
+	}
+	for _, line := range lines {
+		var info EntryInfo
+		if err := json.Unmarshal([]byte(line), &info); err != nil {
+			t.Errorf("bad line %q: %v", line, err)
+		}
+		if info.Key != "a" && info.Key != "b" {
+			t.Errorf("unexpected key %v", info.Key)
+		}
+	}
+}
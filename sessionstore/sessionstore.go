@@ -0,0 +1,146 @@
+// Package sessionstore keeps server-side web sessions in an LFUDA cache:
+// sessions expire after their lifetime or, with WithIdleTimeout, once they
+// weren't read for a while, and when memory runs short the least used are
+// dropped first rather than the oldest.  Store implements the Store
+// interface of github.com/alexedwards/scs as well as Get, Save and Delete.
+package sessionstore
+
+import (
+	"time"
+
+	lfuda "github.com/bparli/lfuda-go"
+)
+
+// SessionStore is the interface of a session store keyed by session ID.
+type SessionStore interface {
+	// Get returns the session's data, or false if there is no such live
+	// session.
+	Get(id string) (data []byte, ok bool, err error)
+	// Save stores the session's data for ttl, or until evicted if ttl is
+	// not positive.
+	Save(id string, data []byte, ttl time.Duration) error
+	// Delete removes the session.
+	Delete(id string) error
+}
+
+var _ SessionStore = (*Store)(nil)
+
+// Option configures a Store.
+type Option func(*Store)
+
+// WithIdleTimeout expires sessions not read for d, within their lifetime.
+func WithIdleTimeout(d time.Duration) Option {
+	return func(s *Store) {
+		s.idle = d
+	}
+}
+
+// WithCacheOptions passes options, such as a policy or an eviction callback,
+// to the underlying cache.  Size funcs are overridden.
+func WithCacheOptions(opts ...lfuda.Option) Option {
+	return func(s *Store) {
+		s.opts = append(s.opts, opts...)
+	}
+}
+
+// Store is a session store holding up to a number of bytes of sessions.
+type Store struct {
+	cache *lfuda.Cache
+	idle  time.Duration
+	opts  []lfuda.Option
+}
+
+// session is a stored session.
+type session struct {
+	data []byte
+	// end of the session's lifetime, zero if unlimited
+	deadline time.Time
+}
+
+// New creates a store holding up to size bytes of session IDs and data.
+func New(size float64, opts ...Option) *Store {
+	s := new(Store)
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.opts = append(s.opts, lfuda.WithSizeFunc(func(key, value interface{}) float64 {
+		return float64(len(key.(string)) + len(value.(session).data))
+	}))
+	s.cache = lfuda.NewWithOptions(size, s.opts...)
+	return s
+}
+
+// Cache returns the cache of sessions, keyed by session ID.
+func (s *Store) Cache() *lfuda.Cache {
+	return s.cache
+}
+
+// Get returns a copy of the session's data, renewing its idle timeout.
+func (s *Store) Get(id string) ([]byte, bool, error) {
+	v, ok := s.cache.Get(id)
+	if !ok {
+		return nil, false, nil
+	}
+	sess := v.(session)
+	if s.idle > 0 {
+		ttl, ok := s.ttl(sess.deadline)
+		if !ok {
+			s.cache.Remove(id)
+			return nil, false, nil
+		}
+		s.cache.Expire(id, ttl)
+	}
+	return append([]byte(nil), sess.data...), true, nil
+}
+
+// Save stores a copy of the session's data for ttl, or until evicted or
+// idle if ttl is not positive.
+func (s *Store) Save(id string, data []byte, ttl time.Duration) error {
+	var deadline time.Time
+	if ttl > 0 {
+		deadline = time.Now().Add(ttl)
+	}
+	return s.save(id, data, deadline)
+}
+
+func (s *Store) save(id string, data []byte, deadline time.Time) error {
+	ttl, ok := s.ttl(deadline)
+	if !ok {
+		s.cache.Remove(id)
+		return nil
+	}
+	s.cache.SetWithTTL(id, session{data: append([]byte(nil), data...), deadline: deadline}, ttl)
+	return nil
+}
+
+// ttl returns how long a session ending at deadline stays before it is idle
+// or ends, 0 if never, or false if it already ended.
+func (s *Store) ttl(deadline time.Time) (time.Duration, bool) {
+	ttl := s.idle
+	if !deadline.IsZero() {
+		left := time.Until(deadline)
+		if left <= 0 {
+			return 0, false
+		}
+		if ttl <= 0 || left < ttl {
+			ttl = left
+		}
+	}
+	return ttl, true
+}
+
+// Delete removes the session.
+func (s *Store) Delete(id string) error {
+	s.cache.Remove(id)
+	return nil
+}
+
+// Find returns the session's data, as scs.Store.
+func (s *Store) Find(token string) ([]byte, bool, error) {
+	return s.Get(token)
+}
+
+// Commit stores the session's data until expiry, as scs.Store.
+func (s *Store) Commit(token string, b []byte, expiry time.Time) error {
+	return s.save(token, b, expiry)
+}
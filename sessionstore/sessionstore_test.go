@@ -0,0 +1,59 @@
+package sessionstore
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	s := New(1 << 20)
+	data := []byte("user=1")
+	s.Save("a", data, 0)
+	data[0] = 'U'
+	if got, ok, err := s.Get("a"); !ok || err != nil || string(got) != "user=1" {
+		t.Errorf("got %q, %v, %v", got, ok, err)
+	}
+	if s.Cache().Size() != float64(len("a")+len(data)) {
+		t.Errorf("sessions should cost their ID and data: %v", s.Cache().Size())
+	}
+	s.Delete("a")
+	if _, ok, _ := s.Get("a"); ok {
+		t.Errorf("deleted session found")
+	}
+
+	s.Commit("b", []byte("x"), time.Now().Add(20*time.Millisecond))
+	if _, ok, _ := s.Find("b"); !ok {
+		t.Errorf("committed session not found")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if _, ok, _ := s.Find("b"); ok {
+		t.Errorf("session found after its expiry")
+	}
+	s.Commit("c", []byte("x"), time.Now().Add(-time.Second))
+	if s.Cache().Contains("c") {
+		t.Errorf("expired session stored")
+	}
+}
+
+func TestIdleTimeout(t *testing.T) {
+	s := New(1<<20, WithIdleTimeout(100*time.Millisecond))
+	s.Save("a", []byte("x"), time.Hour)
+	s.Save("b", []byte("x"), 0)
+	for i := 0; i < 3; i++ {
+		time.Sleep(60 * time.Millisecond)
+		if _, ok, _ := s.Get("a"); !ok {
+			t.Fatalf("reading the session should renew its idle timeout")
+		}
+	}
+	if _, ok, _ := s.Get("b"); ok {
+		t.Errorf("idle session found")
+	}
+
+	s.Save("c", []byte("x"), 50*time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+	s.Get("c")
+	time.Sleep(30 * time.Millisecond)
+	if _, ok, _ := s.Get("c"); ok {
+		t.Errorf("reading the session should not extend its lifetime")
+	}
+}
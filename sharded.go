@@ -0,0 +1,502 @@
+package lfuda
+
+import (
+	"context"
+	"runtime"
+	"time"
+)
+
+// ShardedCache is a thread-safe fixed size cache whose keys are spread over
+// independently locked shards, reducing lock contention between goroutines
+// working on different keys.  Each shard ages and evicts on its own.
+type ShardedCache struct {
+	shards []*Cache
+	hasher Hasher
+}
+
+// ShardStats describes the usage of a single shard.
+type ShardStats struct {
+	Stats
+	Len       int
+	Size      float64
+	Breakdown SizeBreakdown
+}
+
+// NewSharded creates a sharded cache of the given total size in bytes, split
+// evenly between its shards.  The options are applied to every shard; the
+// number of shards is set with WithShards and defaults to the number of CPUs.
+func NewSharded(size float64, opts ...Option) *ShardedCache {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	n := o.shards
+	if n <= 0 {
+		n = runtime.NumCPU()
+	}
+
+	if o.maxItems > 0 {
+		opts = append(opts[:len(opts):len(opts)], WithMaxEntries((o.maxItems+n-1)/n))
+	}
+
+	s := &ShardedCache{shards: make([]*Cache, n), hasher: o.hasher}
+	for i := range s.shards {
+		s.shards[i] = NewWithOptions(size/float64(n), opts...)
+	}
+	return s
+}
+
+func (s *ShardedCache) shard(key interface{}) *Cache {
+	if s.hasher != nil {
+		return s.shards[s.hasher.Hash(key)%uint64(len(s.shards))]
+	}
+	return s.shards[hashKey(key)%uint64(len(s.shards))]
+}
+
+// Shards returns the number of shards.
+func (s *ShardedCache) Shards() int {
+	return len(s.shards)
+}
+
+// Set adds a value to the cache. Returns true if an eviction occurred.
+func (s *ShardedCache) Set(key, value interface{}) bool {
+	return s.shard(key).Set(key, value)
+}
+
+// SetAsync queues a value to be added to its shard, see Cache.SetAsync.
+func (s *ShardedCache) SetAsync(key, value interface{}) bool {
+	return s.shard(key).SetAsync(key, value)
+}
+
+// WaitAsync waits for the values queued by SetAsync before it to be added.
+func (s *ShardedCache) WaitAsync() {
+	for _, c := range s.shards {
+		c.WaitAsync()
+	}
+}
+
+// Hits returns the key's hits without counting an access.
+func (s *ShardedCache) Hits(key interface{}) (float64, bool) {
+	return s.shard(key).Hits(key)
+}
+
+// Inspect returns the key's metadata without counting an access.
+func (s *ShardedCache) Inspect(key interface{}) (EntryInfo, bool) {
+	return s.shard(key).Inspect(key)
+}
+
+// SetEvicting adds a value to the cache and returns the entries evicted from
+// its shard to make room for it.
+func (s *ShardedCache) SetEvicting(key, value interface{}) []Evicted {
+	return s.shard(key).SetEvicting(key, value)
+}
+
+// SetWithSize adds a value to the cache, accounting for it as size bytes.
+// Returns true if an eviction occurred.
+func (s *ShardedCache) SetWithSize(key, value interface{}, size float64) bool {
+	return s.shard(key).SetWithSize(key, value, size)
+}
+
+// SetWithTTL adds a value to the cache that expires after ttl.  Returns true
+// if an eviction occurred.
+func (s *ShardedCache) SetWithTTL(key, value interface{}, ttl time.Duration) bool {
+	return s.shard(key).SetWithTTL(key, value, ttl)
+}
+
+// Get looks up a key's value from the cache.
+func (s *ShardedCache) Get(key interface{}) (interface{}, bool) {
+	return s.shard(key).Get(key)
+}
+
+// GetWithMeta is Get also returning the entry's metadata.  See
+// Cache.GetWithMeta.
+func (s *ShardedCache) GetWithMeta(key interface{}) (value interface{}, meta EntryInfo, ok bool) {
+	return s.shard(key).GetWithMeta(key)
+}
+
+// GetWithVersion is Get also returning the key's version.  See
+// Cache.GetWithVersion.
+func (s *ShardedCache) GetWithVersion(key interface{}) (value interface{}, version uint64, ok bool) {
+	return s.shard(key).GetWithVersion(key)
+}
+
+// SetIfVersion sets the value only if the key's current version is version.
+// See Cache.SetIfVersion.
+func (s *ShardedCache) SetIfVersion(key, value interface{}, version uint64) (current uint64, ok bool) {
+	return s.shard(key).SetIfVersion(key, value, version)
+}
+
+// Lookup is Get for caches holding negative entries.  See Cache.Lookup.
+func (s *ShardedCache) Lookup(key interface{}) (value interface{}, ok bool, err error) {
+	return s.shard(key).Lookup(key)
+}
+
+// SetNegative records that key is known to be missing for ttl.  Returns true
+// if an eviction occurred.
+func (s *ShardedCache) SetNegative(key interface{}, ttl time.Duration) bool {
+	return s.shard(key).SetNegative(key, ttl)
+}
+
+// GetOrLoad returns the value cached for key or, on a miss, loads and caches
+// it.  See Cache.GetOrLoad.
+func (s *ShardedCache) GetOrLoad(ctx context.Context, key interface{}, loader Loader) (interface{}, error) {
+	return s.shard(key).GetOrLoad(ctx, key, loader)
+}
+
+// Warm loads the keys missing from the cache one at a time, stopping at the
+// first error or once ctx is done.  Returns the number of keys loaded.
+func (s *ShardedCache) Warm(ctx context.Context, keys []interface{}, loader Loader) (loaded int, err error) {
+	progress, err := s.WarmWithOptions(ctx, keys, loader, WarmOptions{})
+	return progress.Loaded, err
+}
+
+// WarmWithOptions loads the keys missing from the cache, reporting progress
+// as it goes.  See Cache.WarmWithOptions.
+func (s *ShardedCache) WarmWithOptions(ctx context.Context, keys []interface{}, loader Loader, opts WarmOptions) (WarmProgress, error) {
+	return warm(ctx, keys, opts, s.Contains, func(ctx context.Context, key interface{}) (float64, error) {
+		return s.shard(key).warmKey(ctx, key, loader)
+	})
+}
+
+// Peek returns the key value without updating its hits.
+func (s *ShardedCache) Peek(key interface{}) (interface{}, bool) {
+	return s.shard(key).Peek(key)
+}
+
+// Contains checks if a key is in the cache without updating its hits.
+func (s *ShardedCache) Contains(key interface{}) bool {
+	return s.shard(key).Contains(key)
+}
+
+// ContainsKeys reports for every key whether it is in the cache, taking the
+// read lock of each shard once.
+func (s *ShardedCache) ContainsKeys(keys []interface{}) []bool {
+	found := make([]bool, len(keys))
+	byShard := make(map[*Cache][]int)
+	for i, key := range keys {
+		c := s.shard(key)
+		byShard[c] = append(byShard[c], i)
+	}
+	for c, indexes := range byShard {
+		shardKeys := make([]interface{}, len(indexes))
+		for j, i := range indexes {
+			shardKeys[j] = keys[i]
+		}
+		for j, ok := range c.ContainsKeys(shardKeys) {
+			found[indexes[j]] = ok
+		}
+	}
+	return found
+}
+
+// ContainsAll reports whether every key is in the cache.
+func (s *ShardedCache) ContainsAll(keys []interface{}) bool {
+	for _, ok := range s.ContainsKeys(keys) {
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// ContainsAny reports whether any of the keys is in the cache.
+func (s *ShardedCache) ContainsAny(keys []interface{}) bool {
+	for _, ok := range s.ContainsKeys(keys) {
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainsOrSet checks if a key is in the cache and if not, adds the value.
+// Returns whether found and whether an eviction occurred.
+func (s *ShardedCache) ContainsOrSet(key, value interface{}) (ok, set bool) {
+	return s.shard(key).ContainsOrSet(key, value)
+}
+
+// PeekOrSet checks if a key is in the cache and if not, adds the value.
+// Returns the previous value, whether found and whether an eviction occurred.
+func (s *ShardedCache) PeekOrSet(key, value interface{}) (previous interface{}, ok, set bool) {
+	return s.shard(key).PeekOrSet(key, value)
+}
+
+// Boost adds delta to a key's hits.  Returns false if the key is not in the
+// cache.
+func (s *ShardedCache) Boost(key interface{}, delta float64) bool {
+	return s.shard(key).Boost(key, delta)
+}
+
+// Update atomically replaces a key's value with the one returned by fn.  See
+// Cache.Update.
+func (s *ShardedCache) Update(key interface{}, fn UpdateFunc) (value interface{}, ok bool) {
+	return s.shard(key).Update(key, fn)
+}
+
+// Increment atomically adds delta to an int64 or int value.  See
+// Cache.Increment.
+func (s *ShardedCache) Increment(key interface{}, delta int64) (int64, error) {
+	return s.shard(key).Increment(key, delta)
+}
+
+// Decrement atomically subtracts delta from an int64 or int value.
+func (s *ShardedCache) Decrement(key interface{}, delta int64) (int64, error) {
+	return s.shard(key).Decrement(key, delta)
+}
+
+// IncrementFloat is Increment for float64 values.
+func (s *ShardedCache) IncrementFloat(key interface{}, delta float64) (float64, error) {
+	return s.shard(key).IncrementFloat(key, delta)
+}
+
+// SetPriority puts a key in a priority class.  Returns false if the key is
+// not in the cache.
+func (s *ShardedCache) SetPriority(key interface{}, class PriorityClass) bool {
+	return s.shard(key).SetPriority(key, class)
+}
+
+// Expire sets a key to expire after ttl, or never if ttl is not positive.
+// Returns false if the key is not in the cache.
+func (s *ShardedCache) Expire(key interface{}, ttl time.Duration) bool {
+	return s.shard(key).Expire(key, ttl)
+}
+
+// TTL returns the time left before a key expires, 0 if it never expires.
+// Returns false if the key is not in the cache.
+func (s *ShardedCache) TTL(key interface{}) (time.Duration, bool) {
+	return s.shard(key).TTL(key)
+}
+
+// Touch counts an access to a key and renews its TTL.  Returns false if the
+// key is not in the cache.
+func (s *ShardedCache) Touch(key interface{}) bool {
+	return s.shard(key).Touch(key)
+}
+
+// Remove removes the provided key from the cache.
+func (s *ShardedCache) Remove(key interface{}) bool {
+	return s.shard(key).Remove(key)
+}
+
+// RemoveFunc removes every entry for which fn returns true, locking one
+// shard at a time.  Returns the number of entries removed.
+func (s *ShardedCache) RemoveFunc(fn func(key, value interface{}) bool) int {
+	removed := 0
+	for _, c := range s.shards {
+		removed += c.RemoveFunc(fn)
+	}
+	return removed
+}
+
+// RemovePrefix removes every entry whose key is a string starting with
+// prefix.  Returns the number of entries removed.
+func (s *ShardedCache) RemovePrefix(prefix string) int {
+	removed := 0
+	for _, c := range s.shards {
+		removed += c.RemovePrefix(prefix)
+	}
+	return removed
+}
+
+// PurgeExpired removes every expired entry.  Returns the number of entries
+// removed.
+func (s *ShardedCache) PurgeExpired() int {
+	removed := 0
+	for _, c := range s.shards {
+		removed += c.PurgeExpired()
+	}
+	return removed
+}
+
+// Keys returns a slice of the keys in the cache.  Keys are ordered by
+// frequency within each shard only.
+func (s *ShardedCache) Keys() []interface{} {
+	var keys []interface{}
+	for _, c := range s.shards {
+		keys = append(keys, c.Keys()...)
+	}
+	return keys
+}
+
+// Len returns the number of items in the cache.
+func (s *ShardedCache) Len() int {
+	length := 0
+	for _, c := range s.shards {
+		length += c.Len()
+	}
+	return length
+}
+
+// Size returns the current size of the cache in bytes.
+func (s *ShardedCache) Size() float64 {
+	var size float64
+	for _, c := range s.shards {
+		size += c.Size()
+	}
+	return size
+}
+
+// SizeBreakdown returns the number and size of the cache's entries by state,
+// summed over all shards.
+func (s *ShardedCache) SizeBreakdown() (b SizeBreakdown) {
+	for _, c := range s.shards {
+		b.add(c.SizeBreakdown())
+	}
+	return b
+}
+
+// FrequencyHistogram counts the live entries of every shard by hits.
+func (s *ShardedCache) FrequencyHistogram(buckets []float64) []int {
+	counts := make([]int, len(buckets)+1)
+	for _, c := range s.shards {
+		for i, n := range c.FrequencyHistogram(buckets) {
+			counts[i] += n
+		}
+	}
+	return counts
+}
+
+// Capacity returns the size of the cache in bytes.
+func (s *ShardedCache) Capacity() float64 {
+	var capacity float64
+	for _, c := range s.shards {
+		capacity += c.Capacity()
+	}
+	return capacity
+}
+
+// Resize changes the size of the cache, split evenly between its shards.
+// Returns the number of entries evicted.
+func (s *ShardedCache) Resize(size float64) int {
+	evicted := 0
+	for _, c := range s.shards {
+		evicted += c.Resize(size / float64(len(s.shards)))
+	}
+	return evicted
+}
+
+// SwitchPolicy converts every shard to policy, see Cache.SwitchPolicy.
+func (s *ShardedCache) SwitchPolicy(policy string) error {
+	for _, c := range s.shards {
+		if err := c.SwitchPolicy(policy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HalveHits halves the hits of every entry of every shard.
+func (s *ShardedCache) HalveHits() {
+	for _, c := range s.shards {
+		c.HalveHits()
+	}
+}
+
+// Close stops the background work of every shard.  The cache must not be
+// used afterwards.
+func (s *ShardedCache) Close() error {
+	for _, c := range s.shards {
+		c.Close()
+	}
+	return nil
+}
+
+// Purge is used to completely clear the cache.
+func (s *ShardedCache) Purge() {
+	for _, c := range s.shards {
+		c.Purge()
+	}
+}
+
+// EvictBytes evicts at least n bytes, taking from every shard in proportion
+// to its size.  Returns the number of bytes freed.
+func (s *ShardedCache) EvictBytes(n float64) (freed float64) {
+	total := s.Size()
+	if total <= 0 {
+		return 0
+	}
+	for _, c := range s.shards {
+		freed += c.EvictBytes(n * c.Size() / total)
+	}
+	return freed
+}
+
+// EvictEntries evicts about n entries, taking from every shard in proportion
+// to its number of entries.  Returns the number of entries evicted.
+func (s *ShardedCache) EvictEntries(n int) (evicted int) {
+	total := s.Len()
+	if total == 0 {
+		return 0
+	}
+	for _, c := range s.shards {
+		evicted += c.EvictEntries((n*c.Len() + total - 1) / total)
+	}
+	return evicted
+}
+
+// Clone returns a copy of the cache, cloning one shard at a time.  See
+// Cache.Clone.
+func (s *ShardedCache) Clone() *ShardedCache {
+	clone := &ShardedCache{shards: make([]*Cache, len(s.shards)), hasher: s.hasher}
+	for i, c := range s.shards {
+		clone.shards[i] = c.Clone()
+	}
+	return clone
+}
+
+// Stats returns the sum of the counters of all shards.
+func (s *ShardedCache) Stats() Stats {
+	var total Stats
+	for _, c := range s.shards {
+		st := c.Stats()
+		total.Hits += st.Hits
+		total.Misses += st.Misses
+		total.Loads += st.Loads
+		total.LoadErrors += st.LoadErrors
+		total.NegativeHits += st.NegativeHits
+		total.Panics += st.Panics
+		total.DroppedSets += st.DroppedSets
+		total.Lifetimes.merge(st.Lifetimes)
+		total.EvictionHits.merge(st.EvictionHits)
+		total.LockWaits.merge(st.LockWaits)
+		total.ReadLockWaits.merge(st.ReadLockWaits)
+		total.LockHolds.merge(st.LockHolds)
+	}
+	return total
+}
+
+// ResetStats zeroes the counters and hit ratio windows of all shards.
+func (s *ShardedCache) ResetStats() {
+	for _, c := range s.shards {
+		c.ResetStats()
+	}
+}
+
+// HitRatioOver returns the fraction of Gets that were hits over the last d
+// in all shards.  See Cache.HitRatioOver.
+func (s *ShardedCache) HitRatioOver(d time.Duration) float64 {
+	var hits, misses uint64
+	for _, c := range s.shards {
+		if c.window != nil {
+			h, m := c.window.counts(d)
+			hits += h
+			misses += m
+		}
+	}
+	return ratio(hits, misses)
+}
+
+// ShardStats returns the usage of every shard, to help spot hot keys skewing
+// the load towards a few shards.
+func (s *ShardedCache) ShardStats() []ShardStats {
+	stats := make([]ShardStats, len(s.shards))
+	for i, c := range s.shards {
+		stats[i] = ShardStats{
+			Stats:     c.Stats(),
+			Len:       c.Len(),
+			Size:      c.Size(),
+			Breakdown: c.SizeBreakdown(),
+		}
+	}
+	return stats
+}
@@ -0,0 +1,95 @@
+package lfuda
+
+import (
+	"math/rand"
+	"runtime"
+	"testing"
+)
+
+func TestSharded(t *testing.T) {
+	s := NewSharded(4000, WithShards(4))
+	if s.Shards() != 4 {
+		t.Fatalf("bad shard count: %d", s.Shards())
+	}
+
+	for i := 0; i < 1000; i++ {
+		s.Set(i, i)
+	}
+	for i := 0; i < 1000; i++ {
+		if v, ok := s.Get(i); !ok || v != i {
+			t.Fatalf("bad value for %d: %v, %v", i, v, ok)
+		}
+	}
+	s.Get("missing")
+
+	if s.Len() != 1000 || len(s.Keys()) != 1000 {
+		t.Errorf("bad len: %d", s.Len())
+	}
+
+	total := 0
+	for i, st := range s.ShardStats() {
+		if st.Len == 0 || st.Size == 0 {
+			t.Errorf("shard %d is empty", i)
+		}
+		total += st.Len
+	}
+	if total != 1000 {
+		t.Errorf("shard lengths should sum to the total: %d", total)
+	}
+
+	stats := s.Stats()
+	if stats.Hits != 1000 || stats.Misses != 1 {
+		t.Errorf("bad stats: %+v", stats)
+	}
+
+	if !s.Remove(1) || s.Contains(1) {
+		t.Errorf("1 should have been removed")
+	}
+	s.Purge()
+	if s.Len() != 0 || s.Size() != 0 {
+		t.Errorf("cache should be empty")
+	}
+}
+
+func TestShardedDefaults(t *testing.T) {
+	s := NewSharded(100)
+	if s.Shards() != runtime.NumCPU() {
+		t.Errorf("shard count should default to the number of CPUs: %d", s.Shards())
+	}
+}
+
+func TestShardedMaxEntries(t *testing.T) {
+	s := NewSharded(1000, WithShards(4), WithMaxEntries(10))
+	for i := 0; i < 100; i++ {
+		s.Set(i, i)
+	}
+	// the limit is rounded up per shard
+	if s.Len() > 12 {
+		t.Errorf("entry count should be capped per shard: %d", s.Len())
+	}
+}
+
+// parallelTrace runs the BenchmarkLFUDA workload from all goroutines: one Set
+// for every Get, over twice as many keys as fit in the cache.
+func parallelTrace(b *testing.B, set func(k, v interface{}) bool, get func(k interface{}) (interface{}, bool)) {
+	b.RunParallel(func(pb *testing.PB) {
+		r := rand.New(rand.NewSource(rand.Int63()))
+		for pb.Next() {
+			k := r.Int63() % 16384
+			set(k, k)
+			get(r.Int63() % 32768)
+		}
+	})
+}
+
+func BenchmarkLFUDA_Parallel(b *testing.B) {
+	l := New(8192)
+	b.ResetTimer()
+	parallelTrace(b, l.Set, l.Get)
+}
+
+func BenchmarkSharded_Parallel(b *testing.B) {
+	l := NewSharded(8192)
+	b.ResetTimer()
+	parallelTrace(b, l.Set, l.Get)
+}
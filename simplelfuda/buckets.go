@@ -0,0 +1,163 @@
+package simplelfuda
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// listEntry holds the items sharing a priorityKey
+type listEntry struct {
+	entries     map[*item]byte
+	priorityKey float64
+	// position in the heap
+	index int
+}
+
+// bucketHeap is a min-heap of the priority buckets, so the least valuable
+// items are found in O(1) and moving an item to another priority costs
+// O(log buckets) instead of a walk along a sorted list
+type bucketHeap []*listEntry
+
+func (h bucketHeap) Len() int           { return len(h) }
+func (h bucketHeap) Less(i, j int) bool { return h[i].priorityKey < h[j].priorityKey }
+
+func (h bucketHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *bucketHeap) Push(x interface{}) {
+	b := x.(*listEntry)
+	b.index = len(*h)
+	*h = append(*h, b)
+}
+
+func (h *bucketHeap) Pop() interface{} {
+	old := *h
+	b := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return b
+}
+
+// byPriority is a min-heap over a subset of the buckets that leaves their
+// indexes alone, to select some of them without sorting them all
+type byPriority []*listEntry
+
+func (h byPriority) Len() int           { return len(h) }
+func (h byPriority) Less(i, j int) bool { return h[i].priorityKey < h[j].priorityKey }
+func (h byPriority) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *byPriority) Push(x interface{}) {
+	*h = append(*h, x.(*listEntry))
+}
+
+func (h *byPriority) Pop() interface{} {
+	old := *h
+	b := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return b
+}
+
+// bucket returns the bucket of priorityKey, creating it if needed
+func (l *LFUDA) bucket(priorityKey float64) *listEntry {
+	b, ok := l.buckets[priorityKey]
+	if !ok {
+		b = &listEntry{
+			entries:     make(map[*item]byte),
+			priorityKey: priorityKey,
+		}
+		heap.Push(&l.freqs, b)
+		l.buckets[priorityKey] = b
+	}
+	return b
+}
+
+// lowest returns the bucket of the least valuable items, nil if the cache is
+// empty
+func (l *LFUDA) lowest() *listEntry {
+	if len(l.freqs) == 0 {
+		return nil
+	}
+	return l.freqs[0]
+}
+
+// frontier is a min-heap of positions in the bucket heap, the buckets next
+// in order while walking it
+type frontier struct {
+	freqs   bucketHeap
+	indexes []int
+}
+
+func (f frontier) Len() int { return len(f.indexes) }
+func (f frontier) Less(i, j int) bool {
+	return f.freqs[f.indexes[i]].priorityKey < f.freqs[f.indexes[j]].priorityKey
+}
+func (f frontier) Swap(i, j int) { f.indexes[i], f.indexes[j] = f.indexes[j], f.indexes[i] }
+
+func (f *frontier) Push(x interface{}) {
+	f.indexes = append(f.indexes, x.(int))
+}
+
+func (f *frontier) Pop() interface{} {
+	old := f.indexes
+	i := old[len(old)-1]
+	f.indexes = old[:len(old)-1]
+	return i
+}
+
+// ascending calls fn for every bucket from the least to the most valuable
+// until fn returns false.  A bucket's children in the heap only follow it, so
+// the walk keeps a frontier of the buckets whose parents were visited and
+// visiting k buckets costs O(k log k)
+func (l *LFUDA) ascending(fn func(b *listEntry) bool) {
+	if len(l.freqs) == 0 {
+		return
+	}
+	f := &frontier{freqs: l.freqs, indexes: []int{0}}
+	for f.Len() > 0 {
+		i := heap.Pop(f).(int)
+		if !fn(l.freqs[i]) {
+			return
+		}
+		if left := 2*i + 1; left < len(l.freqs) {
+			heap.Push(f, left)
+			if left+1 < len(l.freqs) {
+				heap.Push(f, left+1)
+			}
+		}
+	}
+}
+
+// top returns the fewest most valuable buckets holding at least k items,
+// or all of them, from the most to the least valuable.  The buckets are
+// selected with a min-heap of the candidates instead of sorting them all
+func (l *LFUDA) top(k int) []*listEntry {
+	var h byPriority
+	n := 0
+	for _, b := range l.freqs {
+		if n >= k && b.priorityKey <= h[0].priorityKey {
+			continue
+		}
+		heap.Push(&h, b)
+		n += len(b.entries)
+		// drop the least valuable bucket while the others hold k items
+		for n-len(h[0].entries) >= k {
+			n -= len(heap.Pop(&h).(*listEntry).entries)
+		}
+	}
+	sort.Slice(h, func(i, j int) bool {
+		return h[i].priorityKey > h[j].priorityKey
+	})
+	return h
+}
+
+// descending returns the buckets from the most to the least valuable
+func (l *LFUDA) descending() []*listEntry {
+	buckets := append([]*listEntry(nil), l.freqs...)
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].priorityKey > buckets[j].priorityKey
+	})
+	return buckets
+}
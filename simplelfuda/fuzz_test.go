@@ -0,0 +1,92 @@
+package simplelfuda
+
+import "testing"
+
+// FuzzLFUDA interleaves operations decoded from the input, checking the
+// size accounting and ordering invariants after each one.
+func FuzzLFUDA(f *testing.F) {
+	f.Add([]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, uint8(0))
+	f.Add([]byte{0, 1, 200, 0, 2, 100, 1, 1, 3, 50, 5, 6, 7}, uint8(1))
+	f.Add([]byte{0, 9, 255, 8, 1, 4, 9, 10, 2, 11, 0, 12}, uint8(2))
+	f.Fuzz(func(t *testing.T, ops []byte, policy uint8) {
+		var l *LFUDA
+		switch policy % 4 {
+		case 0:
+			l = NewLFUDA(256, nil)
+		case 1:
+			l = NewGDSF(256, nil)
+		case 2:
+			l = NewLFU(256, nil)
+		default:
+			l = NewHybrid(256, 0.5, nil)
+		}
+		for len(ops) >= 3 {
+			op, key, arg := ops[0], ops[1]%16, ops[2]
+			ops = ops[3:]
+			switch op % 13 {
+			case 0, 1:
+				l.SetWithSize(key, arg, float64(arg))
+			case 2, 3:
+				l.Get(key)
+			case 4:
+				l.Remove(key)
+			case 5:
+				l.Resize(float64(arg) * 2)
+			case 6:
+				l.Purge()
+			case 7:
+				l.Boost(key, float64(int8(arg)))
+			case 8:
+				l.SetMaxEntries(int(arg % 8))
+			case 9:
+				l.HalveHits()
+			case 10:
+				l.SetMaxHits(float64(arg % 8))
+			case 11:
+				l.SwitchPolicy([]string{"LFUDA", "GDSF", "LFU", "Hybrid"}[arg%4])
+			case 12:
+				l.Evict()
+			}
+			checkInvariants(t, l)
+		}
+	})
+}
+
+func checkInvariants(t *testing.T, l *LFUDA) {
+	t.Helper()
+	if l.Size() < 0 || l.Size() > l.Capacity() {
+		t.Fatalf("size %v out of [0, %v]", l.Size(), l.Capacity())
+	}
+	if l.maxItems > 0 && l.Len() > l.maxItems {
+		t.Fatalf("%d items over the limit of %d", l.Len(), l.maxItems)
+	}
+	if keys := l.Keys(); len(keys) != l.Len() {
+		t.Fatalf("%d keys for %d items", len(keys), l.Len())
+	}
+
+	size, n := 0.0, 0
+	prev := 0.0
+	l.Range(func(info EntryInfo) bool {
+		if n > 0 && info.Priority > prev {
+			t.Fatalf("priorities out of order: %v after %v", info.Priority, prev)
+		}
+		if info.Hits < 0 || (l.maxHits > 0 && info.Hits > l.maxHits) {
+			t.Fatalf("hits out of range: %+v", info)
+		}
+		prev = info.Priority
+		size += info.Size
+		n++
+		return true
+	})
+	if n != l.Len() || size != l.Size() {
+		t.Fatalf("ranged %d items of %v bytes, want %d of %v", n, size, l.Len(), l.Size())
+	}
+	for i, b := range l.freqs {
+		if b.index != i || len(b.entries) == 0 || l.buckets[b.priorityKey] != b {
+			t.Fatalf("bucket %d is inconsistent: %+v", i, b)
+		}
+	}
+	if len(l.buckets) != len(l.freqs) {
+		t.Fatalf("%d buckets indexed for %d in the heap", len(l.buckets), len(l.freqs))
+	}
+}
@@ -1,8 +1,11 @@
 package simplelfuda
 
 import (
-	"container/list"
+	"container/heap"
 	"fmt"
+	"math"
+	"sort"
+	"time"
 )
 
 /*
@@ -23,11 +26,31 @@ type LFUDA struct {
 	// size of the entire cache in bytes
 	size     float64
 	currSize float64
+	// maximum number of items, 0 if unlimited
+	maxItems int
 	items    map[interface{}]*item
-	freqs    *list.List
+	// buckets of items by priorityKey, in a min-heap
+	freqs    bucketHeap
+	buckets  map[float64]*listEntry
 	onEvict  EvictCallback
+	onExpire EvictCallback
 	age      float64
-	policy   cachePolicy
+	aging    Aging
+	// maximum hits of an item, 0 if unlimited
+	maxHits float64
+	policy  cachePolicy
+	// name of the policy, a key of policies or "Hybrid"
+	policyName string
+	// exponent of the item size in Hybrid priorities
+	alpha float64
+	// last version given to a set item
+	version uint64
+	// counts accesses to order items by recency
+	clock uint64
+	// observes the metadata of evicted items
+	evictInfo func(info EntryInfo)
+	// record the time of every access
+	accessTimes bool
 }
 
 type item struct {
@@ -36,56 +59,178 @@ type item struct {
 	size        float64
 	hits        float64
 	priorityKey float64
-	freqNode    *list.Element
+	freqNode    *listEntry
+	// expiration deadline in unix nanoseconds, 0 if the item never expires
+	expires int64
+	// ttl the deadline was last set with, renewed by Touch
+	ttl time.Duration
+	// increases every time the item is set
+	version uint64
+	class   PriorityClass
+	// cost of regenerating the value, weighing GDSF priorities, 0 for 1
+	cost float64
+	// cache clock at the last access
+	accessed uint64
+	// time the item was added in unix nanoseconds
+	created int64
+	// time of the last access in unix nanoseconds, if access times are
+	// recorded
+	lastAccess int64
 }
 
-type listEntry struct {
-	entries     map[*item]byte
-	priorityKey float64
+// PriorityClass scales the hits an entry's priority is computed from, so
+// entries of a higher class survive longer without being pinned.
+type PriorityClass int8
+
+const (
+	PriorityLow PriorityClass = iota - 1
+	PriorityNormal
+	PriorityHigh
+)
+
+// weight returns the factor applied to the hits of entries of the class.
+func (p PriorityClass) weight() float64 {
+	switch {
+	case p < PriorityNormal:
+		return 0.5
+	case p > PriorityNormal:
+		return 2
+	}
+	return 1
+}
+
+// String returns the class name.
+func (p PriorityClass) String() string {
+	switch {
+	case p < PriorityNormal:
+		return "low"
+	case p > PriorityNormal:
+		return "high"
+	}
+	return "normal"
+}
+
+// freq returns the item's hits weighted by its priority class.
+func (e *item) freq() float64 {
+	return e.hits * e.class.weight()
+}
+
+func (e *item) expired() bool {
+	return e.expires != 0 && time.Now().UnixNano() >= e.expires
+}
+
+// EntryInfo describes a cache entry without its value
+type EntryInfo struct {
+	Key      interface{}   `json:"key"`
+	Size     float64       `json:"size"`
+	Hits     float64       `json:"hits"`
+	Priority float64       `json:"priority"`
+	Version  uint64        `json:"version"`
+	Class    PriorityClass `json:"class"`
+	// expiration deadline in unix nanoseconds, 0 if the entry never expires
+	Expires int64 `json:"expires,omitempty"`
+	// cost set with SetCost, 0 if none
+	Cost float64 `json:"cost,omitempty"`
+	// time the entry was added in unix nanoseconds
+	Created int64 `json:"created,omitempty"`
+	// time of the last access in unix nanoseconds, 0 unless access times
+	// are recorded
+	Accessed int64 `json:"accessed,omitempty"`
+}
+
+// Age returns how long ago the entry was added.
+func (e EntryInfo) Age() time.Duration {
+	return time.Since(time.Unix(0, e.Created))
+}
+
+// Idle returns how long ago the entry was last accessed, or false if access
+// times are not recorded.
+func (e EntryInfo) Idle() (time.Duration, bool) {
+	if e.Accessed == 0 {
+		return 0, false
+	}
+	return time.Since(time.Unix(0, e.Accessed)), true
+}
+
+// Aging tunes how the cache age follows evictions.  By default the age
+// jumps to the priority of every evicted item.
+type Aging struct {
+	// Factor scales the evicted item's priority the age is raised to, 1 if
+	// 0.  Below 1 the age lags behind evictions, so new items start further
+	// below old popular ones and those decay more slowly.
+	Factor float64
+	// Damping moves the age by only this fraction of the way to its target
+	// on every eviction, between 0 and 1, smoothing bursts of evictions.  0
+	// disables damping.
+	Damping float64
 }
 
 // NewGDSF constructs an LFUDA of the given size in bytes and uses the GDSF eviction policy
 func NewGDSF(size float64, onEvict EvictCallback) *LFUDA {
 	return &LFUDA{
-		size:     size,
-		currSize: 0,
-		items:    make(map[interface{}]*item),
-		freqs:    list.New(),
-		onEvict:  onEvict,
-		age:      0,
-		policy:   gdsfPolicy,
+		size:       size,
+		currSize:   0,
+		items:      make(map[interface{}]*item),
+		buckets:    make(map[float64]*listEntry),
+		onEvict:    onEvict,
+		age:        0,
+		policy:     gdsfPolicy,
+		policyName: "GDSF",
 	}
 }
 
 // NewLFUDA constructs an LFUDA of the given size in bytes and uses the LFUDA eviction policy
 func NewLFUDA(size float64, onEvict EvictCallback) *LFUDA {
 	return &LFUDA{
-		size:     size,
-		currSize: 0,
-		items:    make(map[interface{}]*item),
-		freqs:    list.New(),
-		onEvict:  onEvict,
-		age:      0,
-		policy:   lfudaPolicy,
+		size:       size,
+		currSize:   0,
+		items:      make(map[interface{}]*item),
+		buckets:    make(map[float64]*listEntry),
+		onEvict:    onEvict,
+		age:        0,
+		policy:     lfudaPolicy,
+		policyName: "LFUDA",
+	}
+}
+
+// NewHybrid constructs an LFUDA of the given size in bytes whose priorities
+// are the hits divided by the size to the power of alpha, plus the age, so
+// alpha 0 behaves like LFUDA and alpha 1 like GDSF
+func NewHybrid(size float64, alpha float64, onEvict EvictCallback) *LFUDA {
+	return &LFUDA{
+		size:       size,
+		currSize:   0,
+		items:      make(map[interface{}]*item),
+		buckets:    make(map[float64]*listEntry),
+		onEvict:    onEvict,
+		age:        0,
+		policy:     hybridPolicy(alpha),
+		policyName: "Hybrid",
+		alpha:      alpha,
 	}
 }
 
 // NewLFU constructs an LFUDA of the given size in bytes and uses the LFU eviction policy
 func NewLFU(size float64, onEvict EvictCallback) *LFUDA {
 	return &LFUDA{
-		size:     size,
-		currSize: 0,
-		items:    make(map[interface{}]*item),
-		freqs:    list.New(),
-		onEvict:  onEvict,
-		age:      0,
-		policy:   lfuPolicy,
+		size:       size,
+		currSize:   0,
+		items:      make(map[interface{}]*item),
+		buckets:    make(map[float64]*listEntry),
+		onEvict:    onEvict,
+		age:        0,
+		policy:     lfuPolicy,
+		policyName: "LFU",
 	}
 }
 
-// Get looks up a key's value from the cache
+// Get looks up a key's value from the cache.  Expired items are removed
 func (l *LFUDA) Get(key interface{}) (interface{}, bool) {
 	if e, ok := l.items[key]; ok {
+		if e.expired() {
+			l.removeItem(e, l.expireCallback())
+			return nil, false
+		}
 		l.increment(e)
 		return e.value, true
 	}
@@ -95,52 +240,70 @@ func (l *LFUDA) Get(key interface{}) (interface{}, bool) {
 
 // Peek looks up a key's value from the cache but will not increment the items hit counter
 func (l *LFUDA) Peek(key interface{}) (interface{}, bool) {
-	if e, ok := l.items[key]; ok {
+	if e, ok := l.items[key]; ok && !e.expired() {
 		return e.value, true
 	}
 	return nil, false
 }
 
+// Info returns a key's metadata without updating its hits.
+func (l *LFUDA) Info(key interface{}) (EntryInfo, bool) {
+	if e, ok := l.items[key]; ok && !e.expired() {
+		return e.info(), true
+	}
+	return EntryInfo{}, false
+}
+
+// Version returns the key's version, which increases every time it is set,
+// even if it was evicted in between.
+func (l *LFUDA) Version(key interface{}) (uint64, bool) {
+	if e, ok := l.items[key]; ok && !e.expired() {
+		return e.version, true
+	}
+	return 0, false
+}
+
 // Set adds a value to the cache.  Returns true if an eviction occurred.
 func (l *LFUDA) Set(key interface{}, value interface{}) bool {
+	// convert to bytes so we can get the size of the value
+	var numBytes float64
+	// if the value is binary
+	if valBytes, ok := value.([]byte); ok {
+		numBytes = float64(len(valBytes))
+	} else {
+		// otherwise use the default format
+		numBytes = float64(len([]byte(fmt.Sprintf("%v", value.(interface{})))))
+	}
+	return l.SetWithSize(key, value, numBytes)
+}
+
+// SetWithSize adds a value to the cache, accounting for it as numBytes
+// instead of deriving its size from the value.  Returns true if an eviction
+// occurred.
+func (l *LFUDA) SetWithSize(key interface{}, value interface{}, numBytes float64) bool {
 	evicted := false
 	if e, ok := l.items[key]; ok {
 		// value already exists for key.  overwrite
-		e.value = value
-		l.increment(e)
+		e.expires = 0
+		e.ttl = 0
+		evicted = l.overwrite(e, value, numBytes)
 	} else {
-		// check if we need to evict
-		// convert to bytes so we can get the size of the value
-
-		var numBytes float64
-		// if the value is binary
-		if valBytes, ok := value.([]byte); ok {
-			numBytes = float64(len(valBytes))
-		} else {
-			// otherwise use the default format
-			numBytes = float64(len([]byte(fmt.Sprintf("%v", value.(interface{})))))
-		}
-
 		// check this value will even fit in the cache.  if not just return
 		if l.size < numBytes {
 			return false
 		}
 
 		// evict until there is room for the new item
-		for {
-			if l.currSize+numBytes > l.size {
-				l.evict()
-				evicted = true
-			} else {
-				break
-			}
-		}
+		evicted = l.makeRoom(numBytes, 1) > 0
 
 		// value doesn't exist.  insert
 		e := new(item)
 		e.size = numBytes
 		e.key = key
 		e.value = value
+		e.created = time.Now().UnixNano()
+		l.version++
+		e.version = l.version
 		l.items[key] = e
 		l.currSize += numBytes
 		l.increment(e)
@@ -148,6 +311,144 @@ func (l *LFUDA) Set(key interface{}, value interface{}) bool {
 	return evicted
 }
 
+// Restore adds an item with the size, hits, priority, class and expiration
+// described by info, e.g. read back from a snapshot, without counting an
+// access.  Returns false if the key is already in the cache, the item
+// expired or it doesn't fit without evicting.
+func (l *LFUDA) Restore(info EntryInfo, value interface{}) bool {
+	if _, ok := l.items[info.Key]; ok {
+		return false
+	}
+	if info.Expires != 0 && time.Now().UnixNano() >= info.Expires {
+		return false
+	}
+	if l.currSize+info.Size > l.size || (l.maxItems > 0 && len(l.items) >= l.maxItems) {
+		return false
+	}
+	e := &item{
+		key:   info.Key,
+		value: value,
+		size:  info.Size,
+		hits:  info.Hits,
+		class: info.Class,
+		cost:  info.Cost,
+
+		created:    info.Created,
+		lastAccess: info.Accessed,
+	}
+	if e.created == 0 {
+		e.created = time.Now().UnixNano()
+	}
+	if !l.accessTimes {
+		e.lastAccess = 0
+	} else if e.lastAccess == 0 {
+		e.lastAccess = e.created
+	}
+	if info.Expires != 0 {
+		e.expires = info.Expires
+		e.ttl = time.Until(time.Unix(0, info.Expires))
+	}
+	l.version++
+	e.version = l.version
+	l.clock++
+	e.accessed = l.clock
+	l.items[info.Key] = e
+	l.currSize += info.Size
+	l.moveTo(e, info.Priority)
+	return true
+}
+
+// SetAge sets the cache age factor, e.g. read back from a snapshot along
+// with the items.
+func (l *LFUDA) SetAge(age float64) {
+	l.age = age
+}
+
+// Replace overwrites the value of an existing key like SetWithSize, but keeps
+// its expiration.  Returns false for ok if the key is not in the cache or
+// expired, and whether an eviction occurred.
+func (l *LFUDA) Replace(key interface{}, value interface{}, numBytes float64) (ok, evicted bool) {
+	e, ok := l.items[key]
+	if !ok || e.expired() {
+		return false, false
+	}
+	return true, l.overwrite(e, value, numBytes)
+}
+
+func (l *LFUDA) overwrite(e *item, value interface{}, numBytes float64) bool {
+	if l.size < numBytes {
+		// the new value won't fit so drop the stale one
+		l.Remove(e.key)
+		return false
+	}
+	e.value = value
+	l.version++
+	e.version = l.version
+	l.currSize += numBytes - e.size
+	e.size = numBytes
+	l.increment(e)
+
+	// the new value may be larger than the old one
+	return l.makeRoom(0, 0) > 0
+}
+
+// Clone returns a copy of the cache with the same items, hits and age, whose
+// evictions call onEvict.  Values are shared with the original and the copy
+// has no expire callback
+func (l *LFUDA) Clone(onEvict EvictCallback) LFUDACache {
+	return l.CloneWith(onEvict, nil)
+}
+
+// CloneWith is Clone storing the values returned by copy, or sharing them if
+// copy is nil.  Items whose value copy fails to copy are left out
+func (l *LFUDA) CloneWith(onEvict EvictCallback, copy func(key, value interface{}) (interface{}, bool)) LFUDACache {
+	c := *l
+	c.items = make(map[interface{}]*item, len(l.items))
+	c.freqs = make(bucketHeap, len(l.freqs))
+	c.buckets = make(map[float64]*listEntry, len(l.buckets))
+	c.onEvict = onEvict
+	c.onExpire = nil
+	c.evictInfo = nil
+	var failed []*item
+	for i, old := range l.freqs {
+		li := &listEntry{
+			entries:     make(map[*item]byte, len(old.entries)),
+			priorityKey: old.priorityKey,
+			index:       i,
+		}
+		c.freqs[i] = li
+		c.buckets[li.priorityKey] = li
+		for e := range old.entries {
+			clone := *e
+			clone.freqNode = li
+			li.entries[&clone] = 1
+			c.items[e.key] = &clone
+			if copy != nil {
+				var ok bool
+				if clone.value, ok = copy(e.key, e.value); !ok {
+					failed = append(failed, &clone)
+				}
+			}
+		}
+	}
+	for _, e := range failed {
+		c.detach(e)
+	}
+	return &c
+}
+
+// Capacity returns the size of the cache in bytes.
+func (l *LFUDA) Capacity() float64 {
+	return l.size
+}
+
+// Resize changes the size of the cache, evicting items until they fit.
+// Returns the number of items evicted
+func (l *LFUDA) Resize(size float64) int {
+	l.size = size
+	return l.makeRoom(0, 0)
+}
+
 // Len returns the number of items in the cache.
 func (l *LFUDA) Len() int {
 	return len(l.items)
@@ -158,76 +459,290 @@ func (l *LFUDA) Size() float64 {
 	return l.currSize
 }
 
-func (l *LFUDA) evict() bool {
-	if place := l.freqs.Front(); place != nil {
-		for entry := range place.Value.(*listEntry).entries {
-			// set age to the value of the evicted object
-			// cache age should be less than or equal to the minimum key value in the cache
-			if l.age < entry.priorityKey {
-				l.age = entry.priorityKey
-			}
+// SetAging sets how the cache age follows evictions.
+func (l *LFUDA) SetAging(aging Aging) {
+	l.aging = aging
+}
 
-			// since entries is a map this is a random key in the lowest frequency node
-			l.Remove(entry.key)
-			return true
+// raiseAge raises the age towards the priority of an evicted item.
+func (l *LFUDA) raiseAge(priorityKey float64) {
+	target := priorityKey
+	if l.aging.Factor > 0 {
+		target *= l.aging.Factor
+	}
+	if l.age >= target {
+		return
+	}
+	if d := l.aging.Damping; d > 0 && d < 1 {
+		l.age += d * (target - l.age)
+	} else {
+		l.age = target
+	}
+}
+
+// SetMaxHits caps the hits of every item at n, or removes the cap if n is not
+// positive, so items that were once very popular can't stay ahead of the
+// cache age long after their popularity collapsed.  Items above the new cap
+// are lowered to it.
+func (l *LFUDA) SetMaxHits(n float64) {
+	if n < 0 {
+		n = 0
+	}
+	l.maxHits = n
+	if n > 0 {
+		l.rescaleHits(func(hits float64) float64 { return math.Min(hits, n) })
+	}
+}
+
+// SetAccessTimes records the time of every access to an item, returned as
+// EntryInfo.Accessed, or stops recording it.  Off by default to spare the
+// clock reads.
+func (l *LFUDA) SetAccessTimes(on bool) {
+	l.accessTimes = on
+	if !on {
+		for _, e := range l.items {
+			e.lastAccess = 0
 		}
 	}
-	return false
 }
 
-func (l *LFUDA) increment(e *item) {
-	oldNode := e.freqNode
-	cursor := e.freqNode
-	var nextPlace *list.Element
+func (l *LFUDA) capHits(e *item) {
+	if l.maxHits > 0 && e.hits > l.maxHits {
+		e.hits = l.maxHits
+	}
+}
 
-	if cursor == nil {
-		// new entry
-		nextPlace = l.freqs.Front()
-	} else {
-		nextPlace = cursor.Next()
+// HalveHits halves the hits of every item, lowering their priorities by the
+// hits they lose, so past popularity decays when called periodically.
+func (l *LFUDA) HalveHits() {
+	l.rescaleHits(func(hits float64) float64 { return hits / 2 })
+}
+
+// rescaleHits replaces the hits of every item by scale(hits) and moves its
+// priority by the change in the hits' share of it, keeping the age it was
+// last accessed at, then rebuilds the frequency list.
+func (l *LFUDA) rescaleHits(scale func(hits float64) float64) {
+	l.reprioritize(func(e *item) float64 {
+		before := l.policy(e, 0)
+		e.hits = scale(e.hits)
+		if math.IsInf(before, 0) {
+			return e.priorityKey
+		}
+		return e.priorityKey + l.policy(e, 0) - before
+	})
+}
+
+// SwitchPolicy recomputes the priority of every item with the policy named
+// "LFUDA", "GDSF", "LFU" or "Hybrid" from its hits, size and the age it was
+// last accessed at, keeping all items.  Switching from LFU, which has no
+// age, resets the cache age.  Returns false if the name is unknown.
+func (l *LFUDA) SwitchPolicy(name string) bool {
+	policy, ok := policies[name]
+	if name == "Hybrid" {
+		policy, ok = hybridPolicy(l.alpha), true
+	}
+	if !ok {
+		return false
 	}
+	l.convert(name, policy)
+	return true
+}
 
-	// must update item's hits before updating priorityKey
-	e.hits++
-	e.priorityKey = l.policy(e, l.age)
-
-	// move up until hits is < next frequency node's
-	for {
-		// we've reached the back or the point where the next frequency
-		// node is greater than the item's hits count.  Either way, create
-		// a new frequency node
-		if nextPlace == nil || nextPlace.Value.(*listEntry).priorityKey > e.priorityKey {
-			// create a new frequency node
-			li := new(listEntry)
-			li.priorityKey = e.priorityKey
-			li.entries = make(map[*item]byte)
-			if cursor != nil {
-				nextPlace = l.freqs.InsertAfter(li, cursor)
-			} else {
-				nextPlace = l.freqs.PushFront(li)
+// SetHybridAlpha sets the exponent of the item size in Hybrid priorities,
+// recomputing them if the cache uses the Hybrid policy.
+func (l *LFUDA) SetHybridAlpha(alpha float64) {
+	l.alpha = alpha
+	if l.policyName == "Hybrid" {
+		l.convert("Hybrid", hybridPolicy(alpha))
+	}
+}
+
+// convert recomputes the priority of every item with policy.
+func (l *LFUDA) convert(name string, policy cachePolicy) {
+	lfu := l.policyName == "LFU"
+	l.reprioritize(func(e *item) float64 {
+		accessedAge := 0.0
+		if !lfu {
+			if hits := l.policy(e, 0); !math.IsInf(hits, 0) {
+				accessedAge = e.priorityKey - hits
 			}
+		}
+		return policy(e, accessedAge)
+	})
+	l.policy = policy
+	l.policyName = name
+	if lfu {
+		l.age = 0
+	}
+}
+
+// reprioritize sets the priority of every item to priority(item) and
+// rebuilds the buckets.
+func (l *LFUDA) reprioritize(priority func(e *item) float64) {
+	l.freqs = nil
+	l.buckets = make(map[float64]*listEntry, len(l.buckets))
+	for _, e := range l.items {
+		e.freqNode = nil
+		l.moveTo(e, priority(e))
+	}
+}
+
+// Evict removes the least valuable item as if the cache were full, raising
+// the cache age.  Returns false if the cache is empty
+func (l *LFUDA) Evict() bool {
+	return l.evict()
+}
+
+func (l *LFUDA) evict() bool {
+	victim := l.popVictim()
+	if victim == nil {
+		return false
+	}
+	l.evicted(victim)
+	return true
+}
+
+// makeRoom evicts the least valuable items until numBytes more bytes and
+// items more items fit, or the cache is empty.  All the victims are picked
+// and removed before the callbacks are invoked, least valuable first, so the
+// callbacks see the cache in its final state.  Returns the number of items
+// evicted
+func (l *LFUDA) makeRoom(numBytes float64, items int) int {
+	var victims []*item
+	for l.currSize+numBytes > l.size || (l.maxItems > 0 && len(l.items)+items > l.maxItems) {
+		victim := l.popVictim()
+		if victim == nil {
 			break
-		} else if nextPlace.Value.(*listEntry).priorityKey == e.priorityKey {
-			// found the right place
-			break
-		} else if e.priorityKey > nextPlace.Value.(*listEntry).priorityKey {
-			// keep searching
-			cursor = nextPlace
-			nextPlace = cursor.Next()
 		}
+		victims = append(victims, victim)
+	}
+	for _, victim := range victims {
+		l.evicted(victim)
+	}
+	return len(victims)
+}
+
+// popVictim removes the least valuable item without invoking callbacks,
+// raising the cache age.  Returns nil if the cache is empty
+func (l *LFUDA) popVictim() *item {
+	place := l.lowest()
+	if place == nil {
+		return nil
+	}
+	for entry := range place.entries {
+		// set age to the value of the evicted object
+		// cache age should be less than or equal to the minimum key value in the cache
+		l.raiseAge(entry.priorityKey)
+
+		// since entries is a map this is a random key in the lowest frequency node
+		l.detach(entry)
+		return entry
+	}
+	return nil
+}
+
+// evicted invokes the callbacks for an item removed by popVictim
+func (l *LFUDA) evicted(e *item) {
+	if l.evictInfo != nil {
+		l.evictInfo(e.info())
+	}
+	callback := l.onEvict
+	if e.expired() {
+		callback = l.expireCallback()
+	}
+	if callback != nil {
+		callback(e.key, e.value)
+	}
+}
+
+func (l *LFUDA) increment(e *item) {
+	// must update item's hits before updating priorityKey
+	e.hits++
+	l.capHits(e)
+	l.clock++
+	e.accessed = l.clock
+	if l.accessTimes {
+		e.lastAccess = time.Now().UnixNano()
 	}
+	l.move(e)
+}
 
-	// set the right frequency node in the master list
-	e.freqNode = nextPlace
-	nextPlace.Value.(*listEntry).entries[e] = 1
+// move recomputes the item's priorityKey and moves it to the matching
+// frequency node, creating the node if needed
+func (l *LFUDA) move(e *item) {
+	l.moveTo(e, l.policy(e, l.age))
+}
 
-	// clenaup
+// moveTo sets the item's priorityKey and moves it to the matching bucket,
+// creating the bucket if needed
+func (l *LFUDA) moveTo(e *item, priorityKey float64) {
+	if priorityKey != priorityKey {
+		// NaN can't be looked up in the buckets
+		priorityKey = 0
+	}
+	oldNode := e.freqNode
+	e.priorityKey = priorityKey
+	if oldNode != nil && oldNode.priorityKey == priorityKey {
+		return
+	}
+
+	place := l.bucket(priorityKey)
+	e.freqNode = place
+	place.entries[e] = 1
+
+	// cleanup
 	if oldNode != nil {
-		// remove from old position
 		l.remEntry(oldNode, e)
 	}
 }
 
+// Boost adds delta to the item's hits without counting as an access, raising
+// or lowering its priority.  Hits never drop below 0.  Returns false if the
+// key is not in the cache
+func (l *LFUDA) Boost(key interface{}, delta float64) bool {
+	e, ok := l.items[key]
+	if !ok {
+		return false
+	}
+	e.hits += delta
+	if e.hits < 0 {
+		e.hits = 0
+	}
+	l.capHits(e)
+	l.move(e)
+	return true
+}
+
+// SetPriorityClass sets the item's priority class, which it keeps until it
+// leaves the cache.  Returns false if the key is not in the cache
+func (l *LFUDA) SetPriorityClass(key interface{}, class PriorityClass) bool {
+	e, ok := l.items[key]
+	if !ok {
+		return false
+	}
+	e.class = class
+	l.move(e)
+	return true
+}
+
+// SetCost sets the cost of regenerating the item's value, e.g. the latency
+// of fetching it, which multiplies its GDSF priority so expensive items are
+// kept over cheap ones of the same size and hits.  The cost is kept until the
+// item leaves the cache; non positive costs reset it to 1.  Returns false if
+// the key is not in the cache
+func (l *LFUDA) SetCost(key interface{}, cost float64) bool {
+	e, ok := l.items[key]
+	if !ok {
+		return false
+	}
+	if cost <= 0 {
+		cost = 0
+	}
+	e.cost = cost
+	l.move(e)
+	return true
+}
+
 // Purge will completely clear the LFUDA cache
 func (l *LFUDA) Purge() {
 	for k, v := range l.items {
@@ -238,48 +753,151 @@ func (l *LFUDA) Purge() {
 	}
 	l.age = 0
 	l.currSize = 0
-	l.freqs.Init()
+	l.freqs = nil
+	l.buckets = make(map[float64]*listEntry)
 }
 
 // Contains checks if a key is in the cache, without updating the recent-ness
 // or deleting it for being stale.
-func (l *LFUDA) Contains(key interface{}) (ok bool) {
-	_, ok = l.items[key]
-	return ok
+func (l *LFUDA) Contains(key interface{}) bool {
+	e, ok := l.items[key]
+	return ok && !e.expired()
+}
+
+// Expire sets the item to expire after ttl, or never if ttl is not positive.
+// Returns false if the key is not in the cache or expired, removing it in
+// the latter case
+func (l *LFUDA) Expire(key interface{}, ttl time.Duration) bool {
+	e, ok := l.items[key]
+	if !ok {
+		return false
+	}
+	if e.expired() {
+		l.removeItem(e, l.expireCallback())
+		return false
+	}
+	if ttl > 0 {
+		e.expires = time.Now().Add(ttl).UnixNano()
+	} else {
+		e.expires = 0
+		ttl = 0
+	}
+	e.ttl = ttl
+	return true
+}
+
+// TTL returns the time left before the item expires, 0 if it never expires.
+// Returns false if the key is not in the cache
+func (l *LFUDA) TTL(key interface{}) (time.Duration, bool) {
+	e, ok := l.items[key]
+	if !ok || e.expired() {
+		return 0, false
+	}
+	if e.expires == 0 {
+		return 0, true
+	}
+	return time.Until(time.Unix(0, e.expires)), true
+}
+
+// Touch counts an access to the item without returning its value and renews
+// its deadline with the ttl it was last given.  Returns false if the key is
+// not in the cache or expired
+func (l *LFUDA) Touch(key interface{}) bool {
+	e, ok := l.items[key]
+	if !ok || e.expired() {
+		return false
+	}
+	if e.ttl > 0 {
+		e.expires = time.Now().Add(e.ttl).UnixNano()
+	}
+	l.increment(e)
+	return true
+}
+
+// PurgeExpired removes every expired item, calling the expire callback.
+// Returns the number of items removed
+func (l *LFUDA) PurgeExpired() int {
+	removed := 0
+	for _, e := range l.items {
+		if e.expired() {
+			l.removeItem(e, l.expireCallback())
+			removed++
+		}
+	}
+	return removed
 }
 
 // Remove removes the provided key from the cache, returning if the
 // key was contained
 func (l *LFUDA) Remove(key interface{}) bool {
 	if item, ok := l.items[key]; ok {
-		if l.onEvict != nil {
-			l.onEvict(item.key, item.value)
-		}
-		delete(l.items, key)
-		l.remEntry(item.freqNode, item)
-
-		// subtract current size of the cache by the size of the evicted item
-		l.currSize -= item.size
-
+		l.removeItem(item, l.onEvict)
 		return true
 	}
 	return false
 }
 
-func (l *LFUDA) remEntry(place *list.Element, entry *item) {
-	entries := place.Value.(*listEntry).entries
-	delete(entries, entry)
-	if len(entries) == 0 {
-		l.freqs.Remove(place)
+func (l *LFUDA) removeItem(item *item, callback EvictCallback) {
+	if callback != nil {
+		callback(item.key, item.value)
+	}
+	l.detach(item)
+}
+
+// detach removes the item from the cache without invoking callbacks
+func (l *LFUDA) detach(item *item) {
+	delete(l.items, item.key)
+	l.remEntry(item.freqNode, item)
+
+	// subtract current size of the cache by the size of the evicted item
+	l.currSize -= item.size
+}
+
+// SetMaxEntries limits the number of items in the cache, or removes the limit
+// if n is not positive.  Items are evicted until the cache is within the new
+// limit.  Returns true if an eviction occurred.
+func (l *LFUDA) SetMaxEntries(n int) bool {
+	if n < 0 {
+		n = 0
+	}
+	l.maxItems = n
+	return l.makeRoom(0, 0) > 0
+}
+
+// SetExpireCallback sets a callback invoked instead of the eviction callback
+// for expired items removed by Get or by eviction
+func (l *LFUDA) SetExpireCallback(onExpire EvictCallback) {
+	l.onExpire = onExpire
+}
+
+// SetEvictObserver sets a function called with the metadata of every evicted
+// item, expired or not, before the callbacks
+func (l *LFUDA) SetEvictObserver(fn func(info EntryInfo)) {
+	l.evictInfo = fn
+}
+
+func (l *LFUDA) expireCallback() EvictCallback {
+	if l.onExpire != nil {
+		return l.onExpire
+	}
+	return l.onEvict
+}
+
+func (l *LFUDA) remEntry(place *listEntry, entry *item) {
+	delete(place.entries, entry)
+	if len(place.entries) == 0 {
+		heap.Remove(&l.freqs, place.index)
+		delete(l.buckets, place.priorityKey)
 	}
 }
 
-// Keys returns a slice of the keys in the cache ordered by frequency
+// Keys returns a slice of the keys in the cache ordered by priority from most
+// to least valuable.  The order of keys with the same priority is unspecified
 func (l *LFUDA) Keys() []interface{} {
 	keys := make([]interface{}, len(l.items))
 	i := 0
-	for node := l.freqs.Back(); node != nil; node = node.Prev() {
-		for ent := range node.Value.(*listEntry).entries {
+	for _, node := range l.descending() {
+		for ent := range node.entries {
 			keys[i] = ent.key
 			i++
 		}
@@ -287,6 +905,124 @@ func (l *LFUDA) Keys() []interface{} {
 	return keys
 }
 
+// KeysByFrequency returns a slice of the keys in the cache ordered by hits
+// from most to least frequently used, then by priority and recency
+func (l *LFUDA) KeysByFrequency() []interface{} {
+	return l.sortedKeys(func(a, b *item) bool {
+		if a.hits != b.hits {
+			return a.hits > b.hits
+		}
+		if a.priorityKey != b.priorityKey {
+			return a.priorityKey > b.priorityKey
+		}
+		return a.accessed > b.accessed
+	})
+}
+
+// KeysByRecency returns a slice of the keys in the cache ordered from most to
+// least recently set or accessed
+func (l *LFUDA) KeysByRecency() []interface{} {
+	return l.sortedKeys(func(a, b *item) bool {
+		return a.accessed > b.accessed
+	})
+}
+
+// KeysByPriority returns a slice of the keys in the cache ordered by priority
+// from most to least valuable, then by recency, so the last keys are the next
+// to be evicted
+func (l *LFUDA) KeysByPriority() []interface{} {
+	return l.sortedKeys(func(a, b *item) bool {
+		if a.priorityKey != b.priorityKey {
+			return a.priorityKey > b.priorityKey
+		}
+		return a.accessed > b.accessed
+	})
+}
+
+func (l *LFUDA) sortedKeys(less func(a, b *item) bool) []interface{} {
+	items := make([]*item, 0, len(l.items))
+	for _, e := range l.items {
+		items = append(items, e)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return less(items[i], items[j])
+	})
+	keys := make([]interface{}, len(items))
+	for i, e := range items {
+		keys[i] = e.key
+	}
+	return keys
+}
+
+// Range calls fn for every entry in the cache, ordered by priority from most
+// to least valuable, until fn returns false.  Hits are not updated.
+func (l *LFUDA) Range(fn func(info EntryInfo) bool) {
+	for _, node := range l.descending() {
+		for ent := range node.entries {
+			if !fn(ent.info()) {
+				return
+			}
+		}
+	}
+}
+
+// TopK returns the k most valuable items, or all of them if there are
+// fewer, from the most valuable.  Hits are not updated.
+func (l *LFUDA) TopK(k int) []EntryInfo {
+	if k <= 0 {
+		return nil
+	}
+	infos := make([]EntryInfo, 0, k)
+	for _, node := range l.top(k) {
+		for ent := range node.entries {
+			if len(infos) == k {
+				return infos
+			}
+			infos = append(infos, ent.info())
+		}
+	}
+	return infos
+}
+
+// Lowest returns the metadata of the least valuable entry, the next to be
+// evicted.  Returns false if the cache is empty
+func (l *LFUDA) Lowest() (EntryInfo, bool) {
+	if place := l.lowest(); place != nil {
+		for ent := range place.entries {
+			return ent.info(), true
+		}
+	}
+	return EntryInfo{}, false
+}
+
+// RangeReverse calls fn for every entry in the cache, ordered by priority from
+// least to most valuable, until fn returns false.  Hits are not updated.
+func (l *LFUDA) RangeReverse(fn func(info EntryInfo) bool) {
+	l.ascending(func(node *listEntry) bool {
+		for ent := range node.entries {
+			if !fn(ent.info()) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func (e *item) info() EntryInfo {
+	return EntryInfo{
+		Key:      e.key,
+		Size:     e.size,
+		Hits:     e.hits,
+		Priority: e.priorityKey,
+		Version:  e.version,
+		Class:    e.class,
+		Expires:  e.expires,
+		Cost:     e.cost,
+		Created:  e.created,
+		Accessed: e.lastAccess,
+	}
+}
+
 // Age returns the cache age factor
 func (l *LFUDA) Age() float64 {
 	return l.age
@@ -294,14 +1030,34 @@ func (l *LFUDA) Age() float64 {
 
 // Ki = Ci * Fi + L where C is set to 1
 func lfudaPolicy(element *item, cacheAge float64) float64 {
-	return element.hits + cacheAge
+	return element.freq() + cacheAge
 }
 
-// Ki = Fi * Ci / Si + L where C is set to 1
+// Ki = Fi * Ci / Si + L where C is set by SetCost, 1 by default
 func gdsfPolicy(element *item, cacheAge float64) float64 {
-	return (element.hits / element.size) + cacheAge
+	if element.cost > 0 {
+		return (element.freq() * element.cost / element.size) + cacheAge
+	}
+	return (element.freq() / element.size) + cacheAge
 }
 
 func lfuPolicy(element *item, cacheAge float64) float64 {
-	return element.hits
+	return element.freq()
+}
+
+// Ki = Fi * Ci / Si^alpha + L, between LFUDA (alpha = 0) and GDSF (alpha = 1)
+func hybridPolicy(alpha float64) cachePolicy {
+	return func(element *item, cacheAge float64) float64 {
+		freq := element.freq()
+		if element.cost > 0 {
+			freq *= element.cost
+		}
+		return freq/math.Pow(element.size, alpha) + cacheAge
+	}
+}
+
+var policies = map[string]cachePolicy{
+	"LFUDA": lfudaPolicy,
+	"GDSF":  gdsfPolicy,
+	"LFU":   lfuPolicy,
 }
@@ -1,11 +1,23 @@
 package simplelfuda
 
+import "time"
+
 // LFUDACache is the interface for simple LFUDA cache.
 type LFUDACache interface {
 	// Adds a value to the cache, returns true if an eviction occurred and
 	// updates the "recently used"-ness of the key.
 	Set(key, value interface{}) bool
 
+	// Adds a value to the cache accounting for it as size bytes, returns true
+	// if an eviction occurred.
+	SetWithSize(key, value interface{}, size float64) bool
+
+	// Adds a value with the given metadata without counting an access.
+	Restore(info EntryInfo, value interface{}) bool
+
+	// Overwrites an existing key's value keeping its expiration.
+	Replace(key, value interface{}, size float64) (ok, evicted bool)
+
 	// Returns key's value from the cache and
 	// updates the "recently used"-ness of the key. #value, isFound
 	Get(key interface{}) (value interface{}, ok bool)
@@ -16,15 +28,111 @@ type LFUDACache interface {
 	// Returns key's value without updating the "recently used"-ness of the key.
 	Peek(key interface{}) (value interface{}, ok bool)
 
+	// Returns a key's metadata without updating the "recently used"-ness of
+	// the key.
+	Info(key interface{}) (info EntryInfo, ok bool)
+
+	// Returns a key's version, increasing every time it is set.
+	Version(key interface{}) (version uint64, ok bool)
+
+	// Adds delta to a key's hits without counting as an access.
+	Boost(key interface{}, delta float64) bool
+
+	// Sets the priority class scaling a key's hits.
+	SetPriorityClass(key interface{}, class PriorityClass) bool
+
+	// Sets the cost of regenerating a key's value, weighing GDSF priorities.
+	SetCost(key interface{}, cost float64) bool
+
+	// Sets how the cache age follows evictions.
+	SetAging(aging Aging)
+
+	// Caps the hits of every key, or removes the cap if n is not positive.
+	SetMaxHits(n float64)
+
+	// Records the time of every access to a key, or stops recording it.
+	SetAccessTimes(on bool)
+
+	// Halves the hits of every key.
+	HalveHits()
+
+	// Recomputes every key's priority with the named policy.
+	SwitchPolicy(name string) bool
+
+	// Sets the exponent of the item size in Hybrid priorities.
+	SetHybridAlpha(alpha float64)
+
+	// Sets a key to expire after ttl, or never if ttl is not positive.
+	Expire(key interface{}, ttl time.Duration) bool
+
+	// Returns the time left before a key expires, 0 if it never expires.
+	TTL(key interface{}) (ttl time.Duration, ok bool)
+
+	// Counts an access to a key and renews its ttl without returning its
+	// value.
+	Touch(key interface{}) bool
+
+	// Limits the number of keys in the cache, 0 for no limit.
+	SetMaxEntries(n int) bool
+
+	// Sets the callback for expired keys, used instead of the eviction
+	// callback.
+	SetExpireCallback(onExpire EvictCallback)
+
+	// Sets a function observing the metadata of evicted keys.
+	SetEvictObserver(fn func(info EntryInfo))
+
+	// Evicts the least valuable key as if the cache were full.
+	Evict() bool
+
+	// Removes every expired key.
+	PurgeExpired() int
+
 	// Removes a key from the cache.
 	Remove(key interface{}) bool
 
-	// Returns a slice of the keys in the cache, from oldest to newest.
+	// Returns a slice of the keys in the cache, from most to least valuable.
 	Keys() []interface{}
 
+	// Returns a slice of the keys in the cache, from most to least frequently
+	// used.
+	KeysByFrequency() []interface{}
+
+	// Returns a slice of the keys in the cache, from most to least recently
+	// used.
+	KeysByRecency() []interface{}
+
+	// Returns a slice of the keys in the cache, from most to least valuable,
+	// with ties broken by recency.
+	KeysByPriority() []interface{}
+
+	// Calls fn for each entry's metadata, from most to least valuable.
+	Range(fn func(info EntryInfo) bool)
+
+	// Returns the metadata of the k most valuable keys.
+	TopK(k int) []EntryInfo
+
+	// Calls fn for each entry's metadata, from least to most valuable.
+	RangeReverse(fn func(info EntryInfo) bool)
+
+	// Returns the metadata of the least valuable entry.
+	Lowest() (EntryInfo, bool)
+
+	// Returns a copy of the cache sharing its values.
+	Clone(onEvict EvictCallback) LFUDACache
+
+	// Returns a copy of the cache with values copied by copy.
+	CloneWith(onEvict EvictCallback, copy func(key, value interface{}) (interface{}, bool)) LFUDACache
+
 	// Returns the number of items in the cache.
 	Len() int
 
+	// Returns the size the cache holds in bytes.
+	Capacity() float64
+
+	// Changes the size of the cache, evicting keys until they fit.
+	Resize(size float64) int
+
 	// Returns the current size of the cache in bytes.
 	Size() float64
 
@@ -33,4 +141,7 @@ type LFUDACache interface {
 
 	// Returns current age factor of the cache
 	Age() float64
+
+	// Sets the age factor of the cache.
+	SetAge(age float64)
 }
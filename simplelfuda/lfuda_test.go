@@ -2,7 +2,9 @@ package simplelfuda
 
 import (
 	"fmt"
+	"math/rand"
 	"testing"
+	"time"
 )
 
 func TestLFUDA(t *testing.T) {
@@ -313,3 +315,651 @@ func TestEvictLFU(t *testing.T) {
 		t.Errorf("cache should still contain key a")
 	}
 }
+
+func TestRange(t *testing.T) {
+	c := NewLFUDA(10, nil)
+	c.Set("a", "a")
+	c.Set("b", "bb")
+	c.Get("a")
+
+	var infos []EntryInfo
+	c.Range(func(info EntryInfo) bool {
+		infos = append(infos, info)
+		return true
+	})
+	if len(infos) != 2 {
+		t.Fatalf("expected 2 entries: %v", infos)
+	}
+	if infos[0].Key != "a" || infos[0].Hits != 2 || infos[0].Size != 1 {
+		t.Errorf("most valuable entry should come first: %+v", infos[0])
+	}
+	if infos[1].Key != "b" || infos[1].Size != 2 {
+		t.Errorf("bad entry: %+v", infos[1])
+	}
+
+	n := 0
+	c.Range(func(info EntryInfo) bool {
+		n++
+		return false
+	})
+	if n != 1 {
+		t.Errorf("Range should stop when fn returns false")
+	}
+}
+
+func TestRangeReverse(t *testing.T) {
+	c := NewLFU(1000, nil)
+	if _, ok := c.Lowest(); ok {
+		t.Errorf("an empty cache has no lowest entry")
+	}
+	for i := 0; i < 200; i++ {
+		c.Set(i, i)
+		c.Boost(i, float64((i*37)%101))
+	}
+	prev, n := -1.0, 0
+	c.RangeReverse(func(info EntryInfo) bool {
+		if info.Priority < prev {
+			t.Fatalf("%v comes after priority %v", info, prev)
+		}
+		prev = info.Priority
+		n++
+		return true
+	})
+	if n != 200 {
+		t.Errorf("got %d entries, want 200", n)
+	}
+	if lowest, _ := c.Lowest(); lowest.Priority != 1 {
+		t.Errorf("bad lowest entry: %+v", lowest)
+	}
+}
+
+func TestSetWithSize(t *testing.T) {
+	c := NewGDSF(10, nil)
+	c.SetWithSize("a", "a", 6)
+	c.SetWithSize("b", "b", 4)
+	if c.Size() != 10 {
+		t.Errorf("explicit sizes should be used: %f", c.Size())
+	}
+
+	if evicted := c.SetWithSize("c", "c", 11); evicted || c.Contains("c") {
+		t.Errorf("oversized value should not be set")
+	}
+
+	// growing an existing value evicts others to make room
+	c.Get("a")
+	if evicted := c.SetWithSize("a", "aa", 8); !evicted {
+		t.Errorf("growing a value should have evicted")
+	}
+	if c.Contains("b") || c.Size() != 8 {
+		t.Errorf("b should have been evicted: %f", c.Size())
+	}
+
+	// overwriting with an oversized value drops the stale one
+	c.SetWithSize("a", "aaa", 20)
+	if c.Contains("a") || c.Size() != 0 {
+		t.Errorf("stale value should have been dropped: %f", c.Size())
+	}
+}
+
+func TestExpire(t *testing.T) {
+	c := NewLFUDA(10, nil)
+	c.Set("a", "a")
+	c.Set("b", "b")
+
+	if !c.Expire("a", time.Millisecond) {
+		t.Errorf("a should be in the cache")
+	}
+	if c.Expire("c", time.Millisecond) {
+		t.Errorf("c should not be in the cache")
+	}
+	c.Expire("b", time.Millisecond)
+	c.Expire("b", 0)
+
+	time.Sleep(5 * time.Millisecond)
+	if c.Contains("a") {
+		t.Errorf("a should have expired")
+	}
+	if _, ok := c.Peek("a"); ok {
+		t.Errorf("a should have expired")
+	}
+	if c.Len() != 2 {
+		t.Errorf("expired items are only removed on access")
+	}
+	if _, ok := c.Get("a"); ok || c.Len() != 1 {
+		t.Errorf("a should have been removed")
+	}
+	if !c.Contains("b") {
+		t.Errorf("b should not expire")
+	}
+
+	// setting a value clears its deadline
+	c.Set("a", "a")
+	c.Expire("a", time.Millisecond)
+	c.Set("a", "z")
+	time.Sleep(5 * time.Millisecond)
+	if !c.Contains("a") {
+		t.Errorf("a should not expire after being overwritten")
+	}
+
+	// expired items can't be revived
+	c.Expire("a", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if c.Expire("a", time.Hour) || c.Len() != 1 {
+		t.Errorf("expired items should be removed by Expire")
+	}
+}
+
+func TestBoost(t *testing.T) {
+	c := NewLFUDA(3, nil)
+	c.Set("a", "a")
+	c.Set("b", "b")
+	c.Set("c", "c")
+	c.Get("a")
+
+	if c.Boost("d", 1) {
+		t.Errorf("d is not in the cache")
+	}
+
+	c.Boost("c", 5)
+	if keys := c.Keys(); keys[0] != "c" || keys[1] != "a" {
+		t.Errorf("c should have become the most valuable key: %v", keys)
+	}
+
+	c.Boost("c", -10)
+	if keys := c.Keys(); keys[2] != "c" {
+		t.Errorf("c should have become the least valuable key: %v", keys)
+	}
+
+	c.Set("d", "d")
+	if c.Contains("c") {
+		t.Errorf("demoted key should have been evicted")
+	}
+}
+
+func TestExpireCallback(t *testing.T) {
+	var evicted, expired []interface{}
+	c := NewLFUDA(2, func(k, v interface{}) { evicted = append(evicted, k) })
+	c.SetExpireCallback(func(k, v interface{}) { expired = append(expired, k) })
+
+	c.Set("a", "a")
+	c.Set("b", "b")
+	c.Get("b")
+	c.Expire("a", time.Millisecond)
+	c.Expire("b", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	// a is evicted while expired, b is removed by Get
+	c.Set("c", "c")
+	c.Get("b")
+	c.Set("d", "d")
+	c.Set("e", "e")
+	if len(expired) != 2 || expired[0] != "a" || expired[1] != "b" {
+		t.Errorf("expired keys should use the expire callback: %v", expired)
+	}
+	if len(evicted) != 1 {
+		t.Errorf("live keys should use the eviction callback: %v", evicted)
+	}
+}
+
+func TestMaxEntries(t *testing.T) {
+	l := NewLFUDA(1000, nil)
+	l.SetMaxEntries(2)
+	l.Set("a", 1)
+	l.Get("a")
+	l.Set("b", 2)
+	if !l.Set("c", 3) {
+		t.Errorf("exceeding the entry limit should evict")
+	}
+	if l.Len() != 2 || !l.Contains("a") || l.Contains("b") {
+		t.Errorf("the least valuable entry should have been evicted: %v", l.Keys())
+	}
+
+	// overwrites don't count as new entries
+	if l.Set("a", 4) {
+		t.Errorf("overwrite should not evict")
+	}
+
+	if !l.SetMaxEntries(1) || l.Len() != 1 || !l.Contains("a") {
+		t.Errorf("lowering the limit should evict down to it: %v", l.Keys())
+	}
+
+	l.SetMaxEntries(0)
+	for i := 0; i < 10; i++ {
+		l.Set(i, i)
+	}
+	if l.Len() != 11 {
+		t.Errorf("removing the limit should allow any number of entries: %d", l.Len())
+	}
+}
+
+func TestTouch(t *testing.T) {
+	l := NewLFU(100, nil)
+	if l.Touch("missing") {
+		t.Errorf("missing key should not be touched")
+	}
+
+	l.Set("a", 1)
+	l.Expire("a", 20*time.Millisecond)
+	time.Sleep(15 * time.Millisecond)
+	if !l.Touch("a") {
+		t.Errorf("a should be touched")
+	}
+	time.Sleep(15 * time.Millisecond)
+	if !l.Contains("a") {
+		t.Errorf("touch should have renewed the deadline")
+	}
+	var hits float64
+	l.Range(func(info EntryInfo) bool {
+		hits = info.Hits
+		return true
+	})
+	if hits != 2 {
+		t.Errorf("touch should count an access: %f", hits)
+	}
+
+	time.Sleep(25 * time.Millisecond)
+	if l.Touch("a") {
+		t.Errorf("expired key should not be touched")
+	}
+}
+
+func TestVersion(t *testing.T) {
+	l := NewLFUDA(2, nil)
+	if _, ok := l.Version("a"); ok {
+		t.Errorf("missing key has no version")
+	}
+	l.Set("a", 1)
+	v1, _ := l.Version("a")
+	l.Boost("a", 1)
+	if v, _ := l.Version("a"); v != v1 {
+		t.Errorf("only sets should change the version")
+	}
+	l.Set("a", 2)
+	v2, _ := l.Version("a")
+	if v2 <= v1 {
+		t.Errorf("version should increase: %d, %d", v1, v2)
+	}
+
+	l.Remove("a")
+	l.Set("a", 3)
+	if v3, _ := l.Version("a"); v3 <= v2 {
+		t.Errorf("version should increase across removals: %d, %d", v2, v3)
+	}
+}
+
+func TestReplace(t *testing.T) {
+	l := NewLFUDA(100, nil)
+	if ok, _ := l.Replace("a", 1, 1); ok || l.Contains("a") {
+		t.Errorf("missing key should not be replaced")
+	}
+	l.Set("a", 1)
+	l.Expire("a", 10*time.Millisecond)
+	if ok, _ := l.Replace("a", 22, 2); !ok {
+		t.Errorf("a should be replaced")
+	}
+	if v, _ := l.Peek("a"); v != 22 || l.Size() != 2 {
+		t.Errorf("bad value or size: %v, %f", v, l.Size())
+	}
+	time.Sleep(15 * time.Millisecond)
+	if l.Contains("a") {
+		t.Errorf("replace should keep the expiration")
+	}
+}
+
+func TestInfo(t *testing.T) {
+	l := NewLFUDA(100, nil)
+	if _, ok := l.Info("a"); ok {
+		t.Errorf("missing key has no metadata")
+	}
+	l.SetWithSize("a", 1, 4)
+	l.Get("a")
+	info, ok := l.Info("a")
+	if !ok || info.Key != "a" || info.Size != 4 || info.Hits != 2 {
+		t.Errorf("bad metadata: %+v", info)
+	}
+	if again, _ := l.Info("a"); again.Hits != info.Hits {
+		t.Errorf("Info should not update hits")
+	}
+}
+
+func TestPriorityClass(t *testing.T) {
+	l := NewLFUDA(2, nil)
+	l.Set("a", 1)
+	l.Set("b", 2)
+	l.Get("a")
+	l.SetPriorityClass("a", PriorityLow)
+	l.SetPriorityClass("b", PriorityHigh)
+
+	// a's 2 hits are worth 1, b's 1 hit is worth 2
+	l.Set("c", 3)
+	if l.Contains("a") || !l.Contains("b") {
+		t.Errorf("low class entry should have been evicted: %v", l.Keys())
+	}
+	if l.SetPriorityClass("a", PriorityHigh) {
+		t.Errorf("a is not in the cache")
+	}
+}
+
+func TestSetCost(t *testing.T) {
+	l := NewGDSF(2, nil)
+	l.Set("a", 1)
+	l.Set("b", 2)
+	l.Get("a")
+	l.SetCost("b", 3)
+
+	// a's 2 hits are worth less than b's single hit costing 3
+	l.Set("c", 3)
+	if l.Contains("a") || !l.Contains("b") {
+		t.Errorf("the cheap entry should have been evicted: %v", l.Keys())
+	}
+	if info, _ := l.Info("b"); info.Cost != 3 || info.Priority != 3 {
+		t.Errorf("bad info: %+v", info)
+	}
+	if l.SetCost("a", 1) {
+		t.Errorf("a is not in the cache")
+	}
+}
+
+func TestAging(t *testing.T) {
+	fill := func(aging Aging) float64 {
+		l := NewLFUDA(1, nil)
+		l.SetAging(aging)
+		l.Set("a", 1)
+		for i := 0; i < 9; i++ {
+			l.Get("a")
+		}
+		l.Set("b", 2)
+		return l.Age()
+	}
+	if age := fill(Aging{}); age != 10 {
+		t.Errorf("the age should jump to the evicted priority: %g", age)
+	}
+	if age := fill(Aging{Factor: 0.5}); age != 5 {
+		t.Errorf("the age should be scaled by the factor: %g", age)
+	}
+	if age := fill(Aging{Damping: 0.1}); age != 1 {
+		t.Errorf("the age should move a tenth of the way: %g", age)
+	}
+}
+
+func TestMaxHits(t *testing.T) {
+	l := NewLFUDA(10, nil)
+	l.Set("a", 1)
+	for i := 0; i < 9; i++ {
+		l.Get("a")
+	}
+	l.SetMaxHits(4)
+	if info, _ := l.Info("a"); info.Hits != 4 || info.Priority != 4 {
+		t.Errorf("a should be lowered to the cap: %+v", info)
+	}
+	l.Get("a")
+	l.Boost("a", 10)
+	if info, _ := l.Info("a"); info.Hits != 4 {
+		t.Errorf("hits should not exceed the cap: %+v", info)
+	}
+}
+
+func TestHalveHits(t *testing.T) {
+	l := NewLFUDA(2, nil)
+	l.Set("a", 1)
+	for i := 0; i < 7; i++ {
+		l.Get("a")
+	}
+	l.SetAge(3)
+	l.Set("b", 2)
+	l.Get("b")
+	l.HalveHits()
+
+	// a keeps no age, b keeps the age 3 it was accessed at
+	if info, _ := l.Info("a"); info.Hits != 4 || info.Priority != 4 {
+		t.Errorf("a's hits should be halved: %+v", info)
+	}
+	if info, _ := l.Info("b"); info.Hits != 1 || info.Priority != 4 {
+		t.Errorf("b's hits should be halved: %+v", info)
+	}
+	l.HalveHits()
+	if keys := l.Keys(); len(keys) != 2 || keys[0] != "b" {
+		t.Errorf("b should now be the most valuable: %v", keys)
+	}
+	l.Set("c", 3)
+	if l.Contains("a") {
+		t.Errorf("a should be evicted first")
+	}
+}
+
+func TestKeysOrdering(t *testing.T) {
+	l := NewLFU(10, nil)
+	l.Set("a", 1)
+	l.Set("b", 2)
+	l.Set("c", 3)
+	l.Get("a")
+	l.Get("a")
+	l.Get("c")
+	l.Boost("b", 5)
+
+	check := func(name string, got []interface{}, want ...string) {
+		t.Helper()
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("%s: got %v, want %v", name, got, want)
+		}
+	}
+	check("frequency", l.KeysByFrequency(), "b", "a", "c")
+	check("recency", l.KeysByRecency(), "c", "a", "b")
+	l.SetPriorityClass("c", PriorityHigh)
+	check("priority", l.KeysByPriority(), "b", "c", "a")
+
+	// ties are broken by recency
+	l.Boost("b", -3)
+	check("priority ties", l.KeysByPriority(), "c", "a", "b")
+}
+
+func TestPurgeExpired(t *testing.T) {
+	var expired []interface{}
+	l := NewLFUDA(10, nil)
+	l.SetExpireCallback(func(key, value interface{}) {
+		expired = append(expired, key)
+	})
+	l.Set("a", 1)
+	l.Set("b", 2)
+	l.Set("c", 3)
+	l.Expire("a", time.Millisecond)
+	l.Expire("b", time.Hour)
+	time.Sleep(5 * time.Millisecond)
+
+	if n := l.PurgeExpired(); n != 1 || l.Len() != 2 || len(expired) != 1 || expired[0] != "a" {
+		t.Errorf("only the expired item should be removed: %d, %v", n, expired)
+	}
+	if n := l.PurgeExpired(); n != 0 {
+		t.Errorf("nothing left to purge: %d", n)
+	}
+}
+
+func TestClone(t *testing.T) {
+	l := NewLFUDA(3, nil)
+	l.Set("a", 1)
+	l.Set("b", 2)
+	l.Get("a")
+	l.Set("c", 3)
+	l.Set("d", 4)
+
+	var evicted []interface{}
+	c := l.Clone(func(key, value interface{}) {
+		evicted = append(evicted, key)
+	})
+	if c.Len() != l.Len() || c.Size() != l.Size() || c.Age() != l.Age() {
+		t.Fatalf("clone should have the same state")
+	}
+	if fmt.Sprint(c.KeysByPriority()) != fmt.Sprint(l.KeysByPriority()) {
+		t.Errorf("clone should have the same order: %v, %v", c.KeysByPriority(), l.KeysByPriority())
+	}
+
+	// the copies are independent
+	c.Get("d")
+	c.Get("d")
+	c.Set("e", 5)
+	if len(evicted) != 1 || c.Contains(evicted[0]) || !l.Contains(evicted[0]) || l.Contains("e") {
+		t.Errorf("clone evictions should not affect the original: %v", evicted)
+	}
+	if info, _ := l.Info("d"); info.Hits != 1 {
+		t.Errorf("clone hits should not affect the original: %+v", info)
+	}
+}
+
+func TestResize(t *testing.T) {
+	l := NewLFUDA(4, nil)
+	for i := 0; i < 4; i++ {
+		l.Set(i, i)
+	}
+	l.Get(3)
+	if n := l.Resize(2); n != 2 || l.Len() != 2 || l.Capacity() != 2 || !l.Contains(3) {
+		t.Errorf("shrinking should evict the least valuable items: %d, %v", n, l.Keys())
+	}
+	if n := l.Resize(10); n != 0 {
+		t.Errorf("growing should not evict")
+	}
+	for i := 4; i < 12; i++ {
+		l.SetWithSize(i, i, 1)
+	}
+	if l.Len() != 10 {
+		t.Errorf("the cache should hold up to its new size: %d", l.Len())
+	}
+}
+
+func TestRestore(t *testing.T) {
+	l := NewLFUDA(10, nil)
+	l.Set("a", 1)
+	l.Get("a")
+	l.Expire("a", time.Hour)
+	l.Set("b", 2)
+	l.Evict()
+
+	r := NewLFUDA(10, nil)
+	r.SetAge(l.Age())
+	l.RangeReverse(func(info EntryInfo) bool {
+		v, _ := l.Peek(info.Key)
+		if !r.Restore(info, v) {
+			t.Errorf("%v should be restored", info.Key)
+		}
+		return true
+	})
+	want, _ := l.Info("a")
+	got, _ := r.Info("a")
+	got.Version, want.Version = 0, 0
+	if got != want || r.Age() != l.Age() || r.Size() != l.Size() {
+		t.Errorf("restored item differs: %+v, %+v", got, want)
+	}
+	if ttl, _ := r.TTL("a"); ttl <= 59*time.Minute {
+		t.Errorf("expiration should be restored: %v", ttl)
+	}
+
+	info := want
+	if r.Restore(info, 1) {
+		t.Errorf("existing keys should not be restored")
+	}
+	info.Key, info.Expires = "expired", time.Now().UnixNano()
+	if r.Restore(info, 1) {
+		t.Errorf("expired items should not be restored")
+	}
+	info.Key, info.Expires, info.Size = "big", 0, 100
+	if r.Restore(info, 1) {
+		t.Errorf("items that don't fit should not be restored")
+	}
+}
+
+func TestSwitchPolicy(t *testing.T) {
+	l := NewLFU(10, nil)
+	l.SetWithSize("a", 1, 4)
+	l.Get("a")
+	l.Evict()
+	if l.SwitchPolicy("MRU") {
+		t.Errorf("unknown policies should be rejected")
+	}
+	l.SetWithSize("a", 1, 4)
+	l.Get("a")
+	l.SetWithSize("b", 2, 1)
+	if !l.SwitchPolicy("GDSF") || l.Age() != 0 {
+		t.Fatalf("switching from LFU should reset the age: %g", l.Age())
+	}
+	if info, _ := l.Info("a"); info.Priority != 0.5 {
+		t.Errorf("a's priority should be its hits per byte: %+v", info)
+	}
+
+	l.SetAge(2)
+	l.Get("b")
+	if !l.SwitchPolicy("LFUDA") {
+		t.Fatal("LFUDA should be known")
+	}
+	if info, _ := l.Info("b"); info.Priority != 4 {
+		t.Errorf("b should keep the age it was accessed at: %+v", info)
+	}
+	if info, _ := l.Info("a"); info.Priority != 2 {
+		t.Errorf("a should have its hits as priority: %+v", info)
+	}
+}
+
+// BenchmarkSetEvict sets new keys into a full cache of n entries with sizes
+// up to 1KiB, each set evicting, while a Zipf distributed working set keeps
+// getting hits.
+func BenchmarkSetEvict(b *testing.B) {
+	for _, policy := range []struct {
+		name string
+		new  func(size float64, onEvict EvictCallback) *LFUDA
+	}{{"LFUDA", NewLFUDA}, {"GDSF", NewGDSF}} {
+		for _, n := range []int{1e4, 1e5, 1e6} {
+			b.Run(fmt.Sprintf("%s/%d", policy.name, n), func(b *testing.B) {
+				r := rand.New(rand.NewSource(1))
+				l := policy.new(float64(n)*1024, nil)
+				for l.Len() < n {
+					l.SetWithSize(l.Len(), nil, float64(1+r.Intn(1023)))
+				}
+				zipf := rand.NewZipf(r, 1.1, 1, uint64(n-1))
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					l.Get(int(zipf.Uint64()))
+					l.SetWithSize(n+i, nil, float64(1+r.Intn(1023)))
+				}
+			})
+		}
+	}
+}
+
+func TestBatchEviction(t *testing.T) {
+	var l *LFUDA
+	var lens []int
+	l = NewLFUDA(10, func(key, value interface{}) {
+		lens = append(lens, l.Len())
+	})
+	for i := 0; i < 5; i++ {
+		l.SetWithSize(i, i, 2)
+	}
+	l.SetWithSize("big", nil, 9)
+	if len(lens) != 5 || lens[0] != 0 || lens[4] != 0 {
+		t.Errorf("callbacks should run once all the victims are removed: %v", lens)
+	}
+}
+
+func TestTopK(t *testing.T) {
+	l := NewGDSF(1000, nil)
+	for i := 0; i < 50; i++ {
+		l.SetWithSize(i, i, float64(1+i%7))
+		for j := 0; j < i%5; j++ {
+			l.Get(i)
+		}
+	}
+	for _, k := range []int{1, 3, 10, 50, 60} {
+		var want []EntryInfo
+		l.Range(func(info EntryInfo) bool {
+			want = append(want, info)
+			return len(want) < k
+		})
+		got := l.TopK(k)
+		if len(got) != len(want) {
+			t.Fatalf("TopK(%d) returned %d items, want %d", k, len(got), len(want))
+		}
+		for i := range got {
+			if got[i].Priority != want[i].Priority {
+				t.Errorf("TopK(%d)[%d] has priority %v, want %v", k, i, got[i].Priority, want[i].Priority)
+			}
+		}
+	}
+}
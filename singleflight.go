@@ -0,0 +1,127 @@
+package lfuda
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// call is an in-flight or completed load.
+type call struct {
+	wg  sync.WaitGroup
+	val interface{}
+	err error
+}
+
+// group coalesces concurrent loads of the same key so that only one of them
+// reaches the origin.
+type group struct {
+	mu       sync.Mutex
+	calls    map[interface{}]*call
+	ctxCalls map[interface{}]*ctxCall
+}
+
+// ctxCall is an in-flight load shared by callers that may give up waiting.
+type ctxCall struct {
+	done    chan struct{}
+	val     interface{}
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// do runs fn for key unless a load of key is already in flight, in which case
+// it waits for and returns that load's result instead.
+func (g *group) do(key interface{}, fn func() (interface{}, error)) (interface{}, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[interface{}]*call)
+	}
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.val, c.err
+	}
+	c := new(call)
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		c.wg.Done()
+	}()
+
+	c.val, c.err = fn()
+	return c.val, c.err
+}
+
+// doContext is do for loads taking a context.  Every caller stops waiting
+// when its own ctx is done.  fn runs in its own goroutine with a context
+// carrying the values of the first caller's ctx, which is canceled once no
+// caller waits for the load anymore.
+func (g *group) doContext(ctx context.Context, key interface{}, fn func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	if g.ctxCalls == nil {
+		g.ctxCalls = make(map[interface{}]*ctxCall)
+	}
+	c, ok := g.ctxCalls[key]
+	if !ok {
+		loadCtx, cancel := context.WithCancel(detachedContext{ctx})
+		c = &ctxCall{done: make(chan struct{}), cancel: cancel}
+		g.ctxCalls[key] = c
+		go func() {
+			defer close(c.done)
+			defer cancel()
+			defer g.forget(key, c)
+			c.val, c.err = fn(loadCtx)
+		}()
+	}
+	c.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.val, c.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		c.waiters--
+		if c.waiters == 0 {
+			// let the next caller start a fresh load
+			c.cancel()
+			if g.ctxCalls[key] == c {
+				delete(g.ctxCalls, key)
+			}
+		}
+		g.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+func (g *group) forget(key interface{}, c *ctxCall) {
+	g.mu.Lock()
+	if g.ctxCalls[key] == c {
+		delete(g.ctxCalls, key)
+	}
+	g.mu.Unlock()
+}
+
+// detachedContext keeps the values of its parent but not its deadline or
+// cancellation.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (deadline time.Time, ok bool) { return }
+func (detachedContext) Done() <-chan struct{}                   { return nil }
+func (detachedContext) Err() error                              { return nil }
+
+func (d detachedContext) Value(key interface{}) interface{} {
+	return d.parent.Value(key)
+}
@@ -0,0 +1,92 @@
+package lfuda
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// defaultSize returns the length of []byte values and of the default format
+// of other values, like simplelfuda does.
+func defaultSize(v interface{}) float64 {
+	if b, ok := v.([]byte); ok {
+		return float64(len(b))
+	}
+	return float64(len(fmt.Sprintf("%v", v)))
+}
+
+// EstimateSize returns an estimate of the memory in bytes held by v,
+// following pointers, slices, maps and interfaces.  Memory reachable through
+// several references, including cycles, is only counted once.
+func EstimateSize(v interface{}) float64 {
+	if v == nil {
+		return 0
+	}
+	rv := reflect.ValueOf(v)
+	s := sizer{seen: make(map[uintptr]bool)}
+	return float64(rv.Type().Size() + s.indirect(rv))
+}
+
+type sizer struct {
+	seen map[uintptr]bool
+}
+
+// visit reports whether the memory at p has not been counted yet.
+func (s *sizer) visit(p uintptr) bool {
+	if p == 0 || s.seen[p] {
+		return false
+	}
+	s.seen[p] = true
+	return true
+}
+
+// indirect returns the size of the memory referenced by v, excluding v itself.
+func (s *sizer) indirect(v reflect.Value) uintptr {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || !s.visit(v.Pointer()) {
+			return 0
+		}
+		return v.Type().Elem().Size() + s.indirect(v.Elem())
+	case reflect.Interface:
+		if v.IsNil() {
+			return 0
+		}
+		e := v.Elem()
+		return e.Type().Size() + s.indirect(e)
+	case reflect.String:
+		return uintptr(v.Len())
+	case reflect.Slice:
+		if v.IsNil() || !s.visit(v.Pointer()) {
+			return 0
+		}
+		size := uintptr(v.Cap()) * v.Type().Elem().Size()
+		for i := 0; i < v.Len(); i++ {
+			size += s.indirect(v.Index(i))
+		}
+		return size
+	case reflect.Array:
+		var size uintptr
+		for i := 0; i < v.Len(); i++ {
+			size += s.indirect(v.Index(i))
+		}
+		return size
+	case reflect.Map:
+		if v.IsNil() || !s.visit(v.Pointer()) {
+			return 0
+		}
+		entry := v.Type().Key().Size() + v.Type().Elem().Size()
+		size := uintptr(v.Len()) * entry
+		iter := v.MapRange()
+		for iter.Next() {
+			size += s.indirect(iter.Key()) + s.indirect(iter.Value())
+		}
+		return size
+	case reflect.Struct:
+		var size uintptr
+		for i := 0; i < v.NumField(); i++ {
+			size += s.indirect(v.Field(i))
+		}
+		return size
+	}
+	return 0
+}
@@ -0,0 +1,107 @@
+package lfuda
+
+import (
+	"testing"
+)
+
+type sizeNode struct {
+	name string
+	next *sizeNode
+}
+
+func TestEstimateSize(t *testing.T) {
+	if s := EstimateSize(nil); s != 0 {
+		t.Errorf("nil should be empty: %f", s)
+	}
+	if s := EstimateSize(int64(1)); s != 8 {
+		t.Errorf("bad int64 size: %f", s)
+	}
+	if s := EstimateSize("abcd"); s != 16+4 {
+		t.Errorf("bad string size: %f", s)
+	}
+	if s := EstimateSize(make([]int32, 2, 10)); s != 24+40 {
+		t.Errorf("slices should count their capacity: %f", s)
+	}
+
+	small := EstimateSize(map[string]int{"a": 1})
+	large := EstimateSize(map[string]int{"a": 1, "b": 2, "c": 3})
+	if large <= small {
+		t.Errorf("larger maps should be larger: %f <= %f", large, small)
+	}
+
+	// cycles are only counted once
+	a := &sizeNode{name: "a"}
+	b := &sizeNode{name: "bb", next: a}
+	a.next = b
+	if s := EstimateSize(a); s != 8+2*(16+8)+1+2 {
+		t.Errorf("bad cyclic size: %f", s)
+	}
+
+	shared := []byte("0123456789")
+	if s := EstimateSize([][]byte{shared, shared}); s != 24+2*24+10 {
+		t.Errorf("shared memory should be counted once: %f", s)
+	}
+}
+
+func TestWithSizeFunc(t *testing.T) {
+	l := NewWithOptions(10, WithSizeFunc(func(key, value interface{}) float64 {
+		return 5
+	}))
+	l.Set(1, 1)
+	l.Set(2, 2)
+	if l.Size() != 10 {
+		t.Errorf("size func should be used: %f", l.Size())
+	}
+	if !l.Set(3, 3) {
+		t.Errorf("set should have evicted")
+	}
+}
+
+func TestWithDeepSize(t *testing.T) {
+	l := NewWithOptions(1000, WithDeepSize())
+	l.Set("a", []int64{1, 2, 3, 4})
+	if l.Size() != 24+32 {
+		t.Errorf("deep size should be used: %f", l.Size())
+	}
+
+	l = NewWithOptions(1000, WithDeepSize(), WithSizeFunc(func(key, value interface{}) float64 {
+		return 1
+	}))
+	l.Set("a", []int64{1, 2, 3, 4})
+	if l.Size() != 1 {
+		t.Errorf("size func should take precedence: %f", l.Size())
+	}
+}
+
+func TestWithKeySizeAndOverhead(t *testing.T) {
+	l := NewWithOptions(1000, WithKeySize())
+	l.Set("key", "value")
+	if l.Size() != 3+5 {
+		t.Errorf("key size should be accounted: %f", l.Size())
+	}
+
+	l = NewWithOptions(1000, WithEntryOverhead(10))
+	l.Set("key", "value")
+	l.SetWithSize("other", "value", 1)
+	if l.Size() != 10+5+10+1 {
+		t.Errorf("entry overhead should be accounted: %f", l.Size())
+	}
+
+	l = NewWithOptions(1000, WithKeySize(), WithEntryOverhead(EntryOverhead), WithSizeFunc(func(key, value interface{}) float64 {
+		return 1
+	}))
+	l.Set("key", "value")
+	if l.Size() != EntryOverhead+3+1 {
+		t.Errorf("key size and overhead should be added to the size func: %f", l.Size())
+	}
+
+	// overhead counts against the budget
+	l = NewWithOptions(20, WithEntryOverhead(10))
+	l.Set(1, 1)
+	if !l.Set(2, 2) {
+		t.Errorf("set should have evicted")
+	}
+	if l.Len() != 1 {
+		t.Errorf("only one entry should fit: %d", l.Len())
+	}
+}
@@ -0,0 +1,221 @@
+package lfuda
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// SnapshotHeader describes the cache a snapshot was taken from.
+type SnapshotHeader struct {
+	Policy   string
+	Capacity float64
+	Age      float64
+	Len      int
+	Created  time.Time
+	// Encrypted is set when values were encrypted by WithEncryption.
+	Encrypted bool
+	// Version is the format version of the snapshot, set when read.
+	Version int
+}
+
+// ErrSnapshotEncrypted is returned when reading an encrypted snapshot into a
+// cache without encryption.
+var ErrSnapshotEncrypted = errors.New("lfuda: snapshot is encrypted")
+
+// ErrSnapshotPolicy is returned when reading a snapshot into a cache with
+// another policy, whose priorities the snapshot's don't match.
+var ErrSnapshotPolicy = errors.New("lfuda: snapshot has another policy")
+
+// SnapshotEntry is an entry of a snapshot.  The value is kept marshaled by
+// the cache's codec, and encrypted if the header says so, so that snapshots
+// can be inspected without knowing the value types.
+type SnapshotEntry struct {
+	EntryInfo
+	Value []byte
+}
+
+// DecodeValue unmarshals the entry's unencrypted value with codec, which
+// must be the codec of the cache that wrote the snapshot.
+func (e SnapshotEntry) DecodeValue(codec Codec) (interface{}, error) {
+	return codec.Unmarshal(e.Value)
+}
+
+// WriteSnapshot writes the cache's entries, with their hits and expiration,
+// and its age to w, from most to least valuable.  Expired and negative
+// entries are skipped, and values are encrypted if WithEncryption is set.
+// Values are marshaled by the cache's Codec and keys are gob encoded, so
+// custom key types must be registered with gob.Register.  The lock is only
+// held to copy the entries, not while writing.
+func (c *Cache) WriteSnapshot(w io.Writer) error {
+	c.flushReads()
+	c.lock.RLock()
+	header := SnapshotHeader{
+		Policy:   c.opts.policy,
+		Capacity: c.capacity(),
+		Age:      c.lfuda.Age(),
+		Created:  time.Now(),
+
+		Encrypted: c.opts.aead != nil,
+	}
+	entries := c.liveEntries()
+	c.lock.RUnlock()
+
+	header.Len = len(entries)
+	rw, err := newRecordWriter(w)
+	if err != nil {
+		return err
+	}
+	enc := gob.NewEncoder(rw)
+	if err := enc.Encode(header); err != nil {
+		return err
+	}
+	if err := rw.flush(); err != nil {
+		return err
+	}
+	codec := c.codec()
+	for _, e := range entries {
+		value, err := codec.Marshal(e.value)
+		if err != nil {
+			return err
+		}
+		if header.Encrypted {
+			if value, err = c.seal(value); err != nil {
+				return err
+			}
+		}
+		if err := enc.Encode(SnapshotEntry{EntryInfo: e.info, Value: value}); err != nil {
+			return err
+		}
+		if err := rw.flush(); err != nil {
+			return err
+		}
+	}
+	return rw.end()
+}
+
+// liveEntry is an entry copied out of the cache.
+type liveEntry struct {
+	info  EntryInfo
+	value interface{}
+}
+
+// liveEntries returns the entries that are neither expired nor negative,
+// from most to least valuable, with the read lock held.
+func (c *Cache) liveEntries() []liveEntry {
+	entries := make([]liveEntry, 0, c.lfuda.Len())
+	c.lfuda.Range(func(info EntryInfo) bool {
+		value, ok := c.decode(c.lfuda.Peek(info.Key))
+		if _, isNeg := value.(negativeEntry); ok && !isNeg {
+			entries = append(entries, liveEntry{info, value})
+		}
+		return true
+	})
+	return entries
+}
+
+// SnapshotReader reads a snapshot written by WriteSnapshot.
+type SnapshotReader struct {
+	dec    *gob.Decoder
+	header SnapshotHeader
+}
+
+// NewSnapshotReader reads the header of the snapshot r, of the current or
+// the previous format version.  Errors for corrupt snapshots or snapshots
+// of other versions match ErrSnapshotCorrupt or ErrSnapshotVersion.
+func NewSnapshotReader(r io.Reader) (*SnapshotReader, error) {
+	stream, version, err := snapshotStream(r)
+	if err != nil {
+		return nil, err
+	}
+	s := &SnapshotReader{dec: gob.NewDecoder(stream)}
+	if err := s.dec.Decode(&s.header); err != nil {
+		if version > 1 && (errors.Is(err, ErrSnapshotCorrupt) || err == io.ErrUnexpectedEOF) {
+			return nil, err
+		}
+		// without the magic, any input is read as a version 1 snapshot
+		return nil, fmt.Errorf("%w: bad header: %v", ErrSnapshotCorrupt, err)
+	}
+	s.header.Version = version
+	return s, nil
+}
+
+// Header returns the snapshot's header.
+func (s *SnapshotReader) Header() SnapshotHeader {
+	return s.header
+}
+
+// Next returns the next entry of the snapshot, or io.EOF after the last one.
+func (s *SnapshotReader) Next() (SnapshotEntry, error) {
+	var e SnapshotEntry
+	err := s.dec.Decode(&e)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF && !errors.Is(err, ErrSnapshotCorrupt) {
+		err = fmt.Errorf("%w: bad entry: %v", ErrSnapshotCorrupt, err)
+	}
+	return e, err
+}
+
+// ReadSnapshot adds the entries of a snapshot written by WriteSnapshot to the
+// cache, with their hits, priority and expiration.  The cache age is
+// restored if the cache is empty.  Entries that expired, are already cached
+// or don't fit without evicting are skipped.  The snapshot must have the
+// cache's policy, otherwise ErrSnapshotPolicy is returned: load it into a
+// cache with its policy and convert that with SwitchPolicy instead.  Returns
+// the number of entries added.
+func (c *Cache) ReadSnapshot(r io.Reader) (loaded int, err error) {
+	s, err := NewSnapshotReader(r)
+	if err != nil {
+		return 0, err
+	}
+	return c.readSnapshot(s)
+}
+
+func (c *Cache) readSnapshot(s *SnapshotReader) (loaded int, err error) {
+	if s.header.Encrypted && c.opts.aead == nil {
+		return 0, ErrSnapshotEncrypted
+	}
+	c.lockOp()
+	if s.header.Policy != c.opts.policy {
+		policy := c.opts.policy
+		c.unlockOp()
+		return 0, fmt.Errorf("%w: %s, not %s", ErrSnapshotPolicy, s.header.Policy, policy)
+	}
+	if c.lfuda.Len() == 0 {
+		c.lfuda.SetAge(s.header.Age)
+	}
+	c.unlockOp()
+
+	for {
+		e, err := s.Next()
+		if err == io.EOF {
+			return loaded, nil
+		}
+		if err != nil {
+			return loaded, err
+		}
+		if s.header.Encrypted {
+			if e.Value, err = c.open(e.Value); err != nil {
+				return loaded, err
+			}
+		}
+		value, err := e.DecodeValue(c.codec())
+		if err != nil {
+			return loaded, err
+		}
+		if c.badKey(e.Key) {
+			continue
+		}
+		encoded, _, err := c.encodeValue(value)
+		if err != nil {
+			return loaded, err
+		}
+		c.lockOp()
+		if c.lfuda.Restore(e.EntryInfo, encoded) {
+			c.stored(e.Key, value)
+			loaded++
+		}
+		c.unlockOp()
+	}
+}
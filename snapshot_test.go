@@ -0,0 +1,125 @@
+package lfuda
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestSnapshot(t *testing.T) {
+	l := NewGDSF(100)
+	l.Set("a", "a")
+	l.Set("b", 2)
+	l.SetWithTTL("c", "c", time.Hour)
+	l.SetWithTTL("gone", "x", time.Nanosecond)
+	for i := 0; i < 3; i++ {
+		l.Get("a")
+	}
+	time.Sleep(time.Millisecond)
+
+	var buf bytes.Buffer
+	if err := l.WriteSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewSnapshotReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := s.Header(); h.Len != 3 || h.Policy != PolicyGDSF || h.Capacity != 100 {
+		t.Errorf("bad header: %+v", h)
+	}
+	e, err := s.Next()
+	if err != nil || e.Key != "a" || e.Hits != 4 {
+		t.Errorf("expected the hottest entry first, got %+v, %v", e, err)
+	}
+
+	c := NewGDSF(100)
+	loaded, err := c.ReadSnapshot(bytes.NewReader(buf.Bytes()))
+	if err != nil || loaded != 3 {
+		t.Fatalf("expected 3 entries loaded, got %d, %v", loaded, err)
+	}
+	if v, ok := c.Peek("b"); !ok || v != 2 {
+		t.Errorf("bad value for b: %v", v)
+	}
+	if ttl, ok := c.TTL("c"); !ok || ttl <= 0 || ttl > time.Hour {
+		t.Errorf("expected c to keep its ttl, got %v", ttl)
+	}
+	if _, meta, _ := c.GetWithMeta("a"); meta.Hits != 5 {
+		t.Errorf("expected a to keep its hits, got %v", meta.Hits)
+	}
+
+	// existing entries are kept
+	loaded, _ = c.ReadSnapshot(bytes.NewReader(buf.Bytes()))
+	if loaded != 0 {
+		t.Errorf("expected cached entries to be skipped, loaded %d", loaded)
+	}
+
+	// a truncated snapshot loads up to its last complete entry
+	_, err = NewGDSF(100).ReadSnapshot(bytes.NewReader(buf.Bytes()[:buf.Len()-3]))
+	if err != io.ErrUnexpectedEOF {
+		t.Errorf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+
+	// priorities of another policy are not restored
+	other := New(100)
+	if loaded, err := other.ReadSnapshot(bytes.NewReader(buf.Bytes())); !errors.Is(err, ErrSnapshotPolicy) || loaded != 0 || other.Len() != 0 {
+		t.Errorf("expected ErrSnapshotPolicy, got %d, %v", loaded, err)
+	}
+}
+
+func TestSnapshotFormat(t *testing.T) {
+	l := New(100)
+	l.Set("a", "a value")
+	l.Set("b", "b value")
+	var buf bytes.Buffer
+	if err := l.WriteSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	snap := buf.Bytes()
+
+	corrupt := append([]byte(nil), snap...)
+	corrupt[bytes.Index(corrupt, []byte("b value"))] = 'B'
+	if _, err := New(100).ReadSnapshot(bytes.NewReader(corrupt)); !errors.Is(err, ErrSnapshotCorrupt) {
+		t.Errorf("expected ErrSnapshotCorrupt, got %v", err)
+	}
+	if _, err := NewSnapshotReader(bytes.NewReader([]byte("not a snapshot"))); !errors.Is(err, ErrSnapshotCorrupt) {
+		t.Errorf("expected ErrSnapshotCorrupt, got %v", err)
+	}
+
+	future := append([]byte(nil), snap...)
+	future[len(snapshotMagic)+1] = SnapshotVersion + 1
+	if _, err := NewSnapshotReader(bytes.NewReader(future)); !errors.Is(err, ErrSnapshotVersion) {
+		t.Errorf("expected ErrSnapshotVersion, got %v", err)
+	}
+
+	// version 1 snapshots are a bare gob stream
+	var v1 bytes.Buffer
+	enc := gob.NewEncoder(&v1)
+	enc.Encode(SnapshotHeader{Policy: PolicyLFUDA, Capacity: 100, Len: 1})
+	enc.Encode(SnapshotEntry{EntryInfo: EntryInfo{Key: "old", Hits: 2, Size: 1}, Value: mustMarshal(t, "old value")})
+	s, err := NewSnapshotReader(&v1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := s.Header(); h.Version != 1 || h.Len != 1 {
+		t.Errorf("bad header: %+v", h)
+	}
+	if e, err := s.Next(); err != nil || e.Key != "old" {
+		t.Errorf("bad entry: %+v, %v", e, err)
+	}
+	if s, _ := NewSnapshotReader(bytes.NewReader(snap)); s.Header().Version != SnapshotVersion {
+		t.Errorf("bad version: %d", s.Header().Version)
+	}
+}
+
+func mustMarshal(t *testing.T, value interface{}) []byte {
+	data, err := GobCodec.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
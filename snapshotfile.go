@@ -0,0 +1,119 @@
+package lfuda
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// WriteSnapshotFile writes a snapshot of the cache to path atomically: it is
+// written to a temporary file in the same directory, then renamed over path,
+// so a crash never leaves a partial snapshot behind.
+func (c *Cache) WriteSnapshotFile(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".snapshot-*")
+	if err != nil {
+		return err
+	}
+	fail := func(err error) error {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	w := bufio.NewWriter(tmp)
+	if err := c.WriteSnapshot(w); err != nil {
+		return fail(err)
+	}
+	if err := w.Flush(); err != nil {
+		return fail(err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fail(err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// StartSnapshotting writes a snapshot of the cache to path with
+// WriteSnapshotFile every interval, unless no key was set, removed, evicted
+// or purged since the last one, until stop is called or the cache is closed.  Failures are
+// logged and retried at the next interval.  stop waits for a snapshot being
+// written.
+func (c *Cache) StartSnapshotting(path string, interval time.Duration) (stop func()) {
+	done, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		written, first := uint64(0), true
+		for {
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			case <-c.done:
+				return
+			}
+			c.lock.RLock()
+			writes := c.writes
+			c.lock.RUnlock()
+			if !first && writes == written {
+				continue
+			}
+			if err := c.WriteSnapshotFile(path); err != nil {
+				c.debug("lfuda: snapshot failed", "path", path, "error", err)
+				continue
+			}
+			written, first = writes, false
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+		<-stopped
+	}
+}
+
+// NewFromSnapshot creates a cache with the capacity and policy of the
+// snapshot at path, then loads its entries for a warm restart.  The options
+// are those of NewWithOptions and take precedence over the snapshot's
+// policy, the entries being converted with SwitchPolicy.  If there is no snapshot the error matches os.ErrNotExist, so the
+// caller can fall back to an empty cache.
+func NewFromSnapshot(path string, opts ...Option) (*Cache, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+
+	s, err := NewSnapshotReader(r)
+	if err != nil {
+		return nil, err
+	}
+	header := s.Header()
+	c := NewWithOptions(header.Capacity, append([]Option{WithPolicy(header.Policy)}, opts...)...)
+	policy := c.Policy()
+	// the entries are loaded with their own policy's priorities first
+	err = c.SwitchPolicy(header.Policy)
+	if err == nil {
+		_, err = c.readSnapshot(s)
+	}
+	if err == nil {
+		err = c.SwitchPolicy(policy)
+	}
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
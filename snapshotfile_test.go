@@ -0,0 +1,76 @@
+package lfuda
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSnapshotFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.snap")
+	if _, err := NewFromSnapshot(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected a missing snapshot: %v", err)
+	}
+
+	l := NewGDSF(100)
+	l.Set("a", 1)
+	l.Set("b", 2)
+	l.Get("b")
+	stop := l.StartSnapshotting(path, time.Millisecond)
+	deadline := time.Now().Add(5 * time.Second)
+	for _, err := os.Stat(path); err != nil && time.Now().Before(deadline); _, err = os.Stat(path) {
+		time.Sleep(time.Millisecond)
+	}
+	stop()
+	stop()
+
+	l2, err := NewFromSnapshot(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l2.Policy() != PolicyGDSF || l2.Capacity() != 100 {
+		t.Errorf("bad cache: %s %g", l2.Policy(), l2.Capacity())
+	}
+	if v, _ := l2.Peek("a"); v != 1 {
+		t.Errorf("bad value: %v", v)
+	}
+	if hits, _ := l2.Hits("b"); hits != 2 {
+		t.Errorf("bad hits: %g", hits)
+	}
+	if l3, err := NewFromSnapshot(path, WithPolicy(PolicyLFU)); err != nil || l3.Policy() != PolicyLFU {
+		t.Errorf("options should override the snapshot's policy: %v", err)
+	}
+
+	// no temporary files are left behind
+	if files, _ := os.ReadDir(filepath.Dir(path)); len(files) != 1 {
+		t.Errorf("unexpected files: %v", files)
+	}
+}
+
+func TestSnapshottingPurge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.snap")
+	l := New(100)
+	defer l.Close()
+	l.Set("a", 1)
+	l.SetWithTTL("b", 2, time.Millisecond)
+	stop := l.StartSnapshotting(path, time.Millisecond)
+	defer stop()
+	waitForSnapshot := func(n int) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if l2, err := NewFromSnapshot(path); err == nil && l2.Len() == n {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+		t.Fatalf("the snapshot should hold %d entries", n)
+	}
+	waitForSnapshot(1)
+	time.Sleep(2 * time.Millisecond)
+	l.PurgeExpired()
+	l.Purge()
+	waitForSnapshot(0)
+}
@@ -0,0 +1,144 @@
+package lfuda
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// Snapshots start with snapshotMagic and a big endian uint16 format version,
+// followed by records: a big endian uint32 length, the CRC-32C of the record
+// and the record, a piece of the gob stream of the header and entries.  A
+// record of length 0 ends the snapshot.  Version 1 snapshots are the bare gob
+// stream.
+const (
+	snapshotMagic = "LFUDASNP"
+	// SnapshotVersion is the format version written by WriteSnapshot.
+	SnapshotVersion = 2
+	// maxSnapshotRecord bounds the records read, so a corrupt length
+	// doesn't allocate unbounded memory.
+	maxSnapshotRecord = 1 << 30
+)
+
+var (
+	// ErrSnapshotCorrupt is matched by the errors reading snapshots that
+	// fail their checksum or don't decode.
+	ErrSnapshotCorrupt = errors.New("lfuda: snapshot is corrupt")
+	// ErrSnapshotVersion is matched by the errors reading snapshots of a
+	// format version this package doesn't know.
+	ErrSnapshotVersion = errors.New("lfuda: unsupported snapshot version")
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// recordWriter frames what is written to it in checksummed records.
+type recordWriter struct {
+	w   io.Writer
+	buf bytes.Buffer
+}
+
+func newRecordWriter(w io.Writer) (*recordWriter, error) {
+	var prefix [len(snapshotMagic) + 2]byte
+	copy(prefix[:], snapshotMagic)
+	binary.BigEndian.PutUint16(prefix[len(snapshotMagic):], SnapshotVersion)
+	if _, err := w.Write(prefix[:]); err != nil {
+		return nil, err
+	}
+	return &recordWriter{w: w}, nil
+}
+
+func (r *recordWriter) Write(p []byte) (int, error) {
+	return r.buf.Write(p)
+}
+
+// flush writes what was written since the last flush as a record.
+func (r *recordWriter) flush() error {
+	var head [8]byte
+	binary.BigEndian.PutUint32(head[:4], uint32(r.buf.Len()))
+	binary.BigEndian.PutUint32(head[4:], crc32.Checksum(r.buf.Bytes(), castagnoli))
+	if _, err := r.w.Write(head[:]); err != nil {
+		return err
+	}
+	_, err := r.w.Write(r.buf.Bytes())
+	r.buf.Reset()
+	return err
+}
+
+// end writes the record ending the snapshot.
+func (r *recordWriter) end() error {
+	_, err := r.w.Write(make([]byte, 8))
+	return err
+}
+
+// recordReader reads the records written by a recordWriter, checking them.
+type recordReader struct {
+	r      io.Reader
+	record []byte
+	read   int
+	ended  bool
+}
+
+func (r *recordReader) Read(p []byte) (int, error) {
+	for len(r.record) == 0 {
+		if r.ended {
+			return 0, io.EOF
+		}
+		if err := r.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.record)
+	r.record = r.record[n:]
+	return n, nil
+}
+
+func (r *recordReader) next() error {
+	var head [8]byte
+	if _, err := io.ReadFull(r.r, head[:]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	n := binary.BigEndian.Uint32(head[:4])
+	if n == 0 {
+		r.ended = true
+		return nil
+	}
+	if n > maxSnapshotRecord {
+		return fmt.Errorf("%w: record %d is %d bytes long", ErrSnapshotCorrupt, r.read, n)
+	}
+	record := make([]byte, n)
+	if _, err := io.ReadFull(r.r, record); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	if crc32.Checksum(record, castagnoli) != binary.BigEndian.Uint32(head[4:]) {
+		return fmt.Errorf("%w: record %d fails its checksum", ErrSnapshotCorrupt, r.read)
+	}
+	r.record = record
+	r.read++
+	return nil
+}
+
+// snapshotStream returns the gob stream of the snapshot r and its format
+// version.
+func snapshotStream(r io.Reader) (io.Reader, int, error) {
+	br := bufio.NewReader(r)
+	prefix, err := br.Peek(len(snapshotMagic) + 2)
+	if err != nil || string(prefix[:len(snapshotMagic)]) != snapshotMagic {
+		return br, 1, nil
+	}
+	br.Discard(len(prefix))
+	version := int(binary.BigEndian.Uint16(prefix[len(snapshotMagic):]))
+	if version != SnapshotVersion {
+		return nil, version, fmt.Errorf("%w %d, this package reads versions 1 to %d", ErrSnapshotVersion, version, SnapshotVersion)
+	}
+	return &recordReader{r: br}, version, nil
+}
@@ -0,0 +1,260 @@
+// Package sqlcache caches database/sql query results in a GDSF lfuda cache,
+// using the size of each result set as its cost so that small, frequently
+// read results are preferred over large ones.
+//
+// Results are tagged with the tables their query reads, and statements run
+// with Exec invalidate the results of the tables they write, so most
+// invalidation needs no hooks.  Tables are found by matching the names
+// after FROM, JOIN, INTO, UPDATE and TRUNCATE, which misses all but the
+// first of comma separated tables and those read through views, functions
+// or triggers; invalidate those with InvalidateTable or an OnExec hook.
+// Schema qualifiers are ignored, so tables of the same name in different
+// schemas invalidate each other.  DDL such as ALTER TABLE or DROP TABLE is
+// not detected either; follow it with InvalidateTable or InvalidateAll.
+package sqlcache
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	lfuda "github.com/bparli/lfuda-go"
+)
+
+// Result is a fully materialized query result set.  Results are shared
+// between callers and must not be modified.
+type Result struct {
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// InvalidationHook is called after every successful Exec so that it can
+// invalidate the cached queries the statement made stale.
+type InvalidationHook func(d *DB, query string, args []interface{})
+
+// DB wraps a *sql.DB, caching the results of the queries run through it.
+type DB struct {
+	db    *sql.DB
+	cache *lfuda.Cache
+	ttl   time.Duration
+
+	// guards hooks and gens
+	lock  sync.RWMutex
+	hooks []InvalidationHook
+	// generation of each table, increased by invalidating it
+	gens map[string]uint64
+}
+
+type entry struct {
+	result *Result
+	size   float64
+	// tables read and their generations when the query started
+	tables []string
+	gens   []uint64
+}
+
+// New wraps db with a result cache of the given size in bytes.  Cached
+// results are served for at most ttl, or until invalidated when ttl is 0.
+func New(db *sql.DB, size float64, ttl time.Duration) *DB {
+	return &DB{
+		db: db,
+		cache: lfuda.NewWithOptions(size,
+			lfuda.WithPolicy(lfuda.PolicyGDSF),
+			lfuda.WithSizeFunc(func(key, value interface{}) float64 { return value.(*entry).size }),
+			lfuda.WithTTLFunc(func(key, value interface{}) time.Duration { return ttl }),
+		),
+		ttl:  ttl,
+		gens: make(map[string]uint64),
+	}
+}
+
+// DB returns the wrapped *sql.DB.
+func (d *DB) DB() *sql.DB {
+	return d.db
+}
+
+// Cache returns the underlying result cache.
+func (d *DB) Cache() *lfuda.Cache {
+	return d.cache
+}
+
+// Query runs a query, returning its cached result when available.
+func (d *DB) Query(query string, args ...interface{}) (*Result, error) {
+	return d.QueryContext(context.Background(), query, args...)
+}
+
+// QueryContext runs a query, returning its cached result when available.
+// Concurrent misses for the same query and arguments share a single query.
+func (d *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*Result, error) {
+	key := Key(query, args...)
+	loaded := false
+	load := func(ctx context.Context, _ interface{}) (interface{}, error) {
+		loaded = true
+		tables := tablesRead(query)
+		gens := d.generations(tables)
+		rows, err := d.db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return nil, err
+		}
+		result, size, err := materialize(rows)
+		if err != nil {
+			return nil, err
+		}
+		return &entry{result: result, size: size, tables: tables, gens: gens}, nil
+	}
+	for {
+		v, err := d.cache.GetOrLoad(ctx, key, load)
+		if err != nil {
+			return nil, err
+		}
+		e := v.(*entry)
+		// a result loaded during the call is as fresh as the call
+		if loaded || d.fresh(e) {
+			return e.result, nil
+		}
+		d.cache.Remove(key)
+	}
+}
+
+// generations returns the current generations of tables.
+func (d *DB) generations(tables []string) []uint64 {
+	gens := make([]uint64, len(tables))
+	d.lock.RLock()
+	for i, table := range tables {
+		gens[i] = d.gens[table]
+	}
+	d.lock.RUnlock()
+	return gens
+}
+
+// fresh reports whether none of the tables e read was invalidated since.
+func (d *DB) fresh(e *entry) bool {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+	for i, table := range e.tables {
+		if d.gens[table] != e.gens[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Exec executes a statement without caching, invalidates the results of
+// the tables it writes and then runs the invalidation hooks.
+func (d *DB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return d.ExecContext(context.Background(), query, args...)
+}
+
+// ExecContext executes a statement without caching, invalidates the
+// results of the tables it writes and then runs the invalidation hooks.
+func (d *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	res, err := d.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	d.InvalidateTable(tablesWritten(query)...)
+
+	d.lock.RLock()
+	hooks := d.hooks
+	d.lock.RUnlock()
+	for _, hook := range hooks {
+		hook(d, query, args)
+	}
+	return res, nil
+}
+
+// OnExec registers a hook run after every successful Exec.
+func (d *DB) OnExec(hook InvalidationHook) {
+	d.lock.Lock()
+	d.hooks = append(d.hooks[:len(d.hooks):len(d.hooks)], hook)
+	d.lock.Unlock()
+}
+
+// Invalidate drops the cached result of a query, returning whether it was
+// cached.
+func (d *DB) Invalidate(query string, args ...interface{}) bool {
+	return d.cache.Remove(Key(query, args...))
+}
+
+// InvalidateTable drops the cached results of the queries reading tables.
+// Table names are matched case-insensitively, without quotes or schema.  Results are
+// dropped when next read.
+func (d *DB) InvalidateTable(tables ...string) {
+	if len(tables) == 0 {
+		return
+	}
+	d.lock.Lock()
+	for _, table := range tables {
+		d.gens[tableName(table)]++
+	}
+	d.lock.Unlock()
+}
+
+// InvalidateAll drops every cached result.
+func (d *DB) InvalidateAll() {
+	d.cache.Purge()
+}
+
+// Key returns the cache key used for a query and its arguments.  Queries
+// differing only in whitespace outside of quotes or a trailing semicolon
+// share keys.
+func Key(query string, args ...interface{}) string {
+	var b strings.Builder
+	b.WriteString(normalize(query))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "\x00%T:%v", arg, arg)
+	}
+	return b.String()
+}
+
+// materialize reads and closes rows, returning the result and its size in
+// bytes.
+func materialize(rows *sql.Rows) (*Result, float64, error) {
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, 0, err
+	}
+	result := &Result{Columns: columns}
+
+	var size float64
+	for _, c := range columns {
+		size += float64(len(c))
+	}
+
+	for rows.Next() {
+		row := make([]interface{}, len(columns))
+		dest := make([]interface{}, len(columns))
+		for i := range row {
+			dest[i] = &row[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, 0, err
+		}
+		for _, v := range row {
+			size += valueSize(v)
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return result, size, nil
+}
+
+func valueSize(v interface{}) float64 {
+	switch v := v.(type) {
+	case nil:
+		return 0
+	case []byte:
+		return float64(len(v))
+	case string:
+		return float64(len(v))
+	default:
+		return float64(len(fmt.Sprintf("%v", v)))
+	}
+}
@@ -0,0 +1,177 @@
+package sqlcache
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeDriver answers every query with one row echoing its argument and
+// counts the queries it served.
+type fakeDriver struct {
+	queries int64
+}
+
+type fakeConn struct{ d *fakeDriver }
+type fakeStmt struct{ d *fakeDriver }
+type fakeRows struct {
+	arg  driver.Value
+	done bool
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) { return &fakeConn{d}, nil }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) { return &fakeStmt{c.d}, nil }
+func (c *fakeConn) Close() error                              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)                 { return nil, driver.ErrSkip }
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(1), nil
+}
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	atomic.AddInt64(&s.d.queries, 1)
+	var arg driver.Value
+	if len(args) > 0 {
+		arg = args[0]
+	}
+	return &fakeRows{arg: arg}, nil
+}
+
+func (r *fakeRows) Columns() []string { return []string{"arg"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = r.arg
+	return nil
+}
+
+var drv = &fakeDriver{}
+
+func init() {
+	sql.Register("sqlcachetest", drv)
+}
+
+func openDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlcachetest", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	atomic.StoreInt64(&drv.queries, 0)
+	return db
+}
+
+func TestQuery(t *testing.T) {
+	d := New(openDB(t), 1024, 0)
+
+	for i := 0; i < 3; i++ {
+		res, err := d.Query("SELECT ?", "a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Rows) != 1 || res.Rows[0][0] != "a" {
+			t.Fatalf("bad result: %+v", res)
+		}
+	}
+	if n := atomic.LoadInt64(&drv.queries); n != 1 {
+		t.Errorf("query should have been served from the cache: %d", n)
+	}
+
+	if _, err := d.Query("SELECT ?", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt64(&drv.queries); n != 2 {
+		t.Errorf("different args should be cached separately: %d", n)
+	}
+
+	if !d.Invalidate("SELECT ?", "a") {
+		t.Errorf("query should have been cached")
+	}
+	d.Query("SELECT ?", "a")
+	if n := atomic.LoadInt64(&drv.queries); n != 3 {
+		t.Errorf("invalidated query should have been rerun: %d", n)
+	}
+}
+
+func TestQueryTTL(t *testing.T) {
+	d := New(openDB(t), 1024, time.Millisecond)
+
+	d.Query("SELECT ?", 1)
+	time.Sleep(5 * time.Millisecond)
+	d.Query("SELECT ?", 1)
+	if n := atomic.LoadInt64(&drv.queries); n != 2 {
+		t.Errorf("expired result should have been refreshed: %d", n)
+	}
+}
+
+func TestExecHooks(t *testing.T) {
+	d := New(openDB(t), 1024, 0)
+	d.OnExec(func(d *DB, query string, args []interface{}) {
+		d.InvalidateAll()
+	})
+
+	d.Query("SELECT ?", 1)
+	if _, err := d.Exec("UPDATE t SET x = ?", 2); err != nil {
+		t.Fatal(err)
+	}
+	if d.Cache().Len() != 0 {
+		t.Errorf("exec hook should have invalidated the cache")
+	}
+}
+
+func TestKey(t *testing.T) {
+	if Key("SELECT ?", 1) == Key("SELECT ?", "1") {
+		t.Errorf("args of different types should not collide")
+	}
+	if Key("SELECT ?", 1) != Key("SELECT ?", 1) {
+		t.Errorf("keys should be stable")
+	}
+}
+
+func TestTableInvalidation(t *testing.T) {
+	d := New(openDB(t), 1024, 0)
+
+	d.Query("SELECT x FROM users WHERE id = ?", 1)
+	d.Query(`SELECT x FROM "Orders" JOIN items ON items.id = orders.item WHERE id = ?`, 1)
+	if _, err := d.Exec("UPDATE users SET x = ? WHERE id = 1", 2); err != nil {
+		t.Fatal(err)
+	}
+	d.Query("SELECT x FROM users WHERE id = ?", 1)
+	d.Query(`SELECT x FROM "Orders" JOIN items ON items.id = orders.item WHERE id = ?`, 1)
+	if n := atomic.LoadInt64(&drv.queries); n != 3 {
+		t.Errorf("only the query reading the updated table should rerun: %d", n)
+	}
+
+	d.Exec("INSERT INTO items (id) VALUES (?)", 3)
+	d.Query(`SELECT x FROM "Orders" JOIN items ON items.id = orders.item WHERE id = ?`, 1)
+	d.InvalidateTable("ORDERS")
+	d.Query(`SELECT x FROM "Orders" JOIN items ON items.id = orders.item WHERE id = ?`, 1)
+	if n := atomic.LoadInt64(&drv.queries); n != 5 {
+		t.Errorf("the joined tables should invalidate the query: %d", n)
+	}
+}
+
+func TestTables(t *testing.T) {
+	if got := tablesRead("select a from t1, x join `s`.`T2` on 1 where b in (select c FROM t1)"); len(got) != 2 || got[0] != "t1" || got[1] != "t2" {
+		t.Errorf("bad tables read: %v", got)
+	}
+	if got := tablesWritten("DELETE FROM t1 WHERE 1"); len(got) != 1 || got[0] != "t1" {
+		t.Errorf("bad tables written: %v", got)
+	}
+	if got := tablesWritten(`UPDATE public."Users" SET a = 1`); len(got) != 1 || got[0] != "users" {
+		t.Errorf("qualified names should match unqualified ones: %v", got)
+	}
+	if Key("SELECT  a\n FROM t WHERE b = ' x  y';", 1) != Key("SELECT a FROM t WHERE b = ' x  y'", 1) {
+		t.Errorf("whitespace should be normalized")
+	}
+	if Key("SELECT a FROM t WHERE b = ' x  y'") == Key("SELECT a FROM t WHERE b = ' x y'") {
+		t.Errorf("quoted whitespace should be kept")
+	}
+}
@@ -0,0 +1,73 @@
+package sqlcache
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// the table names following the keywords of queries reading them
+	readTables = regexp.MustCompile("(?i)\\b(?:FROM|JOIN)\\s+([\\w.\"`\\[\\]]+)")
+	// the table names following the keywords of statements writing them
+	writtenTables = regexp.MustCompile("(?i)\\b(?:INTO|UPDATE|DELETE\\s+FROM|TRUNCATE(?:\\s+TABLE)?)\\s+([\\w.\"`\\[\\]]+)")
+)
+
+// tablesRead returns the tables a query reads.
+func tablesRead(query string) []string {
+	return tables(readTables, query)
+}
+
+// tablesWritten returns the tables a statement writes.
+func tablesWritten(query string) []string {
+	return tables(writtenTables, query)
+}
+
+func tables(re *regexp.Regexp, query string) []string {
+	var names []string
+	for _, m := range re.FindAllStringSubmatch(query, -1) {
+		name := tableName(m[1])
+		dup := false
+		for _, n := range names {
+			dup = dup || n == name
+		}
+		if !dup && name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// tableName returns the lower case name of a table, without quotes or
+// schema, so qualified and unqualified references invalidate each other.
+func tableName(name string) string {
+	name = strings.Trim(strings.NewReplacer("\"", "", "`", "", "[", "", "]", "").Replace(name), ".")
+	return strings.ToLower(name[strings.LastIndexByte(name, '.')+1:])
+}
+
+// normalize collapses the runs of whitespace outside of quotes in query and
+// trims it and its trailing semicolon.
+func normalize(query string) string {
+	var b strings.Builder
+	b.Grow(len(query))
+	var quote rune
+	space := false
+	for _, r := range strings.TrimRight(strings.TrimSpace(query), "; \t\n\r") {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,104 @@
+package lfuda
+
+import (
+	"sync/atomic"
+)
+
+// Stats holds counters describing how a cache has been used.
+type Stats struct {
+	// Gets that found their key
+	Hits uint64
+	// Gets that did not find their key
+	Misses uint64
+	// fetches run by Cached to fill a miss
+	Loads uint64
+	// fetches that returned an error
+	LoadErrors uint64
+	// Cached calls answered from a negative entry
+	NegativeHits uint64
+	// panics recovered by WithRecover
+	Panics uint64
+	// values dropped by SetAsync because its queue was full
+	DroppedSets uint64
+	// lifetimes of evicted entries from set to eviction, in milliseconds
+	Lifetimes Histogram
+	// hits of entries when they were evicted
+	EvictionHits Histogram
+	// waits for the write lock, for the read lock by Get, and holds of the
+	// write lock, in microseconds, recorded with WithLockMetrics
+	LockWaits     Histogram
+	ReadLockWaits Histogram
+	LockHolds     Histogram
+}
+
+// HitRatio returns the fraction of Gets that were hits.
+func (s Stats) HitRatio() float64 {
+	return ratio(s.Hits, s.Misses)
+}
+
+// stats is updated atomically so counting never widens the cache's critical
+// sections.
+type stats struct {
+	hits         atomic.Uint64
+	misses       atomic.Uint64
+	loads        atomic.Uint64
+	loadErrors   atomic.Uint64
+	negativeHits atomic.Uint64
+	panics       atomic.Uint64
+	droppedSets  atomic.Uint64
+	lifetimes    histogram
+	evictionHits histogram
+
+	lockWaits     histogram
+	readLockWaits histogram
+	lockHolds     histogram
+}
+
+func (s *stats) snapshot() Stats {
+	return Stats{
+		Hits:         s.hits.Load(),
+		Misses:       s.misses.Load(),
+		Loads:        s.loads.Load(),
+		LoadErrors:   s.loadErrors.Load(),
+		NegativeHits: s.negativeHits.Load(),
+		Panics:       s.panics.Load(),
+		DroppedSets:  s.droppedSets.Load(),
+		Lifetimes:    s.lifetimes.snapshot(),
+		EvictionHits: s.evictionHits.snapshot(),
+
+		LockWaits:     s.lockWaits.snapshot(),
+		ReadLockWaits: s.readLockWaits.snapshot(),
+		LockHolds:     s.lockHolds.snapshot(),
+	}
+}
+
+// reset zeroes the counters.  Counts racing with it may survive.
+func (s *stats) reset() {
+	s.hits.Store(0)
+	s.misses.Store(0)
+	s.loads.Store(0)
+	s.loadErrors.Store(0)
+	s.negativeHits.Store(0)
+	s.panics.Store(0)
+	s.droppedSets.Store(0)
+	s.lifetimes.reset()
+	s.evictionHits.reset()
+	s.lockWaits.reset()
+	s.readLockWaits.reset()
+	s.lockHolds.reset()
+}
+
+// Stats returns a snapshot of the cache's counters.
+func (c *Cache) Stats() Stats {
+	return c.stats.snapshot()
+}
+
+// ResetStats zeroes the cache's counters and hit ratio windows, e.g. to
+// measure a deployment without the history of a long uptime.  The stats of
+// its namespaces are kept.
+func (c *Cache) ResetStats() {
+	c.stats.reset()
+	if c.window != nil {
+		c.window.reset()
+	}
+}
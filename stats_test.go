@@ -0,0 +1,123 @@
+package lfuda
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStatsConcurrent(t *testing.T) {
+	l := New(1024)
+	for i := 0; i < 100; i++ {
+		l.Set(i, i)
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				l.Get(i % 200)
+			}
+		}()
+	}
+	wg.Wait()
+
+	stats := l.Stats()
+	if stats.Hits != 4000 || stats.Misses != 4000 {
+		t.Errorf("bad stats: %+v", stats)
+	}
+	if stats.HitRatio() != 0.5 {
+		t.Errorf("bad hit ratio: %f", stats.HitRatio())
+	}
+}
+
+// BenchmarkStats_Parallel mixes Gets and Sets from all goroutines while
+// reading the counters, to check that stats stay off the cache lock.
+func BenchmarkStats_Parallel(b *testing.B) {
+	l := New(8192)
+	for i := 0; i < 4096; i++ {
+		l.Set(i, i)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		r := rand.New(rand.NewSource(rand.Int63()))
+		for i := 0; pb.Next(); i++ {
+			key := r.Intn(8192)
+			switch {
+			case i%10 == 0:
+				l.Set(key, key)
+			case i%100 == 1:
+				l.Stats()
+			default:
+				l.Get(key)
+			}
+		}
+	})
+	b.StopTimer()
+
+	stats := l.Stats()
+	b.Logf("hits: %d misses: %d ratio: %f", stats.Hits, stats.Misses, stats.HitRatio())
+}
+
+func TestResetStats(t *testing.T) {
+	l := NewWithOptions(10, WithHitRatioWindow())
+	l.Set(1, 1)
+	l.Get(1)
+	l.Get(2)
+	for i := 2; i < 30; i++ {
+		l.Set(i, i)
+	}
+
+	l.ResetStats()
+	if stats := l.Stats(); stats.Hits != 0 || stats.Misses != 0 || stats.Lifetimes.Count() != 0 || stats.EvictionHits.Count() != 0 {
+		t.Errorf("stats not reset: %+v", stats)
+	}
+	if ratio := l.HitRatioOver(time.Minute); ratio != 0 {
+		t.Errorf("window not reset: %f", ratio)
+	}
+	l.Get(29)
+	if stats := l.Stats(); stats.Hits != 1 {
+		t.Errorf("bad stats after reset: %+v", stats)
+	}
+}
+
+func TestHitRatioOver(t *testing.T) {
+	l := NewWithOptions(10, WithHitRatioWindow())
+	now := time.Unix(1e9, 0)
+	l.window.now = func() time.Time { return now }
+	l.Set(1, 1)
+
+	// 10 minutes ago, every Get missed
+	for i := 0; i < 30; i++ {
+		l.Get(2)
+	}
+	now = now.Add(10 * time.Minute)
+	// now, 3 in 4 Gets hit
+	for i := 0; i < 4; i++ {
+		l.Get(1 + i/3)
+	}
+
+	if ratio := l.HitRatioOver(time.Minute); ratio != 0.75 {
+		t.Errorf("bad 1m ratio: %f", ratio)
+	}
+	if ratio := l.HitRatioOver(15 * time.Minute); ratio != 3.0/34 {
+		t.Errorf("bad 15m ratio: %f", ratio)
+	}
+	if ratio := l.Stats().HitRatio(); ratio != 3.0/34 {
+		t.Errorf("bad cumulative ratio: %f", ratio)
+	}
+
+	// the old misses fall out of the longest window
+	now = now.Add(6 * time.Minute)
+	l.Get(1)
+	if ratio := l.HitRatioOver(15 * time.Minute); ratio != 4.0/5 {
+		t.Errorf("bad 15m ratio: %f", ratio)
+	}
+	if ratio := New(10).HitRatioOver(time.Minute); ratio != 0 {
+		t.Errorf("untracked ratio: %f", ratio)
+	}
+}
@@ -0,0 +1,156 @@
+package stringlfuda
+
+import (
+	"sync"
+
+	"github.com/bparli/lfuda-go/internal/typed"
+)
+
+// InlineSize is the largest value a BytesCache can store inline.
+const InlineSize = 128
+
+// inlineBytes is a value copied into a buffer of the cache when it is short
+// enough, or referenced otherwise.
+type inlineBytes struct {
+	b []byte
+	// b is a buffer of the cache, reused once the entry is gone
+	owned bool
+}
+
+func inlineSize(v inlineBytes) float64 {
+	return float64(len(v.b))
+}
+
+// BytesCache is a thread-safe fixed size lfuda cache of []byte values with
+// string keys.  Values no longer than its inline limit are copied into
+// buffers of that many bytes, which are reused once their entries are gone,
+// so a warm cache stores and returns them without allocating.  Longer values
+// are referenced and must not be modified after they are set.
+type BytesCache struct {
+	lfuda  *typed.Cache[string, inlineBytes]
+	lock   sync.Mutex
+	inline int
+	// buffers of evicted inline values
+	free [][]byte
+}
+
+// NewBytes creates an lfuda of the given size storing values of up to inline
+// bytes inline.  inline is capped at InlineSize.
+func NewBytes(size float64, inline int) *BytesCache {
+	return newBytes(size, typed.LFUDA, inline)
+}
+
+// NewBytesGDSF creates a BytesCache of the given size and the GDSF cache
+// policy.
+func NewBytesGDSF(size float64, inline int) *BytesCache {
+	return newBytes(size, typed.GDSF, inline)
+}
+
+func newBytes(size float64, policy typed.Policy, inline int) *BytesCache {
+	if inline > InlineSize {
+		inline = InlineSize
+	}
+	c := &BytesCache{inline: inline}
+	c.lfuda = typed.New[string, inlineBytes](size, policy, inlineSize, c.release)
+	return c
+}
+
+// release keeps the buffer of a value leaving the cache for reuse.
+func (c *BytesCache) release(key string, v inlineBytes) {
+	if v.owned {
+		c.free = append(c.free, v.b[:0])
+	}
+}
+
+// buffer returns an empty buffer of the inline limit's capacity.
+func (c *BytesCache) buffer() []byte {
+	if n := len(c.free); n > 0 {
+		b := c.free[n-1]
+		c.free[n-1] = nil
+		c.free = c.free[:n-1]
+		return b
+	}
+	return make([]byte, 0, c.inline)
+}
+
+// Set adds a value to the cache. Returns true if an eviction occurred.
+func (c *BytesCache) Set(key string, value []byte) (ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	v := inlineBytes{b: value}
+	if len(value) > 0 && len(value) <= c.inline {
+		v = inlineBytes{b: append(c.buffer(), value...), owned: true}
+	}
+	old, replaced := c.lfuda.Peek(key)
+	ok = c.lfuda.Set(key, v)
+	// the value replaced in place isn't released by the cache
+	if replaced && c.lfuda.Contains(key) {
+		c.release(key, old)
+	}
+	return ok
+}
+
+// Get appends the key's value to dst and returns the extended slice.
+func (c *BytesCache) Get(key string, dst []byte) (value []byte, ok bool) {
+	// copied with the lock held, as the buffer is reused once the entry is
+	// gone
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	v, ok := c.lfuda.Get(key)
+	if !ok {
+		return dst, false
+	}
+	return append(dst, v.b...), true
+}
+
+// Peek appends the key's value to dst without updating its hits.
+func (c *BytesCache) Peek(key string, dst []byte) (value []byte, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	v, ok := c.lfuda.Peek(key)
+	if !ok {
+		return dst, false
+	}
+	return append(dst, v.b...), true
+}
+
+// Contains checks if a key is in the cache without updating its hits.
+func (c *BytesCache) Contains(key string) bool {
+	c.lock.Lock()
+	containKey := c.lfuda.Contains(key)
+	c.lock.Unlock()
+	return containKey
+}
+
+// Remove removes the provided key from the cache.
+func (c *BytesCache) Remove(key string) (present bool) {
+	c.lock.Lock()
+	present = c.lfuda.Remove(key)
+	c.lock.Unlock()
+	return
+}
+
+// Len returns the number of items in the cache.
+func (c *BytesCache) Len() (length int) {
+	c.lock.Lock()
+	length = c.lfuda.Len()
+	c.lock.Unlock()
+	return length
+}
+
+// Size returns the current size of the cache in bytes.
+func (c *BytesCache) Size() (size float64) {
+	c.lock.Lock()
+	size = c.lfuda.Size()
+	c.lock.Unlock()
+	return size
+}
+
+// Purge is used to completely clear the cache.
+func (c *BytesCache) Purge() {
+	c.lock.Lock()
+	c.lfuda.Purge()
+	c.free = nil
+	c.lock.Unlock()
+}
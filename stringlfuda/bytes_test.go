@@ -0,0 +1,116 @@
+package stringlfuda
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestBytesCache(t *testing.T) {
+	l := NewBytes(100, 8)
+	short := []byte("short")
+	long := []byte("longer than eight")
+	l.Set("short", short)
+	l.Set("long", long)
+	short[0] = 'S'
+
+	if v, ok := l.Get("short", nil); !ok || string(v) != "short" {
+		t.Errorf("inline value should have been copied: %s", v)
+	}
+	if v, ok := l.Peek("long", []byte("x")); !ok || string(v) != "xlonger than eight" {
+		t.Errorf("value should be appended to dst: %s", v)
+	}
+	if l.Size() != 5+17 || l.Len() != 2 {
+		t.Errorf("size should be the value lengths: %f", l.Size())
+	}
+
+	if !l.Remove("short") || l.Contains("short") {
+		t.Errorf("short should have been removed")
+	}
+	if _, ok := l.Get("short", nil); ok {
+		t.Errorf("short should miss")
+	}
+	l.Purge()
+	if l.Len() != 0 {
+		t.Errorf("cache should be empty")
+	}
+}
+
+func TestBytesCacheInlineCap(t *testing.T) {
+	l := NewBytes(1000, 1000)
+	value := bytes.Repeat([]byte("a"), InlineSize+1)
+	l.Set("a", value)
+	value[0] = 'b'
+	if v, _ := l.Peek("a", nil); v[0] != 'b' {
+		t.Errorf("values longer than InlineSize should be referenced")
+	}
+}
+
+func TestBytesCacheReuse(t *testing.T) {
+	l := NewBytes(40, 8)
+	want := make(map[string]string)
+	for i := 0; i < 200; i++ {
+		k := string(rune('a' + i%13))
+		v := fmt.Sprintf("%d", i*7919%100000)
+		l.Set(k, []byte(v))
+		want[k] = v
+	}
+	for k, v := range want {
+		if got, ok := l.Peek(k, nil); ok && string(got) != v {
+			t.Errorf("%s: reused buffer corrupted the value: %s, want %s", k, got, v)
+		}
+	}
+	if l.Len() == 0 || len(l.free) > 13 {
+		t.Errorf("buffers should be reused: %d entries, %d free", l.Len(), len(l.free))
+	}
+}
+
+func TestBytesCacheAllocs(t *testing.T) {
+	l := NewBytes(64*100, InlineSize)
+	keys := make([]string, 200)
+	for i := range keys {
+		keys[i] = string(rune('a'+i%26)) + string(rune('a'+i/26))
+	}
+	value := bytes.Repeat([]byte("v"), 64)
+	buf := make([]byte, 0, 64)
+	for _, k := range keys {
+		l.Set(k, value)
+	}
+
+	i := 0
+	allocs := testing.AllocsPerRun(1000, func() {
+		l.Set(keys[i%len(keys)], value)
+		l.Get(keys[(i/2)%len(keys)], buf[:0])
+		i++
+	})
+	if allocs != 0 {
+		t.Errorf("warm cache should not allocate: %f", allocs)
+	}
+}
+
+func BenchmarkBytesInline(b *testing.B) {
+	l := NewBytes(64*4096, InlineSize)
+	keys := trace(b.N)
+	value := bytes.Repeat([]byte("v"), 64)
+	buf := make([]byte, 0, 64)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i, k := range keys {
+		l.Set(k, value)
+		l.Get(keys[i/2], buf[:0])
+	}
+}
+
+func BenchmarkBytesReferenced(b *testing.B) {
+	l := New[[]byte](64 * 4096)
+	keys := trace(b.N)
+	value := bytes.Repeat([]byte("v"), 64)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i, k := range keys {
+		l.Set(k, append([]byte(nil), value...))
+		l.Get(keys[i/2])
+	}
+}
@@ -0,0 +1,52 @@
+// Package stringlfuda provides thread-safe lfuda caches specialized for
+// string keys such as paths or URLs: Cache for values of any type, and
+// BytesCache for small []byte values stored inline in their entries.
+// String, []byte and numeric values are set without allocating once the
+// cache is warm.
+package stringlfuda
+
+import (
+	"github.com/bparli/lfuda-go/internal/typed"
+)
+
+// Cache is a thread-safe fixed size lfuda cache with string keys.
+type Cache[V any] struct {
+	*typed.Locked[string, V]
+}
+
+// New creates an lfuda of the given size.
+func New[V any](size float64) *Cache[V] {
+	return newWithEvict[V](size, typed.LFUDA, nil)
+}
+
+// NewGDSF creates an lfuda of the given size and the GDSF cache policy.
+func NewGDSF[V any](size float64) *Cache[V] {
+	return newWithEvict[V](size, typed.GDSF, nil)
+}
+
+// NewLFU creates an lfuda of the given size and the LFU cache policy.
+func NewLFU[V any](size float64) *Cache[V] {
+	return newWithEvict[V](size, typed.LFU, nil)
+}
+
+// NewWithEvict constructs a fixed size LFUDA cache with the given eviction
+// callback.
+func NewWithEvict[V any](size float64, onEvicted func(key string, value V)) *Cache[V] {
+	return newWithEvict(size, typed.LFUDA, onEvicted)
+}
+
+// NewGDSFWithEvict constructs a fixed size GDSF cache with the given eviction
+// callback.
+func NewGDSFWithEvict[V any](size float64, onEvicted func(key string, value V)) *Cache[V] {
+	return newWithEvict(size, typed.GDSF, onEvicted)
+}
+
+// NewLFUWithEvict constructs a fixed size LFU cache with the given eviction
+// callback.
+func NewLFUWithEvict[V any](size float64, onEvicted func(key string, value V)) *Cache[V] {
+	return newWithEvict(size, typed.LFU, onEvicted)
+}
+
+func newWithEvict[V any](size float64, policy typed.Policy, onEvicted func(key string, value V)) *Cache[V] {
+	return &Cache[V]{typed.NewLocked[string, V](size, policy, onEvicted)}
+}
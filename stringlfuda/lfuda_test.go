@@ -0,0 +1,61 @@
+package stringlfuda
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	lfuda "github.com/bparli/lfuda-go"
+)
+
+func TestCache(t *testing.T) {
+	l := NewGDSF[string](10)
+	l.Set("a", "aaaaaaaa")
+	l.Set("b", "b")
+	for i := 0; i < 3; i++ {
+		l.Get("a")
+	}
+	l.Set("c", "cc")
+	if l.Contains("a") {
+		t.Errorf("GDSF should have evicted the large value")
+	}
+
+	if v, ok := l.Get("b"); !ok || v != "b" {
+		t.Errorf("b should be in the cache")
+	}
+	if keys := l.Keys(); len(keys) != 2 || keys[0] != "b" {
+		t.Errorf("bad keys: %v", keys)
+	}
+}
+
+func trace(n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("/objects/%d", rand.Int63()%32768)
+	}
+	return keys
+}
+
+func BenchmarkString(b *testing.B) {
+	l := New[int](8192)
+	keys := trace(b.N)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i, k := range keys {
+		l.Set(k, i)
+		l.Get(keys[i/2])
+	}
+}
+
+func BenchmarkGeneric(b *testing.B) {
+	l := lfuda.New(8192)
+	keys := trace(b.N)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i, k := range keys {
+		l.Set(k, i)
+		l.Get(keys[i/2])
+	}
+}
@@ -0,0 +1,337 @@
+package lfuda
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Tier is a slower second level store, such as a disk or a remote cache.
+// Entries evicted from a cache configured with WithTier are written to its
+// tier, and Gets missing the cache are looked up in the tier.
+type Tier interface {
+	// Get returns the value stored for key.
+	Get(key interface{}) (value interface{}, ok bool, err error)
+	// Set stores the value for key.
+	Set(key, value interface{}) error
+	// Remove deletes key.
+	Remove(key interface{}) error
+}
+
+// ExpiringTier is a Tier storing the expiration deadlines of entries, so
+// entries with a TTL expire in the tier when they would have in the cache.
+// Entries with a deadline are not stored in tiers not implementing it.
+type ExpiringTier interface {
+	Tier
+	// SetWithDeadline stores the value for key until expires.
+	SetWithDeadline(key, value interface{}, expires time.Time) error
+	// GetWithDeadline returns the value stored for key and its deadline, the
+	// zero time if it never expires.
+	GetWithDeadline(key interface{}) (value interface{}, expires time.Time, ok bool, err error)
+}
+
+// TierStats describes the traffic between a cache and its tier.
+type TierStats struct {
+	// cache misses answered by the tier
+	Hits uint64
+	// cache misses not answered by the tier, including tier errors
+	Misses uint64
+	// evicted entries written to the tier
+	Written uint64
+	// failed writes that were retried
+	Retries uint64
+	// evicted entries dropped after exhausting their retries
+	Failed uint64
+	// evicted entries waiting to be written
+	Pending int
+}
+
+// tierWrite is an evicted entry waiting to be written to the tier.
+type tierWrite struct {
+	value interface{}
+	// expiration deadline in unix nanoseconds, 0 if the entry never expires
+	expires  int64
+	attempts int
+	next     time.Time
+}
+
+// maxTierBackoff caps the delay between retries of a tier write.
+const maxTierBackoff = time.Minute
+
+// tierWriter writes evicted entries to the tier in the background, retrying
+// failed writes with exponential backoff.  Entries stay readable while
+// pending so a failing tier doesn't lose them until retries run out.
+type tierWriter struct {
+	tier Tier
+	// the tier, if it stores deadlines
+	expiring    ExpiringTier
+	maxAttempts int
+	backoff     time.Duration
+	// remove keys whose write is dropped so the tier can't serve a stale value
+	fence bool
+	// reports panics recovered from the tier, nil to let them crash
+	onPanic func(op string, r interface{})
+
+	// writing serializes tier writes so they land in the order they were made
+	writing sync.Mutex
+	mu      sync.Mutex
+	pending map[interface{}]*tierWrite
+	wake    chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+
+	hits, misses, written, retries, failed atomic.Uint64
+}
+
+func newTierWriter(tier Tier, maxAttempts int, backoff time.Duration, fence bool, onPanic func(op string, r interface{})) *tierWriter {
+	w := &tierWriter{
+		tier:        tier,
+		maxAttempts: maxAttempts,
+		backoff:     backoff,
+		fence:       fence,
+		onPanic:     onPanic,
+		pending:     make(map[interface{}]*tierWrite),
+		wake:        make(chan struct{}, 1),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	w.expiring, _ = tier.(ExpiringTier)
+	go w.run()
+	return w
+}
+
+// enqueue schedules writing an evicted entry.  Nil values, which have no
+// content to write, are refused.
+func (w *tierWriter) enqueue(key, value interface{}, expires int64) {
+	if value == nil {
+		return
+	}
+	w.mu.Lock()
+	w.pending[key] = &tierWrite{value: value, expires: expires}
+	w.mu.Unlock()
+	w.notify()
+}
+
+// writeThrough writes an entry before returning.  A failed write is left
+// pending, superseding older writes of the key, and retried in the
+// background.
+func (w *tierWriter) writeThrough(key, value interface{}, expires int64) {
+	p := &tierWrite{value: value, expires: expires}
+	w.mu.Lock()
+	w.pending[key] = p
+	w.mu.Unlock()
+
+	if !w.write(key, p) {
+		w.notify()
+	}
+}
+
+func (w *tierWriter) notify() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// get looks a key up in the pending writes, then in the tier.  Returns the
+// value's deadline in unix nanoseconds, 0 if it never expires; expired values
+// are not found.
+func (w *tierWriter) get(key interface{}) (value interface{}, expires int64, ok bool) {
+	w.mu.Lock()
+	p, ok := w.pending[key]
+	w.mu.Unlock()
+	if ok {
+		value, expires = p.value, p.expires
+	} else {
+		err := w.call("tier get", func() (err error) {
+			if w.expiring == nil {
+				value, ok, err = w.tier.Get(key)
+				return err
+			}
+			var deadline time.Time
+			value, deadline, ok, err = w.expiring.GetWithDeadline(key)
+			if !deadline.IsZero() {
+				expires = deadline.UnixNano()
+			}
+			return err
+		})
+		ok = ok && err == nil
+	}
+	if !ok || (expires != 0 && time.Now().UnixNano() >= expires) {
+		w.misses.Add(1)
+		return nil, 0, false
+	}
+	w.hits.Add(1)
+	return value, expires, true
+}
+
+// remove drops a pending write and deletes the key from the tier.
+func (w *tierWriter) remove(key interface{}) {
+	w.writing.Lock()
+	defer w.writing.Unlock()
+
+	w.mu.Lock()
+	delete(w.pending, key)
+	w.mu.Unlock()
+	w.call("tier remove", func() error {
+		return w.tier.Remove(key)
+	})
+}
+
+// call runs a tier operation, turning a panic into an error if panics are
+// recovered.
+func (w *tierWriter) call(op string, fn func() error) (err error) {
+	if w.onPanic != nil {
+		defer func() {
+			if r := recover(); r != nil {
+				w.onPanic(op, r)
+				err = &PanicError{Op: op, Value: r}
+			}
+		}()
+	}
+	return fn()
+}
+
+func (w *tierWriter) run() {
+	defer close(w.done)
+
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+	for {
+		wait := w.flush(false)
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		if wait > 0 {
+			timer.Reset(wait)
+		}
+
+		select {
+		case <-w.wake:
+		case <-timer.C:
+		case <-w.stop:
+			w.flush(true)
+			return
+		}
+	}
+}
+
+// flush attempts the writes that are due, or all of them if force is set.
+// Returns the delay until the next retry is due, 0 if none is pending.
+func (w *tierWriter) flush(force bool) time.Duration {
+	now := time.Now()
+	due := make(map[interface{}]*tierWrite)
+	w.mu.Lock()
+	for k, p := range w.pending {
+		if force || !now.Before(p.next) {
+			due[k] = p
+		}
+	}
+	w.mu.Unlock()
+
+	for k, p := range due {
+		w.write(k, p)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var wait time.Duration
+	now = time.Now()
+	for _, p := range w.pending {
+		d := p.next.Sub(now)
+		if d <= 0 {
+			d = time.Millisecond
+		}
+		if wait == 0 || d < wait {
+			wait = d
+		}
+	}
+	return wait
+}
+
+// write attempts a pending write unless it was superseded or removed.
+// Returns false if the write failed.
+func (w *tierWriter) write(key interface{}, p *tierWrite) bool {
+	w.writing.Lock()
+	defer w.writing.Unlock()
+
+	w.mu.Lock()
+	current := w.pending[key] == p
+	w.mu.Unlock()
+	if !current {
+		return true
+	}
+
+	err := w.call("tier set", func() error {
+		switch {
+		case p.expires == 0:
+			return w.tier.Set(key, p.value)
+		case w.expiring != nil:
+			return w.expiring.SetWithDeadline(key, p.value, time.Unix(0, p.expires))
+		}
+		// the tier would keep the value past its deadline, and must not
+		// keep an older one either
+		return w.tier.Remove(key)
+	})
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.pending[key] != p {
+		return err == nil
+	}
+	switch {
+	case err == nil:
+		delete(w.pending, key)
+		w.written.Add(1)
+	case p.attempts+1 >= w.maxAttempts:
+		delete(w.pending, key)
+		w.failed.Add(1)
+		if w.fence {
+			w.call("tier remove", func() error {
+				return w.tier.Remove(key)
+			})
+		}
+	default:
+		p.attempts++
+		backoff := w.backoff << (p.attempts - 1)
+		if backoff <= 0 || backoff > maxTierBackoff {
+			backoff = maxTierBackoff
+		}
+		p.next = time.Now().Add(backoff)
+		w.retries.Add(1)
+	}
+	return err == nil
+}
+
+// close stops the writer after a last attempt at every pending write.
+func (w *tierWriter) close() {
+	select {
+	case <-w.stop:
+	default:
+		close(w.stop)
+	}
+	<-w.done
+}
+
+// TierStats returns the counters of the cache's tier.  It returns zero stats
+// if the cache has no tier.
+func (c *Cache) TierStats() TierStats {
+	w := c.tier
+	if w == nil {
+		return TierStats{}
+	}
+	w.mu.Lock()
+	pending := len(w.pending)
+	w.mu.Unlock()
+	return TierStats{
+		Hits:    w.hits.Load(),
+		Misses:  w.misses.Load(),
+		Written: w.written.Load(),
+		Retries: w.retries.Load(),
+		Failed:  w.failed.Load(),
+		Pending: pending,
+	}
+}
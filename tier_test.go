@@ -0,0 +1,276 @@
+package lfuda
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mapTier is an in-memory Tier whose writes can be made to fail.
+type mapTier struct {
+	mu      sync.Mutex
+	entries map[interface{}]interface{}
+	failing bool
+}
+
+func newMapTier() *mapTier {
+	return &mapTier{entries: make(map[interface{}]interface{})}
+}
+
+func (t *mapTier) Get(key interface{}) (interface{}, bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	v, ok := t.entries[key]
+	return v, ok, nil
+}
+
+func (t *mapTier) Set(key, value interface{}) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.failing {
+		return errors.New("tier unavailable")
+	}
+	t.entries[key] = value
+	return nil
+}
+
+func (t *mapTier) Remove(key interface{}) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.entries, key)
+	return nil
+}
+
+func (t *mapTier) setFailing(failing bool) {
+	t.mu.Lock()
+	t.failing = failing
+	t.mu.Unlock()
+}
+
+func (t *mapTier) has(key interface{}) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, ok := t.entries[key]
+	return ok
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestTier(t *testing.T) {
+	tier := newMapTier()
+	l := NewWithOptions(2, WithTier(tier))
+	defer l.Close()
+
+	l.Set(1, 1)
+	l.Get(1)
+	l.Set(2, 2)
+	l.Set(3, 3)
+	if l.Contains(2) {
+		t.Fatalf("2 should have been evicted")
+	}
+
+	// 2 is served from the tier while pending or once written
+	if v, ok := l.Get(2); !ok || v != 2 {
+		t.Errorf("evicted entry should be read from the tier: %v", v)
+	}
+	waitFor(t, func() bool { return l.TierStats().Pending == 0 })
+	if !tier.has(2) {
+		t.Errorf("evicted entry should have been written to the tier")
+	}
+
+	l.Remove(2)
+	if tier.has(2) {
+		t.Errorf("removed entry should be deleted from the tier")
+	}
+	if _, ok := l.Get(42); ok {
+		t.Errorf("42 was never set")
+	}
+
+	stats := l.TierStats()
+	if stats.Hits != 1 || stats.Misses != 1 || stats.Written == 0 {
+		t.Errorf("bad tier stats: %+v", stats)
+	}
+}
+
+func TestTierRetry(t *testing.T) {
+	tier := newMapTier()
+	tier.setFailing(true)
+	l := NewWithOptions(1, WithTier(tier), WithTierRetry(100, time.Millisecond))
+
+	l.Set(1, 1)
+	l.Set(2, 2)
+	waitFor(t, func() bool { return l.TierStats().Retries >= 2 })
+
+	stats := l.TierStats()
+	if stats.Pending != 1 || stats.Written != 0 {
+		t.Errorf("failed write should stay pending: %+v", stats)
+	}
+	if v, ok := l.Get(1); !ok || v != 1 {
+		t.Errorf("pending entry should still be readable")
+	}
+
+	tier.setFailing(false)
+	waitFor(t, func() bool { return tier.has(1) })
+	l.Close()
+	if stats := l.TierStats(); stats.Pending != 0 || stats.Failed != 0 {
+		t.Errorf("write should have succeeded: %+v", stats)
+	}
+}
+
+func TestTierGiveUp(t *testing.T) {
+	tier := newMapTier()
+	tier.setFailing(true)
+	l := NewWithOptions(1, WithTier(tier), WithTierRetry(2, time.Millisecond))
+	defer l.Close()
+
+	l.Set(1, 1)
+	l.Set(2, 2)
+	waitFor(t, func() bool { return l.TierStats().Failed == 1 })
+	if stats := l.TierStats(); stats.Pending != 0 || stats.Retries != 1 {
+		t.Errorf("write should have been dropped after 2 attempts: %+v", stats)
+	}
+}
+
+func TestTierSkipsExpired(t *testing.T) {
+	tier := newMapTier()
+	l := NewWithOptions(1, WithTier(tier))
+
+	l.SetWithTTL(1, 1, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	l.Set(2, 2)
+	l.Close()
+
+	if tier.has(1) {
+		t.Errorf("expired entries should not be written to the tier")
+	}
+}
+
+func TestTierReadAfterWrite(t *testing.T) {
+	tier := newMapTier()
+	l := NewWithOptions(2, WithTier(tier), WithReadAfterWrite())
+	defer l.Close()
+
+	l.Set(1, "a")
+	l.Set(1, "b")
+	if v, _, _ := tier.Get(1); v != "b" {
+		t.Errorf("set should be written through: %v", v)
+	}
+	l.Set(2, 2)
+	l.Get(2)
+	l.Get(2)
+	l.Set(3, 3)
+	if l.Contains(1) {
+		t.Fatalf("1 should have been evicted")
+	}
+	if v, ok := l.Get(1); !ok || v != "b" {
+		t.Errorf("latest value should be read from the tier: %v", v)
+	}
+
+	l.Remove(1)
+	if tier.has(1) {
+		t.Errorf("remove should delete from the tier before returning")
+	}
+
+	l.SetWithTTL(4, 4, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := l.Get(4); ok || tier.has(4) {
+		t.Errorf("expired entry should not be served by the tier")
+	}
+
+	// failed writes stay readable until retried
+	tier.setFailing(true)
+	l.Set(5, 5)
+	l.Set(6, 6)
+	l.Set(7, 7)
+	if v, ok := l.Get(5); !ok || v != 5 {
+		t.Errorf("pending write should be readable: %v", v)
+	}
+	tier.setFailing(false)
+}
+
+// blockingTier blocks Gets until release is closed.
+type blockingTier struct {
+	*mapTier
+	started chan struct{}
+	release chan struct{}
+}
+
+func (t *blockingTier) Get(key interface{}) (interface{}, bool, error) {
+	close(t.started)
+	<-t.release
+	return t.mapTier.Get(key)
+}
+
+func TestTierPromoteFence(t *testing.T) {
+	tier := &blockingTier{mapTier: newMapTier(), started: make(chan struct{}), release: make(chan struct{})}
+	tier.mapTier.Set(1, "old")
+	l := NewWithOptions(10, WithTier(tier))
+	defer l.Close()
+
+	done := make(chan struct{})
+	go func() {
+		l.Get(1)
+		close(done)
+	}()
+	<-tier.started
+	l.Set(1, "new")
+	close(tier.release)
+	<-done
+
+	if v, _ := l.Peek(1); v != "new" {
+		t.Errorf("value read from the tier should not overwrite a newer set: %v", v)
+	}
+}
+
+func TestTierTTL(t *testing.T) {
+	disk, err := OpenDiskTier(t.TempDir(), 1<<20, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer disk.Close()
+	l := NewWithOptions(1, WithTier(disk))
+	defer l.Close()
+
+	l.SetWithTTL(1, 1, time.Minute)
+	l.SetWithTTL(2, 2, 50*time.Millisecond)
+	l.Set(3, 3)
+	waitFor(t, func() bool { return l.TierStats().Pending == 0 })
+	if _, expires, ok, _ := disk.GetWithDeadline(1); !ok || time.Until(expires) <= 0 {
+		t.Errorf("the deadline should be written to the tier: %v", expires)
+	}
+	// promoted entries keep the TTL left
+	if v, ok := l.Get(1); !ok || v != 1 {
+		t.Fatalf("1 should be promoted: %v", v)
+	}
+	if ttl, _ := l.TTL(1); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("the TTL should be restored: %v", ttl)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if _, ok := l.Get(2); ok {
+		t.Errorf("expired entries should not be promoted")
+	}
+
+	// tiers not storing deadlines don't keep entries with a TTL
+	tier := newMapTier()
+	m := NewWithOptions(1, WithTier(tier), WithReadAfterWrite())
+	defer m.Close()
+	m.Set(1, 1)
+	m.SetWithTTL(1, 2, time.Minute)
+	if tier.has(1) {
+		t.Errorf("the tier can't expire the entry")
+	}
+	m.Set(2, 2)
+	if _, ok := m.Get(1); ok {
+		t.Errorf("the entry should not outlive its TTL in the tier")
+	}
+}
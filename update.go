@@ -0,0 +1,33 @@
+package lfuda
+
+// UpdateFunc returns the value to store for a key given its current value
+// and whether it is cached, and whether to store it at all.
+type UpdateFunc func(old interface{}, exists bool) (value interface{}, write bool)
+
+// Update atomically replaces a key's value with the one returned by fn, which
+// runs with the cache locked and so must not call back into the cache.  An
+// updated entry keeps its TTL and its hits are updated as by a Get followed
+// by a Set.  Returns the key's value after the update and whether it is
+// cached.
+func (c *Cache) Update(key interface{}, fn UpdateFunc) (value interface{}, ok bool) {
+	if c.badKey(key) {
+		return
+	}
+	c.lockOp()
+	defer c.unlockOp()
+
+	old, exists := c.decodeHit(c.lfuda.Get(key))
+	if _, isNeg := old.(negativeEntry); isNeg {
+		old, exists = nil, false
+	}
+	value, write := fn(old, exists)
+	if !write {
+		return old, exists
+	}
+	if exists {
+		c.replace(key, value)
+	} else {
+		c.set(key, value)
+	}
+	return c.decode(c.lfuda.Peek(key))
+}
@@ -0,0 +1,73 @@
+package lfuda
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestUpdate(t *testing.T) {
+	l := New(1000)
+	appendOne := func(old interface{}, exists bool) (interface{}, bool) {
+		s, _ := old.([]int)
+		return append(s, 1), true
+	}
+	if v, ok := l.Update("a", appendOne); !ok || len(v.([]int)) != 1 {
+		t.Errorf("absent key should be set: %v", v)
+	}
+	l.Update("a", appendOne)
+	if v, _ := l.Peek("a"); len(v.([]int)) != 2 {
+		t.Errorf("value should have been updated: %v", v)
+	}
+
+	v, ok := l.Update("a", func(old interface{}, exists bool) (interface{}, bool) {
+		return nil, false
+	})
+	if !ok || len(v.([]int)) != 2 {
+		t.Errorf("skipped write should return the current value: %v", v)
+	}
+	if _, ok := l.Update("b", func(interface{}, bool) (interface{}, bool) { return nil, false }); ok || l.Contains("b") {
+		t.Errorf("skipped write should not set an absent key")
+	}
+
+	l.SetNegative("c", 0)
+	l.Update("c", func(old interface{}, exists bool) (interface{}, bool) {
+		if exists || old != nil {
+			t.Errorf("negative entries should be reported as absent")
+		}
+		return 1, true
+	})
+}
+
+func TestUpdateKeepsTTL(t *testing.T) {
+	l := New(100)
+	l.SetWithTTL("a", 1, 20*time.Millisecond)
+	l.Update("a", func(old interface{}, exists bool) (interface{}, bool) {
+		return old.(int) + 1, true
+	})
+	time.Sleep(30 * time.Millisecond)
+	if l.Contains("a") {
+		t.Errorf("update should keep the entry's TTL")
+	}
+}
+
+func TestUpdateConcurrent(t *testing.T) {
+	l := New(10000)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for n := 0; n < 50; n++ {
+				l.Update("list", func(old interface{}, exists bool) (interface{}, bool) {
+					s, _ := old.([]int)
+					return append(s[:len(s):len(s)], i), true
+				})
+			}
+		}(i)
+	}
+	wg.Wait()
+	if v, _ := l.Peek("list"); len(v.([]int)) != 400 {
+		t.Errorf("updates were lost: %d", len(v.([]int)))
+	}
+}
@@ -0,0 +1,8 @@
+// Package lfuda is version 2 of the lfuda cache API.  It consolidates the
+// v1 API around generics, option-based constructors returning errors,
+// methods reporting failures as errors and a pluggable eviction Policy.
+//
+// V1Cache adapts a v1 cache to the same method set, so callers can move to
+// the v2 API before replacing the cache itself.  See the README for the
+// migration guide.
+package lfuda
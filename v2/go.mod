@@ -0,0 +1,10 @@
+module github.com/bparli/lfuda-go/v2
+
+go 1.19
+
+// v2 builds on internal/typed, which no v1 release carries yet.  The
+// placeholder version below resolves only through the replace, so v1 must be
+// tagged first and this requirement bumped to that tag before v2 is released.
+require github.com/bparli/lfuda-go v0.0.0-00010101000000-000000000000
+
+replace github.com/bparli/lfuda-go => ../
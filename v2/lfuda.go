@@ -0,0 +1,127 @@
+package lfuda
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/bparli/lfuda-go/internal/typed"
+)
+
+var (
+	// ErrInvalidSize is returned by New for sizes that are not positive.
+	ErrInvalidSize = errors.New("lfuda: size must be positive")
+	// ErrTooLarge is returned when setting a value larger than the cache.
+	ErrTooLarge = errors.New("lfuda: value larger than the cache")
+)
+
+// Cache is a thread-safe fixed size cache evicting by Policy.
+type Cache[K comparable, V any] struct {
+	lfuda *typed.Cache[K, V]
+	lock  sync.Mutex
+}
+
+// New creates a cache of the given size in bytes.
+func New[K comparable, V any](size float64, opts ...Option[K, V]) (*Cache[K, V], error) {
+	if size <= 0 {
+		return nil, ErrInvalidSize
+	}
+	o := options[K, V]{policy: LFUDA}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	c := &Cache[K, V]{
+		lfuda: typed.New[K, V](size, typed.LFUDA, o.sizeFunc, o.onEvict),
+	}
+	c.lfuda.SetPriorityFunc(o.policy.Priority)
+	return c, nil
+}
+
+// Get looks up a key's value from the cache, counting a hit.
+func (c *Cache[K, V]) Get(key K) (value V, ok bool) {
+	c.lock.Lock()
+	value, ok = c.lfuda.Get(key)
+	c.lock.Unlock()
+	return value, ok
+}
+
+// Peek looks up a key's value without counting a hit.
+func (c *Cache[K, V]) Peek(key K) (value V, ok bool) {
+	c.lock.Lock()
+	value, ok = c.lfuda.Peek(key)
+	c.lock.Unlock()
+	return value, ok
+}
+
+// Contains checks if a key is in the cache without counting a hit.
+func (c *Cache[K, V]) Contains(key K) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lfuda.Contains(key)
+}
+
+// Set adds a value to the cache, evicting entries as needed.  Returns
+// ErrTooLarge if the value can never fit.
+func (c *Cache[K, V]) Set(key K, value V) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.lfuda.Set(key, value)
+	if !c.lfuda.Contains(key) {
+		return ErrTooLarge
+	}
+	return nil
+}
+
+// SetWithSize adds a value to the cache, accounting for it as size bytes.
+// Returns ErrTooLarge if the value can never fit.
+func (c *Cache[K, V]) SetWithSize(key K, value V, size float64) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.lfuda.SetWithSize(key, value, size)
+	if !c.lfuda.Contains(key) {
+		return ErrTooLarge
+	}
+	return nil
+}
+
+// Remove removes a key from the cache.  Returns whether it was present.
+func (c *Cache[K, V]) Remove(key K) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lfuda.Remove(key)
+}
+
+// Keys returns the keys in the cache, from most to least valuable.
+func (c *Cache[K, V]) Keys() []K {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lfuda.Keys()
+}
+
+// Len returns the number of entries in the cache.
+func (c *Cache[K, V]) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lfuda.Len()
+}
+
+// Size returns the current size of the cache in bytes.
+func (c *Cache[K, V]) Size() float64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lfuda.Size()
+}
+
+// Age returns the cache age.
+func (c *Cache[K, V]) Age() float64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lfuda.Age()
+}
+
+// Purge removes every entry and resets the cache age.
+func (c *Cache[K, V]) Purge() {
+	c.lock.Lock()
+	c.lfuda.Purge()
+	c.lock.Unlock()
+}
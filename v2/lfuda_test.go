@@ -0,0 +1,80 @@
+package lfuda
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	evicted := 0
+	c, err := New[string, string](3, WithEvictCallback(func(k, v string) { evicted++ }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Set("a", "a")
+	c.Set("b", "b")
+	c.Get("a")
+	if err := c.Set("c", "cc"); err != nil {
+		t.Fatal(err)
+	}
+	if c.Contains("b") || evicted != 1 {
+		t.Errorf("b should have been evicted: %v", c.Keys())
+	}
+	if v, ok := c.Get("a"); !ok || v != "a" {
+		t.Errorf("a should be cached: %v", v)
+	}
+	if err := c.Set("d", "dddd"); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("oversized value should be rejected: %v", err)
+	}
+	if c.Len() != 2 || c.Size() != 3 {
+		t.Errorf("bad len or size: %d, %f", c.Len(), c.Size())
+	}
+	c.Purge()
+	if c.Len() != 0 || c.Age() != 0 {
+		t.Errorf("cache should be empty")
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	if _, err := New[string, int](0); !errors.Is(err, ErrInvalidSize) {
+		t.Errorf("size should be validated: %v", err)
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	// evict the largest entries first
+	c, _ := New[string, []byte](10, WithPolicy[string, []byte](PolicyFunc(func(hits, size, age float64) float64 {
+		return -size
+	})))
+	c.Set("big", make([]byte, 5))
+	c.Set("small", make([]byte, 1))
+	c.Get("big")
+	c.Set("other", make([]byte, 5))
+	if c.Contains("big") || !c.Contains("small") {
+		t.Errorf("custom policy should have evicted the largest entry: %v", c.Keys())
+	}
+
+	g, _ := New[string, []byte](10, WithPolicy[string, []byte](GDSF), WithSizeFunc[string](func(v []byte) float64 {
+		return float64(len(v))
+	}))
+	g.Set("big", make([]byte, 8))
+	g.Get("big")
+	g.Set("a", make([]byte, 1))
+	g.Set("b", make([]byte, 2))
+	if g.Contains("big") {
+		t.Errorf("GDSF should have evicted the large entry")
+	}
+
+	for _, policy := range []Policy{nil, PolicyFunc(nil)} {
+		d, err := New[string, int](16, WithPolicy[string, int](policy))
+		if err != nil {
+			t.Fatal(err)
+		}
+		d.Set("a", 1)
+		d.Set("b", 2)
+		d.Set("c", 3)
+		if d.Len() != 2 {
+			t.Errorf("a nil policy should keep the default: %v", d.Keys())
+		}
+	}
+}
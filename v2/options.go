@@ -0,0 +1,40 @@
+package lfuda
+
+// Option configures a cache built with New.  Options are typed by the
+// cache's key and value types, so mismatched callbacks fail to compile.
+type Option[K comparable, V any] func(*options[K, V])
+
+type options[K comparable, V any] struct {
+	policy   Policy
+	sizeFunc func(value V) float64
+	onEvict  func(key K, value V)
+}
+
+// WithPolicy sets the eviction policy, LFUDA by default.  A nil policy
+// keeps the default.
+func WithPolicy[K comparable, V any](policy Policy) Option[K, V] {
+	return func(o *options[K, V]) {
+		if f, isFunc := policy.(PolicyFunc); policy == nil || (isFunc && f == nil) {
+			return
+		}
+		o.policy = policy
+	}
+}
+
+// WithSizeFunc sets the function computing the size of values.  By default
+// the size is the length of []byte and string values, the width of booleans
+// and numbers and the length of the default format of other values.  K
+// can't be inferred and must be given, as in WithSizeFunc[string](fn).
+func WithSizeFunc[K comparable, V any](sizeFunc func(value V) float64) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.sizeFunc = sizeFunc
+	}
+}
+
+// WithEvictCallback sets a callback invoked, with the cache lock held, for
+// every entry leaving the cache.
+func WithEvictCallback[K comparable, V any](onEvict func(key K, value V)) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.onEvict = onEvict
+	}
+}
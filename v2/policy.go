@@ -0,0 +1,31 @@
+package lfuda
+
+// Policy computes the priority of cache entries from their hits, their size
+// in bytes and the cache age, which is raised to the priority of every
+// evicted entry.  The entries with the lowest priority are evicted first.
+type Policy interface {
+	Priority(hits, size, age float64) float64
+}
+
+// PolicyFunc adapts an ordinary function to a Policy.
+type PolicyFunc func(hits, size, age float64) float64
+
+// Priority returns f(hits, size, age).
+func (f PolicyFunc) Priority(hits, size, age float64) float64 {
+	return f(hits, size, age)
+}
+
+var (
+	// LFUDA is LFU with dynamic aging: Ki = Fi + L
+	LFUDA Policy = PolicyFunc(func(hits, size, age float64) float64 {
+		return hits + age
+	})
+	// GDSF is GreedyDual-Size with frequency: Ki = Fi / Si + L
+	GDSF Policy = PolicyFunc(func(hits, size, age float64) float64 {
+		return hits/size + age
+	})
+	// LFU ignores the cache age: Ki = Fi
+	LFU Policy = PolicyFunc(func(hits, size, age float64) float64 {
+		return hits
+	})
+)
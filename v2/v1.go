@@ -0,0 +1,115 @@
+package lfuda
+
+import (
+	v1 "github.com/bparli/lfuda-go"
+)
+
+// Interface is the method set shared by Cache and V1Cache.
+type Interface[K comparable, V any] interface {
+	Get(key K) (V, bool)
+	Peek(key K) (V, bool)
+	Contains(key K) bool
+	Set(key K, value V) error
+	SetWithSize(key K, value V, size float64) error
+	Remove(key K) bool
+	Keys() []K
+	Len() int
+	Size() float64
+	Age() float64
+	Purge()
+}
+
+var (
+	_ Interface[string, int] = (*Cache[string, int])(nil)
+	_ Interface[string, int] = (*V1Cache[string, int])(nil)
+)
+
+// V1Cache adapts a v1 cache to the v2 API, for code migrating before the
+// cache itself is replaced.  Entries whose key or value have other types than
+// K and V are treated as missing.
+type V1Cache[K comparable, V any] struct {
+	c *v1.Cache
+}
+
+// FromV1 adapts c to the v2 API.
+func FromV1[K comparable, V any](c *v1.Cache) *V1Cache[K, V] {
+	return &V1Cache[K, V]{c: c}
+}
+
+// Unwrap returns the adapted v1 cache.
+func (a *V1Cache[K, V]) Unwrap() *v1.Cache {
+	return a.c
+}
+
+// Get looks up a key's value from the cache, counting a hit.
+func (a *V1Cache[K, V]) Get(key K) (value V, ok bool) {
+	return typedValue[V](a.c.Get(key))
+}
+
+// Peek looks up a key's value without counting a hit.
+func (a *V1Cache[K, V]) Peek(key K) (value V, ok bool) {
+	return typedValue[V](a.c.Peek(key))
+}
+
+// Contains checks if a key is in the cache without counting a hit.
+func (a *V1Cache[K, V]) Contains(key K) bool {
+	return a.c.Contains(key)
+}
+
+// Set adds a value to the cache.  Returns ErrTooLarge if it was rejected.
+func (a *V1Cache[K, V]) Set(key K, value V) error {
+	if !a.c.SetStored(key, value) {
+		return ErrTooLarge
+	}
+	return nil
+}
+
+// SetWithSize adds a value to the cache, accounting for it as size bytes.
+// Returns ErrTooLarge if it was rejected.
+func (a *V1Cache[K, V]) SetWithSize(key K, value V, size float64) error {
+	if !a.c.SetWithSizeStored(key, value, size) {
+		return ErrTooLarge
+	}
+	return nil
+}
+
+// Remove removes a key from the cache.  Returns whether it was present.
+func (a *V1Cache[K, V]) Remove(key K) bool {
+	return a.c.Remove(key)
+}
+
+// Keys returns the keys of type K in the cache.
+func (a *V1Cache[K, V]) Keys() []K {
+	var keys []K
+	for _, k := range a.c.Keys() {
+		if key, ok := k.(K); ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Len returns the number of entries in the cache.
+func (a *V1Cache[K, V]) Len() int {
+	return a.c.Len()
+}
+
+// Size returns the current size of the cache in bytes.
+func (a *V1Cache[K, V]) Size() float64 {
+	return a.c.Size()
+}
+
+// Age returns the cache age.
+func (a *V1Cache[K, V]) Age() float64 {
+	return a.c.Age()
+}
+
+// Purge removes every entry.
+func (a *V1Cache[K, V]) Purge() {
+	a.c.Purge()
+}
+
+func typedValue[V any](v interface{}, ok bool) (V, bool) {
+	value, typed := v.(V)
+	return value, ok && typed
+}
@@ -0,0 +1,39 @@
+package lfuda
+
+import (
+	"errors"
+	"testing"
+
+	v1 "github.com/bparli/lfuda-go"
+)
+
+func TestFromV1(t *testing.T) {
+	old := v1.New(100)
+	old.Set("legacy", 1)
+	old.Set(2, 2)
+
+	var c Interface[string, int] = FromV1[string, int](old)
+	if v, ok := c.Get("legacy"); !ok || v != 1 {
+		t.Errorf("v1 entries should be readable: %v", v)
+	}
+	if err := c.Set("new", 3); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := old.Peek("new"); v != 3 {
+		t.Errorf("v2 writes should land in the v1 cache: %v", v)
+	}
+	if err := c.SetWithSize("huge", 4, 1000); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("rejected values should be reported: %v", err)
+	}
+
+	old.Set("other", "not an int")
+	if _, ok := c.Get("other"); ok {
+		t.Errorf("values of another type should be missing")
+	}
+	if keys := c.Keys(); len(keys) != 3 {
+		t.Errorf("only string keys should be listed: %v", keys)
+	}
+	if FromV1[string, int](old).Unwrap() != old {
+		t.Errorf("unwrap should return the v1 cache")
+	}
+}
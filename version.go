@@ -0,0 +1,45 @@
+package lfuda
+
+// GetWithVersion is Get also returning the key's version, which increases
+// every time the key is set, even if it left the cache in between.  It is
+// meant for optimistic concurrency with SetIfVersion.  Keys are not looked up
+// in the tier.
+func (c *Cache) GetWithVersion(key interface{}) (value interface{}, version uint64, ok bool) {
+	if c.badKey(key) {
+		return
+	}
+	c.lock.RLock()
+	value, ok = c.decodeHit(c.lfuda.Peek(key))
+	version, _ = c.lfuda.Version(key)
+	c.lock.RUnlock()
+
+	if _, isNeg := value.(negativeEntry); ok && isNeg {
+		c.stats.negativeHits.Add(1)
+		return nil, version, false
+	}
+	if ok {
+		c.recordHit(key)
+	}
+	c.reportGet(key, value, ok)
+	return value, version, ok
+}
+
+// SetIfVersion sets the value only if the key's current version is version,
+// or if the key is absent and version is 0, so that callers updating a value
+// they read can detect concurrent overwrites.  Returns the key's version
+// after the call and whether the value was set.
+func (c *Cache) SetIfVersion(key, value interface{}, version uint64) (current uint64, ok bool) {
+	if c.badKey(key) || c.dropSet() {
+		return
+	}
+	c.lockOp()
+	defer c.unlockOp()
+
+	current, _ = c.lfuda.Version(key)
+	if current != version {
+		return current, false
+	}
+	c.set(key, value)
+	current, ok = c.lfuda.Version(key)
+	return current, ok
+}
@@ -0,0 +1,58 @@
+package lfuda
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSetIfVersion(t *testing.T) {
+	l := New(100)
+	if _, ok := l.SetIfVersion("a", 1, 42); ok {
+		t.Errorf("absent key should only be set with version 0")
+	}
+	v1, ok := l.SetIfVersion("a", 1, 0)
+	if !ok || v1 == 0 {
+		t.Fatalf("absent key should be set with version 0")
+	}
+
+	value, version, ok := l.GetWithVersion("a")
+	if !ok || value != 1 || version != v1 {
+		t.Errorf("bad value or version: %v, %d", value, version)
+	}
+
+	l.Set("a", 2)
+	if current, ok := l.SetIfVersion("a", 3, v1); ok || current == v1 {
+		t.Errorf("concurrent overwrite should be detected: %d", current)
+	}
+	if v, _ := l.Peek("a"); v != 2 {
+		t.Errorf("value should not have been replaced: %v", v)
+	}
+	if _, version, ok := l.GetWithVersion("b"); ok || version != 0 {
+		t.Errorf("missing key should have no version")
+	}
+}
+
+func TestSetIfVersionConcurrent(t *testing.T) {
+	l := New(100)
+	l.Set("counter", 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 100; n++ {
+				for {
+					v, version, _ := l.GetWithVersion("counter")
+					if _, ok := l.SetIfVersion("counter", v.(int)+1, version); ok {
+						break
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if v, _ := l.Peek("counter"); v != 800 {
+		t.Errorf("no increment should be lost: %v", v)
+	}
+}
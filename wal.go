@@ -0,0 +1,284 @@
+package lfuda
+
+import (
+	"encoding/gob"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// walRecord is a set, or a remove if Value is nil.
+type walRecord struct {
+	Key   interface{}
+	Value []byte
+	// hits of compacted entries
+	Hits float64
+	// expiration deadline of a set in unix nanoseconds, 0 if it never
+	// expires
+	Expires int64
+}
+
+// WAL is a write-ahead log of a cache's sets and removes, from which the
+// cache is rebuilt after a crash without taking snapshots.  Values are
+// marshaled by the cache's Codec and keys are gob encoded, so custom types
+// must be registered with gob.Register.  Sets and Expire log the entry's
+// expiration deadline, but renewals by Touch are not logged.  Expirations are
+// logged as removes, and negative entries as removes of their keys.
+type WAL struct {
+	c           *Cache
+	path        string
+	compactSize int64
+
+	// records of the operations holding the cache's write lock, in the
+	// order they held it, waiting to be written
+	pendingLock sync.Mutex
+	pending     []walPending
+
+	mu   sync.Mutex
+	file *os.File
+	enc  *gob.Encoder
+	size int64
+	err  error
+}
+
+// walPending is a set or remove to log.
+type walPending struct {
+	key, value interface{}
+	set        bool
+	expires    int64
+}
+
+// OpenWAL replays the log at path into the cache, if it exists, then logs
+// the cache's sets and removes to it until the returned WAL is closed.  The
+// log is compacted to the cache's entries once it grows past compactSize
+// bytes, or never if compactSize is not positive.  A log truncated by a crash
+// is replayed up to its last complete record.  A cache logs to one WAL at a
+// time.
+func (c *Cache) OpenWAL(path string, compactSize int64) (*WAL, error) {
+	w := &WAL{c: c, path: path, compactSize: compactSize}
+	if !c.wal.CompareAndSwap(nil, w) {
+		return nil, errors.New("lfuda: a WAL is already open")
+	}
+	// the replayed operations aren't logged until the log is compacted
+	if err := w.replay(); err != nil {
+		c.wal.CompareAndSwap(w, nil)
+		return nil, err
+	}
+	// drop what the cache evicted while replaying, and any truncated record
+	if err := w.Compact(); err != nil {
+		c.wal.CompareAndSwap(w, nil)
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *WAL) replay() error {
+	f, err := os.Open(w.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	dec := gob.NewDecoder(f)
+	for {
+		var rec walRecord
+		err := dec.Decode(&rec)
+		if err == io.EOF || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if rec.Value == nil {
+			w.c.Remove(rec.Key)
+			continue
+		}
+		var ttl time.Duration
+		if rec.Expires != 0 {
+			if ttl = time.Until(time.Unix(0, rec.Expires)); ttl <= 0 {
+				w.c.Remove(rec.Key)
+				continue
+			}
+		}
+		value, err := w.c.codec().Unmarshal(rec.Value)
+		if err != nil {
+			return err
+		}
+		w.c.SetWithTTL(rec.Key, value, ttl)
+		if rec.Hits > 1 {
+			w.c.Boost(rec.Key, rec.Hits-1)
+		}
+	}
+}
+
+// journal records a set or remove of the operation holding the write lock.
+func (c *Cache) journal(key, value interface{}, set bool) {
+	if c.wal.Load() == nil {
+		return
+	}
+	if _, isNeg := value.(negativeEntry); isNeg {
+		set = false
+	}
+	c.opJournal = append(c.opJournal, walPending{key: key, value: value, set: set})
+}
+
+// journalOp queues the operation's records to log with the deadlines its
+// sets were left with, with the write lock still held so records follow the
+// order of the operations.
+func (c *Cache) journalOp() {
+	w := c.wal.Load()
+	if w == nil || len(c.opJournal) == 0 {
+		c.opJournal = nil
+		return
+	}
+	for i := range c.opJournal {
+		if p := &c.opJournal[i]; p.set {
+			if info, ok := c.lfuda.Info(p.key); ok {
+				p.expires = info.Expires
+			}
+		}
+	}
+	w.pendingLock.Lock()
+	w.pending = append(w.pending, c.opJournal...)
+	w.pendingLock.Unlock()
+	c.opJournal = nil
+}
+
+// flushWAL writes the records queued by the operation that released the
+// write lock, and by those before it.
+func (c *Cache) flushWAL() {
+	if w := c.wal.Load(); w != nil {
+		w.flush()
+	}
+}
+
+func (w *WAL) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pendingLock.Lock()
+	pending := w.pending
+	w.pending = nil
+	w.pendingLock.Unlock()
+	if w.err != nil || w.enc == nil {
+		return
+	}
+	for _, p := range pending {
+		rec := walRecord{Key: p.key, Expires: p.expires}
+		if p.set {
+			if rec.Value, w.err = w.c.codec().Marshal(p.value); w.err != nil {
+				return
+			}
+		}
+		if w.err = w.enc.Encode(rec); w.err != nil {
+			return
+		}
+	}
+	if w.compactSize > 0 && w.size > w.compactSize {
+		w.err = w.compact()
+	}
+}
+
+// Compact rewrites the log with the cache's entries only.
+func (w *WAL) Compact() error {
+	w.c.flushReads()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.compact()
+}
+
+func (w *WAL) compact() error {
+	// the read lock keeps operations from queuing records, so the entries
+	// reflect exactly the records dropped
+	w.c.lock.RLock()
+	entries := w.c.liveEntries()
+	w.pendingLock.Lock()
+	w.pending = nil
+	w.pendingLock.Unlock()
+	w.c.lock.RUnlock()
+
+	tmp, err := os.CreateTemp(filepath.Dir(w.path), ".wal-*")
+	if err != nil {
+		return err
+	}
+	w.size = 0
+	enc := gob.NewEncoder(countingWriter{w: tmp, n: &w.size})
+	// least valuable first, so replaying into a smaller cache keeps the
+	// most valuable
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		data, err := w.c.codec().Marshal(e.value)
+		if err == nil {
+			err = enc.Encode(walRecord{Key: e.info.Key, Value: data, Hits: e.info.Hits, Expires: e.info.Expires})
+		}
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+			return err
+		}
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), w.path); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if w.file != nil {
+		w.file.Close()
+	}
+	w.file, w.enc = tmp, enc
+	return nil
+}
+
+// Sync writes the queued records and flushes the log to disk.
+func (w *WAL) Sync() error {
+	w.flush()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	return w.file.Sync()
+}
+
+// Err returns the first error encountered while writing the log, after
+// which the log stops.
+func (w *WAL) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// Close stops logging and closes the log, returning the first error
+// encountered while writing it.
+func (w *WAL) Close() error {
+	w.c.wal.CompareAndSwap(w, nil)
+	w.flush()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.file.Close(); w.err == nil {
+		w.err = err
+	}
+	return w.err
+}
+
+// countingWriter adds the bytes written to w to n.
+type countingWriter struct {
+	w io.Writer
+	n *int64
+}
+
+func (cw countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	*cw.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,175 @@
+package lfuda
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWAL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.wal")
+	l := New(100)
+	wal, err := l.OpenWAL(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		l.Set(i, i*10)
+	}
+	l.Get(3)
+	l.Get(3)
+	// compacted entries keep their hits
+	if err := wal.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	l.Set(1, "one")
+	l.Remove(2)
+	l.SetNegative(5, 0)
+	l.Set(4, "four")
+	if err := wal.Close(); err != nil {
+		t.Fatal(err)
+	}
+	l.Set(6, 6)
+
+	// a crash truncates the last record
+	info, _ := os.Stat(path)
+	os.Truncate(path, info.Size()-1)
+	l2 := New(100)
+	if wal, err = l2.OpenWAL(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	defer wal.Close()
+	if l2.Len() != 4 || l2.Contains(2) {
+		t.Errorf("bad keys: %v", l2.Keys())
+	}
+	if v, _ := l2.Peek(1); v != "one" {
+		t.Errorf("bad value: %v", v)
+	}
+	if v, _ := l2.Peek(4); v != 40 {
+		t.Errorf("truncated set replayed: %v", v)
+	}
+	if hits, _ := l2.Hits(3); hits != 3 {
+		t.Errorf("bad hits: %g", hits)
+	}
+}
+
+func TestWALTTL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.wal")
+	l := New(100)
+	wal, err := l.OpenWAL(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.OpenWAL(path, 0); err == nil {
+		t.Errorf("a second WAL should be refused")
+	}
+	l.SetWithTTL("short", 1, 20*time.Millisecond)
+	l.SetWithTTL("long", 2, time.Hour)
+	l.Set("expired", 3)
+	l.Expire("expired", 20*time.Millisecond)
+	l.Set("forever", 4)
+	if err := wal.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a refused WAL replays nothing into the cache
+	other := New(100)
+	if _, err := other.OpenWAL(filepath.Join(t.TempDir(), "other.wal"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.OpenWAL(path, 0); err == nil || other.Len() != 0 {
+		t.Errorf("refused WAL should not touch the cache: %v", other.Keys())
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	// opening compacts the log, whose records keep the deadlines too
+	for i := 0; i < 2; i++ {
+		l2 := New(100)
+		wal, err := l2.OpenWAL(path, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if l2.Contains("short") || l2.Contains("expired") {
+			t.Errorf("expired entries replayed: %v", l2.Keys())
+		}
+		if ttl, ok := l2.TTL("long"); !ok || ttl <= 59*time.Minute || ttl > time.Hour {
+			t.Errorf("replayed entry should keep its TTL: %v", ttl)
+		}
+		if ttl, ok := l2.TTL("forever"); !ok || ttl != 0 {
+			t.Errorf("replayed entry without TTL should not expire: %v", ttl)
+		}
+		wal.Close()
+	}
+}
+
+func TestWALCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.wal")
+	l := New(100)
+	wal, err := l.OpenWAL(path, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10000; i++ {
+		l.Set(i%10, i)
+	}
+	if err := wal.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := os.Stat(path); info.Size() > 2048 {
+		t.Errorf("log not compacted: %d bytes", info.Size())
+	}
+
+	l2 := New(100)
+	if wal, err = l2.OpenWAL(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	wal.Close()
+	for i := 0; i < 10; i++ {
+		if v, _ := l2.Peek(i); v != 9990+i {
+			t.Errorf("bad value of %d: %v", i, v)
+		}
+	}
+}
+
+func TestWALConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.wal")
+	l := New(50)
+	wal, err := l.OpenWAL(path, 512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				l.SetWithTTL(i%20, g*1000+i, time.Minute)
+				l.Get(i % 20)
+				if i%7 == 0 {
+					l.Remove(i % 20)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	if err := wal.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the log replays to the cache's final values
+	l2 := New(50)
+	if wal, err = l2.OpenWAL(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	defer wal.Close()
+	for i := 0; i < 20; i++ {
+		v, ok := l.Peek(i)
+		v2, ok2 := l2.Peek(i)
+		if ok != ok2 || v != v2 {
+			t.Fatalf("key %d: got %v %v, want %v %v", i, v2, ok2, v, ok)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package lfuda
+
+import (
+	"context"
+	"sync"
+)
+
+// WarmOptions configures WarmWithOptions.
+type WarmOptions struct {
+	// Concurrency is the number of keys loaded at once, 1 by default.
+	Concurrency int
+	// ContinueOnError keeps warming after a failed load instead of stopping.
+	ContinueOnError bool
+	// Start skips the keys before it, to resume from WarmProgress.Next.
+	Start int
+	// Progress, if set, is called after every key with the progress so far.
+	// Calls are serialized.
+	Progress func(WarmProgress)
+}
+
+// WarmProgress reports how far warming the cache got.
+type WarmProgress struct {
+	// keys loaded and cached
+	Loaded int
+	// keys found in the cache
+	Skipped int
+	// keys whose load failed
+	Failed int
+	// size of the loaded entries
+	Bytes float64
+	// Next is the index of the first key not handled yet.  Warming again
+	// with Start set to Next resumes where a previous call stopped.
+	Next int
+}
+
+// WarmWithOptions loads the keys missing from the cache, reporting progress
+// as it goes.  Unless opts.ContinueOnError is set, it stops at the first
+// error.  It also stops once ctx is done, returning ctx's error.
+func (c *Cache) WarmWithOptions(ctx context.Context, keys []interface{}, loader Loader, opts WarmOptions) (WarmProgress, error) {
+	return warm(ctx, keys, opts, c.Contains, func(ctx context.Context, key interface{}) (float64, error) {
+		return c.warmKey(ctx, key, loader)
+	})
+}
+
+// warmKey loads a key, returning the size of its entry.
+func (c *Cache) warmKey(ctx context.Context, key interface{}, loader Loader) (float64, error) {
+	if _, err := c.load(ctx, key, loader); err != nil {
+		return 0, err
+	}
+	c.lock.RLock()
+	info, _ := c.lfuda.Info(key)
+	c.lock.RUnlock()
+	return info.Size, nil
+}
+
+func warm(ctx context.Context, keys []interface{}, opts WarmOptions, contains func(key interface{}) bool, load func(ctx context.Context, key interface{}) (float64, error)) (WarmProgress, error) {
+	workers := opts.Concurrency
+	if workers <= 0 {
+		workers = 1
+	}
+	loadCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		progress = WarmProgress{Next: opts.Start}
+		done     = make([]bool, len(keys))
+		firstErr error
+	)
+	// finish records a key's outcome.  Handled keys advance Next past the
+	// keys handled in order; a failure stopping the warming leaves its key
+	// to be retried on resumption.
+	finish := func(i int, handled bool, update func(p *WarmProgress)) {
+		mu.Lock()
+		defer mu.Unlock()
+		update(&progress)
+		done[i] = handled
+		for progress.Next < len(keys) && done[progress.Next] {
+			progress.Next++
+		}
+		if opts.Progress != nil {
+			opts.Progress(progress)
+		}
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				key := keys[i]
+				if loadCtx.Err() != nil {
+					continue
+				}
+				if contains(key) {
+					finish(i, true, func(p *WarmProgress) { p.Skipped++ })
+					continue
+				}
+				size, err := load(loadCtx, key)
+				if err != nil && loadCtx.Err() != nil {
+					// interrupted, the key is left for a later call
+					continue
+				}
+				if err != nil {
+					finish(i, opts.ContinueOnError, func(p *WarmProgress) {
+						p.Failed++
+						if firstErr == nil && !opts.ContinueOnError {
+							firstErr = err
+						}
+					})
+					if !opts.ContinueOnError {
+						cancel()
+					}
+					continue
+				}
+				finish(i, true, func(p *WarmProgress) {
+					p.Loaded++
+					p.Bytes += size
+				})
+			}
+		}()
+	}
+
+feed:
+	for i := opts.Start; i < len(keys); i++ {
+		select {
+		case indexes <- i:
+		case <-loadCtx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	if firstErr != nil {
+		return progress, firstErr
+	}
+	return progress, ctx.Err()
+}
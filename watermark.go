@@ -0,0 +1,82 @@
+package lfuda
+
+// trimBatch is the number of entries the background trimmer evicts per
+// lock hold, so writers aren't stalled for long.
+const trimBatch = 128
+
+// WithSoftLimit sets a soft limit, as a fraction of the capacity between 0
+// and 1, above which a background goroutine evicts by policy until the cache
+// is back under it.  The capacity remains a hard limit that Set never
+// exceeds, evicting synchronously if the trimmer falls behind, so most of
+// the eviction work moves off the write path.  The trimmer stops when the
+// cache is closed.
+func WithSoftLimit(fraction float64) Option {
+	return func(o *options) {
+		o.softLimit = fraction
+	}
+}
+
+// WithAsyncEviction lets Set add entries past the capacity, by up to
+// headroom times it, leaving their eviction to a background goroutine that
+// trims the cache back to the capacity, or to the soft limit if lower.  Set
+// latency then doesn't depend on how much it evicts; only past the headroom
+// does Set evict synchronously.  Size may exceed Capacity while the trimmer
+// catches up.  Each shard of a ShardedCache has its own trimmer.
+func WithAsyncEviction(headroom float64) Option {
+	return func(o *options) {
+		if headroom > 0 {
+			o.headroom = headroom
+		}
+	}
+}
+
+// capacity returns the size of the cache, without the headroom, with the
+// lock held.
+func (c *Cache) capacity() float64 {
+	return c.lfuda.Capacity() / (1 + c.opts.headroom)
+}
+
+// softLimit returns the size above which the trimmer runs, with the lock
+// held.
+func (c *Cache) softLimit() float64 {
+	if c.opts.softLimit > 0 && c.opts.softLimit < 1 {
+		return c.opts.softLimit * c.capacity()
+	}
+	return c.capacity()
+}
+
+// wakeTrimmer starts a trim if the cache is over the soft limit, with the
+// lock held.
+func (c *Cache) wakeTrimmer() {
+	if c.trims != nil && c.lfuda.Size() > c.softLimit() {
+		select {
+		case c.trims <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (c *Cache) trimmer() {
+	for {
+		select {
+		case <-c.trims:
+			for c.trim() {
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// trim evicts up to trimBatch entries over the soft limit.  Returns true if
+// the cache is still over it.
+func (c *Cache) trim() bool {
+	c.lockOp()
+	defer c.unlockOp()
+	for i := 0; i < trimBatch; i++ {
+		if c.lfuda.Size() <= c.softLimit() || !c.lfuda.Evict() {
+			return false
+		}
+	}
+	return c.lfuda.Size() > c.softLimit()
+}
@@ -0,0 +1,60 @@
+package lfuda
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSoftLimit(t *testing.T) {
+	l := NewWithOptions(100, WithSoftLimit(0.5))
+	defer l.Close()
+	for i := 0; i < 100; i++ {
+		l.Set(i, i)
+		if l.Size() > 100 {
+			t.Fatalf("the hard limit should never be exceeded: %v", l.Size())
+		}
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for l.Size() > 50 {
+		if time.Now().After(deadline) {
+			t.Fatalf("the cache should be trimmed to the soft limit: %v", l.Size())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if l.Age() == 0 {
+		t.Errorf("trimming should evict by policy and age the cache")
+	}
+}
+
+func TestAsyncEviction(t *testing.T) {
+	l := NewWithOptions(10, WithAsyncEviction(1))
+	defer l.Close()
+	for i := 0; i < 15; i++ {
+		if l.Set(i, i%10) {
+			t.Fatalf("set %d evicted synchronously within the headroom", i)
+		}
+	}
+	if l.Capacity() != 10 {
+		t.Errorf("the capacity should not include the headroom: %v", l.Capacity())
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for l.Size() > 10 {
+		if time.Now().After(deadline) {
+			t.Fatalf("the cache should be trimmed to its capacity: %v", l.Size())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if evicted := l.Resize(5); evicted != 5 || l.Size() != 5 {
+		t.Errorf("resize should evict down to the capacity: %d evicted, size %v", evicted, l.Size())
+	}
+
+	// past the headroom Set evicts
+	for i := 0; i < 20; i++ {
+		l.Set(100+i, 1)
+		if l.Size() > 10 {
+			t.Fatalf("the headroom should never be exceeded: %v", l.Size())
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package lfuda
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// WithWeakValues holds values of at least minSize bytes weakly: such a value
+// is released at the first garbage collection after one it wasn't read
+// since, so memory pressure reclaims huge values before the policy evicts
+// them.  Only reads counting as hits, such as Get or GetWithVersion, keep a
+// value; Peek and scans such as Range or snapshots don't.  Released entries
+// are missing and are removed in the background, without calling the
+// eviction callback or writing them to a tier.  Clones hold copies of the
+// weak values, released independently of the original's.
+func WithWeakValues(minSize float64) Option {
+	return func(o *options) {
+		o.weakValues = true
+		o.weakSize = minSize
+	}
+}
+
+// weakValue is a value stored weakly.
+type weakValue struct {
+	key interface{}
+	// nil once released
+	value atomic.Pointer[interface{}]
+	// read since the last garbage collection
+	used atomic.Bool
+}
+
+// get returns the value without marking it used, as scans read it.
+func (w *weakValue) get() (interface{}, bool) {
+	p := w.value.Load()
+	if p == nil {
+		return nil, false
+	}
+	return *p, true
+}
+
+// weakValues tracks the weak values in a cache.
+type weakValues struct {
+	lock   sync.Mutex
+	values map[*weakValue]struct{}
+	// signaled after every garbage collection
+	gcs chan struct{}
+}
+
+func (c *Cache) startWeakValues() {
+	c.weak = &weakValues{
+		values: make(map[*weakValue]struct{}),
+		gcs:    make(chan struct{}, 1),
+	}
+	runtime.SetFinalizer(&gcSentinel{done: c.done, gcs: c.weak.gcs}, (*gcSentinel).finalize)
+	go c.releaseWeakValues()
+}
+
+// gcSentinel is garbage after every collection, its finalizer re-arming
+// itself until the cache is closed.
+type gcSentinel struct {
+	done <-chan struct{}
+	gcs  chan<- struct{}
+}
+
+func (s *gcSentinel) finalize() {
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+	select {
+	case s.gcs <- struct{}{}:
+	default:
+	}
+	runtime.SetFinalizer(s, (*gcSentinel).finalize)
+}
+
+// weaken returns the value to store in place of an encoded value of the
+// given size.
+func (c *Cache) weaken(key, encoded interface{}, size float64) interface{} {
+	if c.weak == nil || size < c.opts.weakSize {
+		return encoded
+	}
+	if _, isNeg := encoded.(negativeEntry); isNeg {
+		return encoded
+	}
+	w := &weakValue{key: key}
+	w.value.Store(&encoded)
+	w.used.Store(true)
+	c.weak.lock.Lock()
+	c.weak.values[w] = struct{}{}
+	c.weak.lock.Unlock()
+	return w
+}
+
+// forgetWeak stops tracking a value leaving the cache.
+func (c *Cache) forgetWeak(value interface{}) {
+	if w, ok := value.(*weakValue); ok && c.weak != nil {
+		c.weak.lock.Lock()
+		delete(c.weak.values, w)
+		c.weak.lock.Unlock()
+	}
+}
+
+// releaseWeakValues releases weak values after every garbage collection.
+func (c *Cache) releaseWeakValues() {
+	for {
+		select {
+		case <-c.weak.gcs:
+			c.releaseUnused()
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// releaseUnused releases the weak values not read since it last ran and
+// removes their entries.
+func (c *Cache) releaseUnused() {
+	var released []*weakValue
+	c.weak.lock.Lock()
+	for w := range c.weak.values {
+		if !w.used.Swap(false) {
+			w.value.Store(nil)
+			delete(c.weak.values, w)
+			released = append(released, w)
+		}
+	}
+	c.weak.lock.Unlock()
+	if len(released) == 0 {
+		return
+	}
+
+	c.lockOp()
+	c.opReason = reasonReleased
+	for _, w := range released {
+		// the key may have been set again since
+		if value, _ := c.lfuda.Peek(w.key); value == interface{}(w) {
+			c.lfuda.Remove(w.key)
+		}
+	}
+	c.unlockOp()
+	c.debug("lfuda: released weak values", "count", len(released))
+}
@@ -0,0 +1,97 @@
+package lfuda
+
+import (
+	"io"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestWeakValues(t *testing.T) {
+	evicted := make(map[interface{}]interface{})
+	l := NewWithOptions(100, WithWeakValues(10), WithEvictCallback(func(key, value interface{}) {
+		evicted[key] = value
+	}))
+	// release values by hand rather than after garbage collections
+	l.Close()
+	l.Set("big", make([]byte, 20))
+	l.Set("read", make([]byte, 20))
+	l.Set("versioned", make([]byte, 20))
+	l.Set("small", make([]byte, 5))
+
+	// values survive the collection following their set
+	l.releaseUnused()
+	l.Get("read")
+	l.GetWithVersion("versioned")
+	l.releaseUnused()
+
+	if _, ok := l.Get("big"); ok {
+		t.Errorf("unread weak value not released")
+	}
+	if _, ok := l.Get("read"); !ok {
+		t.Errorf("read weak value released")
+	}
+	if _, _, ok := l.GetWithVersion("versioned"); !ok {
+		t.Errorf("weak value read with its version released")
+	}
+	if _, ok := l.Get("small"); !ok {
+		t.Errorf("strong value released")
+	}
+	if l.Len() != 3 {
+		t.Errorf("released entry not removed: %v", l.Keys())
+	}
+	if len(evicted) != 0 {
+		t.Errorf("released values should not be reported: %v", evicted)
+	}
+
+	// set again, the key holds a new weak value
+	l.Set("big", make([]byte, 20))
+	l.releaseUnused()
+	if _, ok := l.Get("big"); !ok {
+		t.Errorf("new weak value released")
+	}
+	if clone := l.Clone(); len(clone.weak.values) != 3 {
+		t.Errorf("clone tracks %d weak values", len(clone.weak.values))
+	}
+
+	// scans don't keep weak values alive
+	l.Set("scanned", make([]byte, 20))
+	l.releaseUnused()
+	l.Peek("scanned")
+	l.Range(func(info EntryInfo) bool { return true })
+	l.WriteSnapshot(io.Discard)
+	l.releaseUnused()
+	if l.Contains("scanned") {
+		t.Errorf("weak value read by scans only should be released")
+	}
+}
+
+func TestWeakValuesTier(t *testing.T) {
+	tier := newMapTier()
+	l := NewWithOptions(100, WithWeakValues(10), WithTier(tier))
+	l.Set("big", make([]byte, 20))
+	l.releaseUnused()
+	l.releaseUnused()
+	if v, ok := l.Get("big"); ok {
+		t.Errorf("released value found: %v", v)
+	}
+	l.Close()
+	if tier.has("big") {
+		t.Errorf("released value written to the tier")
+	}
+}
+
+func TestWeakValuesGC(t *testing.T) {
+	l := NewWithOptions(1<<30, WithWeakValues(1<<20))
+	defer l.Close()
+	l.Set("big", make([]byte, 1<<20))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for l.Len() > 0 && time.Now().Before(deadline) {
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+	}
+	if l.Len() > 0 {
+		t.Errorf("weak value not released by the garbage collector")
+	}
+}
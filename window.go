@@ -0,0 +1,102 @@
+package lfuda
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// windowResolution is the span of a hit ratio window slot.
+	windowResolution = 10 * time.Second
+	// windowSlots covers the longest window, 15 minutes.
+	windowSlots = 90
+)
+
+// WithHitRatioWindow tracks the hits and misses of the last 15 minutes for
+// HitRatioOver, since the cumulative ratio of Stats hides regressions after a
+// long uptime.
+func WithHitRatioWindow() Option {
+	return func(o *options) {
+		o.hitWindow = true
+	}
+}
+
+// HitRatioOver returns the fraction of Gets that were hits over the last d,
+// e.g. 1, 5 or 15 minutes.  The window is rounded up to 10 seconds and
+// capped at 15 minutes.  It returns 0 unless WithHitRatioWindow is set.
+func (c *Cache) HitRatioOver(d time.Duration) float64 {
+	if c.window == nil {
+		return 0
+	}
+	return ratio(c.window.counts(d))
+}
+
+func ratio(hits, misses uint64) float64 {
+	if hits+misses == 0 {
+		return 0
+	}
+	return float64(hits) / float64(hits+misses)
+}
+
+// hitWindow counts hits and misses in a ring of slots, each reused once its
+// epoch has passed out of the window.
+type hitWindow struct {
+	slots [windowSlots]windowSlot
+	now   func() time.Time
+}
+
+type windowSlot struct {
+	// the windowResolution since the unix epoch the counts belong to
+	epoch  atomic.Int64
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+func newHitWindow() *hitWindow {
+	return &hitWindow{now: time.Now}
+}
+
+func (w *hitWindow) epoch() int64 {
+	return w.now().UnixNano() / int64(windowResolution)
+}
+
+// record counts a Get.  Gets racing with a slot's reuse may be lost.
+func (w *hitWindow) record(hit bool) {
+	epoch := w.epoch()
+	s := &w.slots[epoch%windowSlots]
+	if old := s.epoch.Load(); old < epoch && s.epoch.CompareAndSwap(old, epoch) {
+		s.hits.Store(0)
+		s.misses.Store(0)
+	}
+	if hit {
+		s.hits.Add(1)
+	} else {
+		s.misses.Add(1)
+	}
+}
+
+func (w *hitWindow) counts(d time.Duration) (hits, misses uint64) {
+	n := int64((d + windowResolution - 1) / windowResolution)
+	if n < 1 {
+		n = 1
+	} else if n > windowSlots {
+		n = windowSlots
+	}
+	epoch := w.epoch()
+	for e := epoch - n + 1; e <= epoch; e++ {
+		s := &w.slots[e%windowSlots]
+		if s.epoch.Load() == e {
+			hits += s.hits.Load()
+			misses += s.misses.Load()
+		}
+	}
+	return hits, misses
+}
+
+func (w *hitWindow) reset() {
+	for i := range w.slots {
+		w.slots[i].epoch.Store(0)
+		w.slots[i].hits.Store(0)
+		w.slots[i].misses.Store(0)
+	}
+}